@@ -6,58 +6,348 @@
 package main
 
 import (
+	"app/backendv1/internal/cache"
 	"app/backendv1/internal/config"
 	"app/backendv1/internal/delivery/http_handler"
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/logging"
+	"app/backendv1/internal/metrics"
+	"app/backendv1/internal/repository/memory"
 	"app/backendv1/internal/repository/postgres"
+	"app/backendv1/internal/storage"
+	"app/backendv1/internal/tracing"
 	"app/backendv1/internal/usecase"
+	"app/backendv1/internal/worker"
+	"context"
 	"database/sql"
-	"log"
+	"errors"
+	"flag"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	_ "app/backendv1/docs"
 
 	"github.com/gorilla/mux"
-	_ "github.com/lib/pq"
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	httpSwagger "github.com/swaggo/http-swagger"
 )
 
 func main() {
-	config.LoadEnv()
-	dsn := config.GetDSN()
-	db, err := sql.Open("postgres", dsn)
+	// backend config print [флаги] выводит действующие настройки без секретов
+	if args := os.Args[1:]; len(args) >= 2 && args[0] == "config" && args[1] == "print" {
+		cfg := loadConfig(args[2:])
+		if err := cfg.Print(os.Stdout); err != nil {
+			fatal("could not print config", err)
+		}
+		return
+	}
+
+	cfg := loadConfig(os.Args[1:])
+	if err := logging.Setup(os.Stderr, cfg.Log.Format, cfg.Log.Level); err != nil {
+		fatal("could not init logging", err)
+	}
+	shutdownTracing, err := tracing.Init(context.Background(), cfg.Tracing.OTLPEndpoint)
+	if err != nil {
+		fatal("could not init tracing", err)
+	}
+	defer shutdownTracing(context.Background())
+
+	// каждый SQL-запрос становится спаном трассы запроса
+	connector, err := postgres.NewConnector(cfg.DB.DSN(), tracing.Query)
 	if err != nil {
-		log.Fatal("DB CONNECTION FAILED", err)
+		fatal("db connection failed", err)
 	}
+	db := sql.OpenDB(connector)
 	defer db.Close()
+	db.SetMaxOpenConns(cfg.DB.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.DB.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.DB.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.DB.ConnMaxIdleTime)
 
 	if err := ensureTables(db); err != nil {
-		log.Fatalf("could not create tables: %v", err)
+		fatal("could not create tables", err)
 	}
+	metrics.MustRegister(collectors.NewDBStatsCollector(db, "postgres"), postgres.NewRecordStatsCollector(db))
+	workers := worker.NewGroup(context.Background())
+
+	//cache setup
+	var readCache cache.Cache = cache.Nop{}
+	switch cfg.Cache.Backend {
+	case "memory":
+		readCache = cache.NewLRU(cfg.Cache.Size)
+	case "redis":
+		redisCache, err := cache.NewRedis(context.Background(), cfg.Cache.RedisAddr, cfg.Cache.RedisPassword, cfg.Cache.RedisDB)
+		if err != nil {
+			fatal("redis connection failed", err)
+		}
+		defer redisCache.Close()
+		readCache = redisCache
+	}
+	if cfg.Cache.Backend != "none" {
+		workers.Go(worker.NewCacheInvalidator(cfg.DB.DSN(), readCache).Run)
+	}
+
+	//health setup
+	healthUC := usecase.NewHealthUsecase(postgres.NewHealthRepo(db), schemaVersion)
+	healthHandler := http_handler.NewHealthHandler(healthUC)
+
+	//audit setup
+	auditRepo := postgres.NewAuditRepo(db)
+	auditUC := usecase.NewAuditUsecase(auditRepo)
+	auditHandler := http_handler.NewAuditHandler(auditUC)
+
+	//transactions setup
+	transactor := postgres.NewTransactor(db)
+
 	//namespace setup
 	namespaceRepo := postgres.NewNamespaceRepo(db)
-	namespaceUC := usecase.NewNamespaceService(namespaceRepo)
+	namespaceUC := usecase.NewCachedNamespaceUsecase(usecase.NewNamespaceService(namespaceRepo, transactor, auditUC), readCache, cfg.Cache.TTL)
 	namespaceHandler := http_handler.NewHandler(namespaceUC)
 
+	//limits setup
+	var rateLimitRepo usecase.RateLimitRepo = memory.NewRateLimitRepo()
+	if cfg.RateLimit.Store == "postgres" {
+		rateLimitRepo = postgres.NewRateLimitRepo(db)
+	}
+	limitsUC := usecase.NewLimitsUsecase(postgres.NewLimitsRepo(db), rateLimitRepo, namespaceUC, domain.Limits{
+		ReadsPerMinute:   cfg.RateLimit.Reads,
+		WritesPerMinute:  cfg.RateLimit.Writes,
+		SchemaPerMinute:  cfg.RateLimit.Schema,
+		MaxApps:          cfg.Limits.MaxAppsPerNamespace,
+		MaxRecordsPerApp: cfg.Limits.MaxRecordsPerApp,
+	}, transactor, auditUC)
+	limitsHandler := http_handler.NewLimitsHandler(limitsUC)
+	rateLimitPurger := worker.NewRateLimitPurger(limitsUC, time.Minute)
+	workers.Go(rateLimitPurger.Run)
+
+	//idempotency setup
+	idempotencyUC := usecase.NewIdempotencyUsecase(postgres.NewIdempotencyRepo(db), cfg.Idempotency.TTL, cfg.Idempotency.LockTimeout)
+	idempotencyPurger := worker.NewIdempotencyPurger(idempotencyUC, time.Hour)
+	workers.Go(idempotencyPurger.Run)
+
 	//app setup
 	appRepo := postgres.NewAppRepo(db)
-	appUC := usecase.NewAppUsecase(appRepo)
+	indexRepo := postgres.NewIndexRepo(db)
+	appDataRepo := postgres.NewAppDataRepo(db)
+	jobRepo := postgres.NewJobRepo(db)
+	appUC := usecase.NewCachedAppUsecase(usecase.NewAppUsecase(appRepo, indexRepo, jobRepo, appDataRepo, limitsUC, transactor, auditUC), readCache, cfg.Cache.TTL)
 	appHandler := http_handler.NewAppHandler(appUC)
+	indexUC := usecase.NewIndexUsecase(indexRepo, appUC)
+	indexHandler := http_handler.NewIndexHandler(indexUC)
+	indexReconciler := worker.NewIndexReconciler(indexUC, 5*time.Minute)
+	workers.Go(indexReconciler.Run)
 
 	//appData setup
-	appDataRepo := postgres.NewAppDataRepo(db)
-	appDataUC := usecase.NewAppDataUsecase(appDataRepo)
-	appDataHandler := http_handler.NewAppDataHandler(appDataUC)
+	workflowRepo := postgres.NewWorkflowRepo(db)
+	changeRequestRepo := postgres.NewChangeRequestRepo(db)
+	automationQueue := worker.NewAutomationQueue(cfg.Limits.AutomationQueueSize, cfg.Limits.AutomationWorkers)
+	appDataUC := usecase.NewCachedAppDataUsecase(usecase.NewAppDataUsecase(appDataRepo, appDataRepo, appRepo, namespaceUC, workflowRepo, changeRequestRepo, automationQueue, limitsUC, transactor, auditUC), readCache, cfg.Cache.TTL)
+
+	//publishing setup
+	publishingRepo := postgres.NewPublishingRepo(db)
+	publishingUC := usecase.NewPublishingUsecase(publishingRepo, appUC, namespaceUC, appDataRepo, transactor, auditUC)
+	publishingHandler := http_handler.NewPublishingHandler(publishingUC)
+	appDataHandler := http_handler.NewAppDataHandler(appDataUC, publishingUC)
+
+	//batch setup
+	batchUC := usecase.NewBatchUsecase(transactor, appDataUC, auditUC, limitsUC, cfg.Limits.BatchMaxOperations)
+	batchHandler := http_handler.NewBatchHandler(batchUC)
+
+	//workflow setup
+	workflowUC := usecase.NewWorkflowUsecase(workflowRepo, appDataRepo, appUC, namespaceUC, automationQueue, transactor, auditUC)
+	workflowHandler := http_handler.NewWorkflowHandler(workflowUC)
+
+	//approvals setup
+	approvalUC := usecase.NewApprovalUsecase(changeRequestRepo, appDataUC, transactor, auditUC)
+	approvalHandler := http_handler.NewApprovalHandler(approvalUC)
+
+	//automation setup
+	notificationRepo := postgres.NewNotificationRepo(db)
+	notificationUC := usecase.NewNotificationUsecase(notificationRepo)
+	notificationHandler := http_handler.NewNotificationHandler(notificationUC)
+	automationRepo := postgres.NewAutomationRepo(db)
+	automationUC := usecase.NewAutomationUsecase(automationRepo, appUC, appDataUC, notificationRepo, transactor, auditUC)
+	automationHandler := http_handler.NewAutomationHandler(automationUC)
+	workers.Go(func(ctx context.Context) { automationQueue.Run(ctx, automationUC) })
+
+	//scheduled jobs setup
+	schedulerUC := usecase.NewSchedulerUsecase(jobRepo, appUC, appDataRepo, appDataUC, automationUC, transactor, auditUC, cfg.Workers.JobLease)
+	schedulerHandler := http_handler.NewSchedulerHandler(schedulerUC)
+
+	//attachments setup
+	fileStorage, err := storage.NewFSStorage(cfg.Storage.FilesDir)
+	if err != nil {
+		fatal("could not init file storage", err)
+	}
+	attachmentRepo := postgres.NewAttachmentRepo(db)
+	attachmentUC := usecase.NewAttachmentUsecase(attachmentRepo, fileStorage, appRepo, appDataRepo, auditUC, cfg.Limits.FileMaxSize)
+	attachmentHandler := http_handler.NewAttachmentHandler(attachmentUC)
+
+	//retention setup
+	retentionRepo := postgres.NewRetentionRepo(db)
+	retentionUC := usecase.NewRetentionUsecase(retentionRepo, appUC, attachmentUC, automationQueue, transactor, auditUC, cfg.Limits.RetentionBatchSize)
+	retentionHandler := http_handler.NewRetentionHandler(retentionUC)
+
+	//icons setup
+	iconRepo := postgres.NewIconRepo(db)
+	iconUC := usecase.NewIconUsecase(iconRepo, fileStorage, appUC, namespaceUC)
+	iconHandler := http_handler.NewIconHandler(iconUC)
+
+	if cfg.Features.Workers {
+		//trash purger
+		purger := worker.NewTrashPurger(namespaceUC, appUC, appDataUC, cfg.Workers.TrashRetention, cfg.Workers.TrashPurgeInterval)
+		workers.Go(purger.Run)
+
+		//garbage collector for files
+		gc := worker.NewGarbageCollector(cfg.Workers.FileGCInterval, map[string]worker.Collector{
+			"attachments": attachmentUC,
+			"icons":       iconUC,
+		})
+		workers.Go(gc.Run)
+
+		//expiry of stale change requests
+		approvalExpirer := worker.NewApprovalExpirer(approvalUC, cfg.Workers.ApprovalExpireInterval)
+		workers.Go(approvalExpirer.Run)
+
+		//retention of records
+		retentionEnforcer := worker.NewRetentionEnforcer(retentionUC, cfg.Workers.RetentionInterval)
+		workers.Go(retentionEnforcer.Run)
+
+		//scheduled publishing of records
+		publisher := worker.NewPublisher(publishingUC, cfg.Workers.PublishInterval)
+		workers.Go(publisher.Run)
+
+		//scheduled jobs
+		scheduler := worker.NewScheduler(schedulerUC, cfg.Workers.JobPollInterval)
+		workers.Go(scheduler.Run)
+	}
 
 	r := mux.NewRouter()
+	r.Use(http_handler.RequestMeta(cfg.Server.TrustedProxies))
+	r.Use(http_handler.Tracing)
+	r.Use(http_handler.AccessLog)
+	r.Use(http_handler.Metrics)
+	// повтор сохранённого ответа отдаётся до ограничения частоты и не расходует его
+	r.Use(http_handler.Idempotency(idempotencyUC, cfg.Idempotency.MaxBody))
+	r.Use(http_handler.RateLimit(limitsUC))
+	r.Use(http_handler.Timeout(http_handler.RouteTimeouts{Default: cfg.Timeouts.Request, Routes: cfg.Timeouts.Routes}))
 	namespaceHandler.RegisterRoutes(r)
 	appHandler.RegisterRoutes(r)
 	appDataHandler.RegisterRoutes(r)
-	r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+	publishingHandler.RegisterRoutes(r)
+	auditHandler.RegisterRoutes(r)
+	attachmentHandler.RegisterRoutes(r)
+	iconHandler.RegisterRoutes(r)
+	workflowHandler.RegisterRoutes(r)
+	approvalHandler.RegisterRoutes(r)
+	automationHandler.RegisterRoutes(r)
+	notificationHandler.RegisterRoutes(r)
+	schedulerHandler.RegisterRoutes(r)
+	retentionHandler.RegisterRoutes(r)
+	indexHandler.RegisterRoutes(r)
+	healthHandler.RegisterRoutes(r)
+	limitsHandler.RegisterRoutes(r)
+	batchHandler.RegisterRoutes(r)
+	if cfg.Features.Swagger {
+		r.PathPrefix("/swagger/").Handler(httpSwagger.WrapHandler)
+	}
+
+	server := &http.Server{
+		Addr: cfg.Server.Addr,
+		Handler: http_handler.CORS(http_handler.CORSOptions{
+			AllowedOrigins:   cfg.CORS.AllowedOrigins,
+			AllowedMethods:   cfg.CORS.AllowedMethods,
+			AllowedHeaders:   cfg.CORS.AllowedHeaders,
+			AllowCredentials: cfg.CORS.AllowCredentials,
+			MaxAge:           cfg.CORS.MaxAge,
+		})(r),
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
 
-	log.Println("Server running on :8080")
-	log.Fatal(http.ListenAndServe(":8080", r))
+	// метрики отдаются на отдельном адресе, который доступен только сборщику метрик
+	var metricsServer *http.Server
+	if cfg.Features.Metrics {
+		metricsRouter := mux.NewRouter()
+		metricsRouter.Handle("/metrics", metrics.Handler()).Methods("GET")
+		metricsServer = &http.Server{
+			Addr:              cfg.Server.MetricsAddr,
+			Handler:           metricsRouter,
+			ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+			IdleTimeout:       cfg.Server.IdleTimeout,
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	serverErr := make(chan error, 2)
+	go func() {
+		slog.Info("server running", "addr", server.Addr, "tls", cfg.Server.TLS())
+		if cfg.Server.TLS() {
+			serverErr <- server.ListenAndServeTLS(cfg.Server.TLSCertFile, cfg.Server.TLSKeyFile)
+			return
+		}
+		serverErr <- server.ListenAndServe()
+	}()
+	if metricsServer != nil {
+		go func() {
+			slog.Info("metrics server running", "addr", metricsServer.Addr)
+			serverErr <- metricsServer.ListenAndServe()
+		}()
+	}
+	select {
+	case err := <-serverErr:
+		fatal("server stopped", err)
+	case <-ctx.Done():
+	}
+	// повторный сигнал завершает процесс сразу
+	stop()
+
+	slog.Info("shutting down", "timeout", cfg.Server.ShutdownTimeout)
+	healthUC.Drain()
+	time.Sleep(cfg.Server.ShutdownDelay)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		slog.Error("in-flight requests did not finish in time", "error", err)
+		server.Close()
+	}
+	if metricsServer != nil {
+		metricsServer.Close()
+	}
+	if err := workers.Shutdown(shutdownCtx); err != nil {
+		slog.Error("background workers did not finish in time", "error", err)
+	}
+	slog.Info("server stopped")
 }
 
+// loadConfig собирает настройки из файла, окружения и флагов; при ошибке завершает процесс
+func loadConfig(args []string) *config.Config {
+	cfg, err := config.Load(args)
+	if errors.Is(err, flag.ErrHelp) {
+		os.Exit(0)
+	}
+	if err != nil {
+		fatal("could not load config", err)
+	}
+	return cfg
+}
+
+// fatal пишет ошибку в журнал и завершает процесс
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
+}
+
+// schemaVersion — версия схемы, которую создаёт ensureTables; увеличивается при каждом
+// изменении схемы, чтобы /readyz не пускал трафик на базу без нужных миграций
+const schemaVersion = 8
+
 // init tables
 func ensureTables(db *sql.DB) error {
 	createAppsTable := `
@@ -71,8 +361,357 @@ func ensureTables(db *sql.DB) error {
 		namespace_code TEXT NOT NULL,
 		icon TEXT,
 		FOREIGN KEY (namespace_code) REFERENCES namespaces(code) ON DELETE CASCADE
-	);`
+	);
+	ALTER TABLE namespaces ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+	ALTER TABLE apps ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ;
+	ALTER TABLE apps ADD COLUMN IF NOT EXISTS fields JSONB NOT NULL DEFAULT '[]'::jsonb;
+	ALTER TABLE apps ADD COLUMN IF NOT EXISTS max_file_size BIGINT NOT NULL DEFAULT 0;
+	-- старый формат {"item": [{"<поле>": {"type": "<тип>"}}]} переводится в список описаний полей
+	UPDATE apps SET fields = COALESCE((
+		SELECT jsonb_agg(jsonb_build_object('code', f.key, 'type', f.value->>'type'))
+		FROM jsonb_array_elements(apps.fields->'item') AS item, jsonb_each(item) AS f
+	), '[]'::jsonb)
+	WHERE jsonb_typeof(fields) = 'object';
+	CREATE TABLE IF NOT EXISTS attachments (
+		id UUID PRIMARY KEY,
+		namespace_code TEXT NOT NULL,
+		app_code TEXT NOT NULL,
+		record_uid UUID NOT NULL,
+		field TEXT NOT NULL,
+		file_name TEXT NOT NULL,
+		content_type TEXT NOT NULL,
+		size BIGINT NOT NULL,
+		sha256 TEXT NOT NULL,
+		storage_key TEXT NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	ALTER TABLE namespaces ADD COLUMN IF NOT EXISTS icon TEXT;
+	CREATE TABLE IF NOT EXISTS icons (
+		id UUID PRIMARY KEY,
+		content_type TEXT NOT NULL,
+		size BIGINT NOT NULL,
+		sha256 TEXT NOT NULL,
+		width INT NOT NULL DEFAULT 0,
+		height INT NOT NULL DEFAULT 0,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	CREATE INDEX IF NOT EXISTS attachments_record_idx ON attachments (namespace_code, app_code, record_uid, field);
+	ALTER TABLE apps ADD COLUMN IF NOT EXISTS workflow JSONB;
+	CREATE TABLE IF NOT EXISTS workflow_transitions (
+		id BIGSERIAL PRIMARY KEY,
+		namespace_code TEXT NOT NULL,
+		app_code TEXT NOT NULL,
+		record_uid UUID NOT NULL,
+		from_state TEXT NOT NULL,
+		to_state TEXT NOT NULL,
+		actor TEXT NOT NULL,
+		comment TEXT NOT NULL DEFAULT '',
+		request_id TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	CREATE INDEX IF NOT EXISTS workflow_transitions_record_idx ON workflow_transitions (namespace_code, app_code, record_uid, id);
+	ALTER TABLE apps ADD COLUMN IF NOT EXISTS approval JSONB;
+	CREATE TABLE IF NOT EXISTS change_requests (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		namespace_code TEXT NOT NULL,
+		app_code TEXT NOT NULL,
+		record_uid UUID NOT NULL,
+		kind TEXT NOT NULL,
+		data JSONB NOT NULL,
+		status TEXT NOT NULL,
+		step INT NOT NULL DEFAULT 0,
+		policy JSONB NOT NULL,
+		requested_by TEXT NOT NULL,
+		error TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		expires_at TIMESTAMPTZ NOT NULL,
+		decided_at TIMESTAMPTZ
+	);
+	ALTER TABLE change_requests ADD COLUMN IF NOT EXISTS base_updated_at TIMESTAMPTZ;
+	CREATE INDEX IF NOT EXISTS change_requests_status_idx ON change_requests (status, expires_at);
+	CREATE INDEX IF NOT EXISTS change_requests_record_idx ON change_requests (namespace_code, app_code, record_uid);
+	CREATE TABLE IF NOT EXISTS change_request_votes (
+		change_request_id UUID NOT NULL REFERENCES change_requests (id) ON DELETE CASCADE,
+		step INT NOT NULL,
+		reviewer TEXT NOT NULL,
+		decision TEXT NOT NULL,
+		comment TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		PRIMARY KEY (change_request_id, step, reviewer)
+	);
+	CREATE TABLE IF NOT EXISTS automation_rules (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		namespace_code TEXT NOT NULL,
+		app_code TEXT NOT NULL,
+		name TEXT NOT NULL,
+		events JSONB NOT NULL,
+		condition TEXT NOT NULL DEFAULT '',
+		actions JSONB NOT NULL,
+		enabled BOOLEAN NOT NULL DEFAULT true,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	CREATE INDEX IF NOT EXISTS automation_rules_app_idx ON automation_rules (namespace_code, app_code);
+	CREATE TABLE IF NOT EXISTS automation_runs (
+		id BIGSERIAL PRIMARY KEY,
+		rule_id UUID NOT NULL REFERENCES automation_rules (id) ON DELETE CASCADE,
+		namespace_code TEXT NOT NULL,
+		app_code TEXT NOT NULL,
+		record_uid TEXT NOT NULL,
+		event TEXT NOT NULL,
+		status TEXT NOT NULL,
+		depth INT NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		actions JSONB NOT NULL DEFAULT '[]'::jsonb,
+		started_at TIMESTAMPTZ NOT NULL,
+		finished_at TIMESTAMPTZ NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS automation_runs_rule_idx ON automation_runs (rule_id, id);
+	CREATE TABLE IF NOT EXISTS notifications (
+		id BIGSERIAL PRIMARY KEY,
+		recipient TEXT NOT NULL,
+		message TEXT NOT NULL,
+		namespace_code TEXT NOT NULL DEFAULT '',
+		app_code TEXT NOT NULL DEFAULT '',
+		record_uid TEXT NOT NULL DEFAULT '',
+		rule_id TEXT NOT NULL DEFAULT '',
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		read_at TIMESTAMPTZ
+	);
+	CREATE INDEX IF NOT EXISTS notifications_recipient_idx ON notifications (recipient, id);
+	CREATE TABLE IF NOT EXISTS scheduled_jobs (
+		id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+		namespace_code TEXT NOT NULL REFERENCES namespaces (code) ON DELETE CASCADE,
+		name TEXT NOT NULL,
+		schedule TEXT NOT NULL,
+		timezone TEXT NOT NULL DEFAULT '',
+		action JSONB NOT NULL,
+		paused BOOLEAN NOT NULL DEFAULT false,
+		next_run_at TIMESTAMPTZ,
+		last_run_at TIMESTAMPTZ,
+		lease_owner TEXT,
+		lease_until TIMESTAMPTZ,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		updated_at TIMESTAMPTZ NOT NULL DEFAULT now()
+	);
+	CREATE INDEX IF NOT EXISTS scheduled_jobs_namespace_idx ON scheduled_jobs (namespace_code);
+	CREATE INDEX IF NOT EXISTS scheduled_jobs_due_idx ON scheduled_jobs (next_run_at) WHERE NOT paused;
+	CREATE TABLE IF NOT EXISTS scheduled_job_runs (
+		id BIGSERIAL PRIMARY KEY,
+		job_id UUID NOT NULL REFERENCES scheduled_jobs (id) ON DELETE CASCADE,
+		trigger TEXT NOT NULL,
+		actor TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL,
+		processed BIGINT NOT NULL DEFAULT 0,
+		error TEXT NOT NULL DEFAULT '',
+		started_at TIMESTAMPTZ NOT NULL,
+		finished_at TIMESTAMPTZ
+	);
+	CREATE INDEX IF NOT EXISTS scheduled_job_runs_job_idx ON scheduled_job_runs (job_id, id);
+	-- приложения, вычисляемые поля которых изменились: записи пересчитывает планировщик
+	-- после фиксации схемы; requested_at отличает повторный запрос от уже выполняемого
+	CREATE TABLE IF NOT EXISTS formula_recomputes (
+		namespace_code TEXT NOT NULL,
+		app_code TEXT NOT NULL,
+		requested_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		lease_owner TEXT,
+		lease_until TIMESTAMPTZ,
+		PRIMARY KEY (namespace_code, app_code)
+	);
+	ALTER TABLE apps ADD COLUMN IF NOT EXISTS retention JSONB;
+	ALTER TABLE apps ADD COLUMN IF NOT EXISTS unique_fields JSONB NOT NULL DEFAULT '[]'::jsonb;
+	ALTER TABLE apps ADD COLUMN IF NOT EXISTS numbering JSONB;
+	ALTER TABLE apps ADD COLUMN IF NOT EXISTS drafts BOOLEAN NOT NULL DEFAULT false;
+	-- ошибка последнего построения индексов по схеме; такие приложения достраиваются в фоне
+	ALTER TABLE apps ADD COLUMN IF NOT EXISTS index_error TEXT;
+	ALTER TABLE namespaces ADD COLUMN IF NOT EXISTS locales JSONB NOT NULL DEFAULT '[]'::jsonb;
+	ALTER TABLE namespaces ADD COLUMN IF NOT EXISTS limits JSONB;
+	-- корзины токенов ограничения частоты запросов при RATE_LIMIT_STORE=postgres
+	CREATE UNLOGGED TABLE IF NOT EXISTS rate_limit_buckets (
+		key TEXT PRIMARY KEY,
+		tokens DOUBLE PRECISION NOT NULL,
+		allowed BOOLEAN NOT NULL,
+		updated_at TIMESTAMPTZ NOT NULL
+	);
+	-- ответы на запросы с Idempotency-Key; key — субъект и ключ, response пуст, пока запрос выполняется
+	CREATE TABLE IF NOT EXISTS idempotency_keys (
+		key TEXT PRIMARY KEY,
+		request_hash TEXT NOT NULL,
+		response JSONB,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		completed_at TIMESTAMPTZ
+	);
+	CREATE INDEX IF NOT EXISTS idempotency_keys_created_at_idx ON idempotency_keys (created_at);
+	-- счётчики номеров записей; строка блокируется до конца транзакции вставки, поэтому номера идут без пропусков
+	CREATE TABLE IF NOT EXISTS record_counters (
+		namespace_code TEXT NOT NULL,
+		app_code TEXT NOT NULL,
+		period TEXT NOT NULL,
+		value BIGINT NOT NULL,
+		PRIMARY KEY (namespace_code, app_code, period)
+	);
+	CREATE TABLE IF NOT EXISTS archived_records (
+		namespace_code TEXT NOT NULL,
+		app_code TEXT NOT NULL,
+		uid UUID NOT NULL,
+		data JSONB NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL,
+		archived_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		PRIMARY KEY (namespace_code, app_code, uid)
+	);
+	CREATE TABLE IF NOT EXISTS audit_log (
+		id BIGSERIAL PRIMARY KEY,
+		created_at TIMESTAMPTZ NOT NULL,
+		actor TEXT NOT NULL,
+		action TEXT NOT NULL,
+		namespace_code TEXT NOT NULL DEFAULT '',
+		app_code TEXT NOT NULL DEFAULT '',
+		uid TEXT NOT NULL DEFAULT '',
+		request_id TEXT NOT NULL DEFAULT '',
+		source_ip TEXT NOT NULL DEFAULT '',
+		diff JSON,
+		prev_hash TEXT NOT NULL,
+		hash TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS audit_log_target_idx ON audit_log (namespace_code, app_code, uid);
+	CREATE INDEX IF NOT EXISTS audit_log_created_at_idx ON audit_log (created_at);
+	CREATE OR REPLACE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+	BEGIN
+		RAISE EXCEPTION 'audit_log is append-only';
+	END;
+	$$ LANGUAGE plpgsql;
+	DROP TRIGGER IF EXISTS audit_log_append_only ON audit_log;
+	CREATE TRIGGER audit_log_append_only BEFORE UPDATE OR DELETE OR TRUNCATE ON audit_log
+		FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+	-- уведомления для сброса кэша на всех экземплярах (worker.CacheInvalidator); ключи
+	-- совпадают с ключами пакета cache
+	CREATE OR REPLACE FUNCTION notify_record_change() RETURNS trigger AS $$
+	BEGIN
+		PERFORM pg_notify('cache_invalidate', format('data:%s:%s:%s', TG_TABLE_SCHEMA, TG_TABLE_NAME, OLD.uid));
+		RETURN NULL;
+	END;
+	$$ LANGUAGE plpgsql;
+	CREATE OR REPLACE FUNCTION notify_catalog_change() RETURNS trigger AS $$
+	DECLARE
+		r RECORD;
+	BEGIN
+		IF TG_OP = 'DELETE' THEN r := OLD; ELSE r := NEW; END IF;
+		IF TG_TABLE_NAME = 'namespaces' THEN
+			PERFORM pg_notify('cache_invalidate', 'namespaces');
+			PERFORM pg_notify('cache_invalidate', format('data:%s:*', lower(r.code)));
+		ELSE
+			PERFORM pg_notify('cache_invalidate', 'apps');
+			PERFORM pg_notify('cache_invalidate', format('data:%s:%s:*', lower(r.namespace_code), lower(r.code)));
+		END IF;
+		RETURN NULL;
+	END;
+	$$ LANGUAGE plpgsql;
+	DROP TRIGGER IF EXISTS cache_invalidate ON namespaces;
+	CREATE TRIGGER cache_invalidate AFTER INSERT OR UPDATE OR DELETE ON namespaces
+		FOR EACH ROW EXECUTE FUNCTION notify_catalog_change();
+	DROP TRIGGER IF EXISTS cache_invalidate ON apps;
+	CREATE TRIGGER cache_invalidate AFTER INSERT OR UPDATE OR DELETE ON apps
+		FOR EACH ROW EXECUTE FUNCTION notify_catalog_change();
+	-- таблицы записей создаются динамически и без кавычек, поэтому имена в нижнем регистре.
+	-- Системные столбцы старых записей заполняются по журналу аудита: создание — по app_data.create,
+	-- последнее изменение — по последней правке записи; без истории — время миграции и пустой автор
+	DO $$
+	DECLARE
+		a RECORD;
+		t TEXT;
+	BEGIN
+		FOR a IN SELECT namespace_code, code FROM apps LOOP
+			t := format('%I.%I', lower(a.namespace_code), lower(a.code));
+			CONTINUE WHEN to_regclass(t) IS NULL;
+			EXECUTE format($q$
+				ALTER TABLE %s
+					ADD COLUMN IF NOT EXISTS created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+					ADD COLUMN IF NOT EXISTS legal_hold BOOLEAN NOT NULL DEFAULT false,
+					ADD COLUMN IF NOT EXISTS number TEXT UNIQUE,
+					ADD COLUMN IF NOT EXISTS published_data JSONB,
+					ADD COLUMN IF NOT EXISTS published_at TIMESTAMPTZ,
+					ADD COLUMN IF NOT EXISTS published_by TEXT,
+					ADD COLUMN IF NOT EXISTS publish_at TIMESTAMPTZ,
+					ADD COLUMN IF NOT EXISTS unpublish_at TIMESTAMPTZ
+			$q$, t);
+			EXECUTE format('DROP TRIGGER IF EXISTS cache_invalidate ON %s', t);
+			EXECUTE format('CREATE TRIGGER cache_invalidate AFTER UPDATE OR DELETE ON %s FOR EACH ROW EXECUTE FUNCTION notify_record_change()', t);
+			CONTINUE WHEN EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_schema = lower(a.namespace_code) AND table_name = lower(a.code) AND column_name = 'updated_at'
+			);
+			EXECUTE format($q$
+				ALTER TABLE %s
+					ADD COLUMN updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+					ADD COLUMN created_by TEXT NOT NULL DEFAULT '',
+					ADD COLUMN updated_by TEXT NOT NULL DEFAULT ''
+			$q$, t);
+			EXECUTE format($q$
+				UPDATE %s d SET
+					created_at = COALESCE(h.created_at, d.created_at),
+					created_by = COALESCE(h.created_by, ''),
+					updated_at = COALESCE(h.updated_at, h.created_at, d.created_at),
+					updated_by = COALESCE(h.updated_by, h.created_by, '')
+				FROM (
+					SELECT uid,
+						min(created_at) FILTER (WHERE action = 'app_data.create') AS created_at,
+						(array_agg(actor ORDER BY id) FILTER (WHERE action = 'app_data.create'))[1] AS created_by,
+						max(created_at) AS updated_at,
+						(array_agg(actor ORDER BY id DESC))[1] AS updated_by
+					FROM audit_log
+					WHERE namespace_code = $1 AND app_code = $2 AND uid <> ''
+						AND action IN ('app_data.create', 'app_data.update', 'app_data.patch', 'app_data.transition')
+					GROUP BY uid
+				) h
+				WHERE h.uid = d.uid::text
+			$q$, t) USING a.namespace_code, a.code;
+		END LOOP;
+	END $$;`
 
-	_, err := db.Exec(createAppsTable)
+	if _, err := db.Exec(createAppsTable); err != nil {
+		return err
+	}
+	if _, err := db.Exec(postgres.TimestampFunction); err != nil {
+		return err
+	}
+	if err := ensureAppTables(db); err != nil {
+		return err
+	}
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_version (
+			id BOOLEAN PRIMARY KEY DEFAULT true CHECK (id),
+			version INT NOT NULL,
+			applied_at TIMESTAMPTZ NOT NULL DEFAULT now()
+		);
+		INSERT INTO schema_version (version) VALUES (` + strconv.Itoa(schemaVersion) + `)
+		ON CONFLICT (id) DO UPDATE SET version = GREATEST(schema_version.version, EXCLUDED.version), applied_at = now()`)
 	return err
 }
+
+// ensureAppTables добавляет служебные колонки в таблицы уже созданных приложений
+func ensureAppTables(db *sql.DB) error {
+	rows, err := db.Query("SELECT namespace_code, code FROM apps")
+	if err != nil {
+		return err
+	}
+	var tables []string
+	for rows.Next() {
+		var namespaceCode, code string
+		if err := rows.Scan(&namespaceCode, &code); err != nil {
+			rows.Close()
+			return err
+		}
+		tables = append(tables, namespaceCode+"."+code)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	for _, table := range tables {
+		if _, err := db.Exec("ALTER TABLE " + table + " ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMPTZ"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
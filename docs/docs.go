@@ -15,44 +15,31 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/namespace/{namespace}/app": {
-            "post": {
-                "description": "Создаёт новое приложение внутри namespace",
-                "consumes": [
-                    "application/json"
-                ],
+        "/approvals/inbox": {
+            "get": {
+                "description": "Возвращает ожидающие запросы на изменение, по которым текущий пользователь (X-User-ID, X-User-Roles) может проголосовать, от новых к старым",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "apps"
+                    "approvals"
                 ],
-                "summary": "Создать новое приложение",
+                "summary": "Входящие запросы на согласование",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Namespace Code",
-                        "name": "namespace",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Информация о приложении",
-                        "name": "app",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/domain.App"
-                        }
+                        "type": "integer",
+                        "description": "Количество запросов, по умолчанию 100",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.ChangeRequest"
                             }
                         }
                     },
@@ -77,56 +64,33 @@ const docTemplate = `{
                 }
             }
         },
-        "/namespace/{namespace}/app/{app}": {
-            "put": {
-                "description": "Обновляет информацию о приложении в указанном namespace",
-                "consumes": [
-                    "application/json"
-                ],
+        "/approvals/{id}": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "apps"
+                    "approvals"
                 ],
-                "summary": "Обновить приложение",
+                "summary": "Получить запрос на изменение",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Namespace Code",
-                        "name": "namespace",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "App Code",
-                        "name": "app",
+                        "description": "Change request ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Информация о приложении",
-                        "name": "app",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/domain.App"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/domain.ChangeRequest"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -134,8 +98,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -144,32 +108,47 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "Удаляет приложение по namespace и коду",
+            }
+        },
+        "/approvals/{id}/approve": {
+            "post": {
+                "description": "Голос «за» на текущем шаге. После нужного числа одобрений на последнем шаге изменение применяется к записи",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "apps"
+                    "approvals"
                 ],
-                "summary": "Удалить приложение",
+                "summary": "Одобрить запрос на изменение",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Namespace Code",
-                        "name": "namespace",
+                        "description": "Change request ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "App Code",
-                        "name": "app",
-                        "in": "path",
-                        "required": true
+                        "description": "Комментарий",
+                        "name": "decision",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ApprovalDecision"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ChangeRequest"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -177,51 +156,21 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
                                 "type": "string"
                             }
                         }
-                    }
-                }
-            }
-        },
-        "/namespace/{namespace}/app/{app}/data": {
-            "get": {
-                "description": "Возвращает все данные для указанного приложения в namespace",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "app-data"
-                ],
-                "summary": "Получить все данные приложения",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Namespace Code",
-                        "name": "namespace",
-                        "in": "path",
-                        "required": true
                     },
-                    {
-                        "type": "string",
-                        "description": "App Code",
-                        "name": "app",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/domain.AppData"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     },
@@ -235,9 +184,10 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/approvals/{id}/reject": {
             "post": {
-                "description": "Создаёт новые данные для указанного приложения в namespace",
                 "consumes": [
                     "application/json"
                 ],
@@ -245,43 +195,53 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "app-data"
+                    "approvals"
                 ],
-                "summary": "Создать новые данные приложения",
+                "summary": "Отклонить запрос на изменение",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Namespace Code",
-                        "name": "namespace",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "App Code",
-                        "name": "app",
+                        "description": "Change request ID",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Данные приложения",
-                        "name": "data",
+                        "description": "Комментарий",
+                        "name": "decision",
                         "in": "body",
-                        "required": true,
                         "schema": {
-                            "$ref": "#/definitions/domain.AppData"
+                            "$ref": "#/definitions/domain.ApprovalDecision"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/domain.AppData"
+                            "$ref": "#/definitions/domain.ChangeRequest"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -301,48 +261,84 @@ const docTemplate = `{
                 }
             }
         },
-        "/namespace/{namespace}/app/{app}/data/{uid}": {
+        "/audit": {
             "get": {
-                "description": "Возвращает данные приложения по уникальному идентификатору",
+                "description": "Возвращает записи журнала аудита по фильтру, от новых к старым",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "app-data"
+                    "audit"
                 ],
-                "summary": "Получить данные по UID",
+                "summary": "Получить журнал аудита",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Субъект",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Действие, например app_data.update",
+                        "name": "action",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "description": "Namespace Code",
                         "name": "namespace",
-                        "in": "path",
-                        "required": true
+                        "in": "query"
                     },
                     {
                         "type": "string",
                         "description": "App Code",
                         "name": "app",
-                        "in": "path",
-                        "required": true
+                        "in": "query"
                     },
                     {
                         "type": "string",
                         "description": "Data UID",
                         "name": "uid",
-                        "in": "path",
-                        "required": true
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Начало периода (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC3339)",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы (по умолчанию 100, максимум 1000)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/domain.AppData"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.AuditEntry"
+                            }
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -360,56 +356,74 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "Заменяет все данные для указанного UID",
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/audit/export": {
+            "get": {
+                "description": "Выгружает все записи журнала по фильтру в CSV или NDJSON",
                 "produces": [
-                    "application/json"
+                    "text/csv",
+                    "application/x-ndjson"
                 ],
                 "tags": [
-                    "app-data"
+                    "audit"
                 ],
-                "summary": "Полностью обновить данные",
+                "summary": "Выгрузить журнал аудита",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "csv (по умолчанию) или ndjson",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Субъект",
+                        "name": "actor",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Действие",
+                        "name": "action",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
                         "description": "Namespace Code",
                         "name": "namespace",
-                        "in": "path",
-                        "required": true
+                        "in": "query"
                     },
                     {
                         "type": "string",
                         "description": "App Code",
                         "name": "app",
-                        "in": "path",
-                        "required": true
+                        "in": "query"
                     },
                     {
                         "type": "string",
                         "description": "Data UID",
                         "name": "uid",
-                        "in": "path",
-                        "required": true
+                        "in": "query"
                     },
                     {
-                        "description": "Новые данные",
-                        "name": "data",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/domain.AppData"
-                        }
+                        "type": "string",
+                        "description": "Начало периода (RFC3339)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Конец периода (RFC3339)",
+                        "name": "to",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/domain.AppData"
+                            "type": "string"
                         }
                     },
                     "400": {
@@ -420,58 +434,25 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
-                    },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
-                        }
                     }
                 }
-            },
-            "delete": {
-                "description": "Удаляет данные по UID",
-                "tags": [
-                    "app-data"
+            }
+        },
+        "/audit/verify": {
+            "get": {
+                "description": "Пересчитывает цепочку хешей и возвращает ID первой изменённой записи",
+                "produces": [
+                    "application/json"
                 ],
-                "summary": "Удалить данные",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Namespace Code",
-                        "name": "namespace",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "App Code",
-                        "name": "app",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Data UID",
-                        "name": "uid",
-                        "in": "path",
-                        "required": true
-                    }
+                "tags": [
+                    "audit"
                 ],
+                "summary": "Проверить целостность журнала аудита",
                 "responses": {
-                    "204": {
-                        "description": "No Content"
-                    },
-                    "404": {
-                        "description": "Not Found",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": {
-                                "type": "string"
-                            }
+                            "$ref": "#/definitions/domain.AuditVerification"
                         }
                     },
                     "500": {
@@ -484,9 +465,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "patch": {
-                "description": "Обновляет только указанные поля данных",
+            }
+        },
+        "/batch": {
+            "post": {
+                "description": "Выполняет операции create, update, patch и delete над записями приложений любых namespace по порядку в одной транзакции: либо все, либо ни одной. Операция create может объявить ref, и следующие операции ссылаются на созданную запись значением \"$ref:имя\" в uid или в поле данных, в том числе во вложенных списках и объектах. При ошибке пакет откатывается, а номер неудавшейся операции (с нуля) возвращается в заголовке Batch-Failed-Operation. Изменения приложений с согласованием в пакете не выполняются. Каждая операция списывается из ограничения частоты изменений своего namespace",
                 "consumes": [
                     "application/json"
                 ],
@@ -494,45 +477,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "app-data"
+                    "batch"
                 ],
-                "summary": "Частично обновить данные",
+                "summary": "Выполнить пакет операций над записями",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Namespace Code",
-                        "name": "namespace",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "App Code",
-                        "name": "app",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Data UID",
-                        "name": "uid",
-                        "in": "path",
-                        "required": true
+                        "description": "Ключ повтора: запрос с тем же ключом получит сохранённый ответ, а не выполнит пакет повторно",
+                        "name": "Idempotency-Key",
+                        "in": "header"
                     },
                     {
-                        "description": "Поля для обновления",
-                        "name": "data",
+                        "description": "Операции",
+                        "name": "batch",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/domain.BatchRequest"
                         }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.BatchResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -540,8 +513,8 @@ const docTemplate = `{
                             }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "403": {
+                        "description": "Превышена квота записей",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -557,41 +530,48 @@ const docTemplate = `{
                                 "type": "string"
                             }
                         }
+                    },
+                    "409": {
+                        "description": "Значение уникального поля уже занято или запись изменена параллельно",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "429": {
+                        "description": "Превышено ограничение частоты изменений в namespace одной из операций",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     }
                 }
             }
         },
-        "/namespace/{namespace}/apps": {
+        "/healthz": {
             "get": {
-                "description": "Возвращает список всех приложений в указанном namespace",
+                "description": "Отвечает 200, пока процесс обслуживает запросы; база не проверяется. Подходит для livenessProbe",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "apps"
-                ],
-                "summary": "Получить все приложения по namespace",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Namespace Code",
-                        "name": "namespace",
-                        "in": "path",
-                        "required": true
-                    }
+                    "health"
                 ],
+                "summary": "Проверка живости процесса",
                 "responses": {
                     "200": {
                         "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/domain.App"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
                         "schema": {
                             "type": "object",
                             "additionalProperties": {
@@ -602,36 +582,67 @@ const docTemplate = `{
                 }
             }
         },
-        "/namespaces": {
+        "/icons/{id}/{size}": {
             "get": {
-                "description": "Get list of all namespaces",
+                "description": "Возвращает исходную иконку или уменьшенную копию в PNG (для SVG всегда исходник). Ответ кешируется навсегда",
                 "produces": [
-                    "application/json"
+                    "image/png",
+                    "image/jpeg",
+                    "image/svg+xml"
                 ],
                 "tags": [
-                    "namespaces"
+                    "icons"
+                ],
+                "summary": "Получить иконку",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Icon ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер копии: 32, 64, 128 или 256",
+                        "name": "size",
+                        "in": "path"
+                    }
                 ],
-                "summary": "Get all namespaces",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/domain.Namespace"
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
                             }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
-            },
+            }
+        },
+        "/namespace/{namespace}/app": {
             "post": {
-                "description": "Create a new namespace with the input payload",
+                "description": "Создаёт новое приложение внутри namespace",
                 "consumes": [
                     "application/json"
                 ],
@@ -639,17 +650,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "namespaces"
+                    "apps"
                 ],
-                "summary": "Create a namespace",
+                "summary": "Создать новое приложение",
                 "parameters": [
                     {
-                        "description": "Namespace data",
+                        "type": "string",
+                        "description": "Namespace Code",
                         "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Информация о приложении",
+                        "name": "app",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/domain.Namespace"
+                            "$ref": "#/definitions/domain.App"
                         }
                     }
                 ],
@@ -657,175 +675,4349 @@ const docTemplate = `{
                     "201": {
                         "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/domain.Namespace"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             }
         },
-        "/namespaces/{code}": {
-            "get": {
-                "description": "Get namespace details by its code",
+        "/namespace/{namespace}/app/{app}": {
+            "put": {
+                "description": "Обновляет информацию о приложении в указанном namespace",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "namespaces"
+                    "apps"
                 ],
-                "summary": "Get namespace by code",
+                "summary": "Обновить приложение",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Namespace code",
-                        "name": "code",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Информация о приложении",
+                        "name": "app",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.App"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/domain.Namespace"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "404": {
                         "description": "Not Found",
                         "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "409": {
+                        "description": "В записях есть повторы значений уникальных полей",
                         "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     }
                 }
             },
-            "put": {
-                "description": "Update namespace details",
-                "consumes": [
-                    "application/json"
+            "delete": {
+                "description": "Перемещает приложение в корзину, окончательно оно удаляется по истечении срока хранения",
+                "tags": [
+                    "apps"
+                ],
+                "summary": "Удалить приложение",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/app/{app}/approvals": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "namespaces"
+                    "approvals"
                 ],
-                "summary": "Update namespace",
+                "summary": "Запросы на изменение записей приложения",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Namespace code",
-                        "name": "code",
+                        "description": "Namespace Code",
+                        "name": "namespace",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Namespace data",
-                        "name": "namespace",
-                        "in": "body",
-                        "required": true,
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "pending, approved, rejected, expired, failed",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Data UID",
+                        "name": "uid",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/domain.Namespace"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.ChangeRequest"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
+                }
+            }
+        },
+        "/namespace/{namespace}/app/{app}/data": {
+            "get": {
+                "description": "Возвращает данные приложения в namespace с фильтрами, сортировкой и пагинацией.\nКроме полей данных доступны системные поля $createdAt, $updatedAt, $createdBy, $updatedBy и $number",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "app-data"
+                ],
+                "summary": "Получить все данные приложения",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Локали для локализуемых полей, без заголовка возвращаются значения всех локалей",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "Условие поле:оператор:значение, операторы eq, ne, gt, gte, lt, lte, contains",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Поля сортировки через запятую, минус — по убыванию: -total,name",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Размер страницы",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Смещение",
+                        "name": "offset",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Версия записей: draft или published; для ключей API по умолчанию published",
+                        "name": "stage",
+                        "in": "query"
+                    }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/domain.Namespace"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.AppData"
+                            }
                         }
                     },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             },
-            "delete": {
-                "description": "Delete namespace by code",
+            "post": {
+                "description": "Создаёт новые данные для указанного приложения в namespace",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "namespaces"
+                    "app-data"
                 ],
-                "summary": "Delete namespace",
+                "summary": "Создать новые данные приложения",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Namespace code",
-                        "name": "code",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Локаль строковых значений локализуемых полей, по умолчанию основная локаль namespace",
+                        "name": "Content-Language",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Ключ повтора: запрос с тем же ключом получит сохранённый ответ, а не создаст вторую запись",
+                        "name": "Idempotency-Key",
+                        "in": "header"
+                    },
+                    {
+                        "description": "Данные приложения",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.AppData"
+                        }
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/domain.AppData"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Значение уникального поля уже занято или запрос с тем же ключом ещё выполняется",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "422": {
+                        "description": "Ключ повтора уже использован с другим запросом",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
                     },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
-                            "type": "string"
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "domain.App": {
-            "type": "object",
-            "properties": {
-                "code": {
-                    "type": "string"
-                },
-                "icon": {
-                    "type": "string"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "namespaceCode": {
-                    "type": "string"
-                }
-            }
         },
-        "domain.AppData": {
-            "type": "object",
-            "properties": {
-                "data": {
-                    "description": "Произвольные JSON данные",
-                    "type": "object",
-                    "additionalProperties": true
-                },
-                "uid": {
-                    "description": "Уникальный идентификатор",
-                    "type": "string"
-                }
-            }
+        "/namespace/{namespace}/app/{app}/data/by-number/{number}": {
+            "get": {
+                "description": "Ищет запись по номеру, выданному нумерацией приложения, например INV-2026-00042",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "app-data"
+                ],
+                "summary": "Получить данные по номеру",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Локали для локализуемых полей, без заголовка возвращаются значения всех локалей",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Номер записи",
+                        "name": "number",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Версия записи: draft или published; для ключей API по умолчанию published",
+                        "name": "stage",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.AppData"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/app/{app}/data/trash": {
+            "get": {
+                "description": "Возвращает записи приложения, перемещённые в корзину",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "app-data"
+                ],
+                "summary": "Получить данные из корзины",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.AppData"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/app/{app}/data/{uid}": {
+            "get": {
+                "description": "Возвращает данные приложения по уникальному идентификатору",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "app-data"
+                ],
+                "summary": "Получить данные по UID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Локали для локализуемых полей, без заголовка возвращаются значения всех локалей",
+                        "name": "Accept-Language",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Data UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Версия записи: draft или published; для ключей API по умолчанию published",
+                        "name": "stage",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.AppData"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Заменяет все данные для указанного UID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "app-data"
+                ],
+                "summary": "Полностью обновить данные",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Локаль строковых значений локализуемых полей, по умолчанию основная локаль namespace",
+                        "name": "Content-Language",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Data UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Новые данные",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.AppData"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.AppData"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Значение уникального поля уже занято",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Перемещает данные с указанным UID в корзину",
+                "tags": [
+                    "app-data"
+                ],
+                "summary": "Удалить данные",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Data UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "Обновляет только указанные поля данных",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "app-data"
+                ],
+                "summary": "Частично обновить данные",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Локаль строковых значений локализуемых полей, по умолчанию основная локаль namespace",
+                        "name": "Content-Language",
+                        "in": "header"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Data UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Поля для обновления",
+                        "name": "data",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Значение уникального поля уже занято",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/app/{app}/data/{uid}/files/{field}": {
+            "get": {
+                "description": "Возвращает содержимое файла; ETag равен SHA-256 файла",
+                "produces": [
+                    "application/octet-stream"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Скачать файл из поля записи",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Data UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Код поля типа file",
+                        "name": "field",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Загружает файл в поле типа file, заменяя предыдущий. Тип содержимого определяется по данным файла",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Загрузить файл в поле записи",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Data UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Код поля типа file",
+                        "name": "field",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Файл",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Attachment"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет файл и очищает поле записи",
+                "tags": [
+                    "attachments"
+                ],
+                "summary": "Удалить файл из поля записи",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Data UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Код поля типа file",
+                        "name": "field",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/app/{app}/data/{uid}/hold": {
+            "put": {
+                "description": "Запись под удержанием не удаляется и не архивируется по сроку хранения и не удаляется из корзины окончательно",
+                "tags": [
+                    "retention"
+                ],
+                "summary": "Поставить запись под удержание",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Record UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "retention"
+                ],
+                "summary": "Снять удержание с записи",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Record UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/app/{app}/data/{uid}/publish": {
+            "post": {
+                "description": "Копирует черновик записи в опубликованную версию. Если в теле передано время в будущем, публикация выполняется в это время",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "publishing"
+                ],
+                "summary": "Опубликовать черновик записи",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Record UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Время отложенной публикации",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/http_handler.publishRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/app/{app}/data/{uid}/restore": {
+            "post": {
+                "description": "Возвращает запись с указанным UID из корзины",
+                "tags": [
+                    "app-data"
+                ],
+                "summary": "Восстановить данные",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Data UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Значение уникального поля уже занято",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/app/{app}/data/{uid}/schedule": {
+            "delete": {
+                "description": "Отменяет отложенные публикацию и снятие с публикации записи",
+                "tags": [
+                    "publishing"
+                ],
+                "summary": "Отменить запланированную публикацию",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Record UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/app/{app}/data/{uid}/transition": {
+            "post": {
+                "description": "Выполняет переход, объявленный в workflow приложения. Вместе с переходом можно заполнить поля записи",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "Перевести запись в другое состояние",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Data UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Целевое состояние и комментарий",
+                        "name": "transition",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.TransitionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.AppData"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/app/{app}/data/{uid}/transitions": {
+            "get": {
+                "description": "Возвращает выполненные переходы записи в порядке выполнения",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workflow"
+                ],
+                "summary": "История переходов записи",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Data UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.TransitionRecord"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/app/{app}/data/{uid}/unpublish": {
+            "post": {
+                "description": "Удаляет опубликованную версию записи, черновик сохраняется. Время в будущем откладывает снятие",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "publishing"
+                ],
+                "summary": "Снять запись с публикации",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Record UID",
+                        "name": "uid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Время отложенного снятия с публикации",
+                        "name": "request",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/http_handler.publishRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/app/{app}/icon": {
+            "put": {
+                "description": "Принимает PNG, JPEG или SVG до 2 МБ и создаёт уменьшенные копии 32, 64, 128 и 256 px",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "icons"
+                ],
+                "summary": "Загрузить иконку приложения",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Иконка",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Icon"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "icons"
+                ],
+                "summary": "Удалить иконку приложения",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/app/{app}/indexes": {
+            "get": {
+                "description": "Возвращает все индексы таблицы с размером и статистикой использования из pg_stat_user_indexes. managed — индекс создан по схеме приложения",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "indexes"
+                ],
+                "summary": "Индексы таблицы записей приложения",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.IndexInfo"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/app/{app}/indexes/sync": {
+            "post": {
+                "description": "Создаёт недостающие и недостроенные индексы и удаляет лишние. Индексы строятся CONCURRENTLY, не блокируя запись",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "indexes"
+                ],
+                "summary": "Перестроить индексы по схеме приложения",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.IndexInfo"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "В записях есть повторы значений уникальных полей",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/app/{app}/restore": {
+            "post": {
+                "description": "Возвращает приложение из корзины",
+                "tags": [
+                    "apps"
+                ],
+                "summary": "Восстановить приложение",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/app/{app}/rules": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "automation"
+                ],
+                "summary": "Правила автоматизации приложения",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.AutomationRule"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Правило выполняется асинхронно при создании, изменении или удалении записей приложения",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "automation"
+                ],
+                "summary": "Создать правило автоматизации",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Правило",
+                        "name": "rule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.AutomationRule"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/domain.AutomationRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/app/{app}/rules/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "automation"
+                ],
+                "summary": "Получить правило автоматизации",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.AutomationRule"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "automation"
+                ],
+                "summary": "Изменить правило автоматизации",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Правило",
+                        "name": "rule",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.AutomationRule"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.AutomationRule"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "automation"
+                ],
+                "summary": "Удалить правило автоматизации",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/app/{app}/rules/{id}/runs": {
+            "get": {
+                "description": "Возвращает последние выполнения правила, новые первыми",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "automation"
+                ],
+                "summary": "Журнал выполнения правила",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество записей, по умолчанию 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.AutomationRun"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/app/{app}/rules/{id}/test": {
+            "post": {
+                "description": "Проверяет условие правила на примере записи и возвращает действия, которые были бы выполнены. Ничего не изменяет и не отправляет",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "automation"
+                ],
+                "summary": "Тестовый запуск правила",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "App Code",
+                        "name": "app",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Rule ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Событие и пример записи",
+                        "name": "sample",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.RuleTestRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.RuleTestResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/apps": {
+            "get": {
+                "description": "Возвращает список всех приложений в указанном namespace",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "apps"
+                ],
+                "summary": "Получить все приложения по namespace",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.App"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/apps/trash": {
+            "get": {
+                "description": "Возвращает приложения namespace, перемещённые в корзину",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "apps"
+                ],
+                "summary": "Получить приложения из корзины",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.App"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/jobs": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Запланированные задания namespace",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.ScheduledJob"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Задание выполняется по cron-расписанию: пересчёт вычисляемых полей (recompute), удаление старых записей (purge), выгрузка записей вебхуком (export) или запуск правила автоматизации (run_rule)",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Создать запланированное задание",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Задание",
+                        "name": "job",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.ScheduledJob"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ScheduledJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/jobs/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Получить запланированное задание",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ScheduledJob"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Следующий запуск пересчитывается по новому расписанию. Пауза меняется через /pause и /resume",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Изменить запланированное задание",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Задание",
+                        "name": "job",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.ScheduledJob"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ScheduledJob"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Удалить запланированное задание",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/jobs/{id}/pause": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Приостановить задание",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ScheduledJob"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/jobs/{id}/resume": {
+            "post": {
+                "description": "Пропущенные за время паузы запуски не выполняются, следующий считается от текущего времени",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Возобновить задание",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.ScheduledJob"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/jobs/{id}/run": {
+            "post": {
+                "description": "Запускает задание в фоне и возвращает начатый запуск со статусом running; результат появится в истории запусков. Расписание не меняется",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "Запустить задание вручную",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Accepted",
+                        "schema": {
+                            "$ref": "#/definitions/domain.JobRun"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "409": {
+                        "description": "Задание уже выполняется",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespace/{namespace}/jobs/{id}/runs": {
+            "get": {
+                "description": "Возвращает последние выполнения задания, новые первыми",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "jobs"
+                ],
+                "summary": "История выполнения задания",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "namespace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Job ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество записей, по умолчанию 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.JobRun"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespaces": {
+            "get": {
+                "description": "Get list of all namespaces",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "namespaces"
+                ],
+                "summary": "Get all namespaces",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.Namespace"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Create a new namespace with the input payload",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "namespaces"
+                ],
+                "summary": "Create a namespace",
+                "parameters": [
+                    {
+                        "description": "Namespace data",
+                        "name": "namespace",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.Namespace"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Namespace"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/namespaces/trash": {
+            "get": {
+                "description": "Get list of namespaces moved to the trash",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "namespaces"
+                ],
+                "summary": "Get trashed namespaces",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.Namespace"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/namespaces/{code}": {
+            "get": {
+                "description": "Get namespace details by its code",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "namespaces"
+                ],
+                "summary": "Get namespace by code",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Namespace"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Update namespace details",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "namespaces"
+                ],
+                "summary": "Update namespace",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Namespace data",
+                        "name": "namespace",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.Namespace"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Namespace"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Move namespace to the trash, it is purged after the retention period",
+                "tags": [
+                    "namespaces"
+                ],
+                "summary": "Delete namespace",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/namespaces/{code}/icon": {
+            "put": {
+                "description": "Принимает PNG, JPEG или SVG до 2 МБ и создаёт уменьшенные копии 32, 64, 128 и 256 px",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "icons"
+                ],
+                "summary": "Загрузить иконку namespace",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Иконка",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Icon"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "413": {
+                        "description": "Request Entity Too Large",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "tags": [
+                    "icons"
+                ],
+                "summary": "Удалить иконку namespace",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespaces/{code}/limits": {
+            "get": {
+                "description": "Возвращает ограничения частоты запросов в минуту на субъекта и квоты с учётом значений по умолчанию; 0 — ограничения нет. Свои значения namespace возвращаются в поле limits самого namespace",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "limits"
+                ],
+                "summary": "Действующие ограничения namespace",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Limits"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Заменяет свои ограничения namespace. 0 — значение по умолчанию из настроек сервиса, -1 — без ограничения. Квоты проверяются при создании приложений и записей, уже созданные объекты не удаляются",
+                "consumes": [
+                    "application/json"
+                ],
+                "tags": [
+                    "limits"
+                ],
+                "summary": "Задать ограничения namespace",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Ограничения",
+                        "name": "limits",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/domain.Limits"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Удаляет свои ограничения namespace, после чего действуют значения по умолчанию",
+                "tags": [
+                    "limits"
+                ],
+                "summary": "Сбросить ограничения namespace",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace Code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/namespaces/{code}/restore": {
+            "post": {
+                "description": "Restore namespace from the trash by code",
+                "tags": [
+                    "namespaces"
+                ],
+                "summary": "Restore namespace",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Namespace code",
+                        "name": "code",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "string"
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications": {
+            "get": {
+                "description": "Возвращает уведомления пользователя из X-User-ID, новые первыми",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Уведомления текущего пользователя",
+                "parameters": [
+                    {
+                        "type": "boolean",
+                        "description": "Только непрочитанные",
+                        "name": "unread",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Количество записей, по умолчанию 100",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.Notification"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/notifications/{id}/read": {
+            "post": {
+                "tags": [
+                    "notifications"
+                ],
+                "summary": "Отметить уведомление прочитанным",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Notification ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "type": "string"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/readyz": {
+            "get": {
+                "description": "Отвечает 200, если база доступна и миграции применены, иначе 503 с результатами проверок. Во время остановки всегда 503. Подходит для readinessProbe",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "health"
+                ],
+                "summary": "Проверка готовности принимать запросы",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Readiness"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/domain.Readiness"
+                        }
+                    }
+                }
+            }
+        },
+        "/retention/metrics": {
+            "get": {
+                "description": "Счётчики удалённых и архивированных по сроку хранения записей с момента запуска экземпляра сервиса",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "retention"
+                ],
+                "summary": "Статистика сроков хранения",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/domain.RetentionMetrics"
+                            }
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "domain.ActionResult": {
+            "type": "object",
+            "properties": {
+                "data": {},
+                "error": {
+                    "type": "string"
+                },
+                "target": {
+                    "description": "поле, приложение, адрес или получатели",
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.App": {
+            "type": "object",
+            "properties": {
+                "approval": {
+                    "description": "Правки записей требуют согласования",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.ApprovalPolicy"
+                        }
+                    ]
+                },
+                "code": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "description": "Время перемещения в корзину",
+                    "type": "string"
+                },
+                "drafts": {
+                    "description": "Правки идут в черновик, читателям видна опубликованная версия",
+                    "type": "boolean"
+                },
+                "fields": {
+                    "description": "Описание полей записей",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.Field"
+                    }
+                },
+                "icon": {
+                    "description": "ID иконки, меняется только загрузкой",
+                    "type": "string"
+                },
+                "indexError": {
+                    "description": "Почему индексы не построены по схеме; пусто, если построены",
+                    "type": "string"
+                },
+                "maxFileSize": {
+                    "description": "Лимит размера вложения в байтах, 0 — значение по умолчанию",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "namespaceCode": {
+                    "type": "string"
+                },
+                "numbering": {
+                    "description": "Номера записей вида INV-2026-00042",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.NumberingPolicy"
+                        }
+                    ]
+                },
+                "retention": {
+                    "description": "Срок хранения записей",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.RetentionPolicy"
+                        }
+                    ]
+                },
+                "unique": {
+                    "description": "Составные ограничения уникальности, например [[\"sku\", \"warehouse\"]]",
+                    "type": "array",
+                    "items": {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        }
+                    }
+                },
+                "workflow": {
+                    "description": "Машина состояний записей",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.Workflow"
+                        }
+                    ]
+                }
+            }
+        },
+        "domain.AppData": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "description": "Системные поля, заполняются при каждой записи",
+                    "type": "string"
+                },
+                "createdBy": {
+                    "type": "string"
+                },
+                "data": {
+                    "description": "Произвольные JSON данные",
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "deletedAt": {
+                    "description": "Время перемещения в корзину",
+                    "type": "string"
+                },
+                "legalHold": {
+                    "description": "Запись под удержанием не удаляется по сроку хранения",
+                    "type": "boolean"
+                },
+                "number": {
+                    "description": "Номер записи, если у приложения задана нумерация",
+                    "type": "string"
+                },
+                "publishAt": {
+                    "description": "Запланированная публикация черновика",
+                    "type": "string"
+                },
+                "publishedAt": {
+                    "description": "Публикация, если у приложения включены черновики",
+                    "type": "string"
+                },
+                "uid": {
+                    "description": "Уникальный идентификатор",
+                    "type": "string"
+                },
+                "unpublishAt": {
+                    "description": "Запланированное снятие с публикации",
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "updatedBy": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.ApprovalDecision": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.ApprovalPolicy": {
+            "type": "object",
+            "properties": {
+                "steps": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.ApprovalStep"
+                    }
+                },
+                "ttl": {
+                    "description": "Срок жизни запроса, например \"72h\"; пусто — значение по умолчанию",
+                    "type": "string"
+                }
+            }
+        },
+        "domain.ApprovalStep": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "required": {
+                    "description": "Сколько одобрений нужно для перехода к следующему шагу",
+                    "type": "integer"
+                },
+                "roles": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "domain.ApprovalVote": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "decision": {
+                    "type": "string"
+                },
+                "reviewer": {
+                    "type": "string"
+                },
+                "step": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.Attachment": {
+            "type": "object",
+            "properties": {
+                "appCode": {
+                    "type": "string"
+                },
+                "contentType": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "field": {
+                    "type": "string"
+                },
+                "fileName": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "namespaceCode": {
+                    "type": "string"
+                },
+                "recordUid": {
+                    "type": "string"
+                },
+                "sha256": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.AuditEntry": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor": {
+                    "type": "string"
+                },
+                "appCode": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "diff": {
+                    "description": "Изменения в формате {\"поле\": {\"old\": ..., \"new\": ...}}",
+                    "type": "object"
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "namespaceCode": {
+                    "type": "string"
+                },
+                "prevHash": {
+                    "type": "string"
+                },
+                "requestId": {
+                    "type": "string"
+                },
+                "sourceIp": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.AuditVerification": {
+            "type": "object",
+            "properties": {
+                "brokenAt": {
+                    "description": "ID первой записи, не прошедшей проверку",
+                    "type": "integer"
+                },
+                "checked": {
+                    "type": "integer"
+                },
+                "valid": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "domain.AutomationAction": {
+            "type": "object",
+            "properties": {
+                "app": {
+                    "type": "string"
+                },
+                "field": {
+                    "description": "set_field",
+                    "type": "string"
+                },
+                "formula": {
+                    "type": "string"
+                },
+                "formulas": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "message": {
+                    "type": "string"
+                },
+                "namespace": {
+                    "description": "create_record; пустой Namespace — namespace приложения правила",
+                    "type": "string"
+                },
+                "to": {
+                    "description": "notify; в Message подставляются значения полей записи вида {field}",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "type": "string"
+                },
+                "url": {
+                    "description": "webhook",
+                    "type": "string"
+                },
+                "value": {},
+                "values": {
+                    "type": "object",
+                    "additionalProperties": true
+                }
+            }
+        },
+        "domain.AutomationRule": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.AutomationAction"
+                    }
+                },
+                "appCode": {
+                    "type": "string"
+                },
+                "condition": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "enabled": {
+                    "type": "boolean"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "namespaceCode": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.AutomationRun": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.ActionResult"
+                    }
+                },
+                "appCode": {
+                    "type": "string"
+                },
+                "depth": {
+                    "description": "Сколько правил вызвали событие цепочкой",
+                    "type": "integer"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "event": {
+                    "type": "string"
+                },
+                "finishedAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "namespaceCode": {
+                    "type": "string"
+                },
+                "recordUid": {
+                    "type": "string"
+                },
+                "ruleId": {
+                    "type": "string"
+                },
+                "startedAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.BatchOperation": {
+            "type": "object",
+            "properties": {
+                "app": {
+                    "type": "string"
+                },
+                "data": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "namespace": {
+                    "type": "string"
+                },
+                "op": {
+                    "description": "create, update, patch или delete",
+                    "type": "string"
+                },
+                "ref": {
+                    "description": "Ref — имя записи, создаваемой операцией create, для ссылок из следующих операций",
+                    "type": "string"
+                },
+                "uid": {
+                    "description": "UID — запись для update, patch и delete, uid или ссылка \"$ref:имя\"",
+                    "type": "string"
+                }
+            }
+        },
+        "domain.BatchRequest": {
+            "type": "object",
+            "properties": {
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.BatchOperation"
+                    }
+                }
+            }
+        },
+        "domain.BatchResponse": {
+            "type": "object",
+            "properties": {
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.BatchResult"
+                    }
+                }
+            }
+        },
+        "domain.BatchResult": {
+            "type": "object",
+            "properties": {
+                "app": {
+                    "type": "string"
+                },
+                "namespace": {
+                    "type": "string"
+                },
+                "op": {
+                    "type": "string"
+                },
+                "record": {
+                    "$ref": "#/definitions/domain.AppData"
+                },
+                "ref": {
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.ChangeRequest": {
+            "type": "object",
+            "properties": {
+                "appCode": {
+                    "type": "string"
+                },
+                "baseUpdatedAt": {
+                    "description": "BaseUpdatedAt — время изменения записи, которую заменяет запрос полной замены;\nесли запись изменилась, пока запрос ждал согласования, он не применяется",
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "data": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "decidedAt": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "expiresAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "namespaceCode": {
+                    "type": "string"
+                },
+                "policy": {
+                    "$ref": "#/definitions/domain.ApprovalPolicy"
+                },
+                "recordUid": {
+                    "type": "string"
+                },
+                "requestedBy": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "step": {
+                    "description": "Индекс текущего шага в Policy.Steps",
+                    "type": "integer"
+                },
+                "votes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.ApprovalVote"
+                    }
+                }
+            }
+        },
+        "domain.DataFilter": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "op": {
+                    "type": "string"
+                },
+                "value": {}
+            }
+        },
+        "domain.Field": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "formula": {
+                    "description": "Выражение вычисляемого поля, например price * qty",
+                    "type": "string"
+                },
+                "indexed": {
+                    "description": "Для поля строится индекс, ускоряющий фильтры и сортировку",
+                    "type": "boolean"
+                },
+                "localized": {
+                    "description": "Localized — строковое поле хранит значения по локалям: {\"ru\": \"Стол\", \"en\": \"Table\"}",
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "unique": {
+                    "description": "Значение не повторяется среди записей вне корзины",
+                    "type": "boolean"
+                }
+            }
+        },
+        "domain.Icon": {
+            "type": "object",
+            "properties": {
+                "contentType": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "height": {
+                    "description": "Для SVG не заполняется",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "sha256": {
+                    "type": "string"
+                },
+                "size": {
+                    "type": "integer"
+                },
+                "width": {
+                    "description": "Для SVG не заполняется",
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.IndexInfo": {
+            "type": "object",
+            "properties": {
+                "definition": {
+                    "type": "string"
+                },
+                "managed": {
+                    "description": "индекс создан по схеме приложения",
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scans": {
+                    "type": "integer"
+                },
+                "sizeBytes": {
+                    "type": "integer"
+                },
+                "tuplesFetched": {
+                    "type": "integer"
+                },
+                "tuplesRead": {
+                    "type": "integer"
+                },
+                "unique": {
+                    "type": "boolean"
+                },
+                "valid": {
+                    "description": "false — построение CONCURRENTLY не завершилось",
+                    "type": "boolean"
+                }
+            }
+        },
+        "domain.JobAction": {
+            "type": "object",
+            "properties": {
+                "app": {
+                    "type": "string"
+                },
+                "field": {
+                    "description": "purge: записи, у которых поле Field типа datetime старше OlderThan (например \"720h\")",
+                    "type": "string"
+                },
+                "filters": {
+                    "description": "export: записи по фильтрам отправляются POST-запросом на URL",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.DataFilter"
+                    }
+                },
+                "headers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "olderThan": {
+                    "type": "string"
+                },
+                "ruleId": {
+                    "description": "run_rule",
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.JobRun": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "finishedAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "jobId": {
+                    "type": "string"
+                },
+                "processed": {
+                    "type": "integer"
+                },
+                "startedAt": {
+                    "type": "string"
+                },
+                "status": {
+                    "description": "success или failed",
+                    "type": "string"
+                },
+                "trigger": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.Limits": {
+            "type": "object",
+            "properties": {
+                "maxApps": {
+                    "type": "integer"
+                },
+                "maxRecordsPerApp": {
+                    "type": "integer"
+                },
+                "readsPerMinute": {
+                    "type": "integer"
+                },
+                "schemaChangesPerMinute": {
+                    "type": "integer"
+                },
+                "writesPerMinute": {
+                    "type": "integer"
+                }
+            }
         },
         "domain.Namespace": {
             "type": "object",
@@ -833,8 +5025,332 @@ const docTemplate = `{
                 "code": {
                     "type": "string"
                 },
+                "deletedAt": {
+                    "description": "Время перемещения в корзину",
+                    "type": "string"
+                },
+                "icon": {
+                    "description": "ID иконки, меняется только загрузкой",
+                    "type": "string"
+                },
+                "limits": {
+                    "description": "Свои ограничения namespace, меняются только через /limits",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/domain.Limits"
+                        }
+                    ]
+                },
+                "locales": {
+                    "description": "Цепочка локалей для локализуемых полей, первая — основная",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.Notification": {
+            "type": "object",
+            "properties": {
+                "appCode": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "namespaceCode": {
+                    "type": "string"
+                },
+                "readAt": {
+                    "type": "string"
+                },
+                "recipient": {
+                    "type": "string"
+                },
+                "recordUid": {
+                    "type": "string"
+                },
+                "ruleId": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.NumberingPolicy": {
+            "type": "object",
+            "properties": {
+                "format": {
+                    "type": "string"
+                },
+                "reset": {
+                    "description": "yearly, monthly или пусто — сквозная нумерация",
+                    "type": "string"
+                }
+            }
+        },
+        "domain.Readiness": {
+            "type": "object",
+            "properties": {
+                "checks": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "ready": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "domain.RetentionMetrics": {
+            "type": "object",
+            "properties": {
+                "appCode": {
+                    "type": "string"
+                },
+                "archived": {
+                    "type": "integer"
+                },
+                "batches": {
+                    "type": "integer"
+                },
+                "deleted": {
+                    "type": "integer"
+                },
+                "errors": {
+                    "description": "проходов, завершившихся ошибкой",
+                    "type": "integer"
+                },
+                "held": {
+                    "description": "записей с истёкшим сроком, оставленных из-за удержания, на последнем проходе",
+                    "type": "integer"
+                },
+                "lastDuration": {
+                    "type": "string"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "lastRunAt": {
+                    "type": "string"
+                },
+                "namespaceCode": {
+                    "type": "string"
+                },
+                "runs": {
+                    "type": "integer"
+                }
+            }
+        },
+        "domain.RetentionPolicy": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "integer"
+                },
+                "field": {
+                    "type": "string"
+                },
+                "mode": {
+                    "description": "delete или archive, по умолчанию delete",
+                    "type": "string"
+                }
+            }
+        },
+        "domain.RuleTestRequest": {
+            "type": "object",
+            "properties": {
+                "event": {
+                    "type": "string"
+                },
+                "record": {
+                    "type": "object",
+                    "additionalProperties": true
+                }
+            }
+        },
+        "domain.RuleTestResult": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.ActionResult"
+                    }
+                },
+                "error": {
+                    "type": "string"
+                },
+                "matched": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "domain.ScheduledJob": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/domain.JobAction"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "lastRunAt": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "namespaceCode": {
+                    "type": "string"
+                },
+                "nextRunAt": {
+                    "type": "string"
+                },
+                "paused": {
+                    "type": "boolean"
+                },
+                "schedule": {
+                    "description": "cron-выражение из пяти полей или @daily, @hourly и т.п.",
+                    "type": "string"
+                },
+                "timezone": {
+                    "description": "IANA, по умолчанию UTC",
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.TransitionRecord": {
+            "type": "object",
+            "properties": {
+                "actor": {
+                    "type": "string"
+                },
+                "appCode": {
+                    "type": "string"
+                },
+                "comment": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "namespaceCode": {
+                    "type": "string"
+                },
+                "recordUid": {
+                    "type": "string"
+                },
+                "requestId": {
+                    "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.TransitionRequest": {
+            "type": "object",
+            "properties": {
+                "comment": {
+                    "type": "string"
+                },
+                "data": {
+                    "type": "object",
+                    "additionalProperties": true
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "domain.Workflow": {
+            "type": "object",
+            "properties": {
+                "field": {
+                    "type": "string"
+                },
+                "initial": {
+                    "description": "Состояние новой записи",
+                    "type": "string"
+                },
+                "states": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.WorkflowState"
+                    }
+                },
+                "transitions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/domain.WorkflowTransition"
+                    }
+                }
+            }
+        },
+        "domain.WorkflowState": {
+            "type": "object",
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "requiredFields": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "domain.WorkflowTransition": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "guard": {
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "http_handler.publishRequest": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
                 }
             }
         }
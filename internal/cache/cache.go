@@ -0,0 +1,63 @@
+// Package cache содержит кэш чтений: в памяти процесса или в Redis. Ключи
+// и шаблоны ключей совпадают с теми, что рассылают триггеры базы в канал
+// Channel, поэтому уведомление из базы можно применить как есть
+package cache
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Channel — канал LISTEN/NOTIFY, в который триггеры базы пишут устаревшие ключи
+const Channel = "cache_invalidate"
+
+// Ключи кэша. Коды namespace и приложений приводятся к нижнему регистру,
+// как имена схем и таблиц в базе
+const (
+	NamespacesKey = "namespaces"
+	AppsKey       = "apps"
+)
+
+type Cache interface {
+	// Get возвращает значение и false, если ключа нет или срок его хранения истёк
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+	// DeletePrefix удаляет все ключи с префиксом prefix; пустой префикс очищает кэш
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
+// RecordKey возвращает ключ записи приложения
+func RecordKey(namespace, app, uid string) string {
+	return "data:" + strings.ToLower(namespace) + ":" + strings.ToLower(app) + ":" + strings.ToLower(uid)
+}
+
+// AppRecordsPattern возвращает шаблон ключей всех записей приложения
+func AppRecordsPattern(namespace, app string) string {
+	return "data:" + strings.ToLower(namespace) + ":" + strings.ToLower(app) + ":*"
+}
+
+// NamespaceRecordsPattern возвращает шаблон ключей всех записей namespace
+func NamespaceRecordsPattern(namespace string) string {
+	return "data:" + strings.ToLower(namespace) + ":*"
+}
+
+// Invalidate удаляет ключ или, если он оканчивается на *, все ключи с этим префиксом
+func Invalidate(ctx context.Context, c Cache, key string) error {
+	if prefix, ok := strings.CutSuffix(key, "*"); ok {
+		return c.DeletePrefix(ctx, prefix)
+	}
+	return c.Delete(ctx, key)
+}
+
+// Nop — кэш, в котором ничего не хранится; используется, когда кэш выключен
+type Nop struct{}
+
+func (Nop) Get(ctx context.Context, key string) ([]byte, bool, error) { return nil, false, nil }
+
+func (Nop) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error { return nil }
+
+func (Nop) Delete(ctx context.Context, key string) error { return nil }
+
+func (Nop) DeletePrefix(ctx context.Context, prefix string) error { return nil }
@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func mustGet(t *testing.T, c Cache, key string) (string, bool) {
+	t.Helper()
+	value, ok, err := c.Get(context.Background(), key)
+	if err != nil {
+		t.Fatalf("Get(%q): %v", key, err)
+	}
+	return string(value), ok
+}
+
+func mustSet(t *testing.T, c Cache, key, value string, ttl time.Duration) {
+	t.Helper()
+	if err := c.Set(context.Background(), key, []byte(value), ttl); err != nil {
+		t.Fatalf("Set(%q): %v", key, err)
+	}
+}
+
+func TestLRUGetSet(t *testing.T) {
+	c := NewLRU(10)
+	if _, ok := mustGet(t, c, "a"); ok {
+		t.Fatal("Get on empty cache found a value")
+	}
+	mustSet(t, c, "a", "1", time.Minute)
+	mustSet(t, c, "a", "2", time.Minute)
+	if value, ok := mustGet(t, c, "a"); !ok || value != "2" {
+		t.Errorf("Get(a) = %q, %v, want 2, true", value, ok)
+	}
+	if err := c.Delete(context.Background(), "a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mustGet(t, c, "a"); ok {
+		t.Error("Get after Delete found a value")
+	}
+}
+
+func TestLRUEviction(t *testing.T) {
+	c := NewLRU(2)
+	mustSet(t, c, "a", "1", time.Minute)
+	mustSet(t, c, "b", "2", time.Minute)
+	// чтение делает a недавно использованным, поэтому вытесняется b
+	mustGet(t, c, "a")
+	mustSet(t, c, "c", "3", time.Minute)
+
+	for key, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := mustGet(t, c, key); ok != want {
+			t.Errorf("Get(%q) found = %v, want %v", key, ok, want)
+		}
+	}
+	if c.order.Len() != 2 || len(c.items) != 2 {
+		t.Errorf("cache holds %d/%d items, want 2", c.order.Len(), len(c.items))
+	}
+}
+
+func TestLRUUpdateDoesNotEvict(t *testing.T) {
+	c := NewLRU(2)
+	mustSet(t, c, "a", "1", time.Minute)
+	mustSet(t, c, "b", "2", time.Minute)
+	mustSet(t, c, "a", "3", time.Minute)
+	mustSet(t, c, "c", "4", time.Minute)
+
+	if _, ok := mustGet(t, c, "b"); ok {
+		t.Error("b should be evicted as the least recently used key")
+	}
+	if value, ok := mustGet(t, c, "a"); !ok || value != "3" {
+		t.Errorf("Get(a) = %q, %v, want 3, true", value, ok)
+	}
+}
+
+func TestLRUExpiry(t *testing.T) {
+	c := NewLRU(10)
+	mustSet(t, c, "short", "1", time.Millisecond)
+	mustSet(t, c, "long", "2", time.Minute)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, ok := mustGet(t, c, "short"); ok {
+		t.Error("expired key was returned")
+	}
+	if _, ok := c.items["short"]; ok {
+		t.Error("expired key was not removed on read")
+	}
+	if _, ok := mustGet(t, c, "long"); !ok {
+		t.Error("key within its ttl was not returned")
+	}
+}
+
+func TestLRUDeletePrefix(t *testing.T) {
+	c := NewLRU(10)
+	for _, key := range []string{"data:ns:app:1", "data:ns:app:2", "data:ns:other:1", "apps"} {
+		mustSet(t, c, key, "v", time.Minute)
+	}
+	if err := Invalidate(context.Background(), c, AppRecordsPattern("NS", "App")); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]bool{"data:ns:app:1": false, "data:ns:app:2": false, "data:ns:other:1": true, "apps": true} {
+		if _, ok := mustGet(t, c, key); ok != want {
+			t.Errorf("Get(%q) found = %v, want %v", key, ok, want)
+		}
+	}
+
+	if err := c.DeletePrefix(context.Background(), ""); err != nil {
+		t.Fatal(err)
+	}
+	if c.order.Len() != 0 || len(c.items) != 0 {
+		t.Errorf("empty prefix left %d items", len(c.items))
+	}
+}
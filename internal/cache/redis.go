@@ -0,0 +1,78 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisKeyPrefix отделяет ключи сервиса от чужих ключей в общем Redis
+const redisKeyPrefix = "backend:"
+
+// Redis — кэш в Redis или совместимом с ним сервере, общий для всех экземпляров сервиса
+type Redis struct {
+	client *redis.Client
+}
+
+// NewRedis подключается к Redis по адресу addr и проверяет соединение
+func NewRedis(ctx context.Context, addr, password string, db int) (*Redis, error) {
+	client := redis.NewClient(&redis.Options{Addr: addr, Password: password, DB: db})
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &Redis{client: client}, nil
+}
+
+func (c *Redis) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := c.client.Get(ctx, redisKeyPrefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (c *Redis) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	return c.client.Set(ctx, redisKeyPrefix+key, value, ttl).Err()
+}
+
+func (c *Redis) Delete(ctx context.Context, key string) error {
+	return c.client.Unlink(ctx, redisKeyPrefix+key).Err()
+}
+
+// DeletePrefix перебирает ключи командой SCAN, не блокируя сервер, как это сделала бы KEYS
+func (c *Redis) DeletePrefix(ctx context.Context, prefix string) error {
+	iter := c.client.Scan(ctx, 0, escapePattern(redisKeyPrefix+prefix)+"*", 500).Iterator()
+	var keys []string
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == 500 {
+			if err := c.client.Unlink(ctx, keys...).Err(); err != nil {
+				return err
+			}
+			keys = keys[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) > 0 {
+		return c.client.Unlink(ctx, keys...).Err()
+	}
+	return nil
+}
+
+func (c *Redis) Close() error {
+	return c.client.Close()
+}
+
+// escapePattern экранирует символы шаблонов SCAN MATCH
+func escapePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`).Replace(s)
+}
@@ -0,0 +1,343 @@
+package cache
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeRedis — сервер RESP с командами, которые использует Redis: PING, GET, SET с EX/PX,
+// UNLINK и SCAN с MATCH и COUNT. Остальные команды, например HELLO, отвечают ошибкой,
+// и клиент переходит на RESP2
+type fakeRedis struct {
+	listener net.Listener
+
+	mu    sync.Mutex
+	items map[string]fakeItem
+	// cursors — последний просмотренный ключ по номеру курсора: как и в Redis, удаление
+	// ключей во время перебора не пропускает оставшиеся
+	cursors []string
+	scans   int
+}
+
+type fakeItem struct {
+	value   string
+	expires time.Time
+}
+
+func newFakeRedis(t *testing.T) *fakeRedis {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen: %v", err)
+	}
+	s := &fakeRedis{listener: listener, items: make(map[string]fakeItem), cursors: []string{""}}
+	go s.serve()
+	t.Cleanup(func() { listener.Close() })
+	return s
+}
+
+func (s *fakeRedis) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeRedis) handle(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	for {
+		args, err := readCommand(r)
+		if err != nil {
+			return
+		}
+		s.exec(w, args)
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "*") {
+		return nil, fmt.Errorf("unexpected %q", line)
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, n)
+	for i := range args {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		size, err := strconv.Atoi(strings.TrimSpace(line[1:]))
+		if err != nil {
+			return nil, err
+		}
+		buf := make([]byte, size+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		args[i] = string(buf[:size])
+	}
+	return args, nil
+}
+
+func writeBulk(w *bufio.Writer, s string) {
+	fmt.Fprintf(w, "$%d\r\n%s\r\n", len(s), s)
+}
+
+func (s *fakeRedis) exec(w *bufio.Writer, args []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch strings.ToUpper(args[0]) {
+	case "PING":
+		w.WriteString("+PONG\r\n")
+	case "GET":
+		item, ok := s.get(args[1])
+		if !ok {
+			w.WriteString("$-1\r\n")
+			return
+		}
+		writeBulk(w, item.value)
+	case "SET":
+		item := fakeItem{value: args[2]}
+		for i := 3; i+1 < len(args); i += 2 {
+			n, _ := strconv.Atoi(args[i+1])
+			switch strings.ToUpper(args[i]) {
+			case "EX":
+				item.expires = time.Now().Add(time.Duration(n) * time.Second)
+			case "PX":
+				item.expires = time.Now().Add(time.Duration(n) * time.Millisecond)
+			}
+		}
+		s.items[args[1]] = item
+		w.WriteString("+OK\r\n")
+	case "UNLINK":
+		deleted := 0
+		for _, key := range args[1:] {
+			if _, ok := s.get(key); ok {
+				delete(s.items, key)
+				deleted++
+			}
+		}
+		fmt.Fprintf(w, ":%d\r\n", deleted)
+	case "SCAN":
+		s.scan(w, args)
+	default:
+		fmt.Fprintf(w, "-ERR unknown command '%s'\r\n", args[0])
+	}
+}
+
+func (s *fakeRedis) get(key string) (fakeItem, bool) {
+	item, ok := s.items[key]
+	if ok && !item.expires.IsZero() && time.Now().After(item.expires) {
+		delete(s.items, key)
+		return item, false
+	}
+	return item, ok
+}
+
+// scan отдаёт ключи по порядку порциями по COUNT
+func (s *fakeRedis) scan(w *bufio.Writer, args []string) {
+	s.scans++
+	cursor, _ := strconv.Atoi(args[1])
+	pattern, count := "*", 10
+	for i := 2; i+1 < len(args); i += 2 {
+		switch strings.ToUpper(args[i]) {
+		case "MATCH":
+			pattern = args[i+1]
+		case "COUNT":
+			count, _ = strconv.Atoi(args[i+1])
+		}
+	}
+	var keys []string
+	for key := range s.items {
+		if cursor == 0 || key > s.cursors[cursor] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	batch := keys[:min(count, len(keys))]
+	var matched []string
+	for _, key := range batch {
+		if matchPattern(pattern, key) {
+			matched = append(matched, key)
+		}
+	}
+	next := 0
+	if len(batch) < len(keys) {
+		s.cursors = append(s.cursors, batch[len(batch)-1])
+		next = len(s.cursors) - 1
+	}
+	w.WriteString("*2\r\n")
+	writeBulk(w, strconv.Itoa(next))
+	fmt.Fprintf(w, "*%d\r\n", len(matched))
+	for _, key := range matched {
+		writeBulk(w, key)
+	}
+}
+
+// matchPattern сопоставляет ключ с шаблоном Redis: *, ? и экранирование \
+func matchPattern(pattern, s string) bool {
+	for pattern != "" {
+		switch pattern[0] {
+		case '*':
+			for i := len(s); i >= 0; i-- {
+				if matchPattern(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if s == "" {
+				return false
+			}
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			fallthrough
+		default:
+			if s == "" || s[0] != pattern[0] {
+				return false
+			}
+		}
+		pattern, s = pattern[1:], s[1:]
+	}
+	return s == ""
+}
+
+func (s *fakeRedis) keys() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var keys []string
+	for key := range s.items {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func newTestRedis(t *testing.T) (*Redis, *fakeRedis) {
+	t.Helper()
+	server := newFakeRedis(t)
+	c, err := NewRedis(context.Background(), server.listener.Addr().String(), "", 0)
+	if err != nil {
+		t.Fatalf("NewRedis: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c, server
+}
+
+func TestRedisGetSet(t *testing.T) {
+	c, server := newTestRedis(t)
+	if _, ok := mustGet(t, c, "apps"); ok {
+		t.Fatal("Get on empty cache found a value")
+	}
+	mustSet(t, c, "apps", "list", time.Minute)
+	if value, ok := mustGet(t, c, "apps"); !ok || value != "list" {
+		t.Errorf("Get(apps) = %q, %v, want list, true", value, ok)
+	}
+	if keys := server.keys(); len(keys) != 1 || keys[0] != redisKeyPrefix+"apps" {
+		t.Errorf("server keys = %v, want [%sapps]", keys, redisKeyPrefix)
+	}
+	if err := c.Delete(context.Background(), "apps"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := mustGet(t, c, "apps"); ok {
+		t.Error("Get after Delete found a value")
+	}
+}
+
+func TestRedisExpiry(t *testing.T) {
+	c, _ := newTestRedis(t)
+	mustSet(t, c, "short", "1", 10*time.Millisecond)
+	mustSet(t, c, "long", "2", time.Minute)
+	time.Sleep(30 * time.Millisecond)
+
+	if _, ok := mustGet(t, c, "short"); ok {
+		t.Error("expired key was returned")
+	}
+	if _, ok := mustGet(t, c, "long"); !ok {
+		t.Error("key within its ttl was not returned")
+	}
+}
+
+func TestRedisDeletePrefix(t *testing.T) {
+	c, server := newTestRedis(t)
+	// больше одной порции UNLINK по 500 ключей
+	for i := 0; i < 1200; i++ {
+		mustSet(t, c, fmt.Sprintf("data:ns:app:%04d", i), "v", time.Minute)
+	}
+	mustSet(t, c, "data:ns:other:1", "v", time.Minute)
+	mustSet(t, c, "apps", "v", time.Minute)
+
+	if err := Invalidate(context.Background(), c, AppRecordsPattern("ns", "app")); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{redisKeyPrefix + "apps", redisKeyPrefix + "data:ns:other:1"}
+	if keys := server.keys(); strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Errorf("keys after DeletePrefix = %v, want %v", keys, want)
+	}
+	if server.scans < 2 {
+		t.Errorf("DeletePrefix made %d SCAN calls, want the keys to be iterated in several", server.scans)
+	}
+}
+
+func TestRedisDeletePrefixLiteral(t *testing.T) {
+	c, server := newTestRedis(t)
+	for _, key := range []string{"data:a*b:1", "data:a*b:2", "data:axb:1", "data:a?b:1", "data:a[b]:1", "data:ab:1"} {
+		mustSet(t, c, key, "v", time.Minute)
+	}
+	if err := c.DeletePrefix(context.Background(), "data:a*b:"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.DeletePrefix(context.Background(), "data:a[b]"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{redisKeyPrefix + "data:a?b:1", redisKeyPrefix + "data:ab:1", redisKeyPrefix + "data:axb:1"}
+	if keys := server.keys(); strings.Join(keys, ",") != strings.Join(want, ",") {
+		t.Errorf("keys after DeletePrefix = %v, want %v", keys, want)
+	}
+}
+
+func TestEscapePattern(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"data:ns:app:", "data:ns:app:"},
+		{"a*b", `a\*b`},
+		{"a?b", `a\?b`},
+		{"[ab]", `\[ab\]`},
+		{`a\b`, `a\\b`},
+		{`\*`, `\\\*`},
+	}
+	for _, tt := range tests {
+		if got := escapePattern(tt.in); got != tt.want {
+			t.Errorf("escapePattern(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if !matchPattern(escapePattern(tt.in)+"*", tt.in+"rest") {
+			t.Errorf("escaped %q does not match itself as a prefix", tt.in)
+		}
+	}
+}
@@ -1,27 +1,371 @@
+// Package config описывает настройки сервиса. Значения берутся по возрастанию
+// приоритета из значений по умолчанию, файла YAML или TOML, переменных окружения
+// (в том числе из .env) и флагов командной строки
 package config
 
 import (
+	"errors"
 	"fmt"
-	"log"
-	"os"
-
-	"github.com/joho/godotenv"
+	"net/netip"
+	"strings"
+	"time"
 )
 
-func LoadEnv() {
-	err := godotenv.Load()
-	if err != nil {
-		log.Println(".env файл не найден, используются переменные окружения")
+type Config struct {
+	Server      ServerConfig      `yaml:"server" toml:"server"`
+	DB          DBConfig          `yaml:"db" toml:"db"`
+	Log         LogConfig         `yaml:"log" toml:"log"`
+	Tracing     TracingConfig     `yaml:"tracing" toml:"tracing"`
+	Timeouts    TimeoutsConfig    `yaml:"timeouts" toml:"timeouts"`
+	CORS        CORSConfig        `yaml:"cors" toml:"cors"`
+	Storage     StorageConfig     `yaml:"storage" toml:"storage"`
+	Limits      LimitsConfig      `yaml:"limits" toml:"limits"`
+	RateLimit   RateLimitConfig   `yaml:"rate_limit" toml:"rate_limit"`
+	Cache       CacheConfig       `yaml:"cache" toml:"cache"`
+	Idempotency IdempotencyConfig `yaml:"idempotency" toml:"idempotency"`
+	Workers     WorkersConfig     `yaml:"workers" toml:"workers"`
+	Features    FeaturesConfig    `yaml:"features" toml:"features"`
+}
+
+type ServerConfig struct {
+	// Addr — адрес, на котором сервис принимает запросы (LISTEN_ADDR, по умолчанию :8080)
+	Addr string `yaml:"addr" toml:"addr" env:"LISTEN_ADDR" flag:"addr"`
+	// MetricsAddr — отдельный адрес для /metrics, который не публикуется наружу вместе с API
+	// (METRICS_ADDR, по умолчанию :9090)
+	MetricsAddr string `yaml:"metrics_addr" toml:"metrics_addr" env:"METRICS_ADDR" flag:"metrics-addr"`
+	// TLSCertFile и TLSKeyFile включают HTTPS; задаются только вместе
+	TLSCertFile string `yaml:"tls_cert_file" toml:"tls_cert_file" env:"TLS_CERT_FILE" flag:"tls-cert"`
+	TLSKeyFile  string `yaml:"tls_key_file" toml:"tls_key_file" env:"TLS_KEY_FILE" flag:"tls-key"`
+	// ReadHeaderTimeout ограничивает чтение заголовков запроса (SERVER_READ_HEADER_TIMEOUT, по умолчанию 10 секунд)
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" toml:"read_header_timeout" env:"SERVER_READ_HEADER_TIMEOUT" flag:"read-header-timeout"`
+	// IdleTimeout — сколько держится простаивающее keep-alive соединение (SERVER_IDLE_TIMEOUT, по умолчанию 2 минуты)
+	IdleTimeout time.Duration `yaml:"idle_timeout" toml:"idle_timeout" env:"SERVER_IDLE_TIMEOUT" flag:"idle-timeout"`
+	// ShutdownDelay — пауза между получением сигнала остановки и закрытием приёма соединений:
+	// /readyz уже отвечает 503, и балансировщик успевает убрать экземпляр (SHUTDOWN_DELAY, по умолчанию 0)
+	ShutdownDelay time.Duration `yaml:"shutdown_delay" toml:"shutdown_delay" env:"SHUTDOWN_DELAY" flag:"shutdown-delay"`
+	// ShutdownTimeout — сколько ждать завершения начатых запросов и фоновых процессов при остановке
+	// (SHUTDOWN_TIMEOUT, по умолчанию 30 секунд)
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT" flag:"shutdown-timeout"`
+	// TrustedProxies — адреса и подсети прокси перед сервисом. X-Forwarded-For учитывается только
+	// в запросах от них (TRUSTED_PROXIES, по умолчанию пусто — адрес клиента берётся из соединения)
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies" env:"TRUSTED_PROXIES" flag:"trusted-proxies"`
+}
+
+// TLS сообщает, включён ли HTTPS
+func (c ServerConfig) TLS() bool {
+	return c.TLSCertFile != "" && c.TLSKeyFile != ""
+}
+
+type DBConfig struct {
+	Host     string `yaml:"host" toml:"host" env:"DB_HOST" flag:"db-host"`
+	Port     int    `yaml:"port" toml:"port" env:"DB_PORT" flag:"db-port"`
+	User     string `yaml:"user" toml:"user" env:"DB_USER" flag:"db-user"`
+	Password string `yaml:"password" toml:"password" env:"DB_PASSWORD" flag:"db-password" secret:"true"`
+	Name     string `yaml:"name" toml:"name" env:"DB_NAME" flag:"db-name"`
+	// SSLMode — режим TLS подключения к Postgres: disable, require, verify-ca или verify-full
+	// (DB_SSLMODE, по умолчанию disable)
+	SSLMode string `yaml:"sslmode" toml:"sslmode" env:"DB_SSLMODE" flag:"db-sslmode"`
+	// SSLRootCert — сертификат центра для режимов verify-ca и verify-full (DB_SSLROOTCERT)
+	SSLRootCert string `yaml:"sslrootcert" toml:"sslrootcert" env:"DB_SSLROOTCERT" flag:"db-sslrootcert"`
+	// MaxOpenConns ограничивает число соединений с базой (DB_MAX_OPEN_CONNS, по умолчанию 25, 0 — без ограничения)
+	MaxOpenConns int `yaml:"max_open_conns" toml:"max_open_conns" env:"DB_MAX_OPEN_CONNS" flag:"db-max-open-conns"`
+	// MaxIdleConns — сколько простаивающих соединений остаётся в пуле (DB_MAX_IDLE_CONNS, по умолчанию 10)
+	MaxIdleConns int `yaml:"max_idle_conns" toml:"max_idle_conns" env:"DB_MAX_IDLE_CONNS" flag:"db-max-idle-conns"`
+	// ConnMaxLifetime и ConnMaxIdleTime закрывают старые и долго простаивающие соединения
+	// (DB_CONN_MAX_LIFETIME, по умолчанию 30 минут; DB_CONN_MAX_IDLE_TIME, по умолчанию 5 минут)
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime" toml:"conn_max_lifetime" env:"DB_CONN_MAX_LIFETIME" flag:"db-conn-max-lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time" toml:"conn_max_idle_time" env:"DB_CONN_MAX_IDLE_TIME" flag:"db-conn-max-idle-time"`
+}
+
+// DSN возвращает строку подключения к Postgres
+func (c DBConfig) DSN() string {
+	params := []string{
+		"host=" + quoteDSN(c.Host),
+		fmt.Sprintf("port=%d", c.Port),
+		"user=" + quoteDSN(c.User),
+		"password=" + quoteDSN(c.Password),
+		"dbname=" + quoteDSN(c.Name),
+		"sslmode=" + quoteDSN(c.SSLMode),
+	}
+	if c.SSLRootCert != "" {
+		params = append(params, "sslrootcert="+quoteDSN(c.SSLRootCert))
+	}
+	return strings.Join(params, " ")
+}
+
+// quoteDSN заключает значение в кавычки, чтобы пробелы и кавычки в пароле не ломали строку подключения
+func quoteDSN(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `'`, `\'`)
+	return "'" + value + "'"
+}
+
+type LogConfig struct {
+	// Format — формат журнала: text или json (LOG_FORMAT, по умолчанию text)
+	Format string `yaml:"format" toml:"format" env:"LOG_FORMAT" flag:"log-format"`
+	// Level — уровень журнала: debug, info, warn или error (LOG_LEVEL, по умолчанию info).
+	// На уровне debug в журнал попадают SQL-запросы
+	Level string `yaml:"level" toml:"level" env:"LOG_LEVEL" flag:"log-level"`
+}
+
+type TracingConfig struct {
+	// OTLPEndpoint — адрес коллектора трассировок OpenTelemetry, например http://localhost:4318
+	// (OTEL_EXPORTER_OTLP_ENDPOINT, по умолчанию пусто — спаны не экспортируются)
+	OTLPEndpoint string `yaml:"otlp_endpoint" toml:"otlp_endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT" flag:"otlp-endpoint"`
+}
+
+type TimeoutsConfig struct {
+	// Request ограничивает время обработки HTTP-запроса (REQUEST_TIMEOUT, по умолчанию 30 секунд, 0 — без ограничения)
+	Request time.Duration `yaml:"request" toml:"request" env:"REQUEST_TIMEOUT" flag:"request-timeout"`
+	// Routes задаёт ограничения отдельных маршрутов по шаблону "METHOD /путь". В окружении
+	// это ROUTE_TIMEOUTS вида "GET /audit/export=5m;PUT /namespace/{namespace}/app/{app}=0"
+	Routes map[string]time.Duration `yaml:"routes" toml:"routes" env:"ROUTE_TIMEOUTS" flag:"route-timeouts"`
+}
+
+type CORSConfig struct {
+	// AllowedOrigins — источники, которым разрешены запросы из браузера, через запятую;
+	// * разрешает любой (CORS_ALLOWED_ORIGINS, по умолчанию пусто — CORS выключен)
+	AllowedOrigins []string `yaml:"allowed_origins" toml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS" flag:"cors-allowed-origins"`
+	// AllowedMethods (CORS_ALLOWED_METHODS, по умолчанию GET, POST, PUT, PATCH, DELETE)
+	AllowedMethods []string `yaml:"allowed_methods" toml:"allowed_methods" env:"CORS_ALLOWED_METHODS" flag:"cors-allowed-methods"`
+	// AllowedHeaders (CORS_ALLOWED_HEADERS, по умолчанию Content-Type, Content-Language, Accept-Language,
+	// X-Request-ID, Idempotency-Key)
+	AllowedHeaders []string `yaml:"allowed_headers" toml:"allowed_headers" env:"CORS_ALLOWED_HEADERS" flag:"cors-allowed-headers"`
+	// AllowCredentials разрешает браузеру отправлять cookie; несовместимо с источником * (CORS_ALLOW_CREDENTIALS)
+	AllowCredentials bool `yaml:"allow_credentials" toml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS" flag:"cors-allow-credentials"`
+	// MaxAge — сколько браузер кэширует ответ на предварительный запрос (CORS_MAX_AGE, по умолчанию 10 минут)
+	MaxAge time.Duration `yaml:"max_age" toml:"max_age" env:"CORS_MAX_AGE" flag:"cors-max-age"`
+}
+
+type StorageConfig struct {
+	// FilesDir — каталог для хранения вложений и иконок (FILES_DIR, по умолчанию ./data/files)
+	FilesDir string `yaml:"files_dir" toml:"files_dir" env:"FILES_DIR" flag:"files-dir"`
+}
+
+type LimitsConfig struct {
+	// FileMaxSize — лимит размера вложения в байтах для приложений без своего лимита
+	// (FILE_MAX_SIZE, по умолчанию 10 МБ)
+	FileMaxSize int64 `yaml:"file_max_size" toml:"file_max_size" env:"FILE_MAX_SIZE" flag:"file-max-size"`
+	// RetentionBatchSize — сколько записей удаляется по сроку хранения одним запросом
+	// (RETENTION_BATCH_SIZE, по умолчанию 1000)
+	RetentionBatchSize int `yaml:"retention_batch_size" toml:"retention_batch_size" env:"RETENTION_BATCH_SIZE" flag:"retention-batch-size"`
+	// AutomationQueueSize — размер очереди событий для правил автоматизации (AUTOMATION_QUEUE_SIZE, по умолчанию 1000)
+	AutomationQueueSize int `yaml:"automation_queue_size" toml:"automation_queue_size" env:"AUTOMATION_QUEUE_SIZE" flag:"automation-queue-size"`
+	// AutomationWorkers — число обработчиков событий автоматизации (AUTOMATION_WORKERS, по умолчанию 4)
+	AutomationWorkers int `yaml:"automation_workers" toml:"automation_workers" env:"AUTOMATION_WORKERS" flag:"automation-workers"`
+	// MaxAppsPerNamespace и MaxRecordsPerApp — квоты для namespace без своих квот
+	// (QUOTA_MAX_APPS и QUOTA_MAX_RECORDS, по умолчанию 0 — без ограничения)
+	MaxAppsPerNamespace int64 `yaml:"max_apps_per_namespace" toml:"max_apps_per_namespace" env:"QUOTA_MAX_APPS" flag:"quota-max-apps"`
+	MaxRecordsPerApp    int64 `yaml:"max_records_per_app" toml:"max_records_per_app" env:"QUOTA_MAX_RECORDS" flag:"quota-max-records"`
+	// BatchMaxOperations — сколько операций принимает один пакет POST /batch (BATCH_MAX_OPERATIONS, по умолчанию 100)
+	BatchMaxOperations int `yaml:"batch_max_operations" toml:"batch_max_operations" env:"BATCH_MAX_OPERATIONS" flag:"batch-max-operations"`
+}
+
+type RateLimitConfig struct {
+	// Store — где хранятся корзины токенов: memory — в памяти каждого экземпляра,
+	// postgres — в базе, общие для всех экземпляров (RATE_LIMIT_STORE, по умолчанию memory)
+	Store string `yaml:"store" toml:"store" env:"RATE_LIMIT_STORE" flag:"rate-limit-store"`
+	// Reads, Writes и Schema — запросов в минуту на субъекта в namespace без своих ограничений
+	// для чтения, изменения данных и изменения схемы (RATE_LIMIT_READS, RATE_LIMIT_WRITES,
+	// RATE_LIMIT_SCHEMA, по умолчанию 0 — без ограничения)
+	Reads  int `yaml:"reads" toml:"reads" env:"RATE_LIMIT_READS" flag:"rate-limit-reads"`
+	Writes int `yaml:"writes" toml:"writes" env:"RATE_LIMIT_WRITES" flag:"rate-limit-writes"`
+	Schema int `yaml:"schema" toml:"schema" env:"RATE_LIMIT_SCHEMA" flag:"rate-limit-schema"`
+}
+
+type CacheConfig struct {
+	// Backend — где кэшируются частые чтения: none — кэш выключен, memory — в памяти
+	// каждого экземпляра, redis — в Redis, общем для экземпляров (CACHE_BACKEND, по умолчанию memory)
+	Backend string `yaml:"backend" toml:"backend" env:"CACHE_BACKEND" flag:"cache-backend"`
+	// Size — сколько ключей хранит кэш в памяти (CACHE_SIZE, по умолчанию 10000)
+	Size int `yaml:"size" toml:"size" env:"CACHE_SIZE" flag:"cache-size"`
+	// TTL — срок хранения значений; ограничивает устаревание, если уведомление об изменении
+	// потерялось (CACHE_TTL, по умолчанию 5 минут)
+	TTL           time.Duration `yaml:"ttl" toml:"ttl" env:"CACHE_TTL" flag:"cache-ttl"`
+	RedisAddr     string        `yaml:"redis_addr" toml:"redis_addr" env:"REDIS_ADDR" flag:"redis-addr"`
+	RedisPassword string        `yaml:"redis_password" toml:"redis_password" env:"REDIS_PASSWORD" flag:"redis-password" secret:"true"`
+	RedisDB       int           `yaml:"redis_db" toml:"redis_db" env:"REDIS_DB" flag:"redis-db"`
+}
+
+type IdempotencyConfig struct {
+	// TTL — сколько хранится ответ на запрос с Idempotency-Key; повтор после этого срока
+	// выполняется заново (IDEMPOTENCY_TTL, по умолчанию 24 часа)
+	TTL time.Duration `yaml:"ttl" toml:"ttl" env:"IDEMPOTENCY_TTL" flag:"idempotency-ttl"`
+	// LockTimeout — через сколько ключ запроса, не получившего ответа, считается брошенным,
+	// например после падения экземпляра; должен превышать время самых долгих запросов
+	// (IDEMPOTENCY_LOCK_TIMEOUT, по умолчанию 15 минут)
+	LockTimeout time.Duration `yaml:"lock_timeout" toml:"lock_timeout" env:"IDEMPOTENCY_LOCK_TIMEOUT" flag:"idempotency-lock-timeout"`
+	// MaxBody — наибольшее тело запроса с Idempotency-Key в байтах. Тело читается до выполнения
+	// ради хеша, крупное — во временный файл; запрос с телом больше получает 413
+	// (IDEMPOTENCY_MAX_BODY, по умолчанию 64 МиБ)
+	MaxBody int64 `yaml:"max_body" toml:"max_body" env:"IDEMPOTENCY_MAX_BODY" flag:"idempotency-max-body"`
+}
+
+type WorkersConfig struct {
+	// TrashRetention — срок хранения объектов в корзине (TRASH_RETENTION, по умолчанию 30 дней)
+	TrashRetention time.Duration `yaml:"trash_retention" toml:"trash_retention" env:"TRASH_RETENTION" flag:"trash-retention"`
+	// TrashPurgeInterval — период запуска очистки корзины (TRASH_PURGE_INTERVAL, по умолчанию 1 час)
+	TrashPurgeInterval time.Duration `yaml:"trash_purge_interval" toml:"trash_purge_interval" env:"TRASH_PURGE_INTERVAL" flag:"trash-purge-interval"`
+	// FileGCInterval — период удаления файлов, на которые больше нет ссылок (FILE_GC_INTERVAL, по умолчанию 1 час)
+	FileGCInterval time.Duration `yaml:"file_gc_interval" toml:"file_gc_interval" env:"FILE_GC_INTERVAL" flag:"file-gc-interval"`
+	// ApprovalExpireInterval — период закрытия просроченных запросов на изменение
+	// (APPROVAL_EXPIRE_INTERVAL, по умолчанию 10 минут)
+	ApprovalExpireInterval time.Duration `yaml:"approval_expire_interval" toml:"approval_expire_interval" env:"APPROVAL_EXPIRE_INTERVAL" flag:"approval-expire-interval"`
+	// JobPollInterval — период проверки наступивших запланированных заданий (JOB_POLL_INTERVAL, по умолчанию 30 секунд)
+	JobPollInterval time.Duration `yaml:"job_poll_interval" toml:"job_poll_interval" env:"JOB_POLL_INTERVAL" flag:"job-poll-interval"`
+	// JobLease — на сколько задание закрепляется за экземпляром сервиса; если экземпляр упал,
+	// задание возьмёт другой после истечения аренды (JOB_LEASE, по умолчанию 10 минут)
+	JobLease time.Duration `yaml:"job_lease" toml:"job_lease" env:"JOB_LEASE" flag:"job-lease"`
+	// RetentionInterval — период применения сроков хранения записей (RETENTION_INTERVAL, по умолчанию 1 час)
+	RetentionInterval time.Duration `yaml:"retention_interval" toml:"retention_interval" env:"RETENTION_INTERVAL" flag:"retention-interval"`
+	// PublishInterval — период проверки запланированных публикаций записей (PUBLISH_INTERVAL, по умолчанию 1 минута)
+	PublishInterval time.Duration `yaml:"publish_interval" toml:"publish_interval" env:"PUBLISH_INTERVAL" flag:"publish-interval"`
+}
+
+type FeaturesConfig struct {
+	// Swagger включает документацию API по /swagger/ (FEATURE_SWAGGER, по умолчанию включена)
+	Swagger bool `yaml:"swagger" toml:"swagger" env:"FEATURE_SWAGGER" flag:"feature-swagger"`
+	// Metrics включает /metrics для Prometheus на server.metrics_addr (FEATURE_METRICS, по умолчанию включены)
+	Metrics bool `yaml:"metrics" toml:"metrics" env:"FEATURE_METRICS" flag:"feature-metrics"`
+	// Workers запускает фоновые задачи: очистку корзины, сборку мусора, сроки хранения,
+	// публикации и задания по расписанию. Выключаются на экземплярах, которые только
+	// обслуживают API (FEATURE_WORKERS, по умолчанию включены)
+	Workers bool `yaml:"workers" toml:"workers" env:"FEATURE_WORKERS" flag:"feature-workers"`
+}
+
+// Default возвращает настройки по умолчанию
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Addr:              ":8080",
+			MetricsAddr:       ":9090",
+			ReadHeaderTimeout: 10 * time.Second,
+			IdleTimeout:       2 * time.Minute,
+			ShutdownTimeout:   30 * time.Second,
+		},
+		DB: DBConfig{
+			Port:            5432,
+			SSLMode:         "disable",
+			MaxOpenConns:    25,
+			MaxIdleConns:    10,
+			ConnMaxLifetime: 30 * time.Minute,
+			ConnMaxIdleTime: 5 * time.Minute,
+		},
+		Log:      LogConfig{Format: "text", Level: "info"},
+		Timeouts: TimeoutsConfig{Request: 30 * time.Second, Routes: map[string]time.Duration{}},
+		CORS: CORSConfig{
+			AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
+			AllowedHeaders: []string{"Content-Type", "Content-Language", "Accept-Language", "X-Request-ID", "Idempotency-Key"},
+			MaxAge:         10 * time.Minute,
+		},
+		Storage: StorageConfig{FilesDir: "./data/files"},
+		Limits: LimitsConfig{
+			FileMaxSize:         10 << 20,
+			RetentionBatchSize:  1000,
+			AutomationQueueSize: 1000,
+			AutomationWorkers:   4,
+			BatchMaxOperations:  100,
+		},
+		RateLimit:   RateLimitConfig{Store: "memory"},
+		Cache:       CacheConfig{Backend: "memory", Size: 10000, TTL: 5 * time.Minute},
+		Idempotency: IdempotencyConfig{TTL: 24 * time.Hour, LockTimeout: 15 * time.Minute, MaxBody: 64 << 20},
+		Workers: WorkersConfig{
+			TrashRetention:         30 * 24 * time.Hour,
+			TrashPurgeInterval:     time.Hour,
+			FileGCInterval:         time.Hour,
+			ApprovalExpireInterval: 10 * time.Minute,
+			JobPollInterval:        30 * time.Second,
+			JobLease:               10 * time.Minute,
+			RetentionInterval:      time.Hour,
+			PublishInterval:        time.Minute,
+		},
+		Features: FeaturesConfig{Swagger: true, Metrics: true, Workers: true},
 	}
 }
 
-func GetDSN() string {
-	return fmt.Sprintf(
-		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
-		os.Getenv("DB_HOST"),
-		os.Getenv("DB_PORT"),
-		os.Getenv("DB_USER"),
-		os.Getenv("DB_PASSWORD"),
-		os.Getenv("DB_NAME"),
-	)
+var (
+	sslModes   = map[string]bool{"disable": true, "require": true, "verify-ca": true, "verify-full": true}
+	logFormats = map[string]bool{"text": true, "json": true}
+	logLevels  = map[string]bool{"debug": true, "info": true, "warn": true, "error": true}
+)
+
+// Validate проверяет настройки целиком и возвращает все найденные ошибки разом
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Addr != "", "server.addr is required")
+	check(!c.Features.Metrics || c.Server.MetricsAddr != "", "server.metrics_addr is required when metrics are enabled")
+	check(!c.Features.Metrics || c.Server.MetricsAddr != c.Server.Addr, "server.metrics_addr must differ from server.addr")
+	check((c.Server.TLSCertFile == "") == (c.Server.TLSKeyFile == ""), "server.tls_cert_file and server.tls_key_file must be set together")
+	check(c.Server.ReadHeaderTimeout >= 0, "server.read_header_timeout must not be negative")
+	check(c.Server.IdleTimeout >= 0, "server.idle_timeout must not be negative")
+	check(c.Server.ShutdownDelay >= 0, "server.shutdown_delay must not be negative")
+	check(c.Server.ShutdownTimeout > 0, "server.shutdown_timeout must be positive")
+
+	check(c.DB.Host != "", "db.host is required")
+	check(c.DB.Name != "", "db.name is required")
+	check(c.DB.Port > 0 && c.DB.Port <= 65535, "db.port must be between 1 and 65535")
+	check(sslModes[c.DB.SSLMode], "db.sslmode must be one of disable, require, verify-ca, verify-full")
+	check(c.DB.MaxOpenConns >= 0, "db.max_open_conns must not be negative")
+	check(c.DB.MaxIdleConns >= 0, "db.max_idle_conns must not be negative")
+	check(c.DB.MaxOpenConns == 0 || c.DB.MaxIdleConns <= c.DB.MaxOpenConns, "db.max_idle_conns must not exceed db.max_open_conns")
+	check(c.DB.ConnMaxLifetime >= 0, "db.conn_max_lifetime must not be negative")
+	check(c.DB.ConnMaxIdleTime >= 0, "db.conn_max_idle_time must not be negative")
+
+	check(logFormats[strings.ToLower(c.Log.Format)], "log.format must be text or json")
+	check(logLevels[strings.ToLower(c.Log.Level)], "log.level must be one of debug, info, warn, error")
+
+	check(c.Timeouts.Request >= 0, "timeouts.request must not be negative")
+	for route, timeout := range c.Timeouts.Routes {
+		method, path, ok := strings.Cut(route, " ")
+		check(ok && method != "" && strings.HasPrefix(path, "/"), "timeouts.routes: %q must look like \"METHOD /path\"", route)
+		check(timeout >= 0, "timeouts.routes: %q must not be negative", route)
+	}
+
+	for _, proxy := range c.Server.TrustedProxies {
+		_, prefixErr := netip.ParsePrefix(proxy)
+		_, addrErr := netip.ParseAddr(proxy)
+		check(prefixErr == nil || addrErr == nil, "server.trusted_proxies: %q is not an address or a CIDR", proxy)
+	}
+
+	for _, origin := range c.CORS.AllowedOrigins {
+		check(!(origin == "*" && c.CORS.AllowCredentials), "cors.allow_credentials cannot be combined with origin *")
+	}
+	check(c.CORS.MaxAge >= 0, "cors.max_age must not be negative")
+
+	check(c.Storage.FilesDir != "", "storage.files_dir is required")
+
+	check(c.Limits.FileMaxSize > 0, "limits.file_max_size must be positive")
+	check(c.Limits.RetentionBatchSize > 0, "limits.retention_batch_size must be positive")
+	check(c.Limits.AutomationQueueSize > 0, "limits.automation_queue_size must be positive")
+	check(c.Limits.AutomationWorkers > 0, "limits.automation_workers must be positive")
+	check(c.Limits.MaxAppsPerNamespace >= 0, "limits.max_apps_per_namespace must not be negative")
+	check(c.Limits.MaxRecordsPerApp >= 0, "limits.max_records_per_app must not be negative")
+	check(c.Limits.BatchMaxOperations > 0, "limits.batch_max_operations must be positive")
+
+	check(c.RateLimit.Store == "memory" || c.RateLimit.Store == "postgres", "rate_limit.store must be memory or postgres")
+	check(c.Cache.Backend == "none" || c.Cache.Backend == "memory" || c.Cache.Backend == "redis", "cache.backend must be none, memory or redis")
+	check(c.Cache.Backend != "memory" || c.Cache.Size > 0, "cache.size must be positive")
+	check(c.Cache.Backend != "redis" || c.Cache.RedisAddr != "", "cache.redis_addr is required for the redis backend")
+	check(c.Cache.Backend == "none" || c.Cache.TTL > 0, "cache.ttl must be positive")
+	check(c.Idempotency.TTL > 0, "idempotency.ttl must be positive")
+	check(c.Idempotency.LockTimeout > 0, "idempotency.lock_timeout must be positive")
+	check(c.Idempotency.MaxBody > 0, "idempotency.max_body must be positive")
+	check(c.RateLimit.Reads >= 0 && c.RateLimit.Writes >= 0 && c.RateLimit.Schema >= 0, "rate_limit limits must not be negative")
+
+	check(c.Workers.TrashRetention > 0, "workers.trash_retention must be positive")
+	check(c.Workers.TrashPurgeInterval > 0, "workers.trash_purge_interval must be positive")
+	check(c.Workers.FileGCInterval > 0, "workers.file_gc_interval must be positive")
+	check(c.Workers.ApprovalExpireInterval > 0, "workers.approval_expire_interval must be positive")
+	check(c.Workers.JobPollInterval > 0, "workers.job_poll_interval must be positive")
+	check(c.Workers.JobLease > 0, "workers.job_lease must be positive")
+	check(c.Workers.RetentionInterval > 0, "workers.retention_interval must be positive")
+	check(c.Workers.PublishInterval > 0, "workers.publish_interval must be positive")
+
+	return errors.Join(errs...)
 }
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"io"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+const redacted = "[REDACTED]"
+
+// Redacted возвращает копию настроек, в которой заданные секреты заменены заглушкой
+func (c *Config) Redacted() *Config {
+	copied := *c
+	walk(reflect.ValueOf(&copied).Elem(), func(field reflect.StructField, value reflect.Value) {
+		if field.Tag.Get("secret") == "true" && value.String() != "" {
+			value.SetString(redacted)
+		}
+	})
+	return &copied
+}
+
+// Print выводит действующие настройки в YAML без секретов; вывод годится как файл настроек
+func (c *Config) Print(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(c.Redacted()); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
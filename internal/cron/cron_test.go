@@ -0,0 +1,209 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		expr string
+	}{
+		{"too few fields", "* * * *"},
+		{"too many fields", "* * * * * *"},
+		{"minute out of range", "60 * * * *"},
+		{"hour out of range", "0 24 * * *"},
+		{"day of month zero", "0 0 0 * *"},
+		{"month out of range", "0 0 1 13 *"},
+		{"day of week out of range", "0 0 * * 8"},
+		{"reversed range", "0 10-5 * * *"},
+		{"zero step", "*/0 * * * *"},
+		{"negative step", "*/-5 * * * *"},
+		{"bad step", "*/x * * * *"},
+		{"bad value", "a * * * *"},
+		{"bad name", "0 0 * foo *"},
+		{"unknown alias", "@often"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Parse(tt.expr); err == nil {
+				t.Errorf("Parse(%q) succeeded, want error", tt.expr)
+			}
+		})
+	}
+}
+
+func TestParseFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		field string
+		min   int
+		max   int
+		names map[string]int
+		want  []int
+	}{
+		{"single", "5", 0, 59, nil, []int{5}},
+		{"list", "1,3,5", 0, 59, nil, []int{1, 3, 5}},
+		{"range", "9-12", 0, 23, nil, []int{9, 10, 11, 12}},
+		{"star step", "*/15", 0, 59, nil, []int{0, 15, 30, 45}},
+		{"range step", "10-20/5", 0, 59, nil, []int{10, 15, 20}},
+		{"start step", "50/4", 0, 59, nil, []int{50, 54, 58}},
+		{"step from min", "*/10", 1, 31, nil, []int{1, 11, 21, 31}},
+		{"names", "mon-wed", 0, 7, dayNames, []int{1, 2, 3}},
+		{"mixed case names", "JAN,Jul", 1, 12, monthNames, []int{1, 7}},
+		{"list of ranges", "1-2,20-21", 0, 23, nil, []int{1, 2, 20, 21}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mask, err := parseField(tt.field, tt.min, tt.max, tt.names)
+			if err != nil {
+				t.Fatalf("parseField(%q) error: %v", tt.field, err)
+			}
+			var want uint64
+			for _, v := range tt.want {
+				want |= 1 << uint(v)
+			}
+			if mask != want {
+				t.Errorf("parseField(%q) = %b, want %b", tt.field, mask, want)
+			}
+		})
+	}
+}
+
+func TestNext(t *testing.T) {
+	at := func(value string) time.Time {
+		t.Helper()
+		v, err := time.Parse("2006-01-02 15:04", value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return v
+	}
+	tests := []struct {
+		expr  string
+		after string
+		want  string
+	}{
+		{"* * * * *", "2026-05-04 10:00", "2026-05-04 10:01"},
+		{"*/15 * * * *", "2026-05-04 10:07", "2026-05-04 10:15"},
+		{"*/15 * * * *", "2026-05-04 10:45", "2026-05-04 11:00"},
+		{"0 9-18 * * *", "2026-05-04 18:00", "2026-05-05 09:00"},
+		{"30 9 * * mon-fri", "2026-05-08 10:00", "2026-05-11 09:30"},
+		{"0 0 * * 7", "2026-05-04 00:00", "2026-05-10 00:00"},
+		{"0 0 1 */3 *", "2026-05-04 00:00", "2026-07-01 00:00"},
+		{"@monthly", "2026-12-15 12:00", "2027-01-01 00:00"},
+		{"@yearly", "2026-01-01 00:00", "2027-01-01 00:00"},
+		// день месяца и день недели вместе: подходит любой из них
+		{"0 0 13 * fri", "2026-05-04 00:00", "2026-05-08 00:00"},
+		{"0 0 29 2 *", "2026-03-01 00:00", "2028-02-29 00:00"},
+		{"0 0 31 apr *", "2026-01-01 00:00", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr+" after "+tt.after, func(t *testing.T) {
+			schedule, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.expr, err)
+			}
+			got := schedule.Next(at(tt.after))
+			if tt.want == "" {
+				if !got.IsZero() {
+					t.Errorf("Next = %v, want zero time", got)
+				}
+				return
+			}
+			if want := at(tt.want); !got.Equal(want) {
+				t.Errorf("Next = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestNextDST(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cet := time.FixedZone("CET", 3600)
+	cest := time.FixedZone("CEST", 2*3600)
+	// 29 марта 2026 в 02:00 CET часы переводятся на 03:00 CEST,
+	// 25 октября 2026 в 03:00 CEST — обратно на 02:00 CET
+	tests := []struct {
+		name  string
+		expr  string
+		after time.Time
+		want  []time.Time
+	}{
+		{
+			name:  "skipped time runs after the gap",
+			expr:  "30 2 * * *",
+			after: time.Date(2026, 3, 29, 1, 0, 0, 0, cet),
+			want: []time.Time{
+				time.Date(2026, 3, 29, 3, 30, 0, 0, cest),
+				time.Date(2026, 3, 30, 2, 30, 0, 0, cest),
+			},
+		},
+		{
+			name:  "repeated time runs once",
+			expr:  "30 2 * * *",
+			after: time.Date(2026, 10, 25, 1, 0, 0, 0, cest),
+			want: []time.Time{
+				time.Date(2026, 10, 25, 2, 30, 0, 0, cet),
+				time.Date(2026, 10, 26, 2, 30, 0, 0, cet),
+			},
+		},
+		{
+			name:  "repeated hour does not rerun passed wall time",
+			expr:  "30 2 * * *",
+			after: time.Date(2026, 10, 25, 2, 45, 0, 0, cest),
+			want: []time.Time{
+				time.Date(2026, 10, 26, 2, 30, 0, 0, cet),
+			},
+		},
+		{
+			name:  "hourly job skips the missing hour",
+			expr:  "0 * * * *",
+			after: time.Date(2026, 3, 29, 0, 30, 0, 0, cet),
+			want: []time.Time{
+				time.Date(2026, 3, 29, 1, 0, 0, 0, cet),
+				time.Date(2026, 3, 29, 3, 0, 0, 0, cest),
+				time.Date(2026, 3, 29, 4, 0, 0, 0, cest),
+			},
+		},
+		{
+			name:  "hourly job runs in both repeated hours",
+			expr:  "0 * * * *",
+			after: time.Date(2026, 10, 25, 1, 30, 0, 0, cest),
+			want: []time.Time{
+				time.Date(2026, 10, 25, 2, 0, 0, 0, cest),
+				time.Date(2026, 10, 25, 2, 0, 0, 0, cet),
+				time.Date(2026, 10, 25, 3, 0, 0, 0, cet),
+			},
+		},
+		{
+			name:  "daily job keeps wall time across the change",
+			expr:  "0 9 * * *",
+			after: time.Date(2026, 3, 28, 9, 0, 0, 0, cet),
+			want: []time.Time{
+				time.Date(2026, 3, 29, 9, 0, 0, 0, cest),
+				time.Date(2026, 3, 30, 9, 0, 0, 0, cest),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.expr, err)
+			}
+			after := tt.after.In(berlin)
+			for i, want := range tt.want {
+				got := schedule.Next(after)
+				if !got.Equal(want) {
+					t.Fatalf("run %d: Next(%v) = %v, want %v", i+1, after, got, want)
+				}
+				after = got
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package http_handler
+
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
+
+// headerBatchFailedOperation — номер операции пакета, из-за которой он откачен
+const headerBatchFailedOperation = "Batch-Failed-Operation"
+
+// statusClientClosedRequest — код ответа на запрос, клиент которого отключился
+// до окончания обработки; принят в nginx, в стандартных кодах аналога нет
+const statusClientClosedRequest = 499
+
+// writeError отвечает кодом, соответствующим ошибке бизнес-логики.
+// Для ошибок проверки клиент получает текст причины, для остальных — message.
+// Правка, отправленная на согласование, отвечает 202 с созданным запросом,
+// нарушение уникальности — 409 с полями, значения которых повторяются,
+// повтор ключа Idempotency-Key с другим запросом — 422, истечение времени обработки — 504.
+// Ошибка операции пакета отвечает кодом её причины и номером операции в заголовке
+func writeError(w http.ResponseWriter, err error, message string) {
+	var batch *domain.BatchError
+	if errors.As(err, &batch) {
+		w.Header().Set(headerBatchFailedOperation, strconv.Itoa(batch.Index))
+	}
+
+	// изменение не применено, а отправлено на согласование
+	var pending *domain.PendingApprovalError
+	if errors.As(err, &pending) {
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(pending.Request)
+		return
+	}
+
+	var limited *domain.RateLimitError
+	if errors.As(err, &limited) {
+		w.Header().Set("Retry-After", seconds(limited.Decision.RetryAfter))
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+
+	var duplicate *domain.DuplicateError
+	if errors.As(err, &duplicate) {
+		w.WriteHeader(http.StatusConflict)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": duplicate.Error(), "fields": duplicate.Fields})
+		return
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		http.Error(w, "request timed out", http.StatusGatewayTimeout)
+	case errors.Is(err, context.Canceled):
+		// клиент уже отключился, код нужен только журналу и метрикам
+		w.WriteHeader(statusClientClosedRequest)
+	case errors.Is(err, domain.ErrValidation):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, domain.ErrTooLarge):
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+	case errors.Is(err, domain.ErrConflict):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case errors.Is(err, domain.ErrForbidden):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, domain.ErrQuotaExceeded):
+		http.Error(w, err.Error(), http.StatusForbidden)
+	case errors.Is(err, domain.ErrIdempotencyMismatch):
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+	case errors.Is(err, domain.ErrNotFound):
+		http.Error(w, "not found", http.StatusNotFound)
+	default:
+		http.Error(w, message, http.StatusInternalServerError)
+	}
+}
@@ -2,28 +2,35 @@ package http_handler
 
 import (
 	"app/backendv1/internal/domain"
+	"app/backendv1/internal/reqctx"
 	"app/backendv1/internal/usecase"
 	"encoding/json"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 )
 
 type appDataHandler struct {
-	uc usecase.AppDataUsecase
+	uc         usecase.AppDataUsecase
+	publishing usecase.PublishingUsecase
 }
 
-func NewAppDataHandler(uc usecase.AppDataUsecase) *appDataHandler {
-	return &appDataHandler{uc: uc}
+func NewAppDataHandler(uc usecase.AppDataUsecase, publishing usecase.PublishingUsecase) *appDataHandler {
+	return &appDataHandler{uc: uc, publishing: publishing}
 }
 
 func (h *appDataHandler) RegisterRoutes(r *mux.Router) {
 	r.HandleFunc("/namespace/{namespace}/app/{app}/data", h.Create).Methods("POST")
+	r.HandleFunc("/namespace/{namespace}/app/{app}/data/trash", h.GetTrash).Methods("GET")
+	r.HandleFunc("/namespace/{namespace}/app/{app}/data/by-number/{number}", h.GetByNumber).Methods("GET")
 	r.HandleFunc("/namespace/{namespace}/app/{app}/data/{uid}", h.GetDataByUID).Methods("GET")
 	r.HandleFunc("/namespace/{namespace}/app/{app}/data", h.GetAll).Methods("GET")
 	r.HandleFunc("/namespace/{namespace}/app/{app}/data/{uid}", h.Update).Methods("PUT")
 	r.HandleFunc("/namespace/{namespace}/app/{app}/data/{uid}", h.UpdateDataPartial).Methods("PATCH")
 	r.HandleFunc("/namespace/{namespace}/app/{app}/data/{uid}", h.Delete).Methods("DELETE")
+	r.HandleFunc("/namespace/{namespace}/app/{app}/data/{uid}/restore", h.Restore).Methods("POST")
 }
 
 // CreateDataHandler godoc
@@ -34,9 +41,13 @@ func (h *appDataHandler) RegisterRoutes(r *mux.Router) {
 // @Produce json
 // @Param namespace path string true "Namespace Code"
 // @Param app path string true "App Code"
+// @Param Content-Language header string false "Локаль строковых значений локализуемых полей, по умолчанию основная локаль namespace"
+// @Param Idempotency-Key header string false "Ключ повтора: запрос с тем же ключом получит сохранённый ответ, а не создаст вторую запись"
 // @Param data body domain.AppData true "Данные приложения"
 // @Success 201 {object} domain.AppData
 // @Failure 400 {object} map[string]string
+// @Failure 409 {object} map[string]interface{} "Значение уникального поля уже занято или запрос с тем же ключом ещё выполняется"
+// @Failure 422 {object} map[string]string "Ключ повтора уже использован с другим запросом"
 // @Failure 500 {object} map[string]string
 // @Router /namespace/{namespace}/app/{app}/data [post]
 func (h *appDataHandler) Create(w http.ResponseWriter, r *http.Request) {
@@ -50,8 +61,8 @@ func (h *appDataHandler) Create(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.uc.Create(namespace, appName, &data); err != nil {
-		http.Error(w, "failed to create data", http.StatusInternalServerError)
+	if err := h.uc.Create(r.Context(), namespace, appName, &data); err != nil {
+		writeError(w, err, "failed to create data")
 		return
 	}
 
@@ -66,8 +77,11 @@ func (h *appDataHandler) Create(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param namespace path string true "Namespace Code"
 // @Param app path string true "App Code"
+// @Param Accept-Language header string false "Локали для локализуемых полей, без заголовка возвращаются значения всех локалей"
 // @Param uid path string true "Data UID"
+// @Param stage query string false "Версия записи: draft или published; для ключей API по умолчанию published"
 // @Success 200 {object} domain.AppData
+// @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /namespace/{namespace}/app/{app}/data/{uid} [get]
@@ -77,8 +91,23 @@ func (h *appDataHandler) GetDataByUID(w http.ResponseWriter, r *http.Request) {
 	appName := vars["app"]
 	uid := vars["uid"]
 
-	data, err := h.uc.GetDataByUID(namespace, appName, uid)
+	stage, err := stageParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var data *domain.AppData
+	if stage == domain.StagePublished {
+		data, err = h.publishing.Get(r.Context(), namespace, appName, uid)
+	} else {
+		data, err = h.uc.GetDataByUID(r.Context(), namespace, appName, uid)
+	}
 	if err != nil {
+		writeError(w, err, "failed to get data")
+		return
+	}
+	if data == nil {
 		http.Error(w, "data not found", http.StatusNotFound)
 		return
 	}
@@ -86,14 +115,59 @@ func (h *appDataHandler) GetDataByUID(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(data)
 }
 
+// GetDataByNumberHandler godoc
+// @Summary Получить данные по номеру
+// @Description Ищет запись по номеру, выданному нумерацией приложения, например INV-2026-00042
+// @Tags app-data
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Param Accept-Language header string false "Локали для локализуемых полей, без заголовка возвращаются значения всех локалей"
+// @Param number path string true "Номер записи"
+// @Param stage query string false "Версия записи: draft или published; для ключей API по умолчанию published"
+// @Success 200 {object} domain.AppData
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/data/by-number/{number} [get]
+func (h *appDataHandler) GetByNumber(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	stage, err := stageParam(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var data *domain.AppData
+	if stage == domain.StagePublished {
+		data, err = h.publishing.GetByNumber(r.Context(), vars["namespace"], vars["app"], vars["number"])
+	} else {
+		data, err = h.uc.GetByNumber(r.Context(), vars["namespace"], vars["app"], vars["number"])
+	}
+	if err != nil {
+		writeError(w, err, "failed to get data")
+		return
+	}
+	json.NewEncoder(w).Encode(data)
+}
+
 // GetAllDataHandler godoc
 // @Summary Получить все данные приложения
-// @Description Возвращает все данные для указанного приложения в namespace
+// @Description Возвращает данные приложения в namespace с фильтрами, сортировкой и пагинацией.
+// @Description Кроме полей данных доступны системные поля $createdAt, $updatedAt, $createdBy, $updatedBy и $number
 // @Tags app-data
 // @Produce json
 // @Param namespace path string true "Namespace Code"
 // @Param app path string true "App Code"
+// @Param Accept-Language header string false "Локали для локализуемых полей, без заголовка возвращаются значения всех локалей"
+// @Param filter query []string false "Условие поле:оператор:значение, операторы eq, ne, gt, gte, lt, lte, contains" collectionFormat(multi)
+// @Param sort query string false "Поля сортировки через запятую, минус — по убыванию: -total,name"
+// @Param limit query int false "Размер страницы"
+// @Param offset query int false "Смещение"
+// @Param stage query string false "Версия записей: draft или published; для ключей API по умолчанию published"
 // @Success 200 {array} domain.AppData
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /namespace/{namespace}/app/{app}/data [get]
 func (h *appDataHandler) GetAll(w http.ResponseWriter, r *http.Request) {
@@ -101,9 +175,15 @@ func (h *appDataHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 	namespace := vars["namespace"]
 	appName := vars["app"]
 
-	data, err := h.uc.GetAll(namespace, appName)
+	query, err := parseDataQuery(r)
 	if err != nil {
-		http.Error(w, "failed to get data", http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	data, err := h.uc.GetAll(r.Context(), namespace, appName, query)
+	if err != nil {
+		writeError(w, err, "failed to get data")
 		return
 	}
 
@@ -118,11 +198,13 @@ func (h *appDataHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param namespace path string true "Namespace Code"
 // @Param app path string true "App Code"
+// @Param Content-Language header string false "Локаль строковых значений локализуемых полей, по умолчанию основная локаль namespace"
 // @Param uid path string true "Data UID"
 // @Param data body domain.AppData true "Новые данные"
 // @Success 200 {object} domain.AppData
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]interface{} "Значение уникального поля уже занято"
 // @Router /namespace/{namespace}/app/{app}/data/{uid} [put]
 func (h *appDataHandler) Update(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -139,8 +221,8 @@ func (h *appDataHandler) Update(w http.ResponseWriter, r *http.Request) {
 	// Устанавливаем UID из пути
 	data.UID = uid
 
-	if err := h.uc.Update(namespace, appName, &data); err != nil {
-		http.Error(w, "failed to update data", http.StatusInternalServerError)
+	if err := h.uc.Update(r.Context(), namespace, appName, &data); err != nil {
+		writeError(w, err, "failed to update data")
 		return
 	}
 
@@ -155,11 +237,13 @@ func (h *appDataHandler) Update(w http.ResponseWriter, r *http.Request) {
 // @Produce json
 // @Param namespace path string true "Namespace Code"
 // @Param app path string true "App Code"
+// @Param Content-Language header string false "Локаль строковых значений локализуемых полей, по умолчанию основная локаль namespace"
 // @Param uid path string true "Data UID"
 // @Param data body map[string]interface{} true "Поля для обновления"
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]interface{} "Значение уникального поля уже занято"
 // @Router /namespace/{namespace}/app/{app}/data/{uid} [patch]
 func (h *appDataHandler) UpdateDataPartial(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -173,8 +257,8 @@ func (h *appDataHandler) UpdateDataPartial(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	if err := h.uc.UpdateDataPartial(namespace, appName, uid, partialData); err != nil {
-		http.Error(w, "failed to update data", http.StatusInternalServerError)
+	if err := h.uc.UpdateDataPartial(r.Context(), namespace, appName, uid, partialData); err != nil {
+		writeError(w, err, "failed to update data")
 		return
 	}
 
@@ -184,7 +268,7 @@ func (h *appDataHandler) UpdateDataPartial(w http.ResponseWriter, r *http.Reques
 
 // DeleteDataHandler godoc
 // @Summary Удалить данные
-// @Description Удаляет данные по UID
+// @Description Перемещает данные с указанным UID в корзину
 // @Tags app-data
 // @Param namespace path string true "Namespace Code"
 // @Param app path string true "App Code"
@@ -199,10 +283,125 @@ func (h *appDataHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	appName := vars["app"]
 	uid := vars["uid"]
 
-	if err := h.uc.Delete(namespace, appName, uid); err != nil {
-		http.Error(w, "failed to delete data", http.StatusInternalServerError)
+	if err := h.uc.Delete(r.Context(), namespace, appName, uid); err != nil {
+		writeError(w, err, "failed to delete data")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTrashHandler godoc
+// @Summary Получить данные из корзины
+// @Description Возвращает записи приложения, перемещённые в корзину
+// @Tags app-data
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Success 200 {array} domain.AppData
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/data/trash [get]
+func (h *appDataHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+	appName := vars["app"]
+
+	data, err := h.uc.GetTrash(r.Context(), namespace, appName)
+	if err != nil {
+		writeError(w, err, "failed to get trash")
+		return
+	}
+
+	json.NewEncoder(w).Encode(data)
+}
+
+// RestoreDataHandler godoc
+// @Summary Восстановить данные
+// @Description Возвращает запись с указанным UID из корзины
+// @Tags app-data
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Param uid path string true "Data UID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]interface{} "Значение уникального поля уже занято"
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/data/{uid}/restore [post]
+func (h *appDataHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	namespace := vars["namespace"]
+	appName := vars["app"]
+	uid := vars["uid"]
+
+	if err := h.uc.Restore(r.Context(), namespace, appName, uid); err != nil {
+		writeError(w, err, "failed to restore data")
 		return
 	}
 
 	w.WriteHeader(http.StatusNoContent)
 }
+
+// parseDataQuery разбирает параметры выборки: filter=поле:оператор:значение (можно
+// повторять, оператор eq можно опустить), sort=-поле,поле, limit и offset
+func parseDataQuery(r *http.Request) (domain.DataQuery, error) {
+	q := r.URL.Query()
+	var query domain.DataQuery
+
+	stage, err := stageParam(r)
+	if err != nil {
+		return query, err
+	}
+	query.Stage = stage
+
+	for _, raw := range q["filter"] {
+		parts := strings.SplitN(raw, ":", 3)
+		switch len(parts) {
+		case 2:
+			query.Filters = append(query.Filters, domain.DataFilter{Field: parts[0], Op: domain.FilterEq, Value: parts[1]})
+		case 3:
+			query.Filters = append(query.Filters, domain.DataFilter{Field: parts[0], Op: parts[1], Value: parts[2]})
+		default:
+			return query, errInvalidParam("filter")
+		}
+	}
+
+	if sort := q.Get("sort"); sort != "" {
+		for _, field := range strings.Split(sort, ",") {
+			field = strings.TrimSpace(field)
+			desc := strings.HasPrefix(field, "-")
+			field = strings.TrimPrefix(field, "-")
+			if field == "" {
+				return query, errInvalidParam("sort")
+			}
+			query.Sort = append(query.Sort, domain.DataSort{Field: field, Desc: desc})
+		}
+	}
+
+	for name, dst := range map[string]*int{"limit": &query.Limit, "offset": &query.Offset} {
+		if value := q.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return query, errInvalidParam(name)
+			}
+			*dst = n
+		}
+	}
+	return query, nil
+}
+
+// stageParam возвращает запрошенную версию записей. Без параметра клиентам с ключом API
+// отдаётся опубликованная версия, пользователям — черновик
+func stageParam(r *http.Request) (string, error) {
+	switch stage := r.URL.Query().Get("stage"); stage {
+	case domain.StageDraft, domain.StagePublished:
+		return stage, nil
+	case "":
+		if reqctx.FromContext(r.Context()).APIKey {
+			return domain.StagePublished, nil
+		}
+		return domain.StageDraft, nil
+	default:
+		return "", errInvalidParam("stage")
+	}
+}
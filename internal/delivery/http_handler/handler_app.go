@@ -4,7 +4,6 @@ import (
 	"app/backendv1/internal/domain"
 	"app/backendv1/internal/usecase"
 	"encoding/json"
-	"fmt"
 	"net/http"
 
 	"github.com/gorilla/mux"
@@ -19,11 +18,13 @@ func NewAppHandler(uc usecase.AppUsecase) *appHandler {
 }
 
 func (h *appHandler) RegisterRoutes(r *mux.Router) {
-	r.HandleFunc("/namespace/{namespace}/app", h.Create).Methods("POST")
+	r.Handle("/namespace/{namespace}/app", longRunning(h.Create)).Methods("POST")
 	r.HandleFunc("/apps", h.GetAll).Methods("GET")
 	r.HandleFunc("/namespace/{namespace}/apps", h.GetAllByCodeNamespace).Methods("GET")
-	r.HandleFunc("/namespace/{namespace}/app/{app}", h.Update).Methods("PUT")
+	r.HandleFunc("/namespace/{namespace}/apps/trash", h.GetTrash).Methods("GET")
+	r.Handle("/namespace/{namespace}/app/{app}", longRunning(h.Update)).Methods("PUT")
 	r.HandleFunc("/namespace/{namespace}/app/{app}", h.Delete).Methods("DELETE")
+	r.HandleFunc("/namespace/{namespace}/app/{app}/restore", h.Restore).Methods("POST")
 }
 
 // CreateAppHandler godoc
@@ -40,7 +41,6 @@ func (h *appHandler) RegisterRoutes(r *mux.Router) {
 // @Router /namespace/{namespace}/app [post]
 func (h *appHandler) Create(w http.ResponseWriter, r *http.Request) {
 	namespaceCode := mux.Vars(r)["namespace"]
-	fmt.Print(namespaceCode)
 	var app domain.App
 	if err := json.NewDecoder(r.Body).Decode(&app); err != nil {
 		http.Error(w, "bad request", http.StatusBadRequest)
@@ -50,8 +50,8 @@ func (h *appHandler) Create(w http.ResponseWriter, r *http.Request) {
 	// Важный момент: привязка к namespace из пути
 	app.NamespaceCode = namespaceCode
 
-	if err := h.uc.Create(&app); err != nil {
-		http.Error(w, "create failed", http.StatusInternalServerError)
+	if err := h.uc.Create(r.Context(), &app); err != nil {
+		writeError(w, err, "create failed")
 		return
 	}
 
@@ -70,7 +70,7 @@ func (h *appHandler) Create(w http.ResponseWriter, r *http.Request) {
 // @Router /namespace/{namespace}/apps [get]
 func (h *appHandler) GetAllByCodeNamespace(w http.ResponseWriter, r *http.Request) {
 	code := mux.Vars(r)["namespace"]
-	apps, err := h.uc.GetAllByCodeNamespace(code)
+	apps, err := h.uc.GetAllByCodeNamespace(r.Context(), code)
 	if err != nil {
 		http.Error(w, "error", http.StatusInternalServerError)
 	}
@@ -78,7 +78,7 @@ func (h *appHandler) GetAllByCodeNamespace(w http.ResponseWriter, r *http.Reques
 }
 
 func (h *appHandler) GetAll(w http.ResponseWriter, r *http.Request) {
-	apps, err := h.uc.GetAll()
+	apps, err := h.uc.GetAll(r.Context())
 	if err != nil {
 		http.Error(w, "get all failed", http.StatusInternalServerError)
 		return
@@ -98,6 +98,7 @@ func (h *appHandler) GetAll(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} map[string]string
 // @Failure 400 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]interface{} "В записях есть повторы значений уникальных полей"
 // @Router /namespace/{namespace}/app/{app} [put]
 func (h *appHandler) Update(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
@@ -114,8 +115,8 @@ func (h *appHandler) Update(w http.ResponseWriter, r *http.Request) {
 	app.Code = code
 	app.NamespaceCode = namespaceCode
 
-	if err := h.uc.Update(&app); err != nil {
-		http.Error(w, "update failed", http.StatusInternalServerError)
+	if err := h.uc.Update(r.Context(), &app); err != nil {
+		writeError(w, err, "update failed")
 		return
 	}
 
@@ -124,19 +125,60 @@ func (h *appHandler) Update(w http.ResponseWriter, r *http.Request) {
 
 // DeleteAppHandler godoc
 // @Summary Удалить приложение
-// @Description Удаляет приложение по namespace и коду
+// @Description Перемещает приложение в корзину, окончательно оно удаляется по истечении срока хранения
 // @Tags apps
 // @Param namespace path string true "Namespace Code"
 // @Param app path string true "App Code"
-// @Success 200 {object} map[string]string
+// @Success 204
+// @Failure 404 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /namespace/{namespace}/app/{app} [delete]
 func (h *appHandler) Delete(w http.ResponseWriter, r *http.Request) {
 	appCode := mux.Vars(r)["app"]
 	namespaceCode := mux.Vars(r)["namespace"]
 
-	if err := h.uc.Delete(appCode, namespaceCode); err != nil {
-		http.Error(w, "delete failed", http.StatusInternalServerError)
+	if err := h.uc.Delete(r.Context(), appCode, namespaceCode); err != nil {
+		writeError(w, err, "delete failed")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTrashHandler godoc
+// @Summary Получить приложения из корзины
+// @Description Возвращает приложения namespace, перемещённые в корзину
+// @Tags apps
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Success 200 {array} domain.App
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/apps/trash [get]
+func (h *appHandler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	code := mux.Vars(r)["namespace"]
+	apps, err := h.uc.GetTrashByCodeNamespace(r.Context(), code)
+	if err != nil {
+		http.Error(w, "get trash failed", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(apps)
+}
+
+// RestoreAppHandler godoc
+// @Summary Восстановить приложение
+// @Description Возвращает приложение из корзины
+// @Tags apps
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/restore [post]
+func (h *appHandler) Restore(w http.ResponseWriter, r *http.Request) {
+	appCode := mux.Vars(r)["app"]
+	namespaceCode := mux.Vars(r)["namespace"]
+
+	if err := h.uc.Restore(r.Context(), appCode, namespaceCode); err != nil {
+		writeError(w, err, "restore failed")
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
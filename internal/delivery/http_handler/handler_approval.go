@@ -0,0 +1,152 @@
+package http_handler
+
+import (
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/usecase"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type approvalHandler struct {
+	uc usecase.ApprovalUsecase
+}
+
+func NewApprovalHandler(uc usecase.ApprovalUsecase) *approvalHandler {
+	return &approvalHandler{uc: uc}
+}
+
+func (h *approvalHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/approvals/inbox", h.Inbox).Methods("GET")
+	r.HandleFunc("/approvals/{id}", h.Get).Methods("GET")
+	r.HandleFunc("/approvals/{id}/approve", h.Approve).Methods("POST")
+	r.HandleFunc("/approvals/{id}/reject", h.Reject).Methods("POST")
+	r.HandleFunc("/namespace/{namespace}/app/{app}/approvals", h.List).Methods("GET")
+}
+
+// ApprovalInboxHandler godoc
+// @Summary Входящие запросы на согласование
+// @Description Возвращает ожидающие запросы на изменение, по которым текущий пользователь (X-User-ID, X-User-Roles) может проголосовать, от новых к старым
+// @Tags approvals
+// @Produce json
+// @Param limit query int false "Количество запросов, по умолчанию 100"
+// @Success 200 {array} domain.ChangeRequest
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /approvals/inbox [get]
+func (h *approvalHandler) Inbox(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, errInvalidParam("limit").Error(), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	requests, err := h.uc.Inbox(r.Context(), limit)
+	if err != nil {
+		writeError(w, err, "failed to get inbox")
+		return
+	}
+	json.NewEncoder(w).Encode(requests)
+}
+
+// GetApprovalHandler godoc
+// @Summary Получить запрос на изменение
+// @Tags approvals
+// @Produce json
+// @Param id path string true "Change request ID"
+// @Success 200 {object} domain.ChangeRequest
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /approvals/{id} [get]
+func (h *approvalHandler) Get(w http.ResponseWriter, r *http.Request) {
+	request, err := h.uc.Get(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		writeError(w, err, "failed to get change request")
+		return
+	}
+	json.NewEncoder(w).Encode(request)
+}
+
+// ApproveHandler godoc
+// @Summary Одобрить запрос на изменение
+// @Description Голос «за» на текущем шаге. После нужного числа одобрений на последнем шаге изменение применяется к записи
+// @Tags approvals
+// @Accept json
+// @Produce json
+// @Param id path string true "Change request ID"
+// @Param decision body domain.ApprovalDecision false "Комментарий"
+// @Success 200 {object} domain.ChangeRequest
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /approvals/{id}/approve [post]
+func (h *approvalHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, h.uc.Approve)
+}
+
+// RejectHandler godoc
+// @Summary Отклонить запрос на изменение
+// @Tags approvals
+// @Accept json
+// @Produce json
+// @Param id path string true "Change request ID"
+// @Param decision body domain.ApprovalDecision false "Комментарий"
+// @Success 200 {object} domain.ChangeRequest
+// @Failure 403 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /approvals/{id}/reject [post]
+func (h *approvalHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	h.decide(w, r, h.uc.Reject)
+}
+
+func (h *approvalHandler) decide(w http.ResponseWriter, r *http.Request, decide func(ctx context.Context, id string, decision domain.ApprovalDecision) (*domain.ChangeRequest, error)) {
+	var decision domain.ApprovalDecision
+	if err := json.NewDecoder(r.Body).Decode(&decision); err != nil && err != io.EOF {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	request, err := decide(r.Context(), mux.Vars(r)["id"], decision)
+	if err != nil {
+		writeError(w, err, "failed to save decision")
+		return
+	}
+	json.NewEncoder(w).Encode(request)
+}
+
+// ListApprovalsHandler godoc
+// @Summary Запросы на изменение записей приложения
+// @Tags approvals
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Param status query string false "pending, approved, rejected, expired, failed"
+// @Param uid query string false "Data UID"
+// @Success 200 {array} domain.ChangeRequest
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/approvals [get]
+func (h *approvalHandler) List(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	requests, err := h.uc.List(r.Context(), domain.ChangeRequestFilter{
+		NamespaceCode: vars["namespace"],
+		AppCode:       vars["app"],
+		RecordUID:     r.URL.Query().Get("uid"),
+		Status:        r.URL.Query().Get("status"),
+	})
+	if err != nil {
+		writeError(w, err, "failed to get change requests")
+		return
+	}
+	json.NewEncoder(w).Encode(requests)
+}
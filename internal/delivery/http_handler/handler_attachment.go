@@ -0,0 +1,149 @@
+package http_handler
+
+import (
+	"app/backendv1/internal/usecase"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// uploadFormField — имя части multipart-запроса с файлом
+const uploadFormField = "file"
+
+type attachmentHandler struct {
+	uc usecase.AttachmentUsecase
+}
+
+func NewAttachmentHandler(uc usecase.AttachmentUsecase) *attachmentHandler {
+	return &attachmentHandler{uc: uc}
+}
+
+func (h *attachmentHandler) RegisterRoutes(r *mux.Router) {
+	r.Handle("/namespace/{namespace}/app/{app}/data/{uid}/files/{field}", longRunning(h.Upload)).Methods("POST")
+	r.Handle("/namespace/{namespace}/app/{app}/data/{uid}/files/{field}", longRunning(h.Download)).Methods("GET")
+	r.HandleFunc("/namespace/{namespace}/app/{app}/data/{uid}/files/{field}", h.Delete).Methods("DELETE")
+}
+
+// UploadFileHandler godoc
+// @Summary Загрузить файл в поле записи
+// @Description Загружает файл в поле типа file, заменяя предыдущий. Тип содержимого определяется по данным файла
+// @Tags attachments
+// @Accept multipart/form-data
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Param uid path string true "Data UID"
+// @Param field path string true "Код поля типа file"
+// @Param file formData file true "Файл"
+// @Success 201 {object} domain.Attachment
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/data/{uid}/files/{field} [post]
+func (h *attachmentHandler) Upload(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	part, ok := formFile(w, r)
+	if !ok {
+		return
+	}
+	defer part.Close()
+
+	attachment, err := h.uc.Upload(r.Context(), vars["namespace"], vars["app"], vars["uid"], vars["field"], part.FileName(), part)
+	if err != nil {
+		writeError(w, err, "failed to upload file")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(attachment)
+}
+
+// DownloadFileHandler godoc
+// @Summary Скачать файл из поля записи
+// @Description Возвращает содержимое файла; ETag равен SHA-256 файла
+// @Tags attachments
+// @Produce octet-stream
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Param uid path string true "Data UID"
+// @Param field path string true "Код поля типа file"
+// @Success 200 {file} file
+// @Success 304
+// @Failure 404 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/data/{uid}/files/{field} [get]
+func (h *attachmentHandler) Download(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	attachment, body, err := h.uc.Open(r.Context(), vars["namespace"], vars["app"], vars["uid"], vars["field"])
+	if err != nil {
+		writeError(w, err, "failed to open file")
+		return
+	}
+	defer body.Close()
+
+	etag := `"` + attachment.SHA256 + `"`
+	w.Header().Set("ETag", etag)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.Size, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	io.Copy(w, body)
+}
+
+// DeleteFileHandler godoc
+// @Summary Удалить файл из поля записи
+// @Description Удаляет файл и очищает поле записи
+// @Tags attachments
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Param uid path string true "Data UID"
+// @Param field path string true "Код поля типа file"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/data/{uid}/files/{field} [delete]
+func (h *attachmentHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.uc.Delete(r.Context(), vars["namespace"], vars["app"], vars["uid"], vars["field"]); err != nil {
+		writeError(w, err, "failed to delete file")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// formFile находит в multipart-запросе часть с файлом, не буферизуя запрос целиком
+func formFile(w http.ResponseWriter, r *http.Request) (*multipart.Part, bool) {
+	reader, err := r.MultipartReader()
+	if err != nil {
+		http.Error(w, "multipart/form-data body expected", http.StatusBadRequest)
+		return nil, false
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			http.Error(w, "form field \"file\" is missing", http.StatusBadRequest)
+			return nil, false
+		}
+		if err != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return nil, false
+		}
+		if part.FormName() == uploadFormField {
+			return part, true
+		}
+		part.Close()
+	}
+}
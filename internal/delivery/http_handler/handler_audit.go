@@ -0,0 +1,191 @@
+package http_handler
+
+import (
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/usecase"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// exportPageSize — размер страницы при выгрузке журнала целиком
+const exportPageSize = 1000
+
+type auditHandler struct {
+	uc usecase.AuditUsecase
+}
+
+func NewAuditHandler(uc usecase.AuditUsecase) *auditHandler {
+	return &auditHandler{uc: uc}
+}
+
+func (h *auditHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/audit", h.Find).Methods("GET")
+	r.Handle("/audit/export", longRunning(h.Export)).Methods("GET")
+	r.HandleFunc("/audit/verify", h.Verify).Methods("GET")
+}
+
+// FindAuditHandler godoc
+// @Summary Получить журнал аудита
+// @Description Возвращает записи журнала аудита по фильтру, от новых к старым
+// @Tags audit
+// @Produce json
+// @Param actor query string false "Субъект"
+// @Param action query string false "Действие, например app_data.update"
+// @Param namespace query string false "Namespace Code"
+// @Param app query string false "App Code"
+// @Param uid query string false "Data UID"
+// @Param from query string false "Начало периода (RFC3339)"
+// @Param to query string false "Конец периода (RFC3339)"
+// @Param limit query int false "Размер страницы (по умолчанию 100, максимум 1000)"
+// @Param offset query int false "Смещение"
+// @Success 200 {array} domain.AuditEntry
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /audit [get]
+func (h *auditHandler) Find(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	entries, err := h.uc.Find(r.Context(), filter)
+	if err != nil {
+		http.Error(w, "failed to load audit log", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(entries)
+}
+
+// ExportAuditHandler godoc
+// @Summary Выгрузить журнал аудита
+// @Description Выгружает все записи журнала по фильтру в CSV или NDJSON
+// @Tags audit
+// @Produce text/csv
+// @Produce application/x-ndjson
+// @Param format query string false "csv (по умолчанию) или ndjson"
+// @Param actor query string false "Субъект"
+// @Param action query string false "Действие"
+// @Param namespace query string false "Namespace Code"
+// @Param app query string false "App Code"
+// @Param uid query string false "Data UID"
+// @Param from query string false "Начало периода (RFC3339)"
+// @Param to query string false "Конец периода (RFC3339)"
+// @Success 200 {string} string
+// @Failure 400 {object} map[string]string
+// @Router /audit/export [get]
+func (h *auditHandler) Export(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAuditFilter(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "ndjson" {
+		http.Error(w, "unsupported format", http.StatusBadRequest)
+		return
+	}
+
+	var write func(entry *domain.AuditEntry) error
+	var flush func()
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", `attachment; filename="audit.csv"`)
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"id", "createdAt", "actor", "action", "namespace", "app", "uid", "requestId", "sourceIp", "diff", "prevHash", "hash"})
+		write = func(e *domain.AuditEntry) error {
+			return cw.Write([]string{
+				strconv.FormatInt(e.ID, 10), e.CreatedAt.Format(time.RFC3339Nano), e.Actor, e.Action,
+				e.NamespaceCode, e.AppCode, e.UID, e.RequestID, e.SourceIP, string(e.Diff), e.PrevHash, e.Hash,
+			})
+		}
+		flush = cw.Flush
+	} else {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="audit.ndjson"`)
+		enc := json.NewEncoder(w)
+		write = func(e *domain.AuditEntry) error { return enc.Encode(e) }
+		flush = func() {}
+	}
+
+	filter.Limit = exportPageSize
+	for filter.Offset = 0; ; filter.Offset += exportPageSize {
+		entries, err := h.uc.Find(r.Context(), filter)
+		if err != nil {
+			// заголовки уже отправлены, остаётся только оборвать выгрузку
+			return
+		}
+		for _, entry := range entries {
+			if err := write(entry); err != nil {
+				return
+			}
+		}
+		flush()
+		if len(entries) < exportPageSize {
+			return
+		}
+	}
+}
+
+// VerifyAuditHandler godoc
+// @Summary Проверить целостность журнала аудита
+// @Description Пересчитывает цепочку хешей и возвращает ID первой изменённой записи
+// @Tags audit
+// @Produce json
+// @Success 200 {object} domain.AuditVerification
+// @Failure 500 {object} map[string]string
+// @Router /audit/verify [get]
+func (h *auditHandler) Verify(w http.ResponseWriter, r *http.Request) {
+	result, err := h.uc.Verify(r.Context())
+	if err != nil {
+		http.Error(w, "failed to verify audit log", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+func parseAuditFilter(r *http.Request) (domain.AuditFilter, error) {
+	q := r.URL.Query()
+	filter := domain.AuditFilter{
+		Actor:         q.Get("actor"),
+		Action:        q.Get("action"),
+		NamespaceCode: q.Get("namespace"),
+		AppCode:       q.Get("app"),
+		UID:           q.Get("uid"),
+	}
+
+	for name, dst := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		if value := q.Get(name); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				return filter, errInvalidParam(name)
+			}
+			*dst = &t
+		}
+	}
+	for name, dst := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		if value := q.Get(name); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return filter, errInvalidParam(name)
+			}
+			*dst = n
+		}
+	}
+	return filter, nil
+}
+
+type errInvalidParam string
+
+func (e errInvalidParam) Error() string {
+	return "invalid query parameter: " + string(e)
+}
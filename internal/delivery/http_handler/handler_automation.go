@@ -0,0 +1,224 @@
+package http_handler
+
+import (
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/usecase"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type automationHandler struct {
+	uc usecase.AutomationUsecase
+}
+
+func NewAutomationHandler(uc usecase.AutomationUsecase) *automationHandler {
+	return &automationHandler{uc: uc}
+}
+
+func (h *automationHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/namespace/{namespace}/app/{app}/rules", h.Create).Methods("POST")
+	r.HandleFunc("/namespace/{namespace}/app/{app}/rules", h.GetAll).Methods("GET")
+	r.HandleFunc("/namespace/{namespace}/app/{app}/rules/{id}", h.Get).Methods("GET")
+	r.HandleFunc("/namespace/{namespace}/app/{app}/rules/{id}", h.Update).Methods("PUT")
+	r.HandleFunc("/namespace/{namespace}/app/{app}/rules/{id}", h.Delete).Methods("DELETE")
+	r.HandleFunc("/namespace/{namespace}/app/{app}/rules/{id}/runs", h.Runs).Methods("GET")
+	r.Handle("/namespace/{namespace}/app/{app}/rules/{id}/test", longRunning(h.TestRun)).Methods("POST")
+}
+
+// CreateRuleHandler godoc
+// @Summary Создать правило автоматизации
+// @Description Правило выполняется асинхронно при создании, изменении или удалении записей приложения
+// @Tags automation
+// @Accept json
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Param rule body domain.AutomationRule true "Правило"
+// @Success 201 {object} domain.AutomationRule
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/rules [post]
+func (h *automationHandler) Create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var rule domain.AutomationRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	rule.NamespaceCode = vars["namespace"]
+	rule.AppCode = vars["app"]
+
+	if err := h.uc.CreateRule(r.Context(), &rule); err != nil {
+		writeError(w, err, "failed to create rule")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// GetRulesHandler godoc
+// @Summary Правила автоматизации приложения
+// @Tags automation
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Success 200 {array} domain.AutomationRule
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/rules [get]
+func (h *automationHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	rules, err := h.uc.GetRules(r.Context(), vars["namespace"], vars["app"])
+	if err != nil {
+		writeError(w, err, "failed to get rules")
+		return
+	}
+	json.NewEncoder(w).Encode(rules)
+}
+
+// GetRuleHandler godoc
+// @Summary Получить правило автоматизации
+// @Tags automation
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Param id path string true "Rule ID"
+// @Success 200 {object} domain.AutomationRule
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/rules/{id} [get]
+func (h *automationHandler) Get(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	rule, err := h.uc.GetRule(r.Context(), vars["namespace"], vars["app"], vars["id"])
+	if err != nil {
+		writeError(w, err, "failed to get rule")
+		return
+	}
+	json.NewEncoder(w).Encode(rule)
+}
+
+// UpdateRuleHandler godoc
+// @Summary Изменить правило автоматизации
+// @Tags automation
+// @Accept json
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Param id path string true "Rule ID"
+// @Param rule body domain.AutomationRule true "Правило"
+// @Success 200 {object} domain.AutomationRule
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/rules/{id} [put]
+func (h *automationHandler) Update(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var rule domain.AutomationRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	rule.ID = vars["id"]
+	rule.NamespaceCode = vars["namespace"]
+	rule.AppCode = vars["app"]
+
+	if err := h.uc.UpdateRule(r.Context(), &rule); err != nil {
+		writeError(w, err, "failed to update rule")
+		return
+	}
+	json.NewEncoder(w).Encode(rule)
+}
+
+// DeleteRuleHandler godoc
+// @Summary Удалить правило автоматизации
+// @Tags automation
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Param id path string true "Rule ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/rules/{id} [delete]
+func (h *automationHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.uc.DeleteRule(r.Context(), vars["namespace"], vars["app"], vars["id"]); err != nil {
+		writeError(w, err, "failed to delete rule")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RuleRunsHandler godoc
+// @Summary Журнал выполнения правила
+// @Description Возвращает последние выполнения правила, новые первыми
+// @Tags automation
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Param id path string true "Rule ID"
+// @Param limit query int false "Количество записей, по умолчанию 100"
+// @Success 200 {array} domain.AutomationRun
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/rules/{id}/runs [get]
+func (h *automationHandler) Runs(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, errInvalidParam("limit").Error(), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	runs, err := h.uc.Runs(r.Context(), vars["namespace"], vars["app"], vars["id"], limit)
+	if err != nil {
+		writeError(w, err, "failed to get runs")
+		return
+	}
+	json.NewEncoder(w).Encode(runs)
+}
+
+// TestRuleHandler godoc
+// @Summary Тестовый запуск правила
+// @Description Проверяет условие правила на примере записи и возвращает действия, которые были бы выполнены. Ничего не изменяет и не отправляет
+// @Tags automation
+// @Accept json
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Param id path string true "Rule ID"
+// @Param sample body domain.RuleTestRequest true "Событие и пример записи"
+// @Success 200 {object} domain.RuleTestResult
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/rules/{id}/test [post]
+func (h *automationHandler) TestRun(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req domain.RuleTestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.uc.TestRun(r.Context(), vars["namespace"], vars["app"], vars["id"], req)
+	if err != nil {
+		writeError(w, err, "failed to test rule")
+		return
+	}
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,53 @@
+package http_handler
+
+import (
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/usecase"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type batchHandler struct {
+	uc usecase.BatchUsecase
+}
+
+func NewBatchHandler(uc usecase.BatchUsecase) *batchHandler {
+	return &batchHandler{uc: uc}
+}
+
+func (h *batchHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/batch", h.Execute).Methods("POST")
+}
+
+// ExecuteBatchHandler godoc
+// @Summary Выполнить пакет операций над записями
+// @Description Выполняет операции create, update, patch и delete над записями приложений любых namespace по порядку в одной транзакции: либо все, либо ни одной. Операция create может объявить ref, и следующие операции ссылаются на созданную запись значением "$ref:имя" в uid или в поле данных, в том числе во вложенных списках и объектах. При ошибке пакет откатывается, а номер неудавшейся операции (с нуля) возвращается в заголовке Batch-Failed-Operation. Изменения приложений с согласованием в пакете не выполняются. Каждая операция списывается из ограничения частоты изменений своего namespace
+// @Tags batch
+// @Accept json
+// @Produce json
+// @Param Idempotency-Key header string false "Ключ повтора: запрос с тем же ключом получит сохранённый ответ, а не выполнит пакет повторно"
+// @Param batch body domain.BatchRequest true "Операции"
+// @Success 200 {object} domain.BatchResponse
+// @Failure 400 {object} map[string]string
+// @Failure 403 {object} map[string]string "Превышена квота записей"
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]interface{} "Значение уникального поля уже занято или запись изменена параллельно"
+// @Failure 429 {object} map[string]string "Превышено ограничение частоты изменений в namespace одной из операций"
+// @Failure 500 {object} map[string]string
+// @Router /batch [post]
+func (h *batchHandler) Execute(w http.ResponseWriter, r *http.Request) {
+	var request domain.BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	results, err := h.uc.Execute(r.Context(), principal(r), request.Operations)
+	if err != nil {
+		writeError(w, err, "failed to execute batch")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(domain.BatchResponse{Results: results})
+}
@@ -0,0 +1,51 @@
+package http_handler
+
+import (
+	"app/backendv1/internal/usecase"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type healthHandler struct {
+	uc usecase.HealthUsecase
+}
+
+func NewHealthHandler(uc usecase.HealthUsecase) *healthHandler {
+	return &healthHandler{uc: uc}
+}
+
+func (h *healthHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/healthz", h.Health).Methods("GET")
+	r.HandleFunc("/readyz", h.Ready).Methods("GET")
+}
+
+// HealthHandler godoc
+// @Summary Проверка живости процесса
+// @Description Отвечает 200, пока процесс обслуживает запросы; база не проверяется. Подходит для livenessProbe
+// @Tags health
+// @Produce json
+// @Success 200 {object} map[string]string
+// @Router /healthz [get]
+func (h *healthHandler) Health(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
+}
+
+// ReadyHandler godoc
+// @Summary Проверка готовности принимать запросы
+// @Description Отвечает 200, если база доступна и миграции применены, иначе 503 с результатами проверок. Во время остановки всегда 503. Подходит для readinessProbe
+// @Tags health
+// @Produce json
+// @Success 200 {object} domain.Readiness
+// @Failure 503 {object} domain.Readiness
+// @Router /readyz [get]
+func (h *healthHandler) Ready(w http.ResponseWriter, r *http.Request) {
+	readiness := h.uc.Ready(r.Context())
+	w.Header().Set("Content-Type", "application/json")
+	if !readiness.Ready {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(readiness)
+}
@@ -0,0 +1,165 @@
+package http_handler
+
+import (
+	"app/backendv1/internal/usecase"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// iconCacheControl — иконка с данным ID никогда не меняется, новая загрузка получает новый ID
+const iconCacheControl = "public, max-age=31536000, immutable"
+
+type iconHandler struct {
+	uc usecase.IconUsecase
+}
+
+func NewIconHandler(uc usecase.IconUsecase) *iconHandler {
+	return &iconHandler{uc: uc}
+}
+
+func (h *iconHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/namespace/{namespace}/app/{app}/icon", h.UploadAppIcon).Methods("PUT")
+	r.HandleFunc("/namespace/{namespace}/app/{app}/icon", h.DeleteAppIcon).Methods("DELETE")
+	r.HandleFunc("/namespaces/{code}/icon", h.UploadNamespaceIcon).Methods("PUT")
+	r.HandleFunc("/namespaces/{code}/icon", h.DeleteNamespaceIcon).Methods("DELETE")
+	r.HandleFunc("/icons/{id}", h.Get).Methods("GET")
+	r.HandleFunc("/icons/{id}/{size:[0-9]+}", h.Get).Methods("GET")
+}
+
+// UploadAppIconHandler godoc
+// @Summary Загрузить иконку приложения
+// @Description Принимает PNG, JPEG или SVG до 2 МБ и создаёт уменьшенные копии 32, 64, 128 и 256 px
+// @Tags icons
+// @Accept multipart/form-data
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Param file formData file true "Иконка"
+// @Success 200 {object} domain.Icon
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/icon [put]
+func (h *iconHandler) UploadAppIcon(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	part, ok := formFile(w, r)
+	if !ok {
+		return
+	}
+	defer part.Close()
+
+	icon, err := h.uc.UploadAppIcon(r.Context(), vars["namespace"], vars["app"], part)
+	if err != nil {
+		writeError(w, err, "failed to upload icon")
+		return
+	}
+	json.NewEncoder(w).Encode(icon)
+}
+
+// DeleteAppIconHandler godoc
+// @Summary Удалить иконку приложения
+// @Tags icons
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/icon [delete]
+func (h *iconHandler) DeleteAppIcon(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	if err := h.uc.DeleteAppIcon(r.Context(), vars["namespace"], vars["app"]); err != nil {
+		writeError(w, err, "failed to delete icon")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UploadNamespaceIconHandler godoc
+// @Summary Загрузить иконку namespace
+// @Description Принимает PNG, JPEG или SVG до 2 МБ и создаёт уменьшенные копии 32, 64, 128 и 256 px
+// @Tags icons
+// @Accept multipart/form-data
+// @Produce json
+// @Param code path string true "Namespace code"
+// @Param file formData file true "Иконка"
+// @Success 200 {object} domain.Icon
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 413 {object} map[string]string
+// @Router /namespaces/{code}/icon [put]
+func (h *iconHandler) UploadNamespaceIcon(w http.ResponseWriter, r *http.Request) {
+	part, ok := formFile(w, r)
+	if !ok {
+		return
+	}
+	defer part.Close()
+
+	icon, err := h.uc.UploadNamespaceIcon(r.Context(), mux.Vars(r)["code"], part)
+	if err != nil {
+		writeError(w, err, "failed to upload icon")
+		return
+	}
+	json.NewEncoder(w).Encode(icon)
+}
+
+// DeleteNamespaceIconHandler godoc
+// @Summary Удалить иконку namespace
+// @Tags icons
+// @Param code path string true "Namespace code"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Router /namespaces/{code}/icon [delete]
+func (h *iconHandler) DeleteNamespaceIcon(w http.ResponseWriter, r *http.Request) {
+	if err := h.uc.DeleteNamespaceIcon(r.Context(), mux.Vars(r)["code"]); err != nil {
+		writeError(w, err, "failed to delete icon")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetIconHandler godoc
+// @Summary Получить иконку
+// @Description Возвращает исходную иконку или уменьшенную копию в PNG (для SVG всегда исходник). Ответ кешируется навсегда
+// @Tags icons
+// @Produce image/png
+// @Produce image/jpeg
+// @Produce image/svg+xml
+// @Param id path string true "Icon ID"
+// @Param size path int false "Размер копии: 32, 64, 128 или 256"
+// @Success 200 {file} file
+// @Success 304
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /icons/{id}/{size} [get]
+func (h *iconHandler) Get(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	size := 0
+	if value, ok := vars["size"]; ok {
+		size, _ = strconv.Atoi(value)
+	}
+
+	icon, contentType, body, err := h.uc.Open(r.Context(), vars["id"], size)
+	if err != nil {
+		writeError(w, err, "failed to open icon")
+		return
+	}
+	defer body.Close()
+
+	etag := `"` + icon.SHA256 + "-" + strconv.Itoa(size) + `"`
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", iconCacheControl)
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if contentType == "image/svg+xml" {
+		w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	}
+	io.Copy(w, body)
+}
@@ -0,0 +1,67 @@
+package http_handler
+
+import (
+	"app/backendv1/internal/usecase"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type indexHandler struct {
+	uc usecase.IndexUsecase
+}
+
+func NewIndexHandler(uc usecase.IndexUsecase) *indexHandler {
+	return &indexHandler{uc: uc}
+}
+
+func (h *indexHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/namespace/{namespace}/app/{app}/indexes", h.List).Methods("GET")
+	r.Handle("/namespace/{namespace}/app/{app}/indexes/sync", longRunning(h.Sync)).Methods("POST")
+}
+
+// ListIndexesHandler godoc
+// @Summary Индексы таблицы записей приложения
+// @Description Возвращает все индексы таблицы с размером и статистикой использования из pg_stat_user_indexes. managed — индекс создан по схеме приложения
+// @Tags indexes
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Success 200 {array} domain.IndexInfo
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/indexes [get]
+func (h *indexHandler) List(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	indexes, err := h.uc.List(r.Context(), vars["namespace"], vars["app"])
+	if err != nil {
+		writeError(w, err, "failed to get indexes")
+		return
+	}
+	json.NewEncoder(w).Encode(indexes)
+}
+
+// SyncIndexesHandler godoc
+// @Summary Перестроить индексы по схеме приложения
+// @Description Создаёт недостающие и недостроенные индексы и удаляет лишние. Индексы строятся CONCURRENTLY, не блокируя запись
+// @Tags indexes
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Success 200 {array} domain.IndexInfo
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]interface{} "В записях есть повторы значений уникальных полей"
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/indexes/sync [post]
+func (h *indexHandler) Sync(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	indexes, err := h.uc.Sync(r.Context(), vars["namespace"], vars["app"])
+	if err != nil {
+		writeError(w, err, "failed to sync indexes")
+		return
+	}
+	json.NewEncoder(w).Encode(indexes)
+}
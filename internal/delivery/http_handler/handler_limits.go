@@ -0,0 +1,86 @@
+package http_handler
+
+import (
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/usecase"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type limitsHandler struct {
+	uc usecase.LimitsUsecase
+}
+
+func NewLimitsHandler(uc usecase.LimitsUsecase) *limitsHandler {
+	return &limitsHandler{uc: uc}
+}
+
+func (h *limitsHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/namespaces/{code}/limits", h.Get).Methods("GET")
+	r.HandleFunc("/namespaces/{code}/limits", h.Set).Methods("PUT")
+	r.HandleFunc("/namespaces/{code}/limits", h.Reset).Methods("DELETE")
+}
+
+// GetLimitsHandler godoc
+// @Summary Действующие ограничения namespace
+// @Description Возвращает ограничения частоты запросов в минуту на субъекта и квоты с учётом значений по умолчанию; 0 — ограничения нет. Свои значения namespace возвращаются в поле limits самого namespace
+// @Tags limits
+// @Produce json
+// @Param code path string true "Namespace Code"
+// @Success 200 {object} domain.Limits
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespaces/{code}/limits [get]
+func (h *limitsHandler) Get(w http.ResponseWriter, r *http.Request) {
+	limits, err := h.uc.Get(r.Context(), mux.Vars(r)["code"])
+	if err != nil {
+		writeError(w, err, "failed to get limits")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(limits)
+}
+
+// SetLimitsHandler godoc
+// @Summary Задать ограничения namespace
+// @Description Заменяет свои ограничения namespace. 0 — значение по умолчанию из настроек сервиса, -1 — без ограничения. Квоты проверяются при создании приложений и записей, уже созданные объекты не удаляются
+// @Tags limits
+// @Accept json
+// @Param code path string true "Namespace Code"
+// @Param limits body domain.Limits true "Ограничения"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespaces/{code}/limits [put]
+func (h *limitsHandler) Set(w http.ResponseWriter, r *http.Request) {
+	var limits domain.Limits
+	if err := json.NewDecoder(r.Body).Decode(&limits); err != nil {
+		http.Error(w, "bad request", http.StatusBadRequest)
+		return
+	}
+	if err := h.uc.Set(r.Context(), mux.Vars(r)["code"], &limits); err != nil {
+		writeError(w, err, "failed to set limits")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ResetLimitsHandler godoc
+// @Summary Сбросить ограничения namespace
+// @Description Удаляет свои ограничения namespace, после чего действуют значения по умолчанию
+// @Tags limits
+// @Param code path string true "Namespace Code"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespaces/{code}/limits [delete]
+func (h *limitsHandler) Reset(w http.ResponseWriter, r *http.Request) {
+	if err := h.uc.Set(r.Context(), mux.Vars(r)["code"], nil); err != nil {
+		writeError(w, err, "failed to reset limits")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
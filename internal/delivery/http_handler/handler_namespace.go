@@ -22,9 +22,11 @@ func NewHandler(uc usecase.NamespaceUsecase) *handler {
 func (h *handler) RegisterRoutes(r *mux.Router) {
 	r.HandleFunc("/namespaces", h.Create).Methods("POST")
 	r.HandleFunc("/namespaces", h.GetAll).Methods("GET")
+	r.HandleFunc("/namespaces/trash", h.GetTrash).Methods("GET")
 	r.HandleFunc("/namespaces/{code}", h.GetByCode).Methods("GET")
 	r.HandleFunc("/namespaces/{code}", h.Update).Methods("PUT")
 	r.HandleFunc("/namespaces/{code}", h.Delete).Methods("DELETE")
+	r.HandleFunc("/namespaces/{code}/restore", h.Restore).Methods("POST")
 }
 
 // Create creates a new namespace
@@ -44,7 +46,7 @@ func (h *handler) Create(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "bad request: "+err.Error(), http.StatusBadRequest)
 		return
 	}
-	if err := h.uc.Create(&namespace); err != nil {
+	if err := h.uc.Create(r.Context(), &namespace); err != nil {
 		http.Error(w, "error creating"+err.Error(), http.StatusInternalServerError)
 		return
 	}
@@ -61,7 +63,7 @@ func (h *handler) Create(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /namespaces [get]
 func (h *handler) GetAll(w http.ResponseWriter, r *http.Request) {
-	namespaces, err := h.uc.GetAll()
+	namespaces, err := h.uc.GetAll(r.Context())
 	if err != nil {
 		http.Error(w, "error loading all", http.StatusInternalServerError)
 		return
@@ -80,8 +82,8 @@ func (h *handler) GetAll(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /namespaces/{code} [get]
 func (h *handler) GetByCode(w http.ResponseWriter, r *http.Request) {
-	code := mux.Vars(r)["namespace"]
-	namespace, err := h.uc.GetByCode(code)
+	code := mux.Vars(r)["code"]
+	namespace, err := h.uc.GetByCode(r.Context(), code)
 	if err != nil {
 		http.Error(w, "error loading by code", http.StatusInternalServerError)
 		return
@@ -106,31 +108,67 @@ func (h *handler) GetByCode(w http.ResponseWriter, r *http.Request) {
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /namespaces/{code} [put]
 func (h *handler) Update(w http.ResponseWriter, r *http.Request) {
-	code := mux.Vars(r)["namespace"]
+	code := mux.Vars(r)["code"]
 	var namespace domain.Namespace
 	if err := json.NewDecoder(r.Body).Decode(&namespace); err != nil {
 		http.Error(w, "bad request", http.StatusBadRequest)
 		return
 	}
-	if err := h.uc.Update(code, &namespace); err != nil {
+	if err := h.uc.Update(r.Context(), code, &namespace); err != nil {
 		http.Error(w, "error update", http.StatusInternalServerError)
 		return
 	}
 	json.NewEncoder(w).Encode(namespace)
 }
 
-// Delete deletes a namespace
+// Delete moves a namespace to the trash
 // @Summary Delete namespace
-// @Description Delete namespace by code
+// @Description Move namespace to the trash, it is purged after the retention period
 // @Tags namespaces
 // @Param code path string true "Namespace code"
 // @Success 204
+// @Failure 404 {string} string "Not Found"
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /namespaces/{code} [delete]
 func (h *handler) Delete(w http.ResponseWriter, r *http.Request) {
-	code := mux.Vars(r)["namespace"]
-	if err := h.uc.Delete(code); err != nil {
-		http.Error(w, "delete failed", http.StatusInternalServerError)
+	code := mux.Vars(r)["code"]
+	if err := h.uc.Delete(r.Context(), code); err != nil {
+		writeError(w, err, "delete failed")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetTrash gets namespaces in the trash
+// @Summary Get trashed namespaces
+// @Description Get list of namespaces moved to the trash
+// @Tags namespaces
+// @Produce  json
+// @Success 200 {array} domain.Namespace
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /namespaces/trash [get]
+func (h *handler) GetTrash(w http.ResponseWriter, r *http.Request) {
+	namespaces, err := h.uc.GetTrash(r.Context())
+	if err != nil {
+		http.Error(w, "error loading trash", http.StatusInternalServerError)
+		return
+	}
+	json.NewEncoder(w).Encode(namespaces)
+}
+
+// Restore restores a namespace from the trash
+// @Summary Restore namespace
+// @Description Restore namespace from the trash by code
+// @Tags namespaces
+// @Param code path string true "Namespace code"
+// @Success 204
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /namespaces/{code}/restore [post]
+func (h *handler) Restore(w http.ResponseWriter, r *http.Request) {
+	code := mux.Vars(r)["code"]
+	if err := h.uc.Restore(r.Context(), code); err != nil {
+		writeError(w, err, "restore failed")
 		return
 	}
 	w.WriteHeader(http.StatusNoContent)
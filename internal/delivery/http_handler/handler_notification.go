@@ -0,0 +1,80 @@
+package http_handler
+
+import (
+	"app/backendv1/internal/usecase"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type notificationHandler struct {
+	uc usecase.NotificationUsecase
+}
+
+func NewNotificationHandler(uc usecase.NotificationUsecase) *notificationHandler {
+	return &notificationHandler{uc: uc}
+}
+
+func (h *notificationHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/notifications", h.List).Methods("GET")
+	r.HandleFunc("/notifications/{id}/read", h.MarkRead).Methods("POST")
+}
+
+// ListNotificationsHandler godoc
+// @Summary Уведомления текущего пользователя
+// @Description Возвращает уведомления пользователя из X-User-ID, новые первыми
+// @Tags notifications
+// @Produce json
+// @Param unread query bool false "Только непрочитанные"
+// @Param limit query int false "Количество записей, по умолчанию 100"
+// @Success 200 {array} domain.Notification
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /notifications [get]
+func (h *notificationHandler) List(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	unread := false
+	if value := q.Get("unread"); value != "" {
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			http.Error(w, errInvalidParam("unread").Error(), http.StatusBadRequest)
+			return
+		}
+		unread = b
+	}
+	limit := 0
+	if value := q.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, errInvalidParam("limit").Error(), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	notifications, err := h.uc.List(r.Context(), unread, limit)
+	if err != nil {
+		writeError(w, err, "failed to get notifications")
+		return
+	}
+	json.NewEncoder(w).Encode(notifications)
+}
+
+// MarkNotificationReadHandler godoc
+// @Summary Отметить уведомление прочитанным
+// @Tags notifications
+// @Param id path int true "Notification ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /notifications/{id}/read [post]
+func (h *notificationHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	if err := h.uc.MarkRead(r.Context(), mux.Vars(r)["id"]); err != nil {
+		writeError(w, err, "failed to mark notification")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
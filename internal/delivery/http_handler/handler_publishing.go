@@ -0,0 +1,119 @@
+package http_handler
+
+import (
+	"app/backendv1/internal/usecase"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+type publishingHandler struct {
+	uc usecase.PublishingUsecase
+}
+
+func NewPublishingHandler(uc usecase.PublishingUsecase) *publishingHandler {
+	return &publishingHandler{uc: uc}
+}
+
+func (h *publishingHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/namespace/{namespace}/app/{app}/data/{uid}/publish", h.Publish).Methods("POST")
+	r.HandleFunc("/namespace/{namespace}/app/{app}/data/{uid}/unpublish", h.Unpublish).Methods("POST")
+	r.HandleFunc("/namespace/{namespace}/app/{app}/data/{uid}/schedule", h.CancelSchedule).Methods("DELETE")
+}
+
+// publishRequest — необязательное тело запросов публикации; время в будущем откладывает действие
+type publishRequest struct {
+	At *time.Time `json:"at,omitempty"`
+}
+
+// PublishDataHandler godoc
+// @Summary Опубликовать черновик записи
+// @Description Копирует черновик записи в опубликованную версию. Если в теле передано время в будущем, публикация выполняется в это время
+// @Tags publishing
+// @Accept json
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Param uid path string true "Record UID"
+// @Param request body publishRequest false "Время отложенной публикации"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/data/{uid}/publish [post]
+func (h *publishingHandler) Publish(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	at, ok := decodePublishAt(w, r)
+	if !ok {
+		return
+	}
+	if err := h.uc.Publish(r.Context(), vars["namespace"], vars["app"], vars["uid"], at); err != nil {
+		writeError(w, err, "failed to publish data")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// UnpublishDataHandler godoc
+// @Summary Снять запись с публикации
+// @Description Удаляет опубликованную версию записи, черновик сохраняется. Время в будущем откладывает снятие
+// @Tags publishing
+// @Accept json
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Param uid path string true "Record UID"
+// @Param request body publishRequest false "Время отложенного снятия с публикации"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/data/{uid}/unpublish [post]
+func (h *publishingHandler) Unpublish(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	at, ok := decodePublishAt(w, r)
+	if !ok {
+		return
+	}
+	if err := h.uc.Unpublish(r.Context(), vars["namespace"], vars["app"], vars["uid"], at); err != nil {
+		writeError(w, err, "failed to unpublish data")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// CancelPublishScheduleHandler godoc
+// @Summary Отменить запланированную публикацию
+// @Description Отменяет отложенные публикацию и снятие с публикации записи
+// @Tags publishing
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Param uid path string true "Record UID"
+// @Success 204
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/data/{uid}/schedule [delete]
+func (h *publishingHandler) CancelSchedule(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.uc.CancelSchedule(r.Context(), vars["namespace"], vars["app"], vars["uid"]); err != nil {
+		writeError(w, err, "failed to cancel publication schedule")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodePublishAt читает необязательное время действия; пустое тело означает «сейчас»
+func decodePublishAt(w http.ResponseWriter, r *http.Request) (*time.Time, bool) {
+	var req publishRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return nil, false
+	}
+	return req.At, true
+}
@@ -0,0 +1,73 @@
+package http_handler
+
+import (
+	"app/backendv1/internal/usecase"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type retentionHandler struct {
+	uc usecase.RetentionUsecase
+}
+
+func NewRetentionHandler(uc usecase.RetentionUsecase) *retentionHandler {
+	return &retentionHandler{uc: uc}
+}
+
+func (h *retentionHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/namespace/{namespace}/app/{app}/data/{uid}/hold", h.SetHold).Methods("PUT")
+	r.HandleFunc("/namespace/{namespace}/app/{app}/data/{uid}/hold", h.ReleaseHold).Methods("DELETE")
+	r.HandleFunc("/retention/metrics", h.Metrics).Methods("GET")
+}
+
+// SetLegalHoldHandler godoc
+// @Summary Поставить запись под удержание
+// @Description Запись под удержанием не удаляется и не архивируется по сроку хранения и не удаляется из корзины окончательно
+// @Tags retention
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Param uid path string true "Record UID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/data/{uid}/hold [put]
+func (h *retentionHandler) SetHold(w http.ResponseWriter, r *http.Request) {
+	h.setHold(w, r, true)
+}
+
+// ReleaseLegalHoldHandler godoc
+// @Summary Снять удержание с записи
+// @Tags retention
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Param uid path string true "Record UID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/data/{uid}/hold [delete]
+func (h *retentionHandler) ReleaseHold(w http.ResponseWriter, r *http.Request) {
+	h.setHold(w, r, false)
+}
+
+func (h *retentionHandler) setHold(w http.ResponseWriter, r *http.Request, hold bool) {
+	vars := mux.Vars(r)
+
+	if err := h.uc.SetLegalHold(r.Context(), vars["namespace"], vars["app"], vars["uid"], hold); err != nil {
+		writeError(w, err, "failed to set legal hold")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RetentionMetricsHandler godoc
+// @Summary Статистика сроков хранения
+// @Description Счётчики удалённых и архивированных по сроку хранения записей с момента запуска экземпляра сервиса
+// @Tags retention
+// @Produce json
+// @Success 200 {array} domain.RetentionMetrics
+// @Router /retention/metrics [get]
+func (h *retentionHandler) Metrics(w http.ResponseWriter, r *http.Request) {
+	json.NewEncoder(w).Encode(h.uc.Metrics())
+}
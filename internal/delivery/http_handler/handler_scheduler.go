@@ -0,0 +1,253 @@
+package http_handler
+
+import (
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/usecase"
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+type schedulerHandler struct {
+	uc usecase.SchedulerUsecase
+}
+
+func NewSchedulerHandler(uc usecase.SchedulerUsecase) *schedulerHandler {
+	return &schedulerHandler{uc: uc}
+}
+
+func (h *schedulerHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/namespace/{namespace}/jobs", h.Create).Methods("POST")
+	r.HandleFunc("/namespace/{namespace}/jobs", h.GetAll).Methods("GET")
+	r.HandleFunc("/namespace/{namespace}/jobs/{id}", h.Get).Methods("GET")
+	r.HandleFunc("/namespace/{namespace}/jobs/{id}", h.Update).Methods("PUT")
+	r.HandleFunc("/namespace/{namespace}/jobs/{id}", h.Delete).Methods("DELETE")
+	r.HandleFunc("/namespace/{namespace}/jobs/{id}/run", h.Trigger).Methods("POST")
+	r.HandleFunc("/namespace/{namespace}/jobs/{id}/pause", h.Pause).Methods("POST")
+	r.HandleFunc("/namespace/{namespace}/jobs/{id}/resume", h.Resume).Methods("POST")
+	r.HandleFunc("/namespace/{namespace}/jobs/{id}/runs", h.Runs).Methods("GET")
+}
+
+// CreateJobHandler godoc
+// @Summary Создать запланированное задание
+// @Description Задание выполняется по cron-расписанию: пересчёт вычисляемых полей (recompute), удаление старых записей (purge), выгрузка записей вебхуком (export) или запуск правила автоматизации (run_rule)
+// @Tags jobs
+// @Accept json
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Param job body domain.ScheduledJob true "Задание"
+// @Success 201 {object} domain.ScheduledJob
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/jobs [post]
+func (h *schedulerHandler) Create(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var job domain.ScheduledJob
+	if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	job.NamespaceCode = vars["namespace"]
+
+	if err := h.uc.Create(r.Context(), &job); err != nil {
+		writeError(w, err, "failed to create job")
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(job)
+}
+
+// GetJobsHandler godoc
+// @Summary Запланированные задания namespace
+// @Tags jobs
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Success 200 {array} domain.ScheduledJob
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/jobs [get]
+func (h *schedulerHandler) GetAll(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	jobs, err := h.uc.GetAll(r.Context(), vars["namespace"])
+	if err != nil {
+		writeError(w, err, "failed to get jobs")
+		return
+	}
+	json.NewEncoder(w).Encode(jobs)
+}
+
+// GetJobHandler godoc
+// @Summary Получить запланированное задание
+// @Tags jobs
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Param id path string true "Job ID"
+// @Success 200 {object} domain.ScheduledJob
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/jobs/{id} [get]
+func (h *schedulerHandler) Get(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	job, err := h.uc.Get(r.Context(), vars["namespace"], vars["id"])
+	if err != nil {
+		writeError(w, err, "failed to get job")
+		return
+	}
+	json.NewEncoder(w).Encode(job)
+}
+
+// UpdateJobHandler godoc
+// @Summary Изменить запланированное задание
+// @Description Следующий запуск пересчитывается по новому расписанию. Пауза меняется через /pause и /resume
+// @Tags jobs
+// @Accept json
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Param id path string true "Job ID"
+// @Param job body domain.ScheduledJob true "Задание"
+// @Success 200 {object} domain.ScheduledJob
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/jobs/{id} [put]
+func (h *schedulerHandler) Update(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var job domain.ScheduledJob
+	if err := json.NewDecoder(r.Body).Decode(&job); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+	job.ID = vars["id"]
+	job.NamespaceCode = vars["namespace"]
+
+	if err := h.uc.Update(r.Context(), &job); err != nil {
+		writeError(w, err, "failed to update job")
+		return
+	}
+	json.NewEncoder(w).Encode(job)
+}
+
+// DeleteJobHandler godoc
+// @Summary Удалить запланированное задание
+// @Tags jobs
+// @Param namespace path string true "Namespace Code"
+// @Param id path string true "Job ID"
+// @Success 204
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/jobs/{id} [delete]
+func (h *schedulerHandler) Delete(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	if err := h.uc.Delete(r.Context(), vars["namespace"], vars["id"]); err != nil {
+		writeError(w, err, "failed to delete job")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// TriggerJobHandler godoc
+// @Summary Запустить задание вручную
+// @Description Запускает задание в фоне и возвращает начатый запуск со статусом running; результат появится в истории запусков. Расписание не меняется
+// @Tags jobs
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Param id path string true "Job ID"
+// @Success 202 {object} domain.JobRun
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string "Задание уже выполняется"
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/jobs/{id}/run [post]
+func (h *schedulerHandler) Trigger(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	run, err := h.uc.Trigger(r.Context(), vars["namespace"], vars["id"])
+	if err != nil {
+		writeError(w, err, "failed to run job")
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(run)
+}
+
+// PauseJobHandler godoc
+// @Summary Приостановить задание
+// @Tags jobs
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Param id path string true "Job ID"
+// @Success 200 {object} domain.ScheduledJob
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/jobs/{id}/pause [post]
+func (h *schedulerHandler) Pause(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	job, err := h.uc.Pause(r.Context(), vars["namespace"], vars["id"])
+	if err != nil {
+		writeError(w, err, "failed to pause job")
+		return
+	}
+	json.NewEncoder(w).Encode(job)
+}
+
+// ResumeJobHandler godoc
+// @Summary Возобновить задание
+// @Description Пропущенные за время паузы запуски не выполняются, следующий считается от текущего времени
+// @Tags jobs
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Param id path string true "Job ID"
+// @Success 200 {object} domain.ScheduledJob
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/jobs/{id}/resume [post]
+func (h *schedulerHandler) Resume(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	job, err := h.uc.Resume(r.Context(), vars["namespace"], vars["id"])
+	if err != nil {
+		writeError(w, err, "failed to resume job")
+		return
+	}
+	json.NewEncoder(w).Encode(job)
+}
+
+// JobRunsHandler godoc
+// @Summary История выполнения задания
+// @Description Возвращает последние выполнения задания, новые первыми
+// @Tags jobs
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Param id path string true "Job ID"
+// @Param limit query int false "Количество записей, по умолчанию 100"
+// @Success 200 {array} domain.JobRun
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/jobs/{id}/runs [get]
+func (h *schedulerHandler) Runs(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	limit := 0
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			http.Error(w, errInvalidParam("limit").Error(), http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	runs, err := h.uc.Runs(r.Context(), vars["namespace"], vars["id"], limit)
+	if err != nil {
+		writeError(w, err, "failed to get job runs")
+		return
+	}
+	json.NewEncoder(w).Encode(runs)
+}
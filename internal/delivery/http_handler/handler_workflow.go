@@ -0,0 +1,81 @@
+package http_handler
+
+import (
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/usecase"
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type workflowHandler struct {
+	uc usecase.WorkflowUsecase
+}
+
+func NewWorkflowHandler(uc usecase.WorkflowUsecase) *workflowHandler {
+	return &workflowHandler{uc: uc}
+}
+
+func (h *workflowHandler) RegisterRoutes(r *mux.Router) {
+	r.HandleFunc("/namespace/{namespace}/app/{app}/data/{uid}/transition", h.Transition).Methods("POST")
+	r.HandleFunc("/namespace/{namespace}/app/{app}/data/{uid}/transitions", h.History).Methods("GET")
+}
+
+// TransitionHandler godoc
+// @Summary Перевести запись в другое состояние
+// @Description Выполняет переход, объявленный в workflow приложения. Вместе с переходом можно заполнить поля записи
+// @Tags workflow
+// @Accept json
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Param uid path string true "Data UID"
+// @Param transition body domain.TransitionRequest true "Целевое состояние и комментарий"
+// @Success 200 {object} domain.AppData
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/data/{uid}/transition [post]
+func (h *workflowHandler) Transition(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var req domain.TransitionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	data, err := h.uc.Transition(r.Context(), vars["namespace"], vars["app"], vars["uid"], req)
+	if err != nil {
+		writeError(w, err, "failed to change state")
+		return
+	}
+
+	json.NewEncoder(w).Encode(data)
+}
+
+// TransitionHistoryHandler godoc
+// @Summary История переходов записи
+// @Description Возвращает выполненные переходы записи в порядке выполнения
+// @Tags workflow
+// @Produce json
+// @Param namespace path string true "Namespace Code"
+// @Param app path string true "App Code"
+// @Param uid path string true "Data UID"
+// @Success 200 {array} domain.TransitionRecord
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /namespace/{namespace}/app/{app}/data/{uid}/transitions [get]
+func (h *workflowHandler) History(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	history, err := h.uc.History(r.Context(), vars["namespace"], vars["app"], vars["uid"])
+	if err != nil {
+		writeError(w, err, "failed to get transitions")
+		return
+	}
+
+	json.NewEncoder(w).Encode(history)
+}
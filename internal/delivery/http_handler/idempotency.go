@@ -0,0 +1,198 @@
+package http_handler
+
+import (
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/metrics"
+	"app/backendv1/internal/reqctx"
+	"app/backendv1/internal/usecase"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"hash"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+)
+
+const (
+	headerIdempotencyKey = "Idempotency-Key"
+	// headerIdempotentReplayed отмечает ответ, взятый из сохранённых, а не полученный выполнением
+	headerIdempotentReplayed = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	// bufferedBodySize — сколько тела запроса держится в памяти, остальное уходит во временный файл
+	bufferedBodySize = 64 << 10
+	// maxStoredResponse — наибольшее сохраняемое тело ответа; ответ больше сохраняется без тела
+	maxStoredResponse = 1 << 20
+)
+
+// replayedHeaders — заголовки ответа, которые сохраняются и повторяются вместе с телом;
+// остальные (X-Request-ID, RateLimit-*) относятся к конкретному запросу
+var replayedHeaders = []string{"Content-Type", "Content-Language", "Content-Disposition", "Location"}
+
+// Idempotency выполняет запрос POST, PUT, PATCH или DELETE с заголовком Idempotency-Key
+// не больше одного раза. Ответ сохраняется, и повтор запроса с тем же ключом получает его
+// с заголовком Idempotent-Replayed. Ключ действует в пределах субъекта; тот же ключ с
+// другим методом, путём, локалями или телом отклоняется с 422, повтор во время выполнения — с 409.
+// Ответ 5xx или 429 сохраняется, только если запрос успел зафиксировать изменение, иначе повтор
+// после сбоя выполняет запрос заново. Middleware стоит перед RateLimit, поэтому повтор
+// сохранённого ответа не списывается из ограничения частоты. Тело запроса больше maxBody отклоняется с 413
+func Idempotency(idempotency usecase.IdempotencyUsecase, maxBody int64) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get(headerIdempotencyKey)
+			if header == "" || r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(header) > maxIdempotencyKeyLength {
+				http.Error(w, "Idempotency-Key must be at most "+strconv.Itoa(maxIdempotencyKeyLength)+" characters", http.StatusBadRequest)
+				return
+			}
+			// тело читается целиком: хеш нужен до выполнения, а обработчику — исходное тело.
+			// Локали тоже входят в хеш: от них зависят сохраняемые значения и текст ответа
+			meta := reqctx.FromContext(r.Context())
+			h := sha256.New()
+			io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+			io.WriteString(h, meta.ContentLocale+"|"+strings.Join(meta.Locales, ",")+"\n")
+			body, err := spoolBody(http.MaxBytesReader(w, r.Body, maxBody), h)
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				http.Error(w, "request body with Idempotency-Key must be at most "+strconv.FormatInt(maxBody, 10)+" bytes", http.StatusRequestEntityTooLarge)
+				return
+			}
+			if err != nil {
+				http.Error(w, "failed to read request body", http.StatusBadRequest)
+				return
+			}
+			defer body.Close()
+			r.Body = body
+
+			key := principal(r) + "|" + header
+			route := routeTemplate(r)
+			stored, err := idempotency.Begin(r.Context(), key, hex.EncodeToString(h.Sum(nil)))
+			if err != nil {
+				writeError(w, err, "failed to check idempotency key")
+				return
+			}
+			if stored != nil {
+				metrics.IdempotentReplay(route, r.Method)
+				for name, values := range stored.Header {
+					w.Header()[name] = values
+				}
+				w.Header().Set(headerIdempotentReplayed, "true")
+				w.WriteHeader(stored.Status)
+				w.Write(stored.Body)
+				return
+			}
+
+			ctx, written := reqctx.TrackWrites(r.Context())
+			recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(recorder, r.WithContext(ctx))
+
+			// ключ сохраняется и после отключения клиента: повтор придёт как раз после него
+			ctx = context.WithoutCancel(ctx)
+			// отказ ограничения частоты тоже не сохраняется: запрос не выполнялся, и повтор позже должен пройти
+			failed := recorder.status >= http.StatusInternalServerError || recorder.status == statusClientClosedRequest ||
+				recorder.status == http.StatusTooManyRequests
+			if failed && !written() {
+				if err := idempotency.Release(ctx, key); err != nil {
+					slog.WarnContext(ctx, "failed to release idempotency key", "error", err)
+				}
+				return
+			}
+			response := domain.IdempotentResponse{Status: recorder.status, Header: map[string][]string{}, Body: recorder.body.Bytes()}
+			if recorder.truncated {
+				slog.WarnContext(ctx, "idempotent response is too large, storing it without body", "size_limit", maxStoredResponse)
+				response.Body = nil
+			}
+			for _, name := range replayedHeaders {
+				if values := recorder.Header().Values(name); len(values) > 0 {
+					response.Header[name] = values
+				}
+			}
+			if err := idempotency.Complete(ctx, key, response); err != nil {
+				slog.WarnContext(ctx, "failed to store idempotent response", "error", err)
+			}
+		})
+	}
+}
+
+// spooledBody — тело запроса, прочитанное заранее: начало в памяти, остальное во временном файле
+type spooledBody struct {
+	io.Reader
+	file *os.File
+}
+
+// Close удаляет временный файл
+func (b *spooledBody) Close() error {
+	if b.file == nil {
+		return nil
+	}
+	b.file.Close()
+	return os.Remove(b.file.Name())
+}
+
+// spoolBody читает тело запроса, добавляя его в хеш h, и возвращает его для обработчика.
+// Запросы отличаются по хешу метода, пути с параметрами, локалей и тела. Большое тело, например
+// загружаемый файл, не держится в памяти целиком
+func spoolBody(body io.Reader, h hash.Hash) (*spooledBody, error) {
+	var head bytes.Buffer
+	n, err := io.Copy(io.MultiWriter(&head, h), io.LimitReader(body, bufferedBodySize))
+	if err != nil {
+		return nil, err
+	}
+	if n < bufferedBodySize {
+		return &spooledBody{Reader: &head}, nil
+	}
+	file, err := os.CreateTemp("", "idempotent-body-*")
+	if err != nil {
+		return nil, err
+	}
+	spooled := &spooledBody{file: file}
+	if _, err := io.Copy(io.MultiWriter(file, h), body); err != nil {
+		spooled.Close()
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		spooled.Close()
+		return nil, err
+	}
+	spooled.Reader = io.MultiReader(&head, file)
+	return spooled, nil
+}
+
+// responseRecorder запоминает код и тело ответа, передавая их клиенту; тело больше
+// maxStoredResponse не запоминается
+type responseRecorder struct {
+	http.ResponseWriter
+	status    int
+	body      bytes.Buffer
+	truncated bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if !r.truncated && r.body.Len()+len(b) <= maxStoredResponse {
+		r.body.Write(b)
+	} else if !r.truncated {
+		r.truncated = true
+		r.body = bytes.Buffer{}
+	}
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap даёт http.ResponseController доступ к исходному ResponseWriter
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
@@ -0,0 +1,267 @@
+package http_handler
+
+import (
+	"app/backendv1/internal/logging"
+	"app/backendv1/internal/metrics"
+	"app/backendv1/internal/reqctx"
+	"app/backendv1/internal/tracing"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+)
+
+const (
+	headerRequestID = "X-Request-ID"
+	// headerUserID выставляется шлюзом аутентификации перед сервисом
+	headerUserID = "X-User-ID"
+	// headerUserRoles — роли пользователя через запятую, также от шлюза
+	headerUserRoles = "X-User-Roles"
+	// headerAuthMethod — способ аутентификации, api-key для интеграций по ключу API
+	headerAuthMethod = "X-Auth-Method"
+	authAPIKey       = "api-key"
+	anonymous        = "anonymous"
+)
+
+// RequestMeta кладёт в контекст запроса его идентификатор, субъекта и адрес клиента.
+// Идентификатор берётся из X-Request-ID или создаётся и возвращается в ответе.
+// trustedProxies — адреса и подсети прокси, которым доверяется X-Forwarded-For
+func RequestMeta(trustedProxies []string) mux.MiddlewareFunc {
+	var trusted []netip.Prefix
+	for _, proxy := range trustedProxies {
+		if prefix, err := netip.ParsePrefix(proxy); err == nil {
+			trusted = append(trusted, prefix.Masked())
+		} else if addr, err := netip.ParseAddr(proxy); err == nil {
+			trusted = append(trusted, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		return requestMeta(next, trusted)
+	}
+}
+
+func requestMeta(next http.Handler, trusted []netip.Prefix) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(headerRequestID)
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+		w.Header().Set(headerRequestID, requestID)
+
+		actor := r.Header.Get(headerUserID)
+		if actor == "" {
+			actor = anonymous
+		}
+
+		ctx := reqctx.WithMeta(r.Context(), reqctx.Meta{
+			RequestID: requestID,
+			Actor:     actor,
+			Roles:     userRoles(r),
+			SourceIP:  sourceIP(r, trusted),
+			APIKey:    r.Header.Get(headerAuthMethod) == authAPIKey,
+			Locales:   acceptLanguages(r.Header.Get("Accept-Language")),
+			// из списка языков тела значим только первый
+			ContentLocale: strings.TrimSpace(strings.Split(r.Header.Get("Content-Language"), ",")[0]),
+		})
+		// поля объекта из пути попадают во все записи журнала этого запроса
+		vars := mux.Vars(r)
+		var fields []any
+		for _, name := range []string{"namespace", "app", "uid"} {
+			if value := vars[name]; value != "" {
+				fields = append(fields, name, value)
+			}
+		}
+		if len(fields) > 0 {
+			ctx = logging.With(ctx, fields...)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+var probeRoutes = map[string]bool{"/healthz": true, "/readyz": true}
+
+// AccessLog пишет в журнал запись о каждом запросе: метод, маршрут, код ответа и время
+// обработки; идентификатор запроса и субъект добавляет журнал из контекста
+func AccessLog(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		started := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+
+		level := slog.LevelInfo
+		if recorder.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		} else if probeRoutes[r.URL.Path] {
+			// пробы Kubernetes приходят каждые несколько секунд и засоряли бы журнал
+			level = slog.LevelDebug
+		}
+		slog.LogAttrs(r.Context(), level, "http request",
+			slog.String("method", r.Method),
+			slog.String("route", routeTemplate(r)),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", recorder.status),
+			slog.Duration("latency", time.Since(started)),
+		)
+	})
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func userRoles(r *http.Request) []string {
+	var roles []string
+	for _, role := range strings.Split(r.Header.Get(headerUserRoles), ",") {
+		if role = strings.TrimSpace(role); role != "" {
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// Tracing продолжает трассу из заголовка traceparent или начинает новую
+// и открывает спан запроса, названный по шаблону маршрута
+func Tracing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(r)
+		ctx := tracing.ExtractHTTP(r.Context(), r.Header)
+		ctx, span := tracing.StartServer(ctx, r.Method+" "+route,
+			attribute.String("http.request.method", r.Method),
+			attribute.String("http.route", route),
+			attribute.String("request.id", reqctx.FromContext(ctx).RequestID),
+		)
+		defer span.End()
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r.WithContext(ctx))
+		span.SetAttributes(attribute.Int("http.response.status_code", recorder.status))
+		if recorder.status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(recorder.status))
+		}
+	})
+}
+
+// Metrics учитывает запросы в метриках по шаблону маршрута, а не по пути,
+// чтобы uid и коды приложений не размножали ряды
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := routeTemplate(r)
+		started := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		metrics.ObserveRequest(route, r.Method, recorder.status, time.Since(started).Seconds())
+	})
+}
+
+// routeTemplate возвращает шаблон маршрута mux, например /namespace/{namespace}/app/{app}
+func routeTemplate(r *http.Request) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if template, err := current.GetPathTemplate(); err == nil {
+			return template
+		}
+	}
+	return "unknown"
+}
+
+// statusRecorder запоминает код ответа обработчика
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap даёт http.ResponseController доступ к исходному ResponseWriter
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// acceptLanguages разбирает Accept-Language в список локалей по убыванию веса q.
+// Языки с q=0 отбрасываются, * оставляется: он означает, что подходит любая локаль
+func acceptLanguages(header string) []string {
+	type weighted struct {
+		tag string
+		q   float64
+	}
+	var langs []weighted
+	for _, part := range strings.Split(header, ",") {
+		params := strings.Split(part, ";")
+		tag := strings.TrimSpace(params[0])
+		if tag == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			langs = append(langs, weighted{tag: tag, q: q})
+		}
+	}
+	sort.SliceStable(langs, func(i, j int) bool { return langs[i].q > langs[j].q })
+
+	tags := make([]string, len(langs))
+	for i, lang := range langs {
+		tags[i] = lang.tag
+	}
+	return tags
+}
+
+// sourceIP возвращает адрес клиента. X-Forwarded-For учитывается, только если соединение
+// пришло от доверенного прокси: список читается справа налево, и клиентом считается первый
+// адрес не из доверенных. Всё, что левее, мог подставить сам клиент
+func sourceIP(r *http.Request, trusted []netip.Prefix) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if !isTrusted(host, trusted) {
+		return host
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if !isTrusted(hop, trusted) {
+			return hop
+		}
+		host = hop
+	}
+	return host
+}
+
+// isTrusted сообщает, относится ли адрес к доверенным прокси
+func isTrusted(ip string, trusted []netip.Prefix) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, prefix := range trusted {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
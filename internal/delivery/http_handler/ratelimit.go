@@ -0,0 +1,110 @@
+package http_handler
+
+import (
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/metrics"
+	"app/backendv1/internal/reqctx"
+	"app/backendv1/internal/usecase"
+	"errors"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// schemaRoutes — маршруты, изменяющие namespace, приложения и их индексы; все методы,
+// кроме GET, ограничиваются классом schema
+var schemaRoutes = map[string]bool{
+	"/namespaces":                                   true,
+	"/namespaces/{code}":                            true,
+	"/namespaces/{code}/restore":                    true,
+	"/namespaces/{code}/limits":                     true,
+	"/namespace/{namespace}/app":                    true,
+	"/namespace/{namespace}/app/{app}":              true,
+	"/namespace/{namespace}/app/{app}/restore":      true,
+	"/namespace/{namespace}/app/{app}/indexes/sync": true,
+}
+
+// unlimitedRoutes — служебные маршруты, которые не ограничиваются
+var unlimitedRoutes = map[string]bool{"/healthz": true, "/readyz": true, "/metrics": true, "/swagger/": true}
+
+// batchRoute ограничивается не здесь, а в BatchUsecase: каждая операция пакета
+// списывается из корзины изменений своего namespace
+const batchRoute = "/batch"
+
+// routeClass возвращает класс маршрута для ограничения частоты
+func routeClass(r *http.Request, route string) string {
+	switch {
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		return domain.RouteRead
+	case schemaRoutes[route]:
+		return domain.RouteSchema
+	}
+	return domain.RouteWrite
+}
+
+// RateLimit ограничивает частоту запросов субъекта в namespace по классам маршрутов:
+// чтение, изменение данных и изменение схемы. Состояние корзины отдаётся заголовками
+// RateLimit-Limit, RateLimit-Remaining и RateLimit-Reset, отклонённый запрос получает 429
+// с Retry-After. Если проверить ограничение не удалось, запрос пропускается
+func RateLimit(limits usecase.LimitsUsecase) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			route := routeTemplate(r)
+			if unlimitedRoutes[route] || route == batchRoute {
+				next.ServeHTTP(w, r)
+				return
+			}
+			vars := mux.Vars(r)
+			namespace := vars["namespace"]
+			if namespace == "" && route != "/namespaces" {
+				namespace = vars["code"]
+			}
+			class := routeClass(r, route)
+
+			decision, err := limits.Allow(r.Context(), principal(r), namespace, class)
+			if errors.Is(err, domain.ErrNotFound) {
+				// несуществующий namespace ограничивается значениями по умолчанию, ответ 404 даст обработчик.
+				// В метрику попадают только существующие namespace: путь задаёт клиент
+				namespace = ""
+				decision, err = limits.Allow(r.Context(), principal(r), namespace, class)
+			}
+			if err != nil {
+				slog.WarnContext(r.Context(), "rate limit check failed", "class", class, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+			if decision.Limit > 0 {
+				h := w.Header()
+				h.Set("RateLimit-Limit", strconv.Itoa(decision.Limit))
+				h.Set("RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+				h.Set("RateLimit-Reset", seconds(decision.Reset))
+				h.Set("RateLimit-Policy", strconv.Itoa(decision.Limit)+";w=60")
+			}
+			if !decision.Allowed {
+				metrics.RateLimited(namespace, class)
+				w.Header().Set("Retry-After", seconds(decision.RetryAfter))
+				http.Error(w, "rate limit exceeded", http.StatusTooManyRequests)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// principal возвращает, чьи запросы считаются вместе: пользователь, а для анонимных — адрес клиента
+func principal(r *http.Request) string {
+	meta := reqctx.FromContext(r.Context())
+	if meta.Actor == anonymous {
+		return "ip:" + meta.SourceIP
+	}
+	return "user:" + meta.Actor
+}
+
+// seconds округляет длительность вверх до целых секунд для заголовков
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int(math.Ceil(d.Seconds())))
+}
@@ -0,0 +1,52 @@
+package http_handler
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// RouteTimeouts — ограничения времени обработки запросов. Routes задаёт ограничения
+// отдельных маршрутов по ключу "МЕТОД шаблон", например
+// "GET /namespace/{namespace}/app/{app}/data"; ноль снимает ограничение
+type RouteTimeouts struct {
+	Default time.Duration
+	Routes  map[string]time.Duration
+}
+
+// longRunning отмечает при регистрации маршрут, которому ограничение по умолчанию не
+// подходит: построение индексов, выгрузки и передача файлов. Ограничение снято, если
+// не задано в Routes; отмена клиентом по-прежнему прерывает запросы к базе
+type longRunning http.HandlerFunc
+
+func (h longRunning) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h(w, r)
+}
+
+// Timeout ограничивает время обработки запроса контекстом с дедлайном. Запросы
+// к базе выполняются с контекстом запроса и отменяются на сервере по истечении
+// ограничения или при отключении клиента
+func Timeout(timeouts RouteTimeouts) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			timeout, ok := timeouts.Routes[r.Method+" "+routeTemplate(r)]
+			if !ok {
+				timeout = timeouts.Default
+				if route := mux.CurrentRoute(r); route != nil {
+					if _, long := route.GetHandler().(longRunning); long {
+						timeout = 0
+					}
+				}
+			}
+			if timeout <= 0 {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), timeout)
+			defer cancel()
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -1,8 +1,30 @@
 package domain
 
+import "time"
+
 type App struct {
-	Code          string `json:"code"`
-	Name          string `json:"name"`
-	NamespaceCode string `json:"namespaceCode"`
-	Icon          string `json:"icon"`
+	Code          string           `json:"code"`
+	Name          string           `json:"name"`
+	NamespaceCode string           `json:"namespaceCode"`
+	Icon          string           `json:"icon"`                  // ID иконки, меняется только загрузкой
+	Fields        []Field          `json:"fields"`                // Описание полей записей
+	MaxFileSize   int64            `json:"maxFileSize,omitempty"` // Лимит размера вложения в байтах, 0 — значение по умолчанию
+	Workflow      *Workflow        `json:"workflow,omitempty"`    // Машина состояний записей
+	Approval      *ApprovalPolicy  `json:"approval,omitempty"`    // Правки записей требуют согласования
+	Retention     *RetentionPolicy `json:"retention,omitempty"`   // Срок хранения записей
+	Unique        [][]string       `json:"unique,omitempty"`      // Составные ограничения уникальности, например [["sku", "warehouse"]]
+	Numbering     *NumberingPolicy `json:"numbering,omitempty"`   // Номера записей вида INV-2026-00042
+	Drafts        bool             `json:"drafts,omitempty"`      // Правки идут в черновик, читателям видна опубликованная версия
+	DeletedAt     *time.Time       `json:"deletedAt,omitempty"`   // Время перемещения в корзину
+	IndexError    string           `json:"indexError,omitempty"`  // Почему индексы не построены по схеме; пусто, если построены
+}
+
+// FieldByCode возвращает описание поля приложения или nil
+func (a *App) FieldByCode(code string) *Field {
+	for i := range a.Fields {
+		if a.Fields[i].Code == code {
+			return &a.Fields[i]
+		}
+	}
+	return nil
 }
@@ -1,6 +1,20 @@
 package domain
 
+import "time"
+
 type AppData struct {
-	UID  string                 `json:"uid"`  // Уникальный идентификатор
-	Data map[string]interface{} `json:"data"` // Произвольные JSON данные
+	UID       string                 `json:"uid"`              // Уникальный идентификатор
+	Number    string                 `json:"number,omitempty"` // Номер записи, если у приложения задана нумерация
+	Data      map[string]interface{} `json:"data"`             // Произвольные JSON данные
+	CreatedAt time.Time              `json:"createdAt"`        // Системные поля, заполняются при каждой записи
+	UpdatedAt time.Time              `json:"updatedAt"`
+	CreatedBy string                 `json:"createdBy,omitempty"`
+	UpdatedBy string                 `json:"updatedBy,omitempty"`
+	DeletedAt *time.Time             `json:"deletedAt,omitempty"` // Время перемещения в корзину
+	LegalHold bool                   `json:"legalHold,omitempty"` // Запись под удержанием не удаляется по сроку хранения
+
+	// Публикация, если у приложения включены черновики
+	PublishedAt *time.Time `json:"publishedAt,omitempty"` // Время публикации текущей опубликованной версии
+	PublishAt   *time.Time `json:"publishAt,omitempty"`   // Запланированная публикация черновика
+	UnpublishAt *time.Time `json:"unpublishAt,omitempty"` // Запланированное снятие с публикации
 }
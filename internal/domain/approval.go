@@ -0,0 +1,111 @@
+package domain
+
+import "time"
+
+// Статусы запроса на изменение
+const (
+	ChangePending  = "pending"
+	ChangeApproved = "approved" // одобрен и применён к записи
+	ChangeRejected = "rejected"
+	ChangeExpired  = "expired"
+	ChangeFailed   = "failed" // одобрен, но изменение не удалось применить
+)
+
+// Виды изменений записи
+const (
+	ChangeUpdate = "update" // полная замена данных, PUT
+	ChangePatch  = "patch"  // частичное обновление, PATCH
+)
+
+// Решения проверяющего
+const (
+	DecisionApprove = "approve"
+	DecisionReject  = "reject"
+)
+
+// ApprovalPolicy — правила согласования изменений записей приложения.
+// Шаги проходятся по порядку, каждый требует Required одобрений
+type ApprovalPolicy struct {
+	Steps []ApprovalStep `json:"steps"`
+	TTL   string         `json:"ttl,omitempty"` // Срок жизни запроса, например "72h"; пусто — значение по умолчанию
+}
+
+// ApprovalStep — шаг согласования: проверяющие задаются пользователями и ролями
+type ApprovalStep struct {
+	Name     string   `json:"name,omitempty"`
+	Users    []string `json:"users,omitempty"`
+	Roles    []string `json:"roles,omitempty"`
+	Required int      `json:"required"` // Сколько одобрений нужно для перехода к следующему шагу
+}
+
+// Eligible сообщает, может ли пользователь с ролями roles голосовать на шаге
+func (s *ApprovalStep) Eligible(actor string, roles []string) bool {
+	for _, user := range s.Users {
+		if user == actor {
+			return true
+		}
+	}
+	for _, role := range s.Roles {
+		for _, r := range roles {
+			if role == r {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ChangeRequest — изменение записи, ожидающее согласования. Policy — копия
+// правил на момент создания, чтобы их правка не меняла уже начатые согласования
+type ChangeRequest struct {
+	ID            string                 `json:"id"`
+	NamespaceCode string                 `json:"namespaceCode"`
+	AppCode       string                 `json:"appCode"`
+	RecordUID     string                 `json:"recordUid"`
+	Kind          string                 `json:"kind"`
+	Data          map[string]interface{} `json:"data"`
+	Status        string                 `json:"status"`
+	Step          int                    `json:"step"` // Индекс текущего шага в Policy.Steps
+	Policy        ApprovalPolicy         `json:"policy"`
+	RequestedBy   string                 `json:"requestedBy"`
+	Error         string                 `json:"error,omitempty"`
+	Votes         []ApprovalVote         `json:"votes,omitempty"`
+	CreatedAt     time.Time              `json:"createdAt"`
+	ExpiresAt     time.Time              `json:"expiresAt"`
+	DecidedAt     *time.Time             `json:"decidedAt,omitempty"`
+	// BaseUpdatedAt — время изменения записи, которую заменяет запрос полной замены;
+	// если запись изменилась, пока запрос ждал согласования, он не применяется
+	BaseUpdatedAt *time.Time `json:"baseUpdatedAt,omitempty"`
+}
+
+// ApprovalVote — решение проверяющего на шаге согласования
+type ApprovalVote struct {
+	Step      int       `json:"step"`
+	Reviewer  string    `json:"reviewer"`
+	Decision  string    `json:"decision"`
+	Comment   string    `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// ApprovalDecision — тело запроса на одобрение или отклонение
+type ApprovalDecision struct {
+	Comment string `json:"comment,omitempty"`
+}
+
+// PendingApprovalError возвращается вместо изменения записи приложения,
+// правки которого требуют согласования: изменение сохранено как запрос
+type PendingApprovalError struct {
+	Request *ChangeRequest
+}
+
+func (e *PendingApprovalError) Error() string {
+	return "change request " + e.Request.ID + " is pending approval"
+}
+
+// ChangeRequestFilter — параметры выборки запросов на изменение, пустые поля не учитываются
+type ChangeRequestFilter struct {
+	NamespaceCode string
+	AppCode       string
+	RecordUID     string
+	Status        string
+}
@@ -0,0 +1,29 @@
+package domain
+
+import "time"
+
+// Attachment — файл, прикреплённый к полю типа file записи приложения
+type Attachment struct {
+	ID            string    `json:"id"`
+	NamespaceCode string    `json:"namespaceCode"`
+	AppCode       string    `json:"appCode"`
+	RecordUID     string    `json:"recordUid"`
+	Field         string    `json:"field"`
+	FileName      string    `json:"fileName"`
+	ContentType   string    `json:"contentType"`
+	Size          int64     `json:"size"`
+	SHA256        string    `json:"sha256"`
+	StorageKey    string    `json:"-"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// Ref возвращает значение, которое хранится в поле записи
+func (a *Attachment) Ref() map[string]interface{} {
+	return map[string]interface{}{
+		"id":          a.ID,
+		"fileName":    a.FileName,
+		"contentType": a.ContentType,
+		"size":        a.Size,
+		"sha256":      a.SHA256,
+	}
+}
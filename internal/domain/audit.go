@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEntry — запись журнала аудита. Записи связаны в цепочку:
+// Hash каждой записи вычисляется от её полей и PrevHash предыдущей
+type AuditEntry struct {
+	ID            int64           `json:"id"`
+	CreatedAt     time.Time       `json:"createdAt"`
+	Actor         string          `json:"actor"`
+	Action        string          `json:"action"`
+	NamespaceCode string          `json:"namespaceCode,omitempty"`
+	AppCode       string          `json:"appCode,omitempty"`
+	UID           string          `json:"uid,omitempty"`
+	RequestID     string          `json:"requestId,omitempty"`
+	SourceIP      string          `json:"sourceIp,omitempty"`
+	Diff          json.RawMessage `json:"diff,omitempty" swaggertype:"object"` // Изменения в формате {"поле": {"old": ..., "new": ...}}
+	PrevHash      string          `json:"prevHash"`
+	Hash          string          `json:"hash"`
+}
+
+// AuditFilter — параметры выборки из журнала аудита
+type AuditFilter struct {
+	Actor         string
+	Action        string
+	NamespaceCode string
+	AppCode       string
+	UID           string
+	From          *time.Time
+	To            *time.Time
+	Limit         int
+	Offset        int
+}
+
+// AuditVerification — результат проверки целостности цепочки журнала
+type AuditVerification struct {
+	Valid    bool  `json:"valid"`
+	Checked  int64 `json:"checked"`
+	BrokenAt int64 `json:"brokenAt,omitempty"` // ID первой записи, не прошедшей проверку
+}
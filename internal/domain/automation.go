@@ -0,0 +1,132 @@
+package domain
+
+import "time"
+
+// События изменения записей, на которые реагируют правила автоматизации
+const (
+	EventCreated = "created"
+	EventUpdated = "updated"
+	EventDeleted = "deleted"
+	// EventScheduled — правило запущено заданием по расписанию для существующих записей
+	EventScheduled = "scheduled"
+)
+
+// Действия правил автоматизации
+const (
+	ActionSetField     = "set_field"     // изменить поле записи, вызвавшей событие
+	ActionCreateRecord = "create_record" // создать запись в другом приложении
+	ActionWebhook      = "webhook"       // отправить событие POST-запросом
+	ActionNotify       = "notify"        // отправить уведомление пользователям
+)
+
+// Статусы выполнения правила
+const (
+	RunSuccess = "success"
+	RunFailed  = "failed"
+	RunSkipped = "skipped" // не выполнено из-за защиты от зацикливания
+)
+
+// DataEvent — изменение записи приложения. Chain — правила, действиями
+// которых вызвано изменение, для защиты от зацикливания
+type DataEvent struct {
+	Type          string                 `json:"type"`
+	NamespaceCode string                 `json:"namespaceCode"`
+	AppCode       string                 `json:"appCode"`
+	RecordUID     string                 `json:"recordUid"`
+	Data          map[string]interface{} `json:"data,omitempty"`
+	Actor         string                 `json:"actor"`
+	RequestID     string                 `json:"requestId,omitempty"`
+	Chain         []string               `json:"chain,omitempty"`
+	Trace         map[string]string      `json:"-"` // Контекст трассировки запроса, изменившего запись
+	OccurredAt    time.Time              `json:"occurredAt"`
+}
+
+// AutomationRule — правило «когда запись создана/изменена и выполнено условие — выполнить действия».
+// Condition — логическая формула над полями записи, пустое условие выполняется всегда
+type AutomationRule struct {
+	ID            string             `json:"id"`
+	NamespaceCode string             `json:"namespaceCode"`
+	AppCode       string             `json:"appCode"`
+	Name          string             `json:"name"`
+	Events        []string           `json:"events"`
+	Condition     string             `json:"condition,omitempty"`
+	Actions       []AutomationAction `json:"actions"`
+	Enabled       bool               `json:"enabled"`
+	CreatedAt     time.Time          `json:"createdAt"`
+	UpdatedAt     time.Time          `json:"updatedAt"`
+}
+
+// AutomationAction — действие правила; набор параметров зависит от Type.
+// Значения задаются константой (Value, Values) или формулой над полями записи (Formula, Formulas)
+type AutomationAction struct {
+	Type string `json:"type"`
+
+	// set_field
+	Field   string      `json:"field,omitempty"`
+	Value   interface{} `json:"value,omitempty"`
+	Formula string      `json:"formula,omitempty"`
+
+	// create_record; пустой Namespace — namespace приложения правила
+	Namespace string                 `json:"namespace,omitempty"`
+	App       string                 `json:"app,omitempty"`
+	Values    map[string]interface{} `json:"values,omitempty"`
+	Formulas  map[string]string      `json:"formulas,omitempty"`
+
+	// webhook
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// notify; в Message подставляются значения полей записи вида {field}
+	To      []string `json:"to,omitempty"`
+	Message string   `json:"message,omitempty"`
+}
+
+// ActionResult — итог выполнения (или план при тестовом запуске) одного действия
+type ActionResult struct {
+	Type   string      `json:"type"`
+	Target string      `json:"target,omitempty"` // поле, приложение, адрес или получатели
+	Data   interface{} `json:"data,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// AutomationRun — запись журнала выполнения правила
+type AutomationRun struct {
+	ID            int64          `json:"id"`
+	RuleID        string         `json:"ruleId"`
+	NamespaceCode string         `json:"namespaceCode"`
+	AppCode       string         `json:"appCode"`
+	RecordUID     string         `json:"recordUid"`
+	Event         string         `json:"event"`
+	Status        string         `json:"status"`
+	Depth         int            `json:"depth"` // Сколько правил вызвали событие цепочкой
+	Error         string         `json:"error,omitempty"`
+	Actions       []ActionResult `json:"actions,omitempty"`
+	StartedAt     time.Time      `json:"startedAt"`
+	FinishedAt    time.Time      `json:"finishedAt"`
+}
+
+// RuleTestRequest — тестовый запуск правила на примере записи, без побочных эффектов
+type RuleTestRequest struct {
+	Event  string                 `json:"event"`
+	Record map[string]interface{} `json:"record"`
+}
+
+// RuleTestResult — результат тестового запуска: выполнилось ли условие и что было бы сделано
+type RuleTestResult struct {
+	Matched bool           `json:"matched"`
+	Actions []ActionResult `json:"actions,omitempty"`
+	Error   string         `json:"error,omitempty"`
+}
+
+// Notification — уведомление пользователю от правила автоматизации
+type Notification struct {
+	ID            int64      `json:"id"`
+	Recipient     string     `json:"recipient"`
+	Message       string     `json:"message"`
+	NamespaceCode string     `json:"namespaceCode,omitempty"`
+	AppCode       string     `json:"appCode,omitempty"`
+	RecordUID     string     `json:"recordUid,omitempty"`
+	RuleID        string     `json:"ruleId,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	ReadAt        *time.Time `json:"readAt,omitempty"`
+}
@@ -0,0 +1,62 @@
+package domain
+
+import "fmt"
+
+// Операции пакета над записями приложений
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchPatch  = "patch"
+	BatchDelete = "delete"
+)
+
+// BatchRefPrefix начинает ссылку на запись, созданную ранее в том же пакете:
+// "$ref:order" в uid или значении поля, в том числе вложенном, заменяется на uid записи с ref "order"
+const BatchRefPrefix = "$ref:"
+
+// BatchOperation — операция пакета над записью приложения
+type BatchOperation struct {
+	Op        string `json:"op"` // create, update, patch или delete
+	Namespace string `json:"namespace"`
+	App       string `json:"app"`
+	// UID — запись для update, patch и delete, uid или ссылка "$ref:имя"
+	UID string `json:"uid,omitempty"`
+	// Ref — имя записи, создаваемой операцией create, для ссылок из следующих операций
+	Ref  string                 `json:"ref,omitempty"`
+	Data map[string]interface{} `json:"data,omitempty"`
+}
+
+// BatchRequest — операции, которые выполняются в одной транзакции по порядку
+type BatchRequest struct {
+	Operations []BatchOperation `json:"operations"`
+}
+
+// BatchResult — результат операции пакета; Record — запись после create и update
+type BatchResult struct {
+	Op        string   `json:"op"`
+	Namespace string   `json:"namespace"`
+	App       string   `json:"app"`
+	UID       string   `json:"uid"`
+	Ref       string   `json:"ref,omitempty"`
+	Record    *AppData `json:"record,omitempty"`
+}
+
+// BatchResponse — результаты операций пакета в порядке запроса
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+// BatchError — операция пакета с номером Index (с нуля) не выполнена, весь пакет откачен
+type BatchError struct {
+	Index int
+	Op    string
+	Err   error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("operation %d (%s): %v", e.Index, e.Op, e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
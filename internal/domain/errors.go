@@ -0,0 +1,20 @@
+package domain
+
+import "errors"
+
+var (
+	// ErrNotFound — объект не найден или находится в корзине
+	ErrNotFound = errors.New("not found")
+	// ErrValidation — запрос не прошёл проверку, текст ошибки описывает причину
+	ErrValidation = errors.New("validation failed")
+	// ErrTooLarge — размер загружаемого файла превышает лимит приложения
+	ErrTooLarge = errors.New("file too large")
+	// ErrConflict — объект изменён параллельным запросом или нарушает ограничение
+	ErrConflict = errors.New("conflict")
+	// ErrForbidden — у субъекта нет права на действие
+	ErrForbidden = errors.New("forbidden")
+	// ErrQuotaExceeded — создание объекта превысило бы квоту namespace
+	ErrQuotaExceeded = errors.New("quota exceeded")
+	// ErrIdempotencyMismatch — ключ Idempotency-Key уже использован с другим запросом
+	ErrIdempotencyMismatch = errors.New("idempotency key reused with a different request")
+)
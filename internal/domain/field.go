@@ -0,0 +1,22 @@
+package domain
+
+// Типы полей приложения
+const (
+	FieldTypeString   = "string"
+	FieldTypeNumber   = "number"
+	FieldTypeBoolean  = "boolean"
+	FieldTypeDatetime = "datetime"
+	FieldTypeFile     = "file"
+)
+
+// Field — описание поля записей приложения
+type Field struct {
+	Code    string `json:"code"`
+	Name    string `json:"name,omitempty"`
+	Type    string `json:"type"`
+	Formula string `json:"formula,omitempty"` // Выражение вычисляемого поля, например price * qty
+	Unique  bool   `json:"unique,omitempty"`  // Значение не повторяется среди записей вне корзины
+	Indexed bool   `json:"indexed,omitempty"` // Для поля строится индекс, ускоряющий фильтры и сортировку
+	// Localized — строковое поле хранит значения по локалям: {"ru": "Стол", "en": "Table"}
+	Localized bool `json:"localized,omitempty"`
+}
@@ -0,0 +1,8 @@
+package domain
+
+// Readiness — результат проверки готовности экземпляра принимать запросы.
+// Checks содержит ok или описание проблемы для каждой проверки
+type Readiness struct {
+	Ready  bool              `json:"ready"`
+	Checks map[string]string `json:"checks"`
+}
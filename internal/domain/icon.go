@@ -0,0 +1,18 @@
+package domain
+
+import "time"
+
+// IconVariantSizes — стороны квадрата в пикселях, в который вписываются уменьшенные копии иконок
+var IconVariantSizes = []int{32, 64, 128, 256}
+
+// Icon — загруженная иконка приложения или namespace.
+// В поле icon приложения и namespace хранится ID иконки
+type Icon struct {
+	ID          string    `json:"id"`
+	ContentType string    `json:"contentType"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	Width       int       `json:"width,omitempty"`  // Для SVG не заполняется
+	Height      int       `json:"height,omitempty"` // Для SVG не заполняется
+	CreatedAt   time.Time `json:"createdAt"`
+}
@@ -0,0 +1,15 @@
+package domain
+
+// IdempotentResponse — сохранённый ответ на запрос с заголовком Idempotency-Key;
+// повтор запроса с тем же ключом получает его без повторного выполнения
+type IdempotentResponse struct {
+	Status int                 `json:"status"`
+	Header map[string][]string `json:"header,omitempty"`
+	Body   []byte              `json:"body,omitempty"`
+}
+
+// IdempotencyRecord — запрос, за которым закреплён ключ. Response пуст, пока запрос выполняется
+type IdempotencyRecord struct {
+	RequestHash string
+	Response    *IdempotentResponse
+}
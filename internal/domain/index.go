@@ -0,0 +1,44 @@
+package domain
+
+import (
+	"fmt"
+	"strings"
+)
+
+// IndexSpec — индекс таблицы записей, который сервис поддерживает по схеме приложения
+type IndexSpec struct {
+	Name   string
+	Fields []string
+	Unique bool
+	Gin    bool // GIN по значению поля вместо B-tree, для полей-списков
+}
+
+// IndexInfo — индекс таблицы записей и статистика его использования
+type IndexInfo struct {
+	Name          string `json:"name"`
+	Definition    string `json:"definition"`
+	Unique        bool   `json:"unique"`
+	Valid         bool   `json:"valid"`   // false — построение CONCURRENTLY не завершилось
+	Managed       bool   `json:"managed"` // индекс создан по схеме приложения
+	SizeBytes     int64  `json:"sizeBytes"`
+	Scans         int64  `json:"scans"`
+	TuplesRead    int64  `json:"tuplesRead"`
+	TuplesFetched int64  `json:"tuplesFetched"`
+}
+
+// DuplicateError — значения уникальных полей записи уже есть у другой записи
+type DuplicateError struct {
+	Index  string
+	Fields []string
+}
+
+func (e *DuplicateError) Error() string {
+	if len(e.Fields) == 0 {
+		return fmt.Sprintf("duplicate value violates unique index %s", e.Index)
+	}
+	return fmt.Sprintf("duplicate value of %s", strings.Join(e.Fields, ", "))
+}
+
+func (e *DuplicateError) Unwrap() error {
+	return ErrConflict
+}
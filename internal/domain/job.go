@@ -0,0 +1,70 @@
+package domain
+
+import "time"
+
+// Действия запланированных заданий
+const (
+	JobRecompute = "recompute" // пересчитать вычисляемые поля записей приложения
+	JobPurge     = "purge"     // переместить в корзину записи старше срока
+	JobExport    = "export"    // отправить выборку записей вебхуком
+	JobRunRule   = "run_rule"  // выполнить правило автоматизации для записей приложения
+)
+
+// Как было запущено задание
+const (
+	TriggerSchedule = "schedule"
+	TriggerManual   = "manual"
+)
+
+// ScheduledJob — задание namespace, выполняемое по cron-расписанию в часовом поясе Timezone
+type ScheduledJob struct {
+	ID            string     `json:"id"`
+	NamespaceCode string     `json:"namespaceCode"`
+	Name          string     `json:"name"`
+	Schedule      string     `json:"schedule"`           // cron-выражение из пяти полей или @daily, @hourly и т.п.
+	Timezone      string     `json:"timezone,omitempty"` // IANA, по умолчанию UTC
+	Action        JobAction  `json:"action"`
+	Paused        bool       `json:"paused"`
+	NextRunAt     *time.Time `json:"nextRunAt,omitempty"`
+	LastRunAt     *time.Time `json:"lastRunAt,omitempty"`
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+}
+
+// JobAction — что делает задание; набор параметров зависит от Type
+type JobAction struct {
+	Type string `json:"type"`
+	App  string `json:"app"`
+
+	// purge: записи, у которых поле Field типа datetime старше OlderThan (например "720h")
+	Field     string `json:"field,omitempty"`
+	OlderThan string `json:"olderThan,omitempty"`
+
+	// export: записи по фильтрам отправляются POST-запросом на URL
+	Filters []DataFilter      `json:"filters,omitempty"`
+	URL     string            `json:"url,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+
+	// run_rule
+	RuleID string `json:"ruleId,omitempty"`
+}
+
+// JobRun — запись истории выполнения задания
+type JobRun struct {
+	ID         int64      `json:"id"`
+	JobID      string     `json:"jobId"`
+	Trigger    string     `json:"trigger"`
+	Actor      string     `json:"actor"`
+	Status     string     `json:"status"` // success или failed
+	Processed  int64      `json:"processed"`
+	Error      string     `json:"error,omitempty"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// RecomputeRequest — запрос пересчёта вычисляемых полей записей после изменения формул приложения
+type RecomputeRequest struct {
+	NamespaceCode string
+	AppCode       string
+	RequestedAt   time.Time
+}
@@ -0,0 +1,97 @@
+package domain
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Классы маршрутов, для которых частота запросов ограничивается отдельно
+const (
+	RouteRead   = "read"   // чтение
+	RouteWrite  = "write"  // изменение данных
+	RouteSchema = "schema" // изменение namespace, приложений и их индексов
+)
+
+// Limits — ограничения частоты запросов и квоты namespace. Частота считается
+// в запросах в минуту на субъекта. У namespace 0 означает значение по умолчанию
+// из настроек сервиса, отрицательное значение снимает ограничение; в действующих
+// ограничениях 0 — ограничения нет
+type Limits struct {
+	ReadsPerMinute   int   `json:"readsPerMinute,omitempty"`
+	WritesPerMinute  int   `json:"writesPerMinute,omitempty"`
+	SchemaPerMinute  int   `json:"schemaChangesPerMinute,omitempty"`
+	MaxApps          int64 `json:"maxApps,omitempty"`
+	MaxRecordsPerApp int64 `json:"maxRecordsPerApp,omitempty"`
+}
+
+// Over возвращает ограничения l, в которых незаданные значения взяты из defaults
+func (l *Limits) Over(defaults Limits) Limits {
+	if l == nil {
+		return defaults
+	}
+	pick := func(own, def int64) int64 {
+		switch {
+		case own < 0:
+			return 0
+		case own == 0:
+			return def
+		}
+		return own
+	}
+	return Limits{
+		ReadsPerMinute:   int(pick(int64(l.ReadsPerMinute), int64(defaults.ReadsPerMinute))),
+		WritesPerMinute:  int(pick(int64(l.WritesPerMinute), int64(defaults.WritesPerMinute))),
+		SchemaPerMinute:  int(pick(int64(l.SchemaPerMinute), int64(defaults.SchemaPerMinute))),
+		MaxApps:          pick(l.MaxApps, defaults.MaxApps),
+		MaxRecordsPerApp: pick(l.MaxRecordsPerApp, defaults.MaxRecordsPerApp),
+	}
+}
+
+// PerMinute возвращает ограничение частоты для класса маршрутов
+func (l Limits) PerMinute(class string) int {
+	switch class {
+	case RouteRead:
+		return l.ReadsPerMinute
+	case RouteWrite:
+		return l.WritesPerMinute
+	case RouteSchema:
+		return l.SchemaPerMinute
+	}
+	return 0
+}
+
+// RateDecision — результат списания запроса из корзины токенов
+type RateDecision struct {
+	Allowed    bool
+	Limit      int           // ёмкость корзины, запросов за период
+	Remaining  int           // сколько запросов ещё можно сделать сразу
+	Reset      time.Duration // через сколько корзина наполнится целиком
+	RetryAfter time.Duration // через сколько появится токен, если запрос отклонён
+}
+
+// RateLimitError — запрос превысил ограничение частоты в namespace
+type RateLimitError struct {
+	Namespace string
+	Decision  RateDecision
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limit exceeded in namespace %s", e.Namespace)
+}
+
+// NewRateDecision описывает состояние корзины ёмкостью limit, наполняющейся за period,
+// в которой после списания осталось tokens токенов
+func NewRateDecision(allowed bool, tokens float64, limit int, period time.Duration) RateDecision {
+	perToken := period / time.Duration(limit)
+	decision := RateDecision{
+		Allowed:   allowed,
+		Limit:     limit,
+		Remaining: int(math.Max(0, math.Floor(tokens))),
+		Reset:     time.Duration((float64(limit) - tokens) * float64(perToken)),
+	}
+	if !allowed {
+		decision.RetryAfter = time.Duration((1 - tokens) * float64(perToken))
+	}
+	return decision
+}
@@ -0,0 +1,51 @@
+package domain
+
+import (
+	"regexp"
+	"strings"
+)
+
+var localePattern = regexp.MustCompile(`^[a-z]{2,3}(-[A-Za-z0-9]{2,8})*$`)
+
+// IsLocale сообщает, что tag — языковой тег вида ru, en или en-US
+func IsLocale(tag string) bool {
+	return localePattern.MatchString(tag)
+}
+
+// LocaleChain возвращает порядок поиска значения локализуемого поля: сначала
+// предпочтения клиента, для en-US также en, затем цепочка namespace. Повторы удаляются
+func LocaleChain(preferred, fallback []string) []string {
+	var chain []string
+	seen := make(map[string]bool)
+	add := func(tag string) {
+		if IsLocale(tag) && !seen[tag] {
+			seen[tag] = true
+			chain = append(chain, tag)
+		}
+	}
+	for _, tag := range preferred {
+		add(tag)
+		if i := strings.IndexByte(tag, '-'); i > 0 {
+			add(tag[:i])
+		}
+	}
+	for _, tag := range fallback {
+		add(tag)
+	}
+	return chain
+}
+
+// ResolveLocalized возвращает значение локализуемого поля для первой локали цепочки,
+// в которой оно задано. Значение не в виде словаря локалей возвращается как есть
+func ResolveLocalized(value interface{}, chain []string) interface{} {
+	values, ok := value.(map[string]interface{})
+	if !ok {
+		return value
+	}
+	for _, tag := range chain {
+		if v, ok := values[tag]; ok && v != nil {
+			return v
+		}
+	}
+	return nil
+}
@@ -1,6 +1,12 @@
 package domain
 
+import "time"
+
 type Namespace struct {
-	Code string `json:"code"`
-	Name string `json:"name"`
+	Code      string     `json:"code"`
+	Name      string     `json:"name"`
+	Icon      string     `json:"icon,omitempty"`      // ID иконки, меняется только загрузкой
+	Locales   []string   `json:"locales,omitempty"`   // Цепочка локалей для локализуемых полей, первая — основная
+	Limits    *Limits    `json:"limits,omitempty"`    // Свои ограничения namespace, меняются только через /limits
+	DeletedAt *time.Time `json:"deletedAt,omitempty"` // Время перемещения в корзину
 }
@@ -0,0 +1,64 @@
+package domain
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Периоды, после которых счётчик номеров начинается заново
+const (
+	NumberResetNever   = ""
+	NumberResetYearly  = "yearly"
+	NumberResetMonthly = "monthly"
+)
+
+// numberTokens находит в шаблоне подстановки {YYYY}, {YY}, {MM} и счётчик {000…}
+var numberTokens = regexp.MustCompile(`\{(YYYY|YY|MM|0+)\}`)
+
+// NumberingPolicy — человекочитаемые номера записей приложения, например INV-{YYYY}-{00000}.
+// Ширина счётчика задаётся числом нулей; номер, не помещающийся в ширину, не обрезается
+type NumberingPolicy struct {
+	Format string `json:"format"`
+	Reset  string `json:"reset,omitempty"` // yearly, monthly или пусто — сквозная нумерация
+}
+
+// Period возвращает ключ счётчика для момента t: при сбросе раз в год это год, раз в месяц — год и месяц
+func (p *NumberingPolicy) Period(t time.Time) string {
+	switch p.Reset {
+	case NumberResetYearly:
+		return t.Format("2006")
+	case NumberResetMonthly:
+		return t.Format("2006-01")
+	}
+	return ""
+}
+
+// Number подставляет в шаблон дату t и значение счётчика n
+func (p *NumberingPolicy) Number(t time.Time, n int64) string {
+	return numberTokens.ReplaceAllStringFunc(p.Format, func(token string) string {
+		switch token {
+		case "{YYYY}":
+			return t.Format("2006")
+		case "{YY}":
+			return t.Format("06")
+		case "{MM}":
+			return t.Format("01")
+		}
+		return fmt.Sprintf("%0*d", len(token)-2, n)
+	})
+}
+
+// Tokens возвращает подстановки шаблона без фигурных скобок, счётчик — как "0"
+func (p *NumberingPolicy) Tokens() []string {
+	var tokens []string
+	for _, match := range numberTokens.FindAllStringSubmatch(p.Format, -1) {
+		token := match[1]
+		if strings.HasPrefix(token, "0") {
+			token = "0"
+		}
+		tokens = append(tokens, token)
+	}
+	return tokens
+}
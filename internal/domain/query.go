@@ -0,0 +1,53 @@
+package domain
+
+// Операторы фильтрации записей
+const (
+	FilterEq       = "eq"
+	FilterNe       = "ne"
+	FilterGt       = "gt"
+	FilterGte      = "gte"
+	FilterLt       = "lt"
+	FilterLte      = "lte"
+	FilterContains = "contains"
+)
+
+// Системные поля записи. Их можно использовать в фильтрах и сортировке наравне с полями
+// данных; префикс $ не допускается в кодах полей, поэтому имена не пересекаются
+const (
+	SystemCreatedAt = "$createdAt"
+	SystemUpdatedAt = "$updatedAt"
+	SystemCreatedBy = "$createdBy"
+	SystemUpdatedBy = "$updatedBy"
+	SystemNumber    = "$number"
+)
+
+// DataFilter — условие на значение поля записи. Value приводится к типу поля из схемы приложения
+type DataFilter struct {
+	Field string
+	Op    string
+	Value interface{}
+}
+
+// DataSort — сортировка по полю записи
+type DataSort struct {
+	Field string
+	Desc  bool
+}
+
+// Версии записи в приложении с черновиками
+const (
+	StageDraft     = "draft"
+	StagePublished = "published"
+)
+
+// DataQuery — параметры выборки записей приложения; Limit = 0 означает без ограничения
+type DataQuery struct {
+	Stage string // версия записей, по умолчанию черновик
+	// Локализуемые поля фильтруются и сортируются по значению первой заданной локали из Locales
+	Localized []string
+	Locales   []string
+	Filters   []DataFilter
+	Sort      []DataSort
+	Limit     int
+	Offset    int
+}
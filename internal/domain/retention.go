@@ -0,0 +1,33 @@
+package domain
+
+import "time"
+
+// Что делать с записями, срок хранения которых истёк
+const (
+	RetentionDelete  = "delete"  // удалить окончательно, минуя корзину
+	RetentionArchive = "archive" // перенести в архив archived_records
+)
+
+// RetentionPolicy — срок хранения записей приложения. Возраст записи считается
+// по полю Field типа datetime, а если оно не задано — по времени создания.
+// Записи под удержанием (legal hold) не удаляются и не архивируются
+type RetentionPolicy struct {
+	Days  int    `json:"days"`
+	Field string `json:"field,omitempty"`
+	Mode  string `json:"mode,omitempty"` // delete или archive, по умолчанию delete
+}
+
+// RetentionMetrics — счётчики применения сроков хранения приложения с момента запуска сервиса
+type RetentionMetrics struct {
+	NamespaceCode string     `json:"namespaceCode"`
+	AppCode       string     `json:"appCode"`
+	Runs          int64      `json:"runs"`
+	Batches       int64      `json:"batches"`
+	Deleted       int64      `json:"deleted"`
+	Archived      int64      `json:"archived"`
+	Held          int64      `json:"held"`   // записей с истёкшим сроком, оставленных из-за удержания, на последнем проходе
+	Errors        int64      `json:"errors"` // проходов, завершившихся ошибкой
+	LastError     string     `json:"lastError,omitempty"`
+	LastRunAt     *time.Time `json:"lastRunAt,omitempty"`
+	LastDuration  string     `json:"lastDuration,omitempty"`
+}
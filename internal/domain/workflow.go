@@ -0,0 +1,81 @@
+package domain
+
+import "time"
+
+// Workflow — машина состояний записей приложения. Текущее состояние хранится
+// в строковом поле Field и меняется только переходами
+type Workflow struct {
+	Field       string               `json:"field"`
+	Initial     string               `json:"initial"` // Состояние новой записи
+	States      []WorkflowState      `json:"states"`
+	Transitions []WorkflowTransition `json:"transitions"`
+}
+
+// WorkflowState — состояние записи и поля, обязательные к заполнению в нём
+type WorkflowState struct {
+	Code           string   `json:"code"`
+	Name           string   `json:"name,omitempty"`
+	RequiredFields []string `json:"requiredFields,omitempty"`
+}
+
+// WorkflowTransition — разрешённый переход. Пустой From означает переход из любого
+// состояния, Guard — логическая формула над полями записи, которая должна быть истинной
+type WorkflowTransition struct {
+	Name  string   `json:"name,omitempty"`
+	From  []string `json:"from,omitempty"`
+	To    string   `json:"to"`
+	Guard string   `json:"guard,omitempty"`
+}
+
+// State возвращает описание состояния или nil
+func (w *Workflow) State(code string) *WorkflowState {
+	for i := range w.States {
+		if w.States[i].Code == code {
+			return &w.States[i]
+		}
+	}
+	return nil
+}
+
+// Allowed возвращает переходы, по которым запись может перейти из from в to
+func (w *Workflow) Allowed(from, to string) []WorkflowTransition {
+	var allowed []WorkflowTransition
+	for _, t := range w.Transitions {
+		if t.To != to {
+			continue
+		}
+		if len(t.From) == 0 {
+			allowed = append(allowed, t)
+			continue
+		}
+		for _, state := range t.From {
+			if state == from {
+				allowed = append(allowed, t)
+				break
+			}
+		}
+	}
+	return allowed
+}
+
+// TransitionRequest — запрос на смену состояния записи. Data — поля, которые
+// нужно заполнить вместе с переходом, например обязательные для нового состояния
+type TransitionRequest struct {
+	To      string                 `json:"to"`
+	Comment string                 `json:"comment,omitempty"`
+	Data    map[string]interface{} `json:"data,omitempty"`
+}
+
+// TransitionRecord — выполненный переход записи из одного состояния в другое
+type TransitionRecord struct {
+	ID            int64     `json:"id"`
+	NamespaceCode string    `json:"namespaceCode"`
+	AppCode       string    `json:"appCode"`
+	RecordUID     string    `json:"recordUid"`
+	From          string    `json:"from"`
+	To            string    `json:"to"`
+	Actor         string    `json:"actor"`
+	Comment       string    `json:"comment,omitempty"`
+	RequestID     string    `json:"requestId,omitempty"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
@@ -0,0 +1,109 @@
+package formula
+
+import "fmt"
+
+// check выводит тип выражения; env — типы полей, на которые можно ссылаться
+func check(n node, env map[string]Type) (Type, error) {
+	switch n := n.(type) {
+	case *literal:
+		return n.typ, nil
+	case *fieldRef:
+		t, ok := env[n.name]
+		if !ok {
+			return Null, fmt.Errorf("unknown field %q", n.name)
+		}
+		return t, nil
+	case *unaryExpr:
+		x, err := check(n.x, env)
+		if err != nil {
+			return Null, err
+		}
+		want := Number
+		if n.op == "!" {
+			want = Boolean
+		}
+		if x != want && x != Null {
+			return Null, fmt.Errorf("operator %s expects %s, got %s", n.op, want, x)
+		}
+		return want, nil
+	case *binaryExpr:
+		x, err := check(n.x, env)
+		if err != nil {
+			return Null, err
+		}
+		y, err := check(n.y, env)
+		if err != nil {
+			return Null, err
+		}
+		return checkBinary(n.op, x, y)
+	case *callExpr:
+		fn, ok := functions[n.name]
+		if !ok {
+			return Null, fmt.Errorf("unknown function %q", n.name)
+		}
+		args := make([]Type, len(n.args))
+		for i, arg := range n.args {
+			t, err := check(arg, env)
+			if err != nil {
+				return Null, err
+			}
+			args[i] = t
+		}
+		t, err := fn.check(args)
+		if err != nil {
+			return Null, fmt.Errorf("%s: %w", n.name, err)
+		}
+		return t, nil
+	default:
+		return Null, fmt.Errorf("unsupported expression")
+	}
+}
+
+func checkBinary(op string, x, y Type) (Type, error) {
+	mismatch := fmt.Errorf("operator %s is not defined for %s and %s", op, x, y)
+	switch op {
+	case "&&", "||":
+		if (x == Boolean || x == Null) && (y == Boolean || y == Null) {
+			return Boolean, nil
+		}
+		return Null, mismatch
+	case "==", "!=":
+		if _, ok := unify(x, y); !ok {
+			return Null, mismatch
+		}
+		return Boolean, nil
+	case "<", "<=", ">", ">=":
+		t, ok := unify(x, y)
+		if !ok || t == Boolean {
+			return Null, mismatch
+		}
+		return Boolean, nil
+	case "+":
+		switch {
+		case x == Datetime && (y == Number || y == Null), y == Datetime && (x == Number || x == Null):
+			return Datetime, nil
+		}
+		t, ok := unify(x, y)
+		if !ok || t == Boolean || t == Datetime {
+			return Null, mismatch
+		}
+		return t, nil
+	case "-":
+		switch {
+		case x == Datetime && y == Datetime:
+			return Number, nil
+		case x == Datetime && (y == Number || y == Null):
+			return Datetime, nil
+		}
+		if (x == Number || x == Null) && (y == Number || y == Null) {
+			return Number, nil
+		}
+		return Null, mismatch
+	case "*", "/", "%":
+		if (x == Number || x == Null) && (y == Number || y == Null) {
+			return Number, nil
+		}
+		return Null, mismatch
+	}
+	return Null, fmt.Errorf("unknown operator %s", op)
+}
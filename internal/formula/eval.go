@@ -0,0 +1,209 @@
+package formula
+
+import (
+	"math"
+	"strconv"
+	"time"
+)
+
+// dateLayouts — форматы, в которых принимаются значения полей типа datetime
+var dateLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"}
+
+type evaluator struct {
+	values map[string]interface{}
+	types  map[string]Type
+	now    time.Time
+}
+
+func (e *evaluator) eval(n node) interface{} {
+	switch n := n.(type) {
+	case *literal:
+		return n.value
+	case *fieldRef:
+		return coerce(e.values[n.name], e.types[n.name])
+	case *unaryExpr:
+		x := e.eval(n.x)
+		switch x := x.(type) {
+		case float64:
+			return -x
+		case bool:
+			return !x
+		}
+		return nil
+	case *binaryExpr:
+		x, y := e.eval(n.x), e.eval(n.y)
+		if x == nil || y == nil {
+			return nil
+		}
+		return evalBinary(n.op, x, y)
+	case *callExpr:
+		args := make([]interface{}, len(n.args))
+		for i, arg := range n.args {
+			args[i] = e.eval(arg)
+		}
+		return functions[n.name].eval(args, e.now)
+	}
+	return nil
+}
+
+func evalBinary(op string, x, y interface{}) interface{} {
+	switch x := x.(type) {
+	case bool:
+		y, _ := y.(bool)
+		switch op {
+		case "&&":
+			return x && y
+		case "||":
+			return x || y
+		case "==":
+			return x == y
+		case "!=":
+			return x != y
+		}
+	case string:
+		y, _ := y.(string)
+		switch op {
+		case "+":
+			return x + y
+		case "==":
+			return x == y
+		case "!=":
+			return x != y
+		case "<":
+			return x < y
+		case "<=":
+			return x <= y
+		case ">":
+			return x > y
+		case ">=":
+			return x >= y
+		}
+	case float64:
+		if t, ok := y.(time.Time); ok && op == "+" {
+			return t.Add(time.Duration(x * float64(day)))
+		}
+		y, _ := y.(float64)
+		switch op {
+		case "+":
+			return x + y
+		case "-":
+			return x - y
+		case "*":
+			return x * y
+		case "/":
+			if y == 0 {
+				return nil
+			}
+			return x / y
+		case "%":
+			if y == 0 {
+				return nil
+			}
+			return math.Mod(x, y)
+		case "==":
+			return x == y
+		case "!=":
+			return x != y
+		case "<":
+			return x < y
+		case "<=":
+			return x <= y
+		case ">":
+			return x > y
+		case ">=":
+			return x >= y
+		}
+	case time.Time:
+		if n, ok := y.(float64); ok {
+			switch op {
+			case "+":
+				return x.Add(time.Duration(n * float64(day)))
+			case "-":
+				return x.Add(-time.Duration(n * float64(day)))
+			}
+			return nil
+		}
+		y, _ := y.(time.Time)
+		switch op {
+		case "-":
+			return x.Sub(y).Hours() / 24
+		case "==":
+			return x.Equal(y)
+		case "!=":
+			return !x.Equal(y)
+		case "<":
+			return x.Before(y)
+		case "<=":
+			return !x.After(y)
+		case ">":
+			return x.After(y)
+		case ">=":
+			return !x.Before(y)
+		}
+	}
+	return nil
+}
+
+// coerce приводит значение поля записи к объявленному типу. Значения,
+// не соответствующие типу поля, считаются пустыми
+func coerce(value interface{}, t Type) interface{} {
+	switch t {
+	case Number:
+		switch v := value.(type) {
+		case float64:
+			return v
+		case int:
+			return float64(v)
+		case int64:
+			return float64(v)
+		}
+	case String:
+		if v, ok := value.(string); ok {
+			return v
+		}
+	case Boolean:
+		if v, ok := value.(bool); ok {
+			return v
+		}
+	case Datetime:
+		switch v := value.(type) {
+		case time.Time:
+			return v
+		case string:
+			for _, layout := range dateLayouts {
+				if parsed, err := time.Parse(layout, v); err == nil {
+					return parsed
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// output переводит результат в значение, которое сохраняется в JSON записи
+func output(value interface{}) interface{} {
+	switch v := value.(type) {
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return nil
+		}
+		return v
+	case time.Time:
+		return v.UTC().Format(time.RFC3339)
+	}
+	return value
+}
+
+func toString(value interface{}) string {
+	switch v := output(value).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	}
+	return ""
+}
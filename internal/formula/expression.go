@@ -0,0 +1,62 @@
+package formula
+
+import (
+	"fmt"
+	"time"
+)
+
+// Expression — скомпилированное выражение над полями записи, не привязанное к полю приложения
+type Expression struct {
+	expr  node
+	typ   Type
+	types map[string]Type
+}
+
+// CompileExpression разбирает выражение и выводит тип его результата
+func CompileExpression(source string, fields map[string]Type) (*Expression, error) {
+	expr, err := parse(source)
+	if err != nil {
+		return nil, err
+	}
+	t, err := check(expr, fields)
+	if err != nil {
+		return nil, err
+	}
+	return &Expression{expr: expr, typ: t, types: fields}, nil
+}
+
+// Type возвращает тип результата выражения
+func (x *Expression) Type() Type {
+	return x.typ
+}
+
+// Eval вычисляет выражение и возвращает значение в том виде, в каком оно хранится в записи
+func (x *Expression) Eval(values map[string]interface{}, now time.Time) interface{} {
+	e := &evaluator{values: values, types: x.types, now: now}
+	return output(e.eval(x.expr))
+}
+
+// Condition — скомпилированное логическое выражение над полями записи
+type Condition struct {
+	expr  node
+	types map[string]Type
+}
+
+// CompileCondition разбирает выражение и проверяет, что оно возвращает boolean
+func CompileCondition(source string, fields map[string]Type) (*Condition, error) {
+	x, err := CompileExpression(source, fields)
+	if err != nil {
+		return nil, err
+	}
+	if x.typ != Boolean && x.typ != Null {
+		return nil, fmt.Errorf("condition returns %s, expected boolean", x.typ)
+	}
+	return &Condition{expr: x.expr, types: fields}, nil
+}
+
+// Eval вычисляет условие; неопределённый результат (null) считается ложным
+func (c *Condition) Eval(values map[string]interface{}, now time.Time) bool {
+	e := &evaluator{values: values, types: c.types, now: now}
+	result, _ := e.eval(c.expr).(bool)
+	return result
+}
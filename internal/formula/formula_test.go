@@ -0,0 +1,170 @@
+package formula
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+var testFields = map[string]Type{
+	"price":  Number,
+	"qty":    Number,
+	"name":   String,
+	"active": Boolean,
+	"due":    Datetime,
+}
+
+var testNow = time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func TestExpressionEval(t *testing.T) {
+	values := map[string]interface{}{
+		"price":  10.0,
+		"qty":    3,
+		"name":   "box",
+		"active": true,
+		"due":    "2026-03-15",
+	}
+	tests := []struct {
+		name   string
+		source string
+		want   interface{}
+	}{
+		{"multiplication before addition", "1 + 2 * 3", 7.0},
+		{"parentheses", "(1 + 2) * 3", 9.0},
+		{"left associative subtraction", "10 - 4 - 3", 3.0},
+		{"left associative division", "24 / 4 / 2", 3.0},
+		{"modulo with multiplication", "2 * 7 % 4", 2.0},
+		{"unary minus binds tighter", "-2 * 3 + 10", 4.0},
+		{"comparison after arithmetic", "price * qty > 25", true},
+		{"and before or", "true || false && false", true},
+		{"not before and", "!active && true", false},
+		{"comparisons are left associative", "1 < 2 == true", true},
+		{"fields", "price * qty", 30.0},
+		{"string concatenation", `name + "-" + upper(name)`, "box-BOX"},
+		{"division by zero is null", "price / 0", nil},
+		{"null propagates", "price + null", nil},
+		{"coalesce skips null", "coalesce(null, price)", 10.0},
+		{"if", `if(qty > 2, "many", "few")`, "many"},
+		{"concat converts values", `concat(name, qty, active)`, "box3true"},
+		{"datetime difference in days", "due - now()", 4.5},
+		{"add days", "add_days(due, 1)", "2026-03-16T00:00:00Z"},
+		{"round", "round(10 / 3, 2)", 3.33},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			x, err := CompileExpression(tt.source, testFields)
+			if err != nil {
+				t.Fatalf("CompileExpression(%q): %v", tt.source, err)
+			}
+			if got := x.Eval(values, testNow); got != tt.want {
+				t.Errorf("%s = %#v, want %#v", tt.source, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpressionErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{"1 +", "unexpected end of expression"},
+		{"(1 + 2", "expected )"},
+		{"1 2", `unexpected "2"`},
+		{`"open`, "unterminated string"},
+		{"price # 2", "unexpected character"},
+		{"missing + 1", `unknown field "missing"`},
+		{"nope(1)", `unknown function "nope"`},
+		{"price + name", "operator + is not defined for number and string"},
+		{"active + 1", "operator + is not defined for boolean and number"},
+		{"-name", "operator - expects number, got string"},
+		{"!price", "operator ! expects boolean, got number"},
+		{"price && active", "operator && is not defined"},
+		{"name < active", "operator < is not defined"},
+		{"len(price)", "len: argument 1 must be string, got number"},
+		{"round(price)", "round: expects 2 arguments, got 1"},
+		{`if(price, 1, 2)`, "if: condition must be boolean"},
+		{`if(active, 1, "a")`, "if: branches have different types"},
+	}
+	for _, tt := range tests {
+		_, err := CompileExpression(tt.source, testFields)
+		if err == nil {
+			t.Errorf("CompileExpression(%q) succeeded, want error %q", tt.source, tt.want)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("CompileExpression(%q) error = %q, want %q", tt.source, err, tt.want)
+		}
+	}
+}
+
+func TestCompileProgram(t *testing.T) {
+	// total ссылается на subtotal, объявленный позже: порядок определяется зависимостями
+	program, err := Compile([]Definition{
+		{Field: "total", Type: Number, Source: "subtotal * 1.2"},
+		{Field: "subtotal", Type: Number, Source: "price * qty"},
+		{Field: "label", Type: String, Source: `concat(name, ": ", total)`},
+	}, testFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := map[string]interface{}{"price": 5.0, "qty": 2.0, "name": "box"}
+	program.Apply(values, testNow)
+	if values["subtotal"] != 10.0 || values["total"] != 12.0 || values["label"] != "box: 12" {
+		t.Errorf("Apply = %v", values)
+	}
+}
+
+func TestCompileProgramErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		defs []Definition
+		want string
+	}{
+		{
+			"circular reference",
+			[]Definition{{Field: "a", Type: Number, Source: "b + 1"}, {Field: "b", Type: Number, Source: "a + 1"}},
+			"circular reference: a -> b -> a",
+		},
+		{
+			"self reference",
+			[]Definition{{Field: "a", Type: Number, Source: "a + 1"}},
+			"circular reference: a -> a",
+		},
+		{
+			"result type differs from field type",
+			[]Definition{{Field: "a", Type: String, Source: "price * 2"}},
+			"field a: formula returns number, field type is string",
+		},
+		{
+			"parse error names the field",
+			[]Definition{{Field: "a", Type: Number, Source: "price *"}},
+			"field a: unexpected end of expression",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Compile(tt.defs, testFields)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Compile error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCondition(t *testing.T) {
+	if _, err := CompileCondition("price + 1", testFields); err == nil {
+		t.Error("numeric condition compiled")
+	}
+	c, err := CompileCondition("price > 5 && active", testFields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Eval(map[string]interface{}{"price": 6.0, "active": true}, testNow) {
+		t.Error("condition is false for matching record")
+	}
+	// неопределённое значение считается ложным
+	if c.Eval(map[string]interface{}{"active": true}, testNow) {
+		t.Error("condition is true for record without price")
+	}
+}
@@ -0,0 +1,149 @@
+package formula
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const day = 24 * time.Hour
+
+type function struct {
+	check func(args []Type) (Type, error)
+	eval  func(args []interface{}, now time.Time) interface{}
+}
+
+// functions — функции, доступные в формулах. Если хотя бы один аргумент
+// равен null, результат тоже null, кроме if, coalesce и concat
+var functions = map[string]function{
+	"if": {
+		check: func(args []Type) (Type, error) {
+			if len(args) != 3 {
+				return Null, fmt.Errorf("expects 3 arguments")
+			}
+			if args[0] != Boolean && args[0] != Null {
+				return Null, fmt.Errorf("condition must be boolean, got %s", args[0])
+			}
+			t, ok := unify(args[1], args[2])
+			if !ok {
+				return Null, fmt.Errorf("branches have different types %s and %s", args[1], args[2])
+			}
+			return t, nil
+		},
+		eval: func(args []interface{}, _ time.Time) interface{} {
+			if cond, ok := args[0].(bool); ok && cond {
+				return args[1]
+			}
+			return args[2]
+		},
+	},
+	"coalesce": {
+		check: func(args []Type) (Type, error) {
+			if len(args) == 0 {
+				return Null, fmt.Errorf("expects at least 1 argument")
+			}
+			t := args[0]
+			for _, arg := range args[1:] {
+				var ok bool
+				if t, ok = unify(t, arg); !ok {
+					return Null, fmt.Errorf("arguments have different types")
+				}
+			}
+			return t, nil
+		},
+		eval: func(args []interface{}, _ time.Time) interface{} {
+			for _, arg := range args {
+				if arg != nil {
+					return arg
+				}
+			}
+			return nil
+		},
+	},
+	"concat": {
+		check: func(args []Type) (Type, error) { return String, nil },
+		eval: func(args []interface{}, _ time.Time) interface{} {
+			var sb strings.Builder
+			for _, arg := range args {
+				sb.WriteString(toString(arg))
+			}
+			return sb.String()
+		},
+	},
+	"len": strict(Number, []Type{String}, func(args []interface{}, _ time.Time) interface{} {
+		return float64(utf8.RuneCountInString(args[0].(string)))
+	}),
+	"upper": strict(String, []Type{String}, func(args []interface{}, _ time.Time) interface{} {
+		return strings.ToUpper(args[0].(string))
+	}),
+	"lower": strict(String, []Type{String}, func(args []interface{}, _ time.Time) interface{} {
+		return strings.ToLower(args[0].(string))
+	}),
+	"trim": strict(String, []Type{String}, func(args []interface{}, _ time.Time) interface{} {
+		return strings.TrimSpace(args[0].(string))
+	}),
+	"abs": strict(Number, []Type{Number}, func(args []interface{}, _ time.Time) interface{} {
+		return math.Abs(args[0].(float64))
+	}),
+	"floor": strict(Number, []Type{Number}, func(args []interface{}, _ time.Time) interface{} {
+		return math.Floor(args[0].(float64))
+	}),
+	"ceil": strict(Number, []Type{Number}, func(args []interface{}, _ time.Time) interface{} {
+		return math.Ceil(args[0].(float64))
+	}),
+	"round": strict(Number, []Type{Number, Number}, func(args []interface{}, _ time.Time) interface{} {
+		scale := math.Pow(10, math.Trunc(args[1].(float64)))
+		return math.Round(args[0].(float64)*scale) / scale
+	}),
+	"min": strict(Number, []Type{Number, Number}, func(args []interface{}, _ time.Time) interface{} {
+		return math.Min(args[0].(float64), args[1].(float64))
+	}),
+	"max": strict(Number, []Type{Number, Number}, func(args []interface{}, _ time.Time) interface{} {
+		return math.Max(args[0].(float64), args[1].(float64))
+	}),
+	"now": strict(Datetime, nil, func(_ []interface{}, now time.Time) interface{} {
+		return now
+	}),
+	"days_between": strict(Number, []Type{Datetime, Datetime}, func(args []interface{}, _ time.Time) interface{} {
+		return args[1].(time.Time).Sub(args[0].(time.Time)).Hours() / 24
+	}),
+	"add_days": strict(Datetime, []Type{Datetime, Number}, func(args []interface{}, _ time.Time) interface{} {
+		return args[0].(time.Time).Add(time.Duration(args[1].(float64) * float64(day)))
+	}),
+	"year": strict(Number, []Type{Datetime}, func(args []interface{}, _ time.Time) interface{} {
+		return float64(args[0].(time.Time).Year())
+	}),
+	"month": strict(Number, []Type{Datetime}, func(args []interface{}, _ time.Time) interface{} {
+		return float64(args[0].(time.Time).Month())
+	}),
+	"day": strict(Number, []Type{Datetime}, func(args []interface{}, _ time.Time) interface{} {
+		return float64(args[0].(time.Time).Day())
+	}),
+}
+
+// strict описывает функцию с фиксированными типами аргументов, возвращающую null на null-аргументах
+func strict(result Type, params []Type, eval func(args []interface{}, now time.Time) interface{}) function {
+	return function{
+		check: func(args []Type) (Type, error) {
+			if len(args) != len(params) {
+				return Null, fmt.Errorf("expects %d arguments, got %d", len(params), len(args))
+			}
+			for i, arg := range args {
+				if arg != params[i] && arg != Null {
+					return Null, fmt.Errorf("argument %d must be %s, got %s", i+1, params[i], arg)
+				}
+			}
+			return result, nil
+		},
+		eval: func(args []interface{}, now time.Time) interface{} {
+			for _, arg := range args {
+				if arg == nil {
+					return nil
+				}
+			}
+			return eval(args, now)
+		},
+	}
+}
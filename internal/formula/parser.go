@@ -0,0 +1,166 @@
+package formula
+
+import (
+	"fmt"
+	"strconv"
+)
+
+type node interface{}
+
+type literal struct {
+	value interface{}
+	typ   Type
+}
+
+type fieldRef struct {
+	name string
+}
+
+type unaryExpr struct {
+	op string
+	x  node
+}
+
+type binaryExpr struct {
+	op   string
+	x, y node
+}
+
+type callExpr struct {
+	name string
+	args []node
+}
+
+// precedence — приоритет бинарных операторов, больше — связывает сильнее
+var precedence = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3, "<": 3, "<=": 3, ">": 3, ">=": 3,
+	"+": 4, "-": 4,
+	"*": 5, "/": 5, "%": 5,
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func parse(src string) (node, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+	n, err := p.expr(1)
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+	}
+	return n, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// expr разбирает бинарные выражения с приоритетом не ниже minPrec
+func (p *parser) expr(minPrec int) (node, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		t := p.peek()
+		prec, ok := precedence[t.text]
+		if t.kind != tokOp || !ok || prec < minPrec {
+			return left, nil
+		}
+		p.next()
+		right, err := p.expr(prec + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &binaryExpr{op: t.text, x: left, y: right}
+	}
+}
+
+func (p *parser) unary() (node, error) {
+	if t := p.peek(); t.kind == tokOp && (t.text == "-" || t.text == "!") {
+		p.next()
+		x, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unaryExpr{op: t.text, x: x}, nil
+	}
+	return p.primary()
+}
+
+func (p *parser) primary() (node, error) {
+	t := p.next()
+	switch t.kind {
+	case tokNumber:
+		value, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at %d", t.text, t.pos)
+		}
+		return &literal{value: value, typ: Number}, nil
+	case tokString:
+		return &literal{value: t.text, typ: String}, nil
+	case tokLParen:
+		n, err := p.expr(1)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokRParen {
+			return nil, fmt.Errorf("expected ) at %d", closing.pos)
+		}
+		return n, nil
+	case tokIdent:
+		switch t.text {
+		case "true":
+			return &literal{value: true, typ: Boolean}, nil
+		case "false":
+			return &literal{value: false, typ: Boolean}, nil
+		case "null":
+			return &literal{value: nil, typ: Null}, nil
+		}
+		if p.peek().kind != tokLParen {
+			return &fieldRef{name: t.text}, nil
+		}
+		p.next()
+		call := &callExpr{name: t.text}
+		if p.peek().kind == tokRParen {
+			p.next()
+			return call, nil
+		}
+		for {
+			arg, err := p.expr(1)
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			sep := p.next()
+			if sep.kind == tokRParen {
+				return call, nil
+			}
+			if sep.kind != tokComma {
+				return nil, fmt.Errorf("expected , or ) at %d", sep.pos)
+			}
+		}
+	case tokEOF:
+		return nil, fmt.Errorf("unexpected end of expression")
+	default:
+		return nil, fmt.Errorf("unexpected %q at %d", t.text, t.pos)
+	}
+}
@@ -0,0 +1,114 @@
+package formula
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Definition — вычисляемое поле: код, объявленный тип результата и формула
+type Definition struct {
+	Field  string
+	Type   Type
+	Source string
+}
+
+// Program — набор скомпилированных формул приложения в порядке зависимостей
+type Program struct {
+	steps []step
+	types map[string]Type
+}
+
+type step struct {
+	field string
+	expr  node
+}
+
+// Compile разбирает формулы и проверяет типы. fields — типы обычных полей
+// приложения; формулы могут ссылаться и на другие вычисляемые поля, но без циклов
+func Compile(defs []Definition, fields map[string]Type) (*Program, error) {
+	types := make(map[string]Type, len(fields)+len(defs))
+	for name, t := range fields {
+		types[name] = t
+	}
+	for _, def := range defs {
+		types[def.Field] = def.Type
+	}
+
+	exprs := make(map[string]node, len(defs))
+	deps := make(map[string][]string, len(defs))
+	for _, def := range defs {
+		expr, err := parse(def.Source)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", def.Field, err)
+		}
+		t, err := check(expr, types)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", def.Field, err)
+		}
+		if result, ok := unify(t, def.Type); !ok || result != def.Type {
+			return nil, fmt.Errorf("field %s: formula returns %s, field type is %s", def.Field, t, def.Type)
+		}
+		exprs[def.Field] = expr
+		for _, ref := range refs(expr) {
+			if _, plain := fields[ref]; !plain {
+				deps[def.Field] = append(deps[def.Field], ref)
+			}
+		}
+	}
+
+	// топологическая сортировка: поле вычисляется после полей, на которые ссылается
+	p := &Program{types: types}
+	state := make(map[string]int, len(defs)) // 1 — в обработке, 2 — готово
+	var visit func(field string, path []string) error
+	visit = func(field string, path []string) error {
+		switch state[field] {
+		case 1:
+			return fmt.Errorf("circular reference: %s", strings.Join(append(path, field), " -> "))
+		case 2:
+			return nil
+		}
+		state[field] = 1
+		for _, dep := range deps[field] {
+			if err := visit(dep, append(path, field)); err != nil {
+				return err
+			}
+		}
+		state[field] = 2
+		p.steps = append(p.steps, step{field: field, expr: exprs[field]})
+		return nil
+	}
+	for _, def := range defs {
+		if err := visit(def.Field, nil); err != nil {
+			return nil, err
+		}
+	}
+	return p, nil
+}
+
+// Apply вычисляет формулы по значениям записи и записывает результаты в values
+func (p *Program) Apply(values map[string]interface{}, now time.Time) {
+	e := &evaluator{values: values, types: p.types, now: now}
+	for _, s := range p.steps {
+		values[s.field] = output(e.eval(s.expr))
+	}
+}
+
+// refs возвращает поля, на которые ссылается выражение
+func refs(n node) []string {
+	switch n := n.(type) {
+	case *fieldRef:
+		return []string{n.name}
+	case *unaryExpr:
+		return refs(n.x)
+	case *binaryExpr:
+		return append(refs(n.x), refs(n.y)...)
+	case *callExpr:
+		var out []string
+		for _, arg := range n.args {
+			out = append(out, refs(arg)...)
+		}
+		return out
+	}
+	return nil
+}
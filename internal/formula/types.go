@@ -0,0 +1,44 @@
+// Package formula разбирает, проверяет типы и вычисляет выражения
+// вычисляемых полей записей: арифметику, склейку строк, разность дат и условия
+package formula
+
+// Type — тип значения выражения
+type Type int
+
+const (
+	// Null — тип литерала null, совместим с любым другим типом
+	Null Type = iota
+	Number
+	String
+	Boolean
+	Datetime
+)
+
+func (t Type) String() string {
+	switch t {
+	case Number:
+		return "number"
+	case String:
+		return "string"
+	case Boolean:
+		return "boolean"
+	case Datetime:
+		return "datetime"
+	default:
+		return "null"
+	}
+}
+
+// unify возвращает общий тип двух веток выражения; null приводится к типу другой ветки
+func unify(a, b Type) (Type, bool) {
+	switch {
+	case a == b:
+		return a, true
+	case a == Null:
+		return b, true
+	case b == Null:
+		return a, true
+	default:
+		return Null, false
+	}
+}
@@ -0,0 +1,75 @@
+// Package logging настраивает структурированный журнал log/slog. Записи, сделанные
+// с контекстом (slog.InfoContext и т.п.), автоматически получают идентификатор
+// запроса, субъекта, трассу и поля объекта, с которым идёт работа
+package logging
+
+import (
+	"app/backendv1/internal/reqctx"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Setup делает журналом по умолчанию slog с форматом json или text и уровнем
+// debug, info, warn или error. Стандартный log пишет в тот же журнал
+func Setup(w io.Writer, format, level string) error {
+	var lvl slog.Level
+	if err := lvl.UnmarshalText([]byte(level)); err != nil {
+		return fmt.Errorf("invalid log level %q", level)
+	}
+	opts := &slog.HandlerOptions{Level: lvl}
+
+	var handler slog.Handler
+	switch strings.ToLower(format) {
+	case "json":
+		handler = slog.NewJSONHandler(w, opts)
+	case "text", "":
+		handler = slog.NewTextHandler(w, opts)
+	default:
+		return fmt.Errorf("invalid log format %q, expected json or text", format)
+	}
+	slog.SetDefault(slog.New(&contextHandler{Handler: handler}))
+	return nil
+}
+
+type fieldsKey struct{}
+
+// With добавляет в контекст поля, попадающие во все записи журнала с этим контекстом,
+// например With(ctx, "namespace", ns, "app", app)
+func With(ctx context.Context, args ...any) context.Context {
+	fields, _ := ctx.Value(fieldsKey{}).([]any)
+	merged := make([]any, 0, len(fields)+len(args))
+	merged = append(merged, fields...)
+	merged = append(merged, args...)
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// contextHandler дополняет записи полями из контекста
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if meta := reqctx.FromContext(ctx); meta.RequestID != "" {
+		r.AddAttrs(slog.String("request_id", meta.RequestID), slog.String("actor", meta.Actor))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.HasTraceID() {
+		r.AddAttrs(slog.String("trace_id", span.TraceID().String()))
+	}
+	if fields, ok := ctx.Value(fieldsKey{}).([]any); ok {
+		r.Add(fields...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name)}
+}
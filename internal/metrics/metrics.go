@@ -0,0 +1,120 @@
+// Package metrics содержит метрики Prometheus сервиса. Метрики регистрируются
+// в реестре по умолчанию и отдаются обработчиком Handler на /metrics
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "backend"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "Количество HTTP-запросов по шаблону маршрута, методу и коду ответа.",
+	}, []string{"route", "method", "code"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Время обработки HTTP-запросов по шаблону маршрута и методу.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	ddlOperations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "ddl_operations_total",
+		Help:      "Количество DDL-операций над схемами, таблицами и индексами приложений.",
+	}, []string{"operation", "result"})
+
+	recordWrites = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "record_writes_total",
+		Help:      "Количество изменений записей по namespace, приложению и действию.",
+	}, []string{"namespace", "app", "action"})
+
+	rateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limited_requests_total",
+		Help:      "Количество запросов, отклонённых ограничением частоты, по namespace и классу маршрута.",
+	}, []string{"namespace", "class"})
+
+	cacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_lookups_total",
+		Help:      "Количество обращений к кэшу чтений по виду данных и результату: hit или miss.",
+	}, []string{"cache", "result"})
+
+	automationDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "automation_events_dropped_total",
+		Help:      "Количество событий автоматизации, отброшенных из-за переполнения очереди, по namespace и типу события.",
+	}, []string{"namespace", "event"})
+
+	idempotentReplays = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "idempotent_replays_total",
+		Help:      "Количество повторов запросов с Idempotency-Key, получивших сохранённый ответ.",
+	}, []string{"route", "method"})
+)
+
+// Handler отдаёт метрики в формате Prometheus
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// MustRegister регистрирует дополнительные коллекторы, например статистику пула соединений
+func MustRegister(collectors ...prometheus.Collector) {
+	prometheus.MustRegister(collectors...)
+}
+
+// ObserveRequest учитывает обработанный HTTP-запрос
+func ObserveRequest(route, method string, code int, seconds float64) {
+	httpRequests.WithLabelValues(route, method, strconv.Itoa(code)).Inc()
+	httpDuration.WithLabelValues(route, method).Observe(seconds)
+}
+
+// DDL учитывает DDL-операцию, например create_table; err — её результат
+func DDL(operation string, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	ddlOperations.WithLabelValues(operation, result).Inc()
+}
+
+// RecordWrite учитывает изменение записей приложения; rate считается функцией rate() в Prometheus
+func RecordWrite(namespace, app, action string) {
+	recordWrites.WithLabelValues(namespace, app, action).Inc()
+}
+
+// RateLimited учитывает запрос, отклонённый ограничением частоты; namespace пустой, если
+// такого namespace нет, чтобы число рядов не зависело от путей, которые присылают клиенты
+func RateLimited(namespace, class string) {
+	rateLimited.WithLabelValues(namespace, class).Inc()
+}
+
+// CacheLookup учитывает обращение к кэшу чтений
+func CacheLookup(cache string, hit bool) {
+	result := "miss"
+	if hit {
+		result = "hit"
+	}
+	cacheLookups.WithLabelValues(cache, result).Inc()
+}
+
+// AutomationEventDropped учитывает событие, не попавшее в переполненную очередь автоматизации
+func AutomationEventDropped(namespace, event string) {
+	automationDropped.WithLabelValues(namespace, event).Inc()
+}
+
+// IdempotentReplay учитывает повтор запроса, на который отдан сохранённый ответ
+func IdempotentReplay(route, method string) {
+	idempotentReplays.WithLabelValues(route, method).Inc()
+}
@@ -0,0 +1,63 @@
+// Package memory содержит хранилища в памяти процесса
+package memory
+
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// rateLimitRepo хранит корзины токенов в памяти: ограничения частоты действуют
+// на каждый экземпляр сервиса отдельно
+type rateLimitRepo struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func NewRateLimitRepo() *rateLimitRepo {
+	return &rateLimitRepo{buckets: make(map[string]*bucket)}
+}
+
+// Take пополняет корзину key за прошедшее время и списывает из неё токен, если он есть
+func (r *rateLimitRepo) Take(ctx context.Context, key string, limit int, period time.Duration) (domain.RateDecision, error) {
+	now := time.Now()
+	capacity := float64(limit)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	b, ok := r.buckets[key]
+	if !ok {
+		b = &bucket{tokens: capacity, updated: now}
+		r.buckets[key] = b
+	}
+	b.tokens = math.Min(capacity, b.tokens+now.Sub(b.updated).Seconds()*capacity/period.Seconds())
+	b.updated = now
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return domain.NewRateDecision(allowed, b.tokens, limit, period), nil
+}
+
+// Purge удаляет корзины, не менявшиеся дольше idle: они уже наполнились и ничем не отличаются от новых
+func (r *rateLimitRepo) Purge(ctx context.Context, idle time.Duration) (int64, error) {
+	before := time.Now().Add(-idle)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var purged int64
+	for key, b := range r.buckets {
+		if b.updated.Before(before) {
+			delete(r.buckets, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
@@ -2,9 +2,12 @@ package postgres
 
 import (
 	"app/backendv1/internal/domain"
+	"app/backendv1/internal/metrics"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"time"
 )
 
 type appRepo struct {
@@ -15,57 +18,203 @@ func NewAppRepo(db *sql.DB) *appRepo {
 	return &appRepo{db: db}
 }
 
-func (r *appRepo) Create(app *domain.App) error {
-	// Создаем базовую структуру для fields
-	// Создаем структуру fields согласно требованиям
-	fields := map[string]interface{}{
-		"item": []interface{}{
-			map[string]interface{}{
-				"name": map[string]interface{}{
-					"type": "string",
-				},
-			},
-		},
+func (r *appRepo) Create(ctx context.Context, app *domain.App) error {
+	fieldsJSON, err := marshalFields(app.Fields)
+	if err != nil {
+		return err
 	}
-
-	// Преобразуем в JSON
-	fieldsJSON, err := json.Marshal(fields)
+	workflowJSON, err := marshalOptional(app.Workflow)
+	if err != nil {
+		return err
+	}
+	approvalJSON, err := marshalOptional(app.Approval)
+	if err != nil {
+		return err
+	}
+	retentionJSON, err := marshalOptional(app.Retention)
+	if err != nil {
+		return err
+	}
+	uniqueJSON, err := marshalUnique(app.Unique)
+	if err != nil {
+		return err
+	}
+	numberingJSON, err := marshalOptional(app.Numbering)
+	if err != nil {
+		return err
+	}
+	_, err = conn(ctx, r.db).ExecContext(ctx, "INSERT INTO apps (code, name, namespace_code, icon, fields, max_file_size, workflow, approval, retention, unique_fields, numbering, drafts) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)", app.Code, app.Name, app.NamespaceCode, app.Icon, fieldsJSON, app.MaxFileSize, workflowJSON, approvalJSON, retentionJSON, uniqueJSON, numberingJSON, app.Drafts)
 	if err != nil {
-		return fmt.Errorf("failed to marshal fields: %w", err)
+		return fmt.Errorf("failed to insert app: %w", err)
 	}
-	// fmt.Print(app.Code, app.Name, app.NamespaceCode, app.Icon)
-	_, err = r.db.Exec("INSERT INTO apps (code, name, namespace_code, icon, fields) VALUES ($1, $2, $3, $4, $5)", app.Code, app.Name, app.NamespaceCode, app.Icon, fieldsJSON)
+	query := "CREATE TABLE IF NOT EXISTS " + app.NamespaceCode + "." + app.Code + " (uid uuid PRIMARY KEY DEFAULT gen_random_uuid(), data jsonb not null default '{}'::jsonb, deleted_at timestamptz, created_at timestamptz not null default now(), updated_at timestamptz not null default now(), created_by text not null default '', updated_by text not null default '', legal_hold boolean not null default false, number text unique, published_data jsonb, published_at timestamptz, published_by text, publish_at timestamptz, unpublish_at timestamptz)"
+	_, err = conn(ctx, r.db).ExecContext(ctx, query)
+	metrics.DDL("create_table", err)
 	if err != nil {
-		return fmt.Errorf("failed to marshal fields: %w", err)
+		return err
 	}
-	query := "CREATE TABLE IF NOT EXISTS " + app.NamespaceCode + "." + app.Code + " (uid uuid PRIMARY KEY DEFAULT gen_random_uuid(), data jsonb not null default '{}'::jsonb)"
-	_, err = r.db.Exec(query)
-	// fmt.Printf(query)
-	// fmt.Print(err.Error())
+	// изменения записей сбрасывают кэш на всех экземплярах, функция создаётся при старте
+	table := app.NamespaceCode + "." + app.Code
+	_, err = conn(ctx, r.db).ExecContext(ctx, "DROP TRIGGER IF EXISTS cache_invalidate ON "+table+"; CREATE TRIGGER cache_invalidate AFTER UPDATE OR DELETE ON "+table+" FOR EACH ROW EXECUTE FUNCTION notify_record_change()")
 	return err
 }
 
-func (r *appRepo) GetAllByCodeNamespace(code string) ([]*domain.App, error) {
-	rows, err := r.db.Query("SELECT code, name, namespace_code, icon FROM apps WHERE namespace_code = $1", code)
-	if err != nil {
+func (r *appRepo) GetAllByCodeNamespace(ctx context.Context, code string) ([]*domain.App, error) {
+	return r.list(ctx, "SELECT "+appColumns+` FROM apps
+		WHERE namespace_code = $1 AND deleted_at IS NULL`, code)
+}
+
+func (r *appRepo) GetAll(ctx context.Context) ([]*domain.App, error) {
+	return r.list(ctx, "SELECT "+appColumns+` FROM apps
+		WHERE deleted_at IS NULL
+		AND namespace_code IN (SELECT code FROM namespaces WHERE deleted_at IS NULL)`)
+}
+
+// GetByCode возвращает приложение по коду, nil если оно не найдено или в корзине
+func (r *appRepo) GetByCode(ctx context.Context, code, namespaceCode string) (*domain.App, error) {
+	apps, err := r.list(ctx, "SELECT "+appColumns+` FROM apps
+		WHERE code = $1 AND namespace_code = $2 AND deleted_at IS NULL`, code, namespaceCode)
+	if err != nil || len(apps) == 0 {
 		return nil, err
 	}
-	defer rows.Close()
+	return apps[0], nil
+}
 
-	var apps []*domain.App
-	for rows.Next() {
-		var app domain.App
-		if err := rows.Scan(&app.Code, &app.Name, &app.NamespaceCode, &app.Icon); err != nil {
-			return nil, err
+// GetTrashByCodeNamespace возвращает приложения namespace, находящиеся в корзине
+func (r *appRepo) GetTrashByCodeNamespace(ctx context.Context, code string) ([]*domain.App, error) {
+	return r.list(ctx, "SELECT "+appColumns+` FROM apps
+		WHERE namespace_code = $1 AND deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC`, code)
+}
+
+func (r *appRepo) Update(ctx context.Context, app *domain.App) error {
+	fieldsJSON, err := marshalFields(app.Fields)
+	if err != nil {
+		return err
+	}
+	workflowJSON, err := marshalOptional(app.Workflow)
+	if err != nil {
+		return err
+	}
+	approvalJSON, err := marshalOptional(app.Approval)
+	if err != nil {
+		return err
+	}
+	retentionJSON, err := marshalOptional(app.Retention)
+	if err != nil {
+		return err
+	}
+	uniqueJSON, err := marshalUnique(app.Unique)
+	if err != nil {
+		return err
+	}
+	numberingJSON, err := marshalOptional(app.Numbering)
+	if err != nil {
+		return err
+	}
+	result, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE apps SET name = $1, icon = $2, fields = $3, max_file_size = $4, workflow = $5, approval = $6, retention = $7, unique_fields = $8, numbering = $9, drafts = $10 WHERE code = $11 AND namespace_code = $12 AND deleted_at IS NULL", app.Name, app.Icon, fieldsJSON, app.MaxFileSize, workflowJSON, approvalJSON, retentionJSON, uniqueJSON, numberingJSON, app.Drafts, app.Code, app.NamespaceCode)
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("no rows updated")
+	}
+	return nil
+}
+
+// SetIcon меняет ссылку на иконку приложения
+func (r *appRepo) SetIcon(ctx context.Context, code, namespace_code, iconID string) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE apps SET icon = NULLIF($1, '') WHERE code = $2 AND namespace_code = $3 AND deleted_at IS NULL", iconID, code, namespace_code)
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("app %s: %w", code, domain.ErrNotFound)
+	}
+	return nil
+}
+
+// Delete перемещает приложение в корзину, таблица с данными остаётся до очистки
+func (r *appRepo) Delete(ctx context.Context, code, namespace_code string) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE apps SET deleted_at = now() WHERE code = $1 AND namespace_code = $2 AND deleted_at IS NULL", code, namespace_code)
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("app %s: %w", code, domain.ErrNotFound)
+	}
+	return nil
+}
+
+// Restore возвращает приложение из корзины
+func (r *appRepo) Restore(ctx context.Context, code, namespace_code string) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE apps SET deleted_at = NULL WHERE code = $1 AND namespace_code = $2 AND deleted_at IS NOT NULL", code, namespace_code)
+	if err != nil {
+		return err
+	}
+
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("app %s in trash: %w", code, domain.ErrNotFound)
+	}
+	return nil
+}
+
+// PurgeDeleted окончательно удаляет приложения, пролежавшие в корзине дольше before,
+// вместе с таблицами данных
+func (r *appRepo) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	apps, err := r.list(ctx, "SELECT "+appColumns+` FROM apps
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+
+	var purged int64
+	for _, app := range apps {
+		tx, err := begin(ctx, r.db)
+		if err != nil {
+			return purged, err
 		}
-		apps = append(apps, &app)
+		_, err = tx.ExecContext(ctx, "DROP TABLE IF EXISTS "+app.NamespaceCode+"."+app.Code)
+		metrics.DDL("drop_table", err)
+		if err != nil {
+			tx.Rollback()
+			return purged, fmt.Errorf("failed to drop table %s.%s: %w", app.NamespaceCode, app.Code, err)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM apps WHERE code = $1 AND namespace_code = $2", app.Code, app.NamespaceCode); err != nil {
+			tx.Rollback()
+			return purged, fmt.Errorf("failed to delete app %s: %w", app.Code, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return purged, err
+		}
+		purged++
 	}
-	return apps, nil
+	return purged, nil
 }
 
-func (r *appRepo) GetAll() ([]*domain.App, error) {
-	query := `SELECT code, name, namespace_code, icon FROM apps`
-	rows, err := r.db.Query(query)
+const appColumns = "code, name, namespace_code, COALESCE(icon, ''), fields, max_file_size, workflow, approval, retention, unique_fields, numbering, drafts, deleted_at, COALESCE(index_error, '')"
+
+func (r *appRepo) list(ctx context.Context, query string, args ...interface{}) ([]*domain.App, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -73,32 +222,82 @@ func (r *appRepo) GetAll() ([]*domain.App, error) {
 
 	var apps []*domain.App
 	for rows.Next() {
-		var app domain.App
-		if err := rows.Scan(&app.Code, &app.Name, &app.NamespaceCode, &app.Icon); err != nil {
+		var (
+			app           domain.App
+			fieldsJSON    []byte
+			workflowJSON  []byte
+			approvalJSON  []byte
+			retentionJSON []byte
+			uniqueJSON    []byte
+			numberingJSON []byte
+		)
+		if err := rows.Scan(&app.Code, &app.Name, &app.NamespaceCode, &app.Icon, &fieldsJSON, &app.MaxFileSize, &workflowJSON, &approvalJSON, &retentionJSON, &uniqueJSON, &numberingJSON, &app.Drafts, &app.DeletedAt, &app.IndexError); err != nil {
 			return nil, err
 		}
+		if err := json.Unmarshal(fieldsJSON, &app.Fields); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal fields of app %s: %w", app.Code, err)
+		}
+		if workflowJSON != nil {
+			if err := json.Unmarshal(workflowJSON, &app.Workflow); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal workflow of app %s: %w", app.Code, err)
+			}
+		}
+		if approvalJSON != nil {
+			if err := json.Unmarshal(approvalJSON, &app.Approval); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal approval policy of app %s: %w", app.Code, err)
+			}
+		}
+		if retentionJSON != nil {
+			if err := json.Unmarshal(retentionJSON, &app.Retention); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal retention policy of app %s: %w", app.Code, err)
+			}
+		}
+		if numberingJSON != nil {
+			if err := json.Unmarshal(numberingJSON, &app.Numbering); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal numbering of app %s: %w", app.Code, err)
+			}
+		}
+		if err := json.Unmarshal(uniqueJSON, &app.Unique); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal unique constraints of app %s: %w", app.Code, err)
+		}
 		apps = append(apps, &app)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 	return apps, nil
 }
 
-func (r *appRepo) Update(app *domain.App) error {
-	result, err := r.db.Exec("UPDATE apps SET name = $1, icon = $2  WHERE code = $3 AND namespace_code = $4", app.Name, app.Icon, app.Code, app.NamespaceCode)
+func marshalFields(fields []domain.Field) ([]byte, error) {
+	if fields == nil {
+		fields = []domain.Field{}
+	}
+	fieldsJSON, err := json.Marshal(fields)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("failed to marshal fields: %w", err)
 	}
+	return fieldsJSON, nil
+}
 
-	count, err := result.RowsAffected()
-	if err != nil {
-		return err
+func marshalUnique(unique [][]string) ([]byte, error) {
+	if unique == nil {
+		unique = [][]string{}
 	}
-	if count == 0 {
-		return fmt.Errorf("no rows updated")
+	uniqueJSON, err := json.Marshal(unique)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal unique constraints: %w", err)
 	}
-	return nil
+	return uniqueJSON, nil
 }
 
-func (r *appRepo) Delete(code, namespace_code string) error {
-	_, err := r.db.Exec("DELETE FROM apps WHERE code = $1 AND namespace_code = $2", code, namespace_code)
-	return err
+// marshalOptional сериализует необязательную настройку приложения; nil сохраняется как NULL
+func marshalOptional[T any](value *T) (interface{}, error) {
+	if value == nil {
+		return nil, nil
+	}
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal %T: %w", value, err)
+	}
+	return valueJSON, nil
 }
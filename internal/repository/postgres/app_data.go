@@ -2,10 +2,14 @@ package postgres
 
 import (
 	"app/backendv1/internal/domain"
+	"app/backendv1/internal/reqctx"
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strings"
+	"time"
 )
 
 type appDataRepo struct {
@@ -16,102 +20,326 @@ func NewAppDataRepo(db *sql.DB) *appDataRepo {
 	return &appDataRepo{db: db}
 }
 
-// Create создает новую запись
-func (r *appDataRepo) Create(namespace, table string, data *domain.AppData) error {
+// Create создает новую запись; время создания и автор заполняются базой и контекстом запроса.
+// Номер записи, если он есть, выделяется заранее в той же транзакции
+func (r *appDataRepo) Create(ctx context.Context, namespace, table string, data *domain.AppData) error {
 	jsonData, err := json.Marshal(data.Data)
 	if err != nil {
 		return fmt.Errorf("failed to marshal data: %w", err)
 	}
 
 	query := fmt.Sprintf(`
-		INSERT INTO %s.%s (data) 
-		VALUES ($1)
+		INSERT INTO %s.%s (data, created_by, updated_by, number) 
+		VALUES ($1, $2, $2, NULLIF($3, ''))
+		RETURNING uid, created_at, updated_at, created_by, updated_by
 	`, namespace, table)
 
-	_, err = r.db.Exec(query, jsonData)
+	err = conn(ctx, r.db).QueryRowContext(ctx, query, jsonData, actor(ctx), data.Number).Scan(&data.UID, &data.CreatedAt, &data.UpdatedAt, &data.CreatedBy, &data.UpdatedBy)
 	if err != nil {
+		if index, ok := uniqueViolation(err); ok {
+			return &domain.DuplicateError{Index: index}
+		}
 		return fmt.Errorf("failed to insert data: %w", err)
 	}
+	return nil
+}
+
+// NextValue увеличивает счётчик номеров периода; строка счётчика заблокирована до конца
+// транзакции, а при её откате откатывается и счётчик, поэтому номера идут без пропусков
+func (r *appDataRepo) NextValue(ctx context.Context, namespace, table, period string) (int64, error) {
+	var value int64
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO record_counters (namespace_code, app_code, period, value)
+		VALUES ($1, $2, $3, 1)
+		ON CONFLICT (namespace_code, app_code, period) DO UPDATE SET value = record_counters.value + 1
+		RETURNING value
+	`, namespace, table, period).Scan(&value)
+	if err != nil {
+		return 0, fmt.Errorf("failed to allocate number: %w", err)
+	}
+	return value, nil
+}
+
+// NumberTaken проверяет, есть ли номер у записи, в том числе в корзине
+func (r *appDataRepo) NumberTaken(ctx context.Context, namespace, table, number string) (bool, error) {
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s.%s WHERE number = $1)", namespace, table)
+
+	var taken bool
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, number).Scan(&taken); err != nil {
+		return false, fmt.Errorf("failed to check number: %w", err)
+	}
+	return taken, nil
+}
+
+// Unnumbered возвращает записи без номера, в том числе из корзины, в порядке создания
+func (r *appDataRepo) Unnumbered(ctx context.Context, namespace, table string, limit int) ([]*domain.AppData, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM %s.%s
+		WHERE number IS NULL
+		ORDER BY created_at, uid
+		LIMIT $1
+	`, dataColumns, namespace, table)
+
+	return r.list(ctx, query, limit)
+}
+
+// SetNumber присваивает номер записи; системные поля изменения не меняются
+func (r *appDataRepo) SetNumber(ctx context.Context, namespace, table, uid, number string) error {
+	query := fmt.Sprintf("UPDATE %s.%s SET number = $1 WHERE uid = $2", namespace, table)
 
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, number, uid); err != nil {
+		if index, ok := uniqueViolation(err); ok {
+			return &domain.DuplicateError{Index: index}
+		}
+		return fmt.Errorf("failed to set number: %w", err)
+	}
 	return nil
 }
 
 // GetByUID возвращает запись по UID
-func (r *appDataRepo) GetDataByUID(namespace, table, uid string) (*domain.AppData, error) {
+func (r *appDataRepo) GetDataByUID(ctx context.Context, namespace, table, uid string) (*domain.AppData, error) {
 	query := fmt.Sprintf(`
-		SELECT uid, data 
+		SELECT %s
 		FROM %s.%s 
-		WHERE uid = $1
-	`, namespace, table)
+		WHERE uid = $1 AND deleted_at IS NULL
+	`, dataColumns, namespace, table)
 
-	var (
-		dbUID    string
-		jsonData []byte
-	)
-
-	err := r.db.QueryRow(query, uid).Scan(&dbUID, &jsonData)
+	records, err := r.list(ctx, query, uid)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("record with uid %s not found", uid)
-		}
-		return nil, fmt.Errorf("failed to query data: %w", err)
+		return nil, err
 	}
-
-	var data map[string]interface{}
-	if err := json.Unmarshal(jsonData, &data); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal data: %w", err)
+	if len(records) == 0 {
+		return nil, fmt.Errorf("record with uid %s: %w", uid, domain.ErrNotFound)
 	}
-
-	return &domain.AppData{
-		UID:  dbUID,
-		Data: data,
-	}, nil
+	return records[0], nil
 }
 
-// GetAll возвращает все записи
-func (r *appDataRepo) GetAll(namespace, table string) ([]*domain.AppData, error) {
+// GetByNumber возвращает запись по номеру
+func (r *appDataRepo) GetByNumber(ctx context.Context, namespace, table, number string) (*domain.AppData, error) {
 	query := fmt.Sprintf(`
-		SELECT uid, data 
-		FROM %s.%s
-	`, namespace, table)
+		SELECT %s
+		FROM %s.%s 
+		WHERE number = $1 AND deleted_at IS NULL
+	`, dataColumns, namespace, table)
 
-	rows, err := r.db.Query(query)
+	records, err := r.list(ctx, query, number)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query data: %w", err)
+		return nil, err
 	}
-	defer rows.Close()
+	if len(records) == 0 {
+		return nil, fmt.Errorf("record with number %s: %w", number, domain.ErrNotFound)
+	}
+	return records[0], nil
+}
 
-	var results []*domain.AppData
-	for rows.Next() {
-		var (
-			uid      string
-			jsonData []byte
-		)
+// GetAll возвращает записи, кроме находящихся в корзине, с учётом фильтров, сортировки и пагинации.
+// Значения полей сравниваются как jsonb, только если тип значения совпадает с типом условия
+// Для опубликованной версии читается и фильтруется published_data, а неопубликованные записи пропускаются
+func (r *appDataRepo) GetAll(ctx context.Context, namespace, table string, q domain.DataQuery) ([]*domain.AppData, error) {
+	conditions := []string{"deleted_at IS NULL"}
+	column := "data"
+	if q.Stage == domain.StagePublished {
+		column = "published_data"
+		conditions = append(conditions, "published_data IS NOT NULL")
+	}
+	var args []interface{}
+	arg := func(value interface{}) string {
+		args = append(args, value)
+		return fmt.Sprintf("$%d", len(args))
+	}
+	localized := make(map[string]bool, len(q.Localized))
+	for _, field := range q.Localized {
+		localized[field] = true
+	}
+	// значение локализуемого поля берётся из первой заданной локали цепочки
+	valuePath := func(field string) string {
+		path := fieldPath(column, field, arg)
+		if !localized[field] || len(q.Locales) == 0 {
+			return path
+		}
+		values := make([]string, len(q.Locales))
+		for i, locale := range q.Locales {
+			values[i] = path + "->" + arg(locale) + "::text"
+		}
+		return "COALESCE(" + strings.Join(values, ", ") + ")"
+	}
 
-		if err := rows.Scan(&uid, &jsonData); err != nil {
-			return nil, fmt.Errorf("failed to scan data: %w", err)
+	for _, f := range q.Filters {
+		if column, ok := systemColumns[f.Field]; ok {
+			if f.Op == domain.FilterContains {
+				conditions = append(conditions, fmt.Sprintf("strpos(lower(%s::text), lower(%s::text)) > 0", column, arg(fmt.Sprint(f.Value))))
+				continue
+			}
+			op, ok := systemOps[f.Op]
+			if !ok {
+				return nil, fmt.Errorf("%w: unknown filter operator %q", domain.ErrValidation, f.Op)
+			}
+			conditions = append(conditions, fmt.Sprintf("%s %s %s", column, op, arg(f.Value)))
+			continue
+		}
+		field := valuePath(f.Field)
+		if f.Op == domain.FilterContains {
+			conditions = append(conditions, fmt.Sprintf("strpos(lower(%s #>> '{}'), lower(%s::text)) > 0", field, arg(fmt.Sprint(f.Value))))
+			continue
 		}
+		value, err := json.Marshal(f.Value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal filter value: %w", err)
+		}
+		v := arg(string(value)) + "::jsonb"
+		switch f.Op {
+		case domain.FilterEq:
+			conditions = append(conditions, fmt.Sprintf("%s = %s", field, v))
+		case domain.FilterNe:
+			conditions = append(conditions, fmt.Sprintf("%s IS DISTINCT FROM %s", field, v))
+		default:
+			op, ok := comparisonOps[f.Op]
+			if !ok {
+				return nil, fmt.Errorf("%w: unknown filter operator %q", domain.ErrValidation, f.Op)
+			}
+			conditions = append(conditions, fmt.Sprintf("jsonb_typeof(%s) = jsonb_typeof(%s) AND %s %s %s", field, v, field, op, v))
+		}
+	}
 
-		var data map[string]interface{}
-		if err := json.Unmarshal(jsonData, &data); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal data: %w", err)
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM %s.%s
+		WHERE %s
+	`, selectColumns(column), namespace, table, strings.Join(conditions, " AND "))
+
+	var order []string
+	for _, s := range q.Sort {
+		direction := "ASC"
+		if s.Desc {
+			direction = "DESC"
+		}
+		if column, ok := systemColumns[s.Field]; ok {
+			order = append(order, column+" "+direction)
+			continue
 		}
+		order = append(order, fmt.Sprintf("%s %s NULLS LAST", valuePath(s.Field), direction))
+	}
+	// uid в конце делает порядок однозначным, чтобы страницы не пересекались
+	order = append(order, "uid")
+	query += " ORDER BY " + strings.Join(order, ", ")
+	if q.Limit > 0 {
+		query += " LIMIT " + arg(q.Limit)
+	}
+	if q.Offset > 0 {
+		query += " OFFSET " + arg(q.Offset)
+	}
+
+	return r.list(ctx, query, args...)
+}
+
+var comparisonOps = map[string]string{
+	domain.FilterGt:  ">",
+	domain.FilterGte: ">=",
+	domain.FilterLt:  "<",
+	domain.FilterLte: "<=",
+}
 
-		results = append(results, &domain.AppData{
-			UID:  uid,
-			Data: data,
-		})
+// systemOps — операторы для системных столбцов, которые сравниваются как обычные значения SQL
+var systemOps = map[string]string{
+	domain.FilterEq:  "=",
+	domain.FilterNe:  "<>",
+	domain.FilterGt:  ">",
+	domain.FilterGte: ">=",
+	domain.FilterLt:  "<",
+	domain.FilterLte: "<=",
+}
+
+// systemColumns сопоставляет системные поля записи со столбцами таблицы
+var systemColumns = map[string]string{
+	domain.SystemCreatedAt: "created_at",
+	domain.SystemUpdatedAt: "updated_at",
+	domain.SystemCreatedBy: "created_by",
+	domain.SystemUpdatedBy: "updated_by",
+	domain.SystemNumber:    "number",
+}
+
+// fieldKeyPattern — коды, которые можно подставить в запрос литералом
+var fieldKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// fieldPath возвращает выражение значения поля записи в столбце column. Коды полей подставляются
+// литералом, чтобы выражение совпадало с индексами по схеме приложения; прочие имена передаются параметром
+func fieldPath(column, field string, arg func(interface{}) string) string {
+	if fieldKeyPattern.MatchString(field) {
+		return column + "->'" + field + "'"
 	}
+	return column + "->(" + arg(field) + "::text)"
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, fmt.Errorf("rows iteration error: %w", err)
+// selectColumns возвращает столбцы записи для list; column — черновик data или опубликованная версия published_data
+func selectColumns(column string) string {
+	return "uid, COALESCE(number, ''), " + column + `, deleted_at, legal_hold, created_at, updated_at, created_by, updated_by,
+		published_at, publish_at, unpublish_at`
+}
+
+var dataColumns = selectColumns("data")
+
+// stageColumns — столбец версии записи, в которой пересчитываются вычисляемые поля
+var stageColumns = map[string]string{
+	domain.StageDraft:     "data",
+	domain.StagePublished: "published_data",
+}
+
+// LockComputed блокирует до конца транзакции и возвращает до limit записей с uid больше after
+// в порядке uid, включая корзину. Для опубликованной версии берутся только опубликованные записи
+func (r *appDataRepo) LockComputed(ctx context.Context, namespace, table, stage, after string, limit int) ([]*domain.AppData, error) {
+	column, ok := stageColumns[stage]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown stage %q", domain.ErrValidation, stage)
 	}
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM %s.%s
+		WHERE %s IS NOT NULL AND ($1 = '' OR uid > $1::uuid)
+		ORDER BY uid
+		LIMIT $2
+		FOR UPDATE
+	`, selectColumns(column), namespace, table, column)
 
-	return results, nil
+	return r.list(ctx, query, after, limit)
+}
+
+// SetComputed записывает значения вычисляемых полей в версию stage записи; время и автор
+// изменения не меняются, потому что запись меняет не пользователь, а схема приложения
+func (r *appDataRepo) SetComputed(ctx context.Context, namespace, table, stage, uid string, values map[string]interface{}) error {
+	column, ok := stageColumns[stage]
+	if !ok {
+		return fmt.Errorf("%w: unknown stage %q", domain.ErrValidation, stage)
+	}
+	jsonValues, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed to marshal computed fields: %w", err)
+	}
+	query := fmt.Sprintf("UPDATE %s.%s SET %s = %s || $1::jsonb WHERE uid = $2 AND %s IS NOT NULL", namespace, table, column, column, column)
+
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, jsonValues, uid); err != nil {
+		if index, ok := uniqueViolation(err); ok {
+			return &domain.DuplicateError{Index: index}
+		}
+		return fmt.Errorf("failed to set computed fields: %w", err)
+	}
+	return nil
+}
+
+// GetTrash возвращает записи, находящиеся в корзине
+func (r *appDataRepo) GetTrash(ctx context.Context, namespace, table string) ([]*domain.AppData, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM %s.%s
+		WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`, dataColumns, namespace, table)
+
+	return r.list(ctx, query)
 }
 
 // Update полностью обновляет запись
-func (r *appDataRepo) Update(namespace, table string, data *domain.AppData) error {
+func (r *appDataRepo) Update(ctx context.Context, namespace, table string, data *domain.AppData) error {
 	jsonData, err := json.Marshal(data.Data)
 	if err != nil {
 		return fmt.Errorf("failed to marshal data: %w", err)
@@ -119,29 +347,27 @@ func (r *appDataRepo) Update(namespace, table string, data *domain.AppData) erro
 
 	query := fmt.Sprintf(`
 		UPDATE %s.%s 
-		SET data = $1
-		WHERE uid = $2
+		SET data = $1, updated_at = now(), updated_by = $3
+		WHERE uid = $2 AND deleted_at IS NULL
+		RETURNING COALESCE(number, ''), created_at, updated_at, created_by, updated_by
 	`, namespace, table)
 
-	result, err := r.db.Exec(query, jsonData, data.UID)
-	if err != nil {
-		return fmt.Errorf("failed to update data: %w", err)
+	err = conn(ctx, r.db).QueryRowContext(ctx, query, jsonData, data.UID, actor(ctx)).Scan(&data.Number, &data.CreatedAt, &data.UpdatedAt, &data.CreatedBy, &data.UpdatedBy)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("record with uid %s: %w", data.UID, domain.ErrNotFound)
 	}
-
-	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to check rows affected: %w", err)
-	}
-
-	if rowsAffected == 0 {
-		return fmt.Errorf("record with uid %s not found", data.UID)
+		if index, ok := uniqueViolation(err); ok {
+			return &domain.DuplicateError{Index: index}
+		}
+		return fmt.Errorf("failed to update data: %w", err)
 	}
 
 	return nil
 }
 
 // UpdatePartial частично обновляет JSON данные
-func (r *appDataRepo) UpdateDataPartial(namespace, table, uid string, partialData map[string]interface{}) error {
+func (r *appDataRepo) UpdateDataPartial(ctx context.Context, namespace, table, uid string, partialData map[string]interface{}) error {
 	setParts := make([]string, 0, len(partialData))
 	args := make([]interface{}, 0, len(partialData)+1)
 	argPos := 1
@@ -156,16 +382,20 @@ func (r *appDataRepo) UpdateDataPartial(namespace, table, uid string, partialDat
 		argPos++
 	}
 
-	args = append(args, uid)
+	setParts = append(setParts, "updated_at = now()", fmt.Sprintf("updated_by = $%d", argPos))
+	args = append(args, actor(ctx), uid)
 
 	query := fmt.Sprintf(`
 		UPDATE %s.%s 
 		SET %s
-		WHERE uid = $%d
-	`, namespace, table, strings.Join(setParts, ", "), argPos)
+		WHERE uid = $%d AND deleted_at IS NULL
+	`, namespace, table, strings.Join(setParts, ", "), argPos+1)
 
-	result, err := r.db.Exec(query, args...)
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, args...)
 	if err != nil {
+		if index, ok := uniqueViolation(err); ok {
+			return &domain.DuplicateError{Index: index}
+		}
 		return fmt.Errorf("failed to update data: %w", err)
 	}
 
@@ -175,20 +405,21 @@ func (r *appDataRepo) UpdateDataPartial(namespace, table, uid string, partialDat
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("record with uid %s not found", uid)
+		return fmt.Errorf("record with uid %s: %w", uid, domain.ErrNotFound)
 	}
 
 	return nil
 }
 
-// Delete удаляет запись
-func (r *appDataRepo) Delete(namespace, table, uid string) error {
+// Delete перемещает запись в корзину
+func (r *appDataRepo) Delete(ctx context.Context, namespace, table, uid string) error {
 	query := fmt.Sprintf(`
-		DELETE FROM %s.%s 
-		WHERE uid = $1
+		UPDATE %s.%s 
+		SET deleted_at = now()
+		WHERE uid = $1 AND deleted_at IS NULL
 	`, namespace, table)
 
-	result, err := r.db.Exec(query, uid)
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, uid)
 	if err != nil {
 		return fmt.Errorf("failed to delete data: %w", err)
 	}
@@ -199,8 +430,91 @@ func (r *appDataRepo) Delete(namespace, table, uid string) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("record with uid %s not found", uid)
+		return fmt.Errorf("record with uid %s: %w", uid, domain.ErrNotFound)
 	}
 
 	return nil
 }
+
+// Restore возвращает запись из корзины
+func (r *appDataRepo) Restore(ctx context.Context, namespace, table, uid string) error {
+	query := fmt.Sprintf(`
+		UPDATE %s.%s 
+		SET deleted_at = NULL
+		WHERE uid = $1 AND deleted_at IS NOT NULL
+	`, namespace, table)
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, uid)
+	if err != nil {
+		if index, ok := uniqueViolation(err); ok {
+			return &domain.DuplicateError{Index: index}
+		}
+		return fmt.Errorf("failed to restore data: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("record with uid %s in trash: %w", uid, domain.ErrNotFound)
+	}
+
+	return nil
+}
+
+// PurgeDeleted окончательно удаляет записи, пролежавшие в корзине дольше before.
+// Записи под удержанием остаются в корзине
+func (r *appDataRepo) PurgeDeleted(ctx context.Context, namespace, table string, before time.Time) (int64, error) {
+	query := fmt.Sprintf(`
+		DELETE FROM %s.%s 
+		WHERE deleted_at IS NOT NULL AND deleted_at < $1 AND NOT legal_hold
+	`, namespace, table)
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, before)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge data: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+func (r *appDataRepo) list(ctx context.Context, query string, args ...interface{}) ([]*domain.AppData, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query data: %w", err)
+	}
+	defer rows.Close()
+
+	var results []*domain.AppData
+	for rows.Next() {
+		var (
+			record   domain.AppData
+			jsonData []byte
+		)
+
+		if err := rows.Scan(&record.UID, &record.Number, &jsonData, &record.DeletedAt, &record.LegalHold,
+			&record.CreatedAt, &record.UpdatedAt, &record.CreatedBy, &record.UpdatedBy,
+			&record.PublishedAt, &record.PublishAt, &record.UnpublishAt); err != nil {
+			return nil, fmt.Errorf("failed to scan data: %w", err)
+		}
+
+		if err := json.Unmarshal(jsonData, &record.Data); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal data: %w", err)
+		}
+
+		results = append(results, &record)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return results, nil
+}
+
+// actor возвращает субъект текущего запроса для столбцов created_by и updated_by
+func actor(ctx context.Context) string {
+	return reqctx.FromContext(ctx).Actor
+}
@@ -0,0 +1,213 @@
+package postgres
+
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+type changeRequestRepo struct {
+	db *sql.DB
+}
+
+func NewChangeRequestRepo(db *sql.DB) *changeRequestRepo {
+	return &changeRequestRepo{db: db}
+}
+
+func (r *changeRequestRepo) Create(ctx context.Context, c *domain.ChangeRequest) error {
+	dataJSON, err := json.Marshal(c.Data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal data: %w", err)
+	}
+	policyJSON, err := json.Marshal(c.Policy)
+	if err != nil {
+		return fmt.Errorf("failed to marshal policy: %w", err)
+	}
+	err = conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO change_requests (namespace_code, app_code, record_uid, kind, data, status, step, policy, requested_by, created_at, expires_at, base_updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		RETURNING id
+	`, c.NamespaceCode, c.AppCode, c.RecordUID, c.Kind, dataJSON, c.Status, c.Step, policyJSON, c.RequestedBy, c.CreatedAt, c.ExpiresAt, c.BaseUpdatedAt).Scan(&c.ID)
+	if err != nil {
+		return fmt.Errorf("failed to insert change request: %w", err)
+	}
+	return nil
+}
+
+// GetByID возвращает запрос на изменение с голосами, nil если его нет
+func (r *changeRequestRepo) GetByID(ctx context.Context, id string) (*domain.ChangeRequest, error) {
+	requests, err := r.list(ctx, "SELECT "+changeRequestColumns+" FROM change_requests c WHERE c.id::text = $1", id)
+	if err != nil || len(requests) == 0 {
+		return nil, err
+	}
+	return requests[0], nil
+}
+
+func (r *changeRequestRepo) List(ctx context.Context, filter domain.ChangeRequestFilter) ([]*domain.ChangeRequest, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	add := func(column, value string) {
+		if value != "" {
+			args = append(args, value)
+			conditions = append(conditions, fmt.Sprintf("%s = $%d", column, len(args)))
+		}
+	}
+	add("c.namespace_code", filter.NamespaceCode)
+	add("c.app_code", filter.AppCode)
+	add("c.record_uid::text", filter.RecordUID)
+	add("c.status", filter.Status)
+
+	query := "SELECT " + changeRequestColumns + " FROM change_requests c"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	query += " ORDER BY c.created_at DESC"
+	return r.list(ctx, query, args...)
+}
+
+// Inbox возвращает ожидающие запросы, по которым actor с ролями roles может проголосовать
+// на текущем шаге: он не автор, указан в шаге пользователем или ролью и ещё не голосовал
+func (r *changeRequestRepo) Inbox(ctx context.Context, actor string, roles []string, limit int) ([]*domain.ChangeRequest, error) {
+	if roles == nil {
+		roles = []string{}
+	}
+	return r.list(ctx, "SELECT "+changeRequestColumns+` FROM change_requests c
+		WHERE c.status = 'pending' AND c.expires_at > now() AND c.requested_by <> $1
+			AND (c.policy->'steps'->c.step->'users' ? $1 OR c.policy->'steps'->c.step->'roles' ?| $2)
+			AND NOT EXISTS (
+				SELECT 1 FROM change_request_votes v
+				WHERE v.change_request_id = c.id AND v.step = c.step AND v.reviewer = $1
+			)
+		ORDER BY c.created_at DESC
+		LIMIT $3`, actor, pq.Array(roles), limit)
+}
+
+// LockRecord блокирует запись до конца транзакции и возвращает время её изменения
+func (r *changeRequestRepo) LockRecord(ctx context.Context, namespace, appName, uid string) (time.Time, error) {
+	var updatedAt time.Time
+	query := fmt.Sprintf("SELECT updated_at FROM %s.%s WHERE uid = $1 AND deleted_at IS NULL FOR UPDATE", namespace, appName)
+	err := conn(ctx, r.db).QueryRowContext(ctx, query, uid).Scan(&updatedAt)
+	if err == sql.ErrNoRows {
+		return updatedAt, fmt.Errorf("record %s: %w", uid, domain.ErrNotFound)
+	}
+	return updatedAt, err
+}
+
+// AddVote сохраняет голос проверяющего, повторный голос на шаге отклоняется
+func (r *changeRequestRepo) AddVote(ctx context.Context, id string, vote *domain.ApprovalVote) error {
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO change_request_votes (change_request_id, step, reviewer, decision, comment)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT DO NOTHING
+		RETURNING created_at
+	`, id, vote.Step, vote.Reviewer, vote.Decision, vote.Comment).Scan(&vote.CreatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("%w: %s has already voted", domain.ErrConflict, vote.Reviewer)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to insert vote: %w", err)
+	}
+	return nil
+}
+
+func (r *changeRequestRepo) Advance(ctx context.Context, id string, step int) (bool, error) {
+	return r.exec(ctx, `
+		UPDATE change_requests SET step = step + 1
+		WHERE id = $1 AND status = 'pending' AND step = $2
+	`, id, step)
+}
+
+func (r *changeRequestRepo) Decide(ctx context.Context, id string, step int, status string) (bool, error) {
+	return r.exec(ctx, `
+		UPDATE change_requests SET status = $3, decided_at = now()
+		WHERE id = $1 AND status = 'pending' AND step = $2
+	`, id, step, status)
+}
+
+// MarkFailed отмечает ожидающий запрос, изменение которого не удалось применить
+func (r *changeRequestRepo) MarkFailed(ctx context.Context, id, message string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE change_requests SET status = 'failed', error = $2, decided_at = now() WHERE id = $1 AND status = 'pending'", id, message)
+	return err
+}
+
+func (r *changeRequestRepo) Expire(ctx context.Context, now time.Time) ([]*domain.ChangeRequest, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		UPDATE change_requests SET status = 'expired', decided_at = now()
+		WHERE status = 'pending' AND expires_at < $1
+		RETURNING id, namespace_code, app_code, record_uid
+	`, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expire change requests: %w", err)
+	}
+	defer rows.Close()
+
+	var expired []*domain.ChangeRequest
+	for rows.Next() {
+		c := domain.ChangeRequest{Status: domain.ChangeExpired}
+		if err := rows.Scan(&c.ID, &c.NamespaceCode, &c.AppCode, &c.RecordUID); err != nil {
+			return expired, err
+		}
+		expired = append(expired, &c)
+	}
+	return expired, rows.Err()
+}
+
+// exec выполняет условное обновление и сообщает, было ли оно применено
+func (r *changeRequestRepo) exec(ctx context.Context, query string, args ...interface{}) (bool, error) {
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// changeRequestColumns — голоса собираются подзапросом, чтобы список читался одним запросом
+const changeRequestColumns = `c.id, c.namespace_code, c.app_code, c.record_uid, c.kind, c.data, c.status, c.step,
+	c.policy, c.requested_by, c.error, c.created_at, c.expires_at, c.decided_at, c.base_updated_at,
+	COALESCE((SELECT json_agg(json_build_object(
+		'step', v.step, 'reviewer', v.reviewer, 'decision', v.decision,
+		'comment', v.comment, 'createdAt', v.created_at) ORDER BY v.created_at)
+		FROM change_request_votes v WHERE v.change_request_id = c.id), '[]')`
+
+func (r *changeRequestRepo) list(ctx context.Context, query string, args ...interface{}) ([]*domain.ChangeRequest, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query change requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []*domain.ChangeRequest
+	for rows.Next() {
+		var (
+			c                               domain.ChangeRequest
+			dataJSON, policyJSON, votesJSON []byte
+		)
+		if err := rows.Scan(&c.ID, &c.NamespaceCode, &c.AppCode, &c.RecordUID, &c.Kind, &dataJSON, &c.Status, &c.Step,
+			&policyJSON, &c.RequestedBy, &c.Error, &c.CreatedAt, &c.ExpiresAt, &c.DecidedAt, &c.BaseUpdatedAt, &votesJSON); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(dataJSON, &c.Data); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal data of change request %s: %w", c.ID, err)
+		}
+		if err := json.Unmarshal(policyJSON, &c.Policy); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal policy of change request %s: %w", c.ID, err)
+		}
+		if err := json.Unmarshal(votesJSON, &c.Votes); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal votes of change request %s: %w", c.ID, err)
+		}
+		requests = append(requests, &c)
+	}
+	return requests, rows.Err()
+}
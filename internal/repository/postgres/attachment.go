@@ -0,0 +1,107 @@
+package postgres
+
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+type attachmentRepo struct {
+	db *sql.DB
+}
+
+func NewAttachmentRepo(db *sql.DB) *attachmentRepo {
+	return &attachmentRepo{db: db}
+}
+
+// Create сохраняет метаданные вложения, сам файл уже лежит в хранилище
+func (r *attachmentRepo) Create(ctx context.Context, a *domain.Attachment) error {
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO attachments (id, namespace_code, app_code, record_uid, field, file_name, content_type, size, sha256, storage_key)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING created_at
+	`, a.ID, a.NamespaceCode, a.AppCode, a.RecordUID, a.Field, a.FileName, a.ContentType, a.Size, a.SHA256, a.StorageKey).Scan(&a.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert attachment: %w", err)
+	}
+	return nil
+}
+
+// GetByField возвращает текущее вложение поля записи, nil если его нет
+func (r *attachmentRepo) GetByField(ctx context.Context, namespace, app, uid, field string) (*domain.Attachment, error) {
+	row := conn(ctx, r.db).QueryRowContext(ctx, "SELECT "+attachmentColumns+` FROM attachments
+		WHERE namespace_code = $1 AND app_code = $2 AND record_uid = $3 AND field = $4
+		ORDER BY created_at DESC
+		LIMIT 1`, namespace, app, uid, field)
+
+	var a domain.Attachment
+	err := row.Scan(&a.ID, &a.NamespaceCode, &a.AppCode, &a.RecordUID, &a.Field, &a.FileName,
+		&a.ContentType, &a.Size, &a.SHA256, &a.StorageKey, &a.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attachment: %w", err)
+	}
+	return &a, nil
+}
+
+func (r *attachmentRepo) Delete(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM attachments WHERE id = $1", id)
+	return err
+}
+
+// ListByRecords возвращает вложения перечисленных записей приложения
+func (r *attachmentRepo) ListByRecords(ctx context.Context, namespace, app string, uids []string) ([]*domain.Attachment, error) {
+	query := "SELECT " + attachmentColumns + ` FROM attachments
+		WHERE namespace_code = $1 AND app_code = $2 AND record_uid = ANY($3::uuid[])`
+	return r.list(ctx, query, namespace, app, pq.Array(uids))
+}
+
+// FindOrphans возвращает вложения приложения, записи которых окончательно удалены.
+// Вложения записей, перенесённых в архив по сроку хранения, сохраняются
+func (r *attachmentRepo) FindOrphans(ctx context.Context, namespace, app string, limit int) ([]*domain.Attachment, error) {
+	query := "SELECT " + attachmentColumns + ` FROM attachments a
+		WHERE a.namespace_code = $1 AND a.app_code = $2
+		AND NOT EXISTS (SELECT 1 FROM ` + namespace + "." + app + ` t WHERE t.uid = a.record_uid)
+		AND NOT EXISTS (SELECT 1 FROM archived_records r
+			WHERE r.namespace_code = a.namespace_code AND r.app_code = a.app_code AND r.uid = a.record_uid)
+		LIMIT $3`
+	return r.list(ctx, query, namespace, app, limit)
+}
+
+// FindDetached возвращает вложения приложений, которые окончательно удалены
+func (r *attachmentRepo) FindDetached(ctx context.Context, limit int) ([]*domain.Attachment, error) {
+	query := "SELECT " + attachmentColumns + ` FROM attachments a
+		WHERE NOT EXISTS (SELECT 1 FROM apps p WHERE p.code = a.app_code AND p.namespace_code = a.namespace_code)
+		LIMIT $1`
+	return r.list(ctx, query, limit)
+}
+
+const attachmentColumns = "id, namespace_code, app_code, record_uid, field, file_name, content_type, size, sha256, storage_key, created_at"
+
+func (r *attachmentRepo) list(ctx context.Context, query string, args ...interface{}) ([]*domain.Attachment, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var attachments []*domain.Attachment
+	for rows.Next() {
+		var a domain.Attachment
+		if err := rows.Scan(&a.ID, &a.NamespaceCode, &a.AppCode, &a.RecordUID, &a.Field, &a.FileName,
+			&a.ContentType, &a.Size, &a.SHA256, &a.StorageKey, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, &a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return attachments, nil
+}
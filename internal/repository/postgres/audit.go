@@ -0,0 +1,196 @@
+package postgres
+
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// auditLockKey — ключ advisory lock, сериализующего добавление записей в цепочку
+const auditLockKey = 7_270_001
+
+type auditRepo struct {
+	db *sql.DB
+}
+
+func NewAuditRepo(db *sql.DB) *auditRepo {
+	return &auditRepo{db: db}
+}
+
+// Append добавляет запись в конец цепочки журнала
+func (r *auditRepo) Append(ctx context.Context, entry *domain.AuditEntry) error {
+	return r.AppendAll(ctx, []*domain.AuditEntry{entry})
+}
+
+// AppendAll добавляет записи в конец цепочки журнала по порядку, захватывая цепочку один раз
+func (r *auditRepo) AppendAll(ctx context.Context, entries []*domain.AuditEntry) error {
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", auditLockKey); err != nil {
+		return fmt.Errorf("failed to lock audit log: %w", err)
+	}
+
+	var prevHash string
+	err = tx.QueryRowContext(ctx, "SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1").Scan(&prevHash)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("failed to read audit chain head: %w", err)
+	}
+
+	for _, entry := range entries {
+		// Postgres хранит время с точностью до микросекунд, хеш должен совпадать при проверке
+		entry.CreatedAt = time.Now().UTC().Truncate(time.Microsecond)
+		entry.PrevHash = prevHash
+		entry.Hash = auditHash(entry)
+
+		var diff interface{}
+		if len(entry.Diff) > 0 {
+			diff = string(entry.Diff)
+		}
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO audit_log (created_at, actor, action, namespace_code, app_code, uid, request_id, source_ip, diff, prev_hash, hash)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+			RETURNING id
+		`, entry.CreatedAt, entry.Actor, entry.Action, entry.NamespaceCode, entry.AppCode, entry.UID,
+			entry.RequestID, entry.SourceIP, diff, entry.PrevHash, entry.Hash).Scan(&entry.ID)
+		if err != nil {
+			return fmt.Errorf("failed to insert audit entry: %w", err)
+		}
+		prevHash = entry.Hash
+	}
+
+	return tx.Commit()
+}
+
+// Find возвращает записи журнала по фильтру, от новых к старым
+func (r *auditRepo) Find(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	var (
+		conditions []string
+		args       []interface{}
+	)
+	add := func(condition string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.Actor != "" {
+		add("actor = $%d", filter.Actor)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if filter.NamespaceCode != "" {
+		add("namespace_code = $%d", filter.NamespaceCode)
+	}
+	if filter.AppCode != "" {
+		add("app_code = $%d", filter.AppCode)
+	}
+	if filter.UID != "" {
+		add("uid = $%d", filter.UID)
+	}
+	if filter.From != nil {
+		add("created_at >= $%d", *filter.From)
+	}
+	if filter.To != nil {
+		add("created_at < $%d", *filter.To)
+	}
+
+	query := "SELECT " + auditColumns + " FROM audit_log"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*domain.AuditEntry
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return entries, nil
+}
+
+// Verify проходит всю цепочку и пересчитывает хеши записей
+func (r *auditRepo) Verify(ctx context.Context) (*domain.AuditVerification, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT "+auditColumns+" FROM audit_log ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit log: %w", err)
+	}
+	defer rows.Close()
+
+	result := &domain.AuditVerification{Valid: true}
+	prevHash := ""
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		result.Checked++
+		if entry.PrevHash != prevHash || entry.Hash != auditHash(entry) {
+			result.Valid = false
+			result.BrokenAt = entry.ID
+			return result, nil
+		}
+		prevHash = entry.Hash
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return result, nil
+}
+
+const auditColumns = "id, created_at, actor, action, namespace_code, app_code, uid, request_id, source_ip, diff, prev_hash, hash"
+
+func scanAuditEntry(rows *sql.Rows) (*domain.AuditEntry, error) {
+	var (
+		entry domain.AuditEntry
+		diff  []byte
+	)
+	err := rows.Scan(&entry.ID, &entry.CreatedAt, &entry.Actor, &entry.Action, &entry.NamespaceCode, &entry.AppCode,
+		&entry.UID, &entry.RequestID, &entry.SourceIP, &diff, &entry.PrevHash, &entry.Hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan audit entry: %w", err)
+	}
+	entry.Diff = diff
+	return &entry, nil
+}
+
+// auditHash вычисляет хеш записи; diff хранится в колонке типа json, которая сохраняет текст как есть
+func auditHash(entry *domain.AuditEntry) string {
+	h := sha256.New()
+	for _, part := range []string{
+		entry.PrevHash,
+		entry.CreatedAt.UTC().Format(time.RFC3339Nano),
+		entry.Actor,
+		entry.Action,
+		entry.NamespaceCode,
+		entry.AppCode,
+		entry.UID,
+		entry.RequestID,
+		entry.SourceIP,
+		string(entry.Diff),
+	} {
+		fmt.Fprintf(h, "%d:%s;", len(part), part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
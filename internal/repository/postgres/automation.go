@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+type automationRepo struct {
+	db *sql.DB
+}
+
+func NewAutomationRepo(db *sql.DB) *automationRepo {
+	return &automationRepo{db: db}
+}
+
+func (r *automationRepo) CreateRule(ctx context.Context, rule *domain.AutomationRule) error {
+	eventsJSON, actionsJSON, err := marshalRule(rule)
+	if err != nil {
+		return err
+	}
+	err = conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO automation_rules (namespace_code, app_code, name, events, condition, actions, enabled)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`, rule.NamespaceCode, rule.AppCode, rule.Name, eventsJSON, rule.Condition, actionsJSON, rule.Enabled).Scan(&rule.ID, &rule.CreatedAt, &rule.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert rule: %w", err)
+	}
+	return nil
+}
+
+// GetRule возвращает правило по ID, nil если его нет
+func (r *automationRepo) GetRule(ctx context.Context, id string) (*domain.AutomationRule, error) {
+	rules, err := r.listRules(ctx, "SELECT "+ruleColumns+" FROM automation_rules WHERE id::text = $1", id)
+	if err != nil || len(rules) == 0 {
+		return nil, err
+	}
+	return rules[0], nil
+}
+
+func (r *automationRepo) ListRules(ctx context.Context, namespace, app string) ([]*domain.AutomationRule, error) {
+	return r.listRules(ctx, "SELECT "+ruleColumns+` FROM automation_rules
+		WHERE namespace_code = $1 AND app_code = $2
+		ORDER BY created_at`, namespace, app)
+}
+
+func (r *automationRepo) UpdateRule(ctx context.Context, rule *domain.AutomationRule) error {
+	eventsJSON, actionsJSON, err := marshalRule(rule)
+	if err != nil {
+		return err
+	}
+	err = conn(ctx, r.db).QueryRowContext(ctx, `
+		UPDATE automation_rules
+		SET name = $1, events = $2, condition = $3, actions = $4, enabled = $5, updated_at = now()
+		WHERE id = $6
+		RETURNING created_at, updated_at
+	`, rule.Name, eventsJSON, rule.Condition, actionsJSON, rule.Enabled, rule.ID).Scan(&rule.CreatedAt, &rule.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("rule %s: %w", rule.ID, domain.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update rule: %w", err)
+	}
+	return nil
+}
+
+func (r *automationRepo) DeleteRule(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM automation_rules WHERE id = $1", id)
+	return err
+}
+
+// SaveRun добавляет запись в журнал выполнения правил
+func (r *automationRepo) SaveRun(ctx context.Context, run *domain.AutomationRun) error {
+	actionsJSON, err := json.Marshal(run.Actions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal actions: %w", err)
+	}
+	err = conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO automation_runs (rule_id, namespace_code, app_code, record_uid, event, status, depth, error, actions, started_at, finished_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING id
+	`, run.RuleID, run.NamespaceCode, run.AppCode, run.RecordUID, run.Event, run.Status, run.Depth, run.Error, actionsJSON, run.StartedAt, run.FinishedAt).Scan(&run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to insert run: %w", err)
+	}
+	return nil
+}
+
+// ListRuns возвращает последние выполнения правила, новые первыми
+func (r *automationRepo) ListRuns(ctx context.Context, ruleID string, limit int) ([]*domain.AutomationRun, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, rule_id, namespace_code, app_code, record_uid, event, status, depth, error, actions, started_at, finished_at
+		FROM automation_runs
+		WHERE rule_id = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`, ruleID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*domain.AutomationRun
+	for rows.Next() {
+		var (
+			run         domain.AutomationRun
+			actionsJSON []byte
+		)
+		if err := rows.Scan(&run.ID, &run.RuleID, &run.NamespaceCode, &run.AppCode, &run.RecordUID, &run.Event, &run.Status,
+			&run.Depth, &run.Error, &actionsJSON, &run.StartedAt, &run.FinishedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(actionsJSON, &run.Actions); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal actions of run %d: %w", run.ID, err)
+		}
+		runs = append(runs, &run)
+	}
+	return runs, rows.Err()
+}
+
+const ruleColumns = "id, namespace_code, app_code, name, events, condition, actions, enabled, created_at, updated_at"
+
+func (r *automationRepo) listRules(ctx context.Context, query string, args ...interface{}) ([]*domain.AutomationRule, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*domain.AutomationRule
+	for rows.Next() {
+		var (
+			rule                    domain.AutomationRule
+			eventsJSON, actionsJSON []byte
+		)
+		if err := rows.Scan(&rule.ID, &rule.NamespaceCode, &rule.AppCode, &rule.Name, &eventsJSON, &rule.Condition,
+			&actionsJSON, &rule.Enabled, &rule.CreatedAt, &rule.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(eventsJSON, &rule.Events); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal events of rule %s: %w", rule.ID, err)
+		}
+		if err := json.Unmarshal(actionsJSON, &rule.Actions); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal actions of rule %s: %w", rule.ID, err)
+		}
+		rules = append(rules, &rule)
+	}
+	return rules, rows.Err()
+}
+
+func marshalRule(rule *domain.AutomationRule) (events, actions []byte, err error) {
+	if events, err = json.Marshal(rule.Events); err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal events: %w", err)
+	}
+	if actions, err = json.Marshal(rule.Actions); err != nil {
+		return nil, nil, fmt.Errorf("failed to marshal actions: %w", err)
+	}
+	return events, actions, nil
+}
@@ -0,0 +1,166 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// queryCanceled — код ошибки Postgres для запроса, отменённого по просьбе клиента
+const queryCanceled = "57014"
+
+// QueryHook вызывается перед каждым запросом: возвращает контекст, в котором запрос
+// выполняется, и функцию, которая получает ошибку запроса и возвращает её же или замену
+type QueryHook func(ctx context.Context, query string) (context.Context, func(err error) error)
+
+// NewConnector возвращает подключение к Postgres, вызывающее hooks вокруг каждого запроса.
+// Встроенные обработчики выполняются ближе всех к драйверу. Запрос, прерванный отменой
+// или истечением контекста, возвращает ошибку, оборачивающую context.Canceled или
+// context.DeadlineExceeded: сам драйвер отменяет выполняющийся запрос на сервере,
+// но сообщает об этом ошибкой Postgres, по которой причину не отличить от прочих.
+// На уровне debug каждый запрос пишется в журнал вместе с полями контекста
+func NewConnector(dsn string, hooks ...QueryHook) (driver.Connector, error) {
+	connector, err := pq.NewConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	hooks = append(append([]QueryHook{}, hooks...), logQuery, cancelQuery)
+	return &hookedConnector{Connector: connector, hooks: hooks}, nil
+}
+
+type hookedConnector struct {
+	driver.Connector
+	hooks []QueryHook
+}
+
+func (c *hookedConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.Connector.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &hookedConn{Conn: conn, hooks: c.hooks}, nil
+}
+
+// hookedConn передаёт вызовы соединению драйвера. Если драйвер не поддерживает
+// метод, возвращается driver.ErrSkip и database/sql выбирает другой путь
+type hookedConn struct {
+	driver.Conn
+	hooks []QueryHook
+}
+
+func (c *hookedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	var result driver.Result
+	err := c.run(ctx, query, func(ctx context.Context) (err error) {
+		result, err = execer.ExecContext(ctx, query, args)
+		return err
+	})
+	return result, err
+}
+
+func (c *hookedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	var rows driver.Rows
+	err := c.run(ctx, query, func(ctx context.Context) (err error) {
+		rows, err = queryer.QueryContext(ctx, query, args)
+		return err
+	})
+	return rows, err
+}
+
+// run выполняет запрос внутри обработчиков: первый из них получает ошибку последним.
+// driver.ErrSkip — не ошибка запроса, обработчики получают вместо неё nil
+func (c *hookedConn) run(ctx context.Context, query string, exec func(ctx context.Context) error) error {
+	done := make([]func(error) error, len(c.hooks))
+	for i, hook := range c.hooks {
+		ctx, done[i] = hook(ctx, query)
+	}
+	err := exec(ctx)
+	skipped := err == driver.ErrSkip
+	if skipped {
+		err = nil
+	}
+	for i := len(done) - 1; i >= 0; i-- {
+		err = done[i](err)
+	}
+	if skipped {
+		return driver.ErrSkip
+	}
+	return err
+}
+
+func (c *hookedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if preparer, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return preparer.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *hookedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if beginner, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return beginner.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *hookedConn) Ping(ctx context.Context) error {
+	if pinger, ok := c.Conn.(driver.Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *hookedConn) ResetSession(ctx context.Context) error {
+	if resetter, ok := c.Conn.(driver.SessionResetter); ok {
+		return resetter.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *hookedConn) IsValid() bool {
+	if validator, ok := c.Conn.(driver.Validator); ok {
+		return validator.IsValid()
+	}
+	return true
+}
+
+// cancelQuery заменяет ошибку запроса, отменённого из-за контекста, на ошибку контекста
+func cancelQuery(ctx context.Context, query string) (context.Context, func(err error) error) {
+	return ctx, func(err error) error {
+		if err == nil || ctx.Err() == nil {
+			return err
+		}
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == queryCanceled {
+			return fmt.Errorf("%w: %v", ctx.Err(), err)
+		}
+		return err
+	}
+}
+
+// logQuery пишет запрос в журнал; namespace, app и uid добавляются из контекста запроса
+func logQuery(ctx context.Context, query string) (context.Context, func(err error) error) {
+	if !slog.Default().Enabled(ctx, slog.LevelDebug) {
+		return ctx, func(err error) error { return err }
+	}
+	started := time.Now()
+	return ctx, func(err error) error {
+		attrs := []slog.Attr{slog.String("query", query), slog.Duration("duration", time.Since(started))}
+		if err != nil {
+			attrs = append(attrs, slog.Any("error", err))
+		}
+		slog.LogAttrs(ctx, slog.LevelDebug, "sql", attrs...)
+		return err
+	}
+}
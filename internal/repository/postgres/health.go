@@ -0,0 +1,36 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+)
+
+type healthRepo struct {
+	db *sql.DB
+}
+
+func NewHealthRepo(db *sql.DB) *healthRepo {
+	return &healthRepo{db: db}
+}
+
+func (r *healthRepo) Ping(ctx context.Context) error {
+	return r.db.PingContext(ctx)
+}
+
+// SchemaVersion возвращает версию схемы, записанную при последней миграции; 0 — миграций не было
+func (r *healthRepo) SchemaVersion(ctx context.Context) (int, error) {
+	var exists bool
+	if err := r.db.QueryRowContext(ctx, "SELECT to_regclass('schema_version') IS NOT NULL").Scan(&exists); err != nil {
+		return 0, err
+	}
+	if !exists {
+		return 0, nil
+	}
+	var version int
+	err := r.db.QueryRowContext(ctx, "SELECT version FROM schema_version").Scan(&version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	return version, err
+}
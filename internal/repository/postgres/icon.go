@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type iconRepo struct {
+	db *sql.DB
+}
+
+func NewIconRepo(db *sql.DB) *iconRepo {
+	return &iconRepo{db: db}
+}
+
+func (r *iconRepo) Create(ctx context.Context, icon *domain.Icon) error {
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO icons (id, content_type, size, sha256, width, height)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at
+	`, icon.ID, icon.ContentType, icon.Size, icon.SHA256, icon.Width, icon.Height).Scan(&icon.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert icon: %w", err)
+	}
+	return nil
+}
+
+// GetByID возвращает иконку, nil если её нет
+func (r *iconRepo) GetByID(ctx context.Context, id string) (*domain.Icon, error) {
+	var icon domain.Icon
+	err := conn(ctx, r.db).QueryRowContext(ctx, "SELECT "+iconColumns+" FROM icons WHERE id::text = $1", id).
+		Scan(&icon.ID, &icon.ContentType, &icon.Size, &icon.SHA256, &icon.Width, &icon.Height, &icon.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query icon: %w", err)
+	}
+	return &icon, nil
+}
+
+func (r *iconRepo) Delete(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM icons WHERE id::text = $1", id)
+	return err
+}
+
+// FindUnreferenced возвращает иконки старше before, на которые не ссылается
+// ни одно приложение и ни один namespace
+func (r *iconRepo) FindUnreferenced(ctx context.Context, before time.Time, limit int) ([]*domain.Icon, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT "+iconColumns+` FROM icons i
+		WHERE i.created_at < $1
+		AND NOT EXISTS (SELECT 1 FROM apps a WHERE a.icon = i.id::text)
+		AND NOT EXISTS (SELECT 1 FROM namespaces n WHERE n.icon = i.id::text)
+		LIMIT $2`, before, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query icons: %w", err)
+	}
+	defer rows.Close()
+
+	var icons []*domain.Icon
+	for rows.Next() {
+		var icon domain.Icon
+		if err := rows.Scan(&icon.ID, &icon.ContentType, &icon.Size, &icon.SHA256, &icon.Width, &icon.Height, &icon.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan icon: %w", err)
+		}
+		icons = append(icons, &icon)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+	return icons, nil
+}
+
+const iconColumns = "id, content_type, size, sha256, width, height, created_at"
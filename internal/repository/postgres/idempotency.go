@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+type idempotencyRepo struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepo(db *sql.DB) *idempotencyRepo {
+	return &idempotencyRepo{db: db}
+}
+
+// interval переводит длительность в параметр запроса типа interval
+func interval(d time.Duration) string {
+	return fmt.Sprintf("%d milliseconds", d.Milliseconds())
+}
+
+// Reserve закрепляет ключ за запросом с хешем hash и возвращает nil, если ключ свободен,
+// его срок ttl истёк или выполнявший его запрос не завершился за lock. Иначе возвращает
+// запрос, за которым ключ уже закреплён. Закрепление — один запрос, поэтому из
+// параллельных запросов с одним ключом выполняется только один
+func (r *idempotencyRepo) Reserve(ctx context.Context, key, hash string, ttl, lock time.Duration) (*domain.IdempotencyRecord, error) {
+	// ключ мог удалить параллельный запрос между вставкой и чтением, тогда попытка повторяется
+	for attempt := 0; attempt < 3; attempt++ {
+		var reserved bool
+		err := r.db.QueryRowContext(ctx, `
+			INSERT INTO idempotency_keys AS k (key, request_hash) VALUES ($1, $2)
+			ON CONFLICT (key) DO UPDATE SET
+				request_hash = EXCLUDED.request_hash, response = NULL, completed_at = NULL, created_at = now()
+			WHERE (k.completed_at IS NOT NULL AND k.created_at < now() - $3::interval)
+				OR (k.completed_at IS NULL AND k.created_at < now() - $4::interval)
+			RETURNING true
+		`, key, hash, interval(ttl), interval(lock)).Scan(&reserved)
+		if err == nil {
+			return nil, nil
+		}
+		if !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+
+		var (
+			record   domain.IdempotencyRecord
+			response []byte
+		)
+		err = r.db.QueryRowContext(ctx, "SELECT request_hash, response FROM idempotency_keys WHERE key = $1", key).Scan(&record.RequestHash, &response)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if response != nil {
+			if err := json.Unmarshal(response, &record.Response); err != nil {
+				return nil, err
+			}
+		}
+		return &record, nil
+	}
+	return nil, fmt.Errorf("%w: idempotency key %s is being released concurrently", domain.ErrConflict, key)
+}
+
+// Complete сохраняет ответ на запрос, за которым закреплён ключ
+func (r *idempotencyRepo) Complete(ctx context.Context, key string, response domain.IdempotentResponse) error {
+	responseJSON, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, "UPDATE idempotency_keys SET response = $2, completed_at = now() WHERE key = $1 AND completed_at IS NULL", key, responseJSON)
+	return err
+}
+
+// Release освобождает ключ запроса, который не завершился, чтобы повтор выполнился заново
+func (r *idempotencyRepo) Release(ctx context.Context, key string) error {
+	_, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE key = $1 AND completed_at IS NULL", key)
+	return err
+}
+
+// Purge удаляет ключи старше ttl
+func (r *idempotencyRepo) Purge(ctx context.Context, ttl time.Duration) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE created_at < now() - $1::interval", interval(ttl))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
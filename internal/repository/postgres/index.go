@@ -0,0 +1,198 @@
+package postgres
+
+import (
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/metrics"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// managedIndexPattern выделяет индексы, созданные по схеме приложения
+const managedIndexPattern = "^(ux|ix|gx)_"
+
+type indexRepo struct {
+	db *sql.DB
+}
+
+func NewIndexRepo(db *sql.DB) *indexRepo {
+	return &indexRepo{db: db}
+}
+
+// SyncIndexes приводит индексы таблицы записей к specs: недостающие и недостроенные
+// создаются, лишние удаляются. Всё выполняется CONCURRENTLY, чтобы не блокировать запись;
+// такие команды нельзя выполнять в транзакции, поэтому каждая идёт отдельным запросом
+func (r *indexRepo) SyncIndexes(ctx context.Context, namespace, table string, specs []domain.IndexSpec) error {
+	existing, err := r.managed(ctx, namespace, table)
+	if err != nil {
+		return err
+	}
+
+	wanted := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		wanted[spec.Name] = true
+		valid, ok := existing[spec.Name]
+		if ok && valid {
+			continue
+		}
+		if ok {
+			if err := r.drop(ctx, namespace, spec.Name); err != nil {
+				return err
+			}
+		}
+		if err := r.create(ctx, namespace, table, spec); err != nil {
+			return err
+		}
+	}
+
+	for name := range existing {
+		if !wanted[name] {
+			if err := r.drop(ctx, namespace, name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// HasDuplicates сообщает, есть ли в таблице записи, которые нарушили бы уникальный индекс spec
+func (r *indexRepo) HasDuplicates(ctx context.Context, namespace, table string, spec domain.IndexSpec) (bool, error) {
+	columns := make([]string, len(spec.Fields))
+	predicate := []string{"deleted_at IS NULL"}
+	for i, field := range spec.Fields {
+		columns[i] = fmt.Sprintf("(data->'%s')", field)
+		predicate = append(predicate, fmt.Sprintf("jsonb_typeof(data->'%s') <> 'null'", field))
+	}
+	var exists bool
+	err := r.db.QueryRowContext(ctx, fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s.%s WHERE %s GROUP BY %s HAVING count(*) > 1)",
+		namespace, table, strings.Join(predicate, " AND "), strings.Join(columns, ", "))).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check duplicates for index %s: %w", spec.Name, err)
+	}
+	return exists, nil
+}
+
+// SetIndexError сохраняет у приложения ошибку построения индексов; пустая строка её снимает
+func (r *indexRepo) SetIndexError(ctx context.Context, namespace, table, message string) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE apps SET index_error = NULLIF($3, '')
+		WHERE namespace_code = $1 AND code = $2 AND index_error IS DISTINCT FROM NULLIF($3, '')
+	`, namespace, table, message)
+	return err
+}
+
+func (r *indexRepo) create(ctx context.Context, namespace, table string, spec domain.IndexSpec) error {
+	var query string
+	if spec.Gin {
+		query = fmt.Sprintf("CREATE INDEX CONCURRENTLY %s ON %s.%s USING gin ((data->'%s') jsonb_path_ops) WHERE deleted_at IS NULL",
+			spec.Name, namespace, table, spec.Fields[0])
+	} else {
+		columns := make([]string, len(spec.Fields))
+		predicate := []string{"deleted_at IS NULL"}
+		for i, field := range spec.Fields {
+			columns[i] = fmt.Sprintf("(data->'%s')", field)
+			if spec.Unique {
+				// JSON null, как и отсутствующее поле, не участвует в уникальности
+				predicate = append(predicate, fmt.Sprintf("jsonb_typeof(data->'%s') <> 'null'", field))
+			}
+		}
+		unique := ""
+		if spec.Unique {
+			unique = "UNIQUE "
+		}
+		query = fmt.Sprintf("CREATE %sINDEX CONCURRENTLY %s ON %s.%s (%s) WHERE %s",
+			unique, spec.Name, namespace, table, strings.Join(columns, ", "), strings.Join(predicate, " AND "))
+	}
+
+	_, err := r.db.ExecContext(ctx, query)
+	metrics.DDL("create_index", err)
+	if err != nil {
+		// неудачное построение оставляет недействительный индекс, который мешает повторной попытке
+		if dropErr := r.drop(ctx, namespace, spec.Name); dropErr != nil {
+			return fmt.Errorf("failed to drop invalid index %s: %w", spec.Name, dropErr)
+		}
+		if _, ok := uniqueViolation(err); ok {
+			return &domain.DuplicateError{Index: spec.Name}
+		}
+		return fmt.Errorf("failed to create index %s: %w", spec.Name, err)
+	}
+	return nil
+}
+
+func (r *indexRepo) drop(ctx context.Context, namespace, name string) error {
+	_, err := r.db.ExecContext(ctx, fmt.Sprintf("DROP INDEX CONCURRENTLY IF EXISTS %s.%s", namespace, name))
+	metrics.DDL("drop_index", err)
+	if err != nil {
+		return fmt.Errorf("failed to drop index %s: %w", name, err)
+	}
+	return nil
+}
+
+// managed возвращает индексы таблицы, созданные по схеме, и признак завершённого построения
+func (r *indexRepo) managed(ctx context.Context, namespace, table string) (map[string]bool, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT c.relname, i.indisvalid
+		FROM pg_index i
+		JOIN pg_class c ON c.oid = i.indexrelid
+		JOIN pg_class t ON t.oid = i.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		WHERE n.nspname = lower($1) AND t.relname = lower($2) AND c.relname ~ $3
+	`, namespace, table, managedIndexPattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indexes: %w", err)
+	}
+	defer rows.Close()
+
+	indexes := make(map[string]bool)
+	for rows.Next() {
+		var (
+			name  string
+			valid bool
+		)
+		if err := rows.Scan(&name, &valid); err != nil {
+			return nil, err
+		}
+		indexes[name] = valid
+	}
+	return indexes, rows.Err()
+}
+
+// ListIndexes возвращает все индексы таблицы записей со статистикой из pg_stat_user_indexes
+func (r *indexRepo) ListIndexes(ctx context.Context, namespace, table string) ([]*domain.IndexInfo, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT s.indexrelname, pg_get_indexdef(s.indexrelid), i.indisunique, i.indisvalid, s.indexrelname ~ $3,
+			pg_relation_size(s.indexrelid), s.idx_scan, s.idx_tup_read, s.idx_tup_fetch
+		FROM pg_stat_user_indexes s
+		JOIN pg_index i ON i.indexrelid = s.indexrelid
+		WHERE s.schemaname = lower($1) AND s.relname = lower($2)
+		ORDER BY s.indexrelname
+	`, namespace, table, managedIndexPattern)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indexes: %w", err)
+	}
+	defer rows.Close()
+
+	var indexes []*domain.IndexInfo
+	for rows.Next() {
+		var index domain.IndexInfo
+		if err := rows.Scan(&index.Name, &index.Definition, &index.Unique, &index.Valid, &index.Managed,
+			&index.SizeBytes, &index.Scans, &index.TuplesRead, &index.TuplesFetched); err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, &index)
+	}
+	return indexes, rows.Err()
+}
+
+// uniqueViolation сообщает, нарушена ли уникальность, и возвращает имя индекса
+func uniqueViolation(err error) (string, bool) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return pqErr.Constraint, true
+	}
+	return "", false
+}
@@ -0,0 +1,112 @@
+package postgres
+
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type limitsRepo struct {
+	db *sql.DB
+}
+
+func NewLimitsRepo(db *sql.DB) *limitsRepo {
+	return &limitsRepo{db: db}
+}
+
+// SetLimits сохраняет собственные ограничения namespace; nil возвращает значения по умолчанию
+func (r *limitsRepo) SetLimits(ctx context.Context, namespace string, limits *domain.Limits) error {
+	var limitsJSON []byte
+	if limits != nil {
+		var err error
+		if limitsJSON, err = json.Marshal(limits); err != nil {
+			return err
+		}
+	}
+	result, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE namespaces SET limits = $1 WHERE code = $2 AND deleted_at IS NULL", limitsJSON, namespace)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("namespace %s: %w", namespace, domain.ErrNotFound)
+	}
+	return nil
+}
+
+// CountApps возвращает число приложений namespace, включая находящиеся в корзине:
+// их таблицы остаются в базе до очистки. Строка namespace блокируется до конца
+// транзакции, поэтому параллельные создания приложений считают по очереди
+func (r *limitsRepo) CountApps(ctx context.Context, namespace string) (int64, error) {
+	q := conn(ctx, r.db)
+	if _, err := q.ExecContext(ctx, "SELECT 1 FROM namespaces WHERE code = $1 FOR UPDATE", namespace); err != nil {
+		return 0, fmt.Errorf("failed to lock namespace: %w", err)
+	}
+	var count int64
+	err := q.QueryRowContext(ctx, "SELECT count(*) FROM apps WHERE namespace_code = $1", namespace).Scan(&count)
+	return count, err
+}
+
+// CountRecords считает записи приложения вне корзины, но не больше limit:
+// для проверки квоты точное число сверх неё не нужно, а полный подсчёт большой таблицы дорог.
+// Параллельные создания записей считают по очереди: до конца транзакции берётся advisory lock
+// приложения из двух ключей, который не пересекается с ключом журнала. Строку приложения
+// он не трогает, поэтому правки схемы и чтение не ждут проверки квоты
+func (r *limitsRepo) CountRecords(ctx context.Context, namespace, table string, limit int64) (int64, error) {
+	q := conn(ctx, r.db)
+	if _, err := q.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1), hashtext($2))", namespace, table); err != nil {
+		return 0, fmt.Errorf("failed to lock app: %w", err)
+	}
+	var count int64
+	query := fmt.Sprintf("SELECT count(*) FROM (SELECT 1 FROM %s.%s WHERE deleted_at IS NULL LIMIT $1) t", namespace, table)
+	err := q.QueryRowContext(ctx, query, limit).Scan(&count)
+	return count, err
+}
+
+// rateLimitRepo хранит корзины токенов в Postgres, чтобы ограничения частоты
+// действовали на все экземпляры сервиса вместе
+type rateLimitRepo struct {
+	db *sql.DB
+}
+
+func NewRateLimitRepo(db *sql.DB) *rateLimitRepo {
+	return &rateLimitRepo{db: db}
+}
+
+// Take пополняет корзину key за прошедшее время и списывает из неё токен, если он есть.
+// Всё делается одним запросом, поэтому параллельные запросы экземпляров не теряют списаний
+func (r *rateLimitRepo) Take(ctx context.Context, key string, limit int, period time.Duration) (domain.RateDecision, error) {
+	var (
+		tokens  float64
+		allowed bool
+	)
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+		VALUES ($1, $2::float8 - 1, true, now())
+		ON CONFLICT (key) DO UPDATE SET
+			allowed = LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * $3::float8) >= 1,
+			tokens = LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * $3::float8)
+				- CASE WHEN LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM now() - b.updated_at) * $3::float8) >= 1 THEN 1 ELSE 0 END,
+			updated_at = now()
+		RETURNING tokens, allowed
+	`, key, limit, float64(limit)/period.Seconds()).Scan(&tokens, &allowed)
+	if err != nil {
+		return domain.RateDecision{}, err
+	}
+	return domain.NewRateDecision(allowed, tokens, limit, period), nil
+}
+
+// Purge удаляет корзины, не менявшиеся дольше idle: они уже наполнились и ничем не отличаются от новых
+func (r *rateLimitRepo) Purge(ctx context.Context, idle time.Duration) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM rate_limit_buckets WHERE updated_at < now() - $1::interval", fmt.Sprintf("%d milliseconds", idle.Milliseconds()))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// recordStatsCollector отдаёт число записей в таблицах приложений. Точный count(*)
+// по всем таблицам на каждый сбор слишком дорог, поэтому берётся оценка из статистики Postgres
+type recordStatsCollector struct {
+	db      *sql.DB
+	records *prometheus.Desc
+}
+
+func NewRecordStatsCollector(db *sql.DB) *recordStatsCollector {
+	return &recordStatsCollector{
+		db: db,
+		records: prometheus.NewDesc("backend_app_records",
+			"Оценка числа записей приложения, включая записи в корзине.",
+			[]string{"namespace", "app"}, nil),
+	}
+}
+
+func (c *recordStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.records
+}
+
+func (c *recordStatsCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	rows, err := c.db.QueryContext(ctx, `
+		SELECT a.namespace_code, a.code, s.n_live_tup
+		FROM apps a
+		JOIN pg_stat_user_tables s ON s.schemaname = lower(a.namespace_code) AND s.relname = lower(a.code)
+		WHERE a.deleted_at IS NULL
+	`)
+	if err != nil {
+		slog.ErrorContext(ctx, "metrics: failed to collect record counts", "error", err)
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			namespace, app string
+			count          int64
+		)
+		if err := rows.Scan(&namespace, &app, &count); err != nil {
+			slog.ErrorContext(ctx, "metrics: failed to scan record counts", "error", err)
+			return
+		}
+		ch <- prometheus.MustNewConstMetric(c.records, prometheus.GaugeValue, float64(count), namespace, app)
+	}
+}
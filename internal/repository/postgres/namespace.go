@@ -2,8 +2,12 @@ package postgres
 
 import (
 	"app/backendv1/internal/domain"
+	"app/backendv1/internal/metrics"
+	"context"
 	"database/sql"
-	"errors"
+	"encoding/json"
+	"fmt"
+	"time"
 )
 
 type namespaceRepo struct {
@@ -14,48 +18,178 @@ func NewNamespaceRepo(db *sql.DB) *namespaceRepo {
 	return &namespaceRepo{db: db}
 }
 
-func (r *namespaceRepo) Create(namespace *domain.Namespace) error {
-	_, err := r.db.Exec("CREATE SCHEMA " + namespace.Code)
+func (r *namespaceRepo) Create(ctx context.Context, namespace *domain.Namespace) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "CREATE SCHEMA "+namespace.Code)
+	metrics.DDL("create_schema", err)
 	if err != nil {
 		return err
 	}
-	_, err = r.db.Exec("INSERT INTO namespaces (code, name) VALUES ($1, $2)", namespace.Code, namespace.Name)
+	locales, err := json.Marshal(namespaceLocales(namespace))
+	if err != nil {
+		return err
+	}
+	_, err = conn(ctx, r.db).ExecContext(ctx, "INSERT INTO namespaces (code, name, locales) VALUES ($1, $2, $3)", namespace.Code, namespace.Name, locales)
 	return err
 }
 
-func (r *namespaceRepo) GetAll() ([]domain.Namespace, error) {
-	rows, err := r.db.Query("SELECT code, name FROM namespaces")
-	if err != nil {
+func (r *namespaceRepo) GetAll(ctx context.Context) ([]domain.Namespace, error) {
+	return r.list(ctx, "SELECT "+namespaceColumns+" FROM namespaces WHERE deleted_at IS NULL")
+}
+
+func (r *namespaceRepo) GetByCode(ctx context.Context, code string) (*domain.Namespace, error) {
+	namespaces, err := r.list(ctx, "SELECT "+namespaceColumns+" FROM namespaces WHERE code = $1 AND deleted_at IS NULL", code)
+	if err != nil || len(namespaces) == 0 {
 		return nil, err
 	}
-	defer rows.Close()
+	return &namespaces[0], nil
+}
 
-	var namespaces []domain.Namespace
+func (r *namespaceRepo) Update(ctx context.Context, code string, namespace *domain.Namespace) error {
+	locales, err := json.Marshal(namespaceLocales(namespace))
+	if err != nil {
+		return err
+	}
+	_, err = conn(ctx, r.db).ExecContext(ctx, "UPDATE namespaces SET name = $1, locales = $2 WHERE code = $3 AND deleted_at IS NULL", namespace.Name, locales, code)
+	return err
+}
+
+// SetIcon меняет ссылку на иконку namespace
+func (r *namespaceRepo) SetIcon(ctx context.Context, code, iconID string) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE namespaces SET icon = NULLIF($1, '') WHERE code = $2 AND deleted_at IS NULL", iconID, code)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("namespace %s: %w", code, domain.ErrNotFound)
+	}
+	return nil
+}
+
+// Delete перемещает namespace в корзину, схема остаётся до очистки
+func (r *namespaceRepo) Delete(ctx context.Context, code string) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE namespaces SET deleted_at = now() WHERE code = $1 AND deleted_at IS NULL", code)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("namespace %s: %w", code, domain.ErrNotFound)
+	}
+	return nil
+}
+
+// GetTrash возвращает namespace, находящиеся в корзине
+func (r *namespaceRepo) GetTrash(ctx context.Context) ([]domain.Namespace, error) {
+	return r.list(ctx, "SELECT "+namespaceColumns+" FROM namespaces WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC")
+}
+
+// Restore возвращает namespace из корзины
+func (r *namespaceRepo) Restore(ctx context.Context, code string) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE namespaces SET deleted_at = NULL WHERE code = $1 AND deleted_at IS NOT NULL", code)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("namespace %s in trash: %w", code, domain.ErrNotFound)
+	}
+	return nil
+}
+
+// PurgeDeleted окончательно удаляет namespace, пролежавшие в корзине дольше before,
+// вместе со схемой и всеми таблицами приложений
+func (r *namespaceRepo) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, "SELECT code FROM namespaces WHERE deleted_at IS NOT NULL AND deleted_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+	var codes []string
 	for rows.Next() {
-		var namespace domain.Namespace
-		if err := rows.Scan(&namespace.Code, &namespace.Name); err != nil {
-			return nil, err
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			rows.Close()
+			return 0, err
 		}
-		namespaces = append(namespaces, namespace)
+		codes = append(codes, code)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
 	}
-	return namespaces, nil
-}
 
-func (r *namespaceRepo) GetByCode(code string) (*domain.Namespace, error) {
-	var namespace domain.Namespace
-	err := r.db.QueryRow("SELECT code, name FROM namespaces WHERE code = $1", code).Scan(&namespace.Code, &namespace.Name)
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil, nil
+	var purged int64
+	for _, code := range codes {
+		tx, err := begin(ctx, r.db)
+		if err != nil {
+			return purged, err
+		}
+		_, err = tx.ExecContext(ctx, "DROP SCHEMA IF EXISTS "+code+" CASCADE")
+		metrics.DDL("drop_schema", err)
+		if err != nil {
+			tx.Rollback()
+			return purged, fmt.Errorf("failed to drop schema %s: %w", code, err)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM namespaces WHERE code = $1", code); err != nil {
+			tx.Rollback()
+			return purged, fmt.Errorf("failed to delete namespace %s: %w", code, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return purged, err
+		}
+		purged++
 	}
-	return &namespace, err
+	return purged, nil
 }
 
-func (r *namespaceRepo) Update(code string, namespace *domain.Namespace) error {
-	_, err := r.db.Exec("UPDATE namespaces SET name = $1 WHERE code = $2", namespace.Name, code)
-	return err
+const namespaceColumns = "code, name, COALESCE(icon, ''), locales, limits, deleted_at"
+
+// namespaceLocales возвращает цепочку локалей для записи в столбец NOT NULL
+func namespaceLocales(namespace *domain.Namespace) []string {
+	if namespace.Locales == nil {
+		return []string{}
+	}
+	return namespace.Locales
 }
 
-func (r *namespaceRepo) Delete(code string) error {
-	_, err := r.db.Exec("DELETE FROM namespaces WHERE code = $1", code)
-	return err
+func (r *namespaceRepo) list(ctx context.Context, query string, args ...interface{}) ([]domain.Namespace, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var namespaces []domain.Namespace
+	for rows.Next() {
+		var (
+			namespace   domain.Namespace
+			localesJSON []byte
+			limitsJSON  []byte
+		)
+		if err := rows.Scan(&namespace.Code, &namespace.Name, &namespace.Icon, &localesJSON, &limitsJSON, &namespace.DeletedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(localesJSON, &namespace.Locales); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal locales of namespace %s: %w", namespace.Code, err)
+		}
+		if limitsJSON != nil {
+			if err := json.Unmarshal(limitsJSON, &namespace.Limits); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal limits of namespace %s: %w", namespace.Code, err)
+			}
+		}
+		namespaces = append(namespaces, namespace)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return namespaces, nil
 }
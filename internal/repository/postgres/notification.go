@@ -0,0 +1,68 @@
+package postgres
+
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+type notificationRepo struct {
+	db *sql.DB
+}
+
+func NewNotificationRepo(db *sql.DB) *notificationRepo {
+	return &notificationRepo{db: db}
+}
+
+func (r *notificationRepo) Create(ctx context.Context, n *domain.Notification) error {
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO notifications (recipient, message, namespace_code, app_code, record_uid, rule_id)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at
+	`, n.Recipient, n.Message, n.NamespaceCode, n.AppCode, n.RecordUID, n.RuleID).Scan(&n.ID, &n.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert notification: %w", err)
+	}
+	return nil
+}
+
+// ListByRecipient возвращает уведомления пользователя, новые первыми
+func (r *notificationRepo) ListByRecipient(ctx context.Context, recipient string, unreadOnly bool, limit int) ([]*domain.Notification, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, recipient, message, namespace_code, app_code, record_uid, rule_id, created_at, read_at
+		FROM notifications
+		WHERE recipient = $1 AND (NOT $2 OR read_at IS NULL)
+		ORDER BY id DESC
+		LIMIT $3
+	`, recipient, unreadOnly, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notifications: %w", err)
+	}
+	defer rows.Close()
+
+	var notifications []*domain.Notification
+	for rows.Next() {
+		var n domain.Notification
+		if err := rows.Scan(&n.ID, &n.Recipient, &n.Message, &n.NamespaceCode, &n.AppCode, &n.RecordUID, &n.RuleID, &n.CreatedAt, &n.ReadAt); err != nil {
+			return nil, err
+		}
+		notifications = append(notifications, &n)
+	}
+	return notifications, rows.Err()
+}
+
+func (r *notificationRepo) MarkRead(ctx context.Context, id int64, recipient string) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, "UPDATE notifications SET read_at = COALESCE(read_at, now()) WHERE id = $1 AND recipient = $2", id, recipient)
+	if err != nil {
+		return err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("notification %d: %w", id, domain.ErrNotFound)
+	}
+	return nil
+}
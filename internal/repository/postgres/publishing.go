@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// publishingRepo хранит опубликованную версию записи в published_data рядом с черновиком в data
+type publishingRepo struct {
+	db *sql.DB
+}
+
+func NewPublishingRepo(db *sql.DB) *publishingRepo {
+	return &publishingRepo{db: db}
+}
+
+// Publish копирует черновик в опубликованную версию и снимает запланированную публикацию
+func (r *publishingRepo) Publish(ctx context.Context, namespace, table, uid string) error {
+	query := fmt.Sprintf(`
+		UPDATE %s.%s
+		SET published_data = data, published_at = now(), published_by = $1, publish_at = NULL
+		WHERE uid = $2 AND deleted_at IS NULL
+	`, namespace, table)
+
+	return r.exec(ctx, query, uid, actor(ctx), uid)
+}
+
+// Unpublish снимает запись с публикации; черновик остаётся без изменений
+func (r *publishingRepo) Unpublish(ctx context.Context, namespace, table, uid string) error {
+	query := fmt.Sprintf(`
+		UPDATE %s.%s
+		SET published_data = NULL, published_at = NULL, published_by = NULL, unpublish_at = NULL
+		WHERE uid = $1 AND deleted_at IS NULL
+	`, namespace, table)
+
+	return r.exec(ctx, query, uid, uid)
+}
+
+// SchedulePublish планирует публикацию черновика на время at
+func (r *publishingRepo) SchedulePublish(ctx context.Context, namespace, table, uid string, at time.Time) error {
+	query := fmt.Sprintf("UPDATE %s.%s SET publish_at = $1 WHERE uid = $2 AND deleted_at IS NULL", namespace, table)
+	return r.exec(ctx, query, uid, at, uid)
+}
+
+// ScheduleUnpublish планирует снятие записи с публикации на время at
+func (r *publishingRepo) ScheduleUnpublish(ctx context.Context, namespace, table, uid string, at time.Time) error {
+	query := fmt.Sprintf("UPDATE %s.%s SET unpublish_at = $1 WHERE uid = $2 AND deleted_at IS NULL", namespace, table)
+	return r.exec(ctx, query, uid, at, uid)
+}
+
+// CancelSchedule отменяет запланированные публикацию и снятие с публикации
+func (r *publishingRepo) CancelSchedule(ctx context.Context, namespace, table, uid string) error {
+	query := fmt.Sprintf("UPDATE %s.%s SET publish_at = NULL, unpublish_at = NULL WHERE uid = $1 AND deleted_at IS NULL", namespace, table)
+	return r.exec(ctx, query, uid, uid)
+}
+
+func (r *publishingRepo) GetPublished(ctx context.Context, namespace, table, uid string) (*domain.AppData, error) {
+	return r.getPublished(ctx, namespace, table, "uid", uid)
+}
+
+func (r *publishingRepo) GetPublishedByNumber(ctx context.Context, namespace, table, number string) (*domain.AppData, error) {
+	return r.getPublished(ctx, namespace, table, "number", number)
+}
+
+func (r *publishingRepo) getPublished(ctx context.Context, namespace, table, column, value string) (*domain.AppData, error) {
+	query := fmt.Sprintf(`
+		SELECT %s
+		FROM %s.%s
+		WHERE %s = $1 AND deleted_at IS NULL AND published_data IS NOT NULL
+	`, selectColumns("published_data"), namespace, table, column)
+
+	records, err := (&appDataRepo{db: r.db}).list(ctx, query, value)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("published record with %s %s: %w", column, value, domain.ErrNotFound)
+	}
+	return records[0], nil
+}
+
+// PublishDue публикует записи, время публикации которых наступило к now, и возвращает их uid
+func (r *publishingRepo) PublishDue(ctx context.Context, namespace, table string, now time.Time) ([]string, error) {
+	query := fmt.Sprintf(`
+		UPDATE %s.%s
+		SET published_data = data, published_at = publish_at, published_by = $1, publish_at = NULL
+		WHERE publish_at <= $2 AND deleted_at IS NULL
+		RETURNING uid
+	`, namespace, table)
+
+	return r.uids(ctx, query, actor(ctx), now)
+}
+
+// UnpublishDue снимает с публикации записи, время снятия которых наступило к now
+func (r *publishingRepo) UnpublishDue(ctx context.Context, namespace, table string, now time.Time) ([]string, error) {
+	query := fmt.Sprintf(`
+		UPDATE %s.%s
+		SET published_data = NULL, published_at = NULL, published_by = NULL, unpublish_at = NULL
+		WHERE unpublish_at <= $1 AND deleted_at IS NULL
+		RETURNING uid
+	`, namespace, table)
+
+	return r.uids(ctx, query, now)
+}
+
+func (r *publishingRepo) exec(ctx context.Context, query, uid string, args ...interface{}) error {
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update publication: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("record with uid %s: %w", uid, domain.ErrNotFound)
+	}
+	return nil
+}
+
+func (r *publishingRepo) uids(ctx context.Context, query string, args ...interface{}) ([]string, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply scheduled publication: %w", err)
+	}
+	defer rows.Close()
+
+	var uids []string
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return nil, fmt.Errorf("failed to scan uid: %w", err)
+		}
+		uids = append(uids, uid)
+	}
+	return uids, rows.Err()
+}
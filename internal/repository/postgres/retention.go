@@ -0,0 +1,118 @@
+package postgres
+
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// TimestampFunction создаёт try_timestamptz: приведение текста к timestamptz, которое на
+// невозможной дате, например 2026-02-30, возвращает NULL вместо ошибки всего запроса
+const TimestampFunction = `
+	CREATE OR REPLACE FUNCTION try_timestamptz(value TEXT) RETURNS TIMESTAMPTZ AS $$
+	BEGIN
+		RETURN value::timestamptz;
+	EXCEPTION WHEN others THEN
+		RETURN NULL;
+	END;
+	$$ LANGUAGE plpgsql STABLE;`
+
+type retentionRepo struct {
+	db *sql.DB
+}
+
+func NewRetentionRepo(db *sql.DB) *retentionRepo {
+	return &retentionRepo{db: db}
+}
+
+// ApplyRetention удаляет или переносит в archived_records до limit записей старше before
+// и возвращает их uid. SKIP LOCKED пропускает записи, которые сейчас кто-то изменяет
+func (r *retentionRepo) ApplyRetention(ctx context.Context, namespace, table string, policy domain.RetentionPolicy, before time.Time, limit int) ([]string, error) {
+	age, args := retentionAge(policy, before, limit)
+	expired := fmt.Sprintf(`
+		SELECT uid FROM %s.%s
+		WHERE NOT legal_hold AND %s < $1
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED
+	`, namespace, table, age)
+
+	var query string
+	if policy.Mode == domain.RetentionArchive {
+		args = append(args, namespace, table)
+		query = fmt.Sprintf(`
+			WITH moved AS (
+				DELETE FROM %s.%s WHERE uid IN (%s)
+				RETURNING uid, data, created_at
+			)
+			INSERT INTO archived_records (namespace_code, app_code, uid, data, created_at)
+			SELECT $%d, $%d, uid, data, created_at FROM moved
+			ON CONFLICT (namespace_code, app_code, uid) DO UPDATE SET data = EXCLUDED.data, archived_at = now()
+			RETURNING uid
+		`, namespace, table, expired, len(args)-1, len(args))
+	} else {
+		query = fmt.Sprintf("DELETE FROM %s.%s WHERE uid IN (%s) RETURNING uid", namespace, table, expired)
+	}
+
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply retention: %w", err)
+	}
+	defer rows.Close()
+
+	var uids []string
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			return nil, fmt.Errorf("failed to scan removed uid: %w", err)
+		}
+		uids = append(uids, uid)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to apply retention: %w", err)
+	}
+	return uids, nil
+}
+
+func (r *retentionRepo) CountHeld(ctx context.Context, namespace, table string, policy domain.RetentionPolicy, before time.Time) (int64, error) {
+	age, args := retentionAge(policy, before)
+	query := fmt.Sprintf("SELECT count(*) FROM %s.%s WHERE legal_hold AND %s < $1", namespace, table, age)
+
+	var n int64
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("failed to count held records: %w", err)
+	}
+	return n, nil
+}
+
+// SetLegalHold ставит запись под удержание или снимает его, в том числе для записи в корзине
+func (r *retentionRepo) SetLegalHold(ctx context.Context, namespace, table, uid string, hold bool) error {
+	query := fmt.Sprintf("UPDATE %s.%s SET legal_hold = $1 WHERE uid = $2", namespace, table)
+
+	result, err := conn(ctx, r.db).ExecContext(ctx, query, hold, uid)
+	if err != nil {
+		return fmt.Errorf("failed to set legal hold: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("record with uid %s: %w", uid, domain.ErrNotFound)
+	}
+	return nil
+}
+
+// retentionAge возвращает выражение возраста записи и аргументы запроса, начиная с before ($1).
+// Значения поля, не похожие на дату или не разбираемые как дата, дают NULL, и такие записи
+// не удаляются; ключевые слова вроде now отсекает проверка формата
+func retentionAge(policy domain.RetentionPolicy, before time.Time, extra ...interface{}) (string, []interface{}) {
+	args := append([]interface{}{before}, extra...)
+	if policy.Field == "" {
+		return "created_at", args
+	}
+	args = append(args, policy.Field)
+	value := fmt.Sprintf("data->>($%d::text)", len(args))
+	return fmt.Sprintf(`(CASE WHEN %s ~ '^\d{4}-\d{2}-\d{2}' THEN try_timestamptz(%s) END)`, value, value), args
+}
@@ -0,0 +1,111 @@
+package postgres
+
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"database/sql"
+	"os"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testDB подключается к базе из TEST_DB_DSN; без неё тест пропускается
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DB_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DB_DSN is not set")
+	}
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Ping(); err != nil {
+		t.Fatalf("ping: %v", err)
+	}
+	return db
+}
+
+func TestRetentionSkipsMalformedDates(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	exec := func(query string, args ...interface{}) {
+		t.Helper()
+		if _, err := db.ExecContext(ctx, query, args...); err != nil {
+			t.Fatalf("%s: %v", strings.TrimSpace(query), err)
+		}
+	}
+	exec(TimestampFunction)
+	exec("DROP SCHEMA IF EXISTS retention_test CASCADE")
+	exec("CREATE SCHEMA retention_test")
+	t.Cleanup(func() { db.Exec("DROP SCHEMA IF EXISTS retention_test CASCADE") })
+	exec(`CREATE TABLE retention_test.records (
+		uid TEXT PRIMARY KEY,
+		data JSONB NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+		legal_hold BOOLEAN NOT NULL DEFAULT false
+	)`)
+	for uid, closedAt := range map[string]string{
+		"expired":       "2020-01-01",
+		"impossible":    "2020-02-30",
+		"trailing":      "2020-01-01garbage",
+		"keyword":       "now",
+		"recent":        "2099-01-01",
+		"expired-again": "2020-06-01T10:00:00Z",
+	} {
+		exec(`INSERT INTO retention_test.records (uid, data) VALUES ($1, jsonb_build_object('closedAt', $2::text))`, uid, closedAt)
+	}
+	exec(`UPDATE retention_test.records SET legal_hold = true WHERE uid = 'expired-again'`)
+
+	repo := NewRetentionRepo(db)
+	policy := domain.RetentionPolicy{Days: 1, Field: "closedAt"}
+	before := time.Now()
+
+	held, err := repo.CountHeld(ctx, "retention_test", "records", policy, before)
+	if err != nil {
+		t.Fatalf("CountHeld: %v", err)
+	}
+	if held != 1 {
+		t.Errorf("CountHeld = %d, want 1", held)
+	}
+
+	uids, err := repo.ApplyRetention(ctx, "retention_test", "records", policy, before, 100)
+	if err != nil {
+		t.Fatalf("ApplyRetention: %v", err)
+	}
+	if len(uids) != 1 || uids[0] != "expired" {
+		t.Errorf("removed %v, want [expired]", uids)
+	}
+
+	rows, err := db.QueryContext(ctx, "SELECT uid FROM retention_test.records")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var left []string
+	for rows.Next() {
+		var uid string
+		if err := rows.Scan(&uid); err != nil {
+			t.Fatal(err)
+		}
+		left = append(left, uid)
+	}
+	sort.Strings(left)
+	want := "expired-again,impossible,keyword,recent,trailing"
+	if got := strings.Join(left, ","); got != want {
+		t.Errorf("records left = %s, want %s", got, want)
+	}
+}
+
+func TestRetentionAgeCastsSafely(t *testing.T) {
+	age, args := retentionAge(domain.RetentionPolicy{Days: 30, Field: "closedAt"}, time.Now(), 100)
+	if strings.Contains(age, "::timestamptz") || !strings.Contains(age, "try_timestamptz(data->>($3::text))") {
+		t.Errorf("retentionAge = %s, want a cast through try_timestamptz", age)
+	}
+	if len(args) != 3 || args[2] != "closedAt" {
+		t.Errorf("args = %v", args)
+	}
+}
@@ -0,0 +1,266 @@
+package postgres
+
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type jobRepo struct {
+	db *sql.DB
+}
+
+func NewJobRepo(db *sql.DB) *jobRepo {
+	return &jobRepo{db: db}
+}
+
+const jobColumns = "id, namespace_code, name, schedule, timezone, action, paused, next_run_at, last_run_at, created_at, updated_at"
+
+func (r *jobRepo) Create(ctx context.Context, job *domain.ScheduledJob) error {
+	actionJSON, err := json.Marshal(job.Action)
+	if err != nil {
+		return fmt.Errorf("failed to marshal action: %w", err)
+	}
+	err = conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO scheduled_jobs (namespace_code, name, schedule, timezone, action, paused, next_run_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at, updated_at
+	`, job.NamespaceCode, job.Name, job.Schedule, job.Timezone, actionJSON, job.Paused, job.NextRunAt).Scan(&job.ID, &job.CreatedAt, &job.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert job: %w", err)
+	}
+	return nil
+}
+
+// GetByID возвращает задание по ID, nil если его нет
+func (r *jobRepo) GetByID(ctx context.Context, id string) (*domain.ScheduledJob, error) {
+	jobs, err := r.list(ctx, "SELECT "+jobColumns+" FROM scheduled_jobs WHERE id::text = $1", id)
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+	return jobs[0], nil
+}
+
+func (r *jobRepo) ListByNamespace(ctx context.Context, namespace string) ([]*domain.ScheduledJob, error) {
+	return r.list(ctx, "SELECT "+jobColumns+" FROM scheduled_jobs WHERE namespace_code = $1 ORDER BY created_at", namespace)
+}
+
+func (r *jobRepo) Update(ctx context.Context, job *domain.ScheduledJob) error {
+	actionJSON, err := json.Marshal(job.Action)
+	if err != nil {
+		return fmt.Errorf("failed to marshal action: %w", err)
+	}
+	err = conn(ctx, r.db).QueryRowContext(ctx, `
+		UPDATE scheduled_jobs
+		SET name = $1, schedule = $2, timezone = $3, action = $4, next_run_at = $5, updated_at = now()
+		WHERE id = $6
+		RETURNING last_run_at, created_at, updated_at
+	`, job.Name, job.Schedule, job.Timezone, actionJSON, job.NextRunAt, job.ID).Scan(&job.LastRunAt, &job.CreatedAt, &job.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("job %s: %w", job.ID, domain.ErrNotFound)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update job: %w", err)
+	}
+	return nil
+}
+
+func (r *jobRepo) Delete(ctx context.Context, id string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, "DELETE FROM scheduled_jobs WHERE id = $1", id)
+	return err
+}
+
+func (r *jobRepo) SetPaused(ctx context.Context, id string, paused bool, nextRunAt *time.Time) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE scheduled_jobs SET paused = $1, next_run_at = $2, updated_at = now() WHERE id = $3
+	`, paused, nextRunAt, id)
+	return err
+}
+
+// ClaimDue берёт в аренду наступившие задания. SKIP LOCKED не даёт двум
+// экземплярам взять одно задание, а истёкшая аренда освобождает задание
+// экземпляра, который упал во время выполнения
+func (r *jobRepo) ClaimDue(ctx context.Context, owner string, lease time.Duration, limit int) ([]*domain.ScheduledJob, error) {
+	return r.list(ctx, `
+		UPDATE scheduled_jobs
+		SET lease_owner = $1, lease_until = now() + $2::double precision * interval '1 second'
+		WHERE id IN (
+			SELECT id FROM scheduled_jobs
+			WHERE NOT paused AND next_run_at <= now() AND (lease_until IS NULL OR lease_until < now())
+			ORDER BY next_run_at
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING `+jobColumns, owner, lease.Seconds(), limit)
+}
+
+func (r *jobRepo) Claim(ctx context.Context, id, owner string, lease time.Duration) (bool, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE scheduled_jobs
+		SET lease_owner = $1, lease_until = now() + $2::double precision * interval '1 second'
+		WHERE id = $3 AND (lease_until IS NULL OR lease_until < now())
+	`, owner, lease.Seconds(), id)
+	if err != nil {
+		return false, fmt.Errorf("failed to claim job: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Extend продлевает аренду, только если она всё ещё принадлежит owner
+func (r *jobRepo) Extend(ctx context.Context, id, owner string, lease time.Duration) (bool, error) {
+	res, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE scheduled_jobs
+		SET lease_until = now() + $1::double precision * interval '1 second'
+		WHERE id = $2 AND lease_owner = $3
+	`, lease.Seconds(), id, owner)
+	if err != nil {
+		return false, fmt.Errorf("failed to extend job lease: %w", err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// Release снимает аренду, только если она всё ещё принадлежит owner
+func (r *jobRepo) Release(ctx context.Context, id, owner string, lastRunAt time.Time, nextRunAt *time.Time) error {
+	query := `UPDATE scheduled_jobs SET lease_owner = NULL, lease_until = NULL, last_run_at = $1 WHERE id = $2 AND lease_owner = $3`
+	args := []interface{}{lastRunAt, id, owner}
+	if nextRunAt != nil {
+		query = `UPDATE scheduled_jobs SET lease_owner = NULL, lease_until = NULL, last_run_at = $1, next_run_at = $4 WHERE id = $2 AND lease_owner = $3`
+		args = append(args, *nextRunAt)
+	}
+	if _, err := conn(ctx, r.db).ExecContext(ctx, query, args...); err != nil {
+		return fmt.Errorf("failed to release job: %w", err)
+	}
+	return nil
+}
+
+// RequestRecompute ставит приложение в очередь пересчёта; повторный запрос обновляет
+// requested_at, и уже идущий пересчёт не снимет его с очереди
+func (r *jobRepo) RequestRecompute(ctx context.Context, namespace, appName string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		INSERT INTO formula_recomputes (namespace_code, app_code) VALUES ($1, $2)
+		ON CONFLICT (namespace_code, app_code) DO UPDATE SET requested_at = now()
+	`, namespace, appName)
+	if err != nil {
+		return fmt.Errorf("failed to request recompute: %w", err)
+	}
+	return nil
+}
+
+// ClaimRecompute берёт в аренду самый ранний запрос пересчёта, nil если очередь пуста
+func (r *jobRepo) ClaimRecompute(ctx context.Context, owner string, lease time.Duration) (*domain.RecomputeRequest, error) {
+	var req domain.RecomputeRequest
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		UPDATE formula_recomputes
+		SET lease_owner = $1, lease_until = now() + $2::double precision * interval '1 second'
+		WHERE (namespace_code, app_code) IN (
+			SELECT namespace_code, app_code FROM formula_recomputes
+			WHERE lease_until IS NULL OR lease_until < now()
+			ORDER BY requested_at
+			LIMIT 1
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING namespace_code, app_code, requested_at
+	`, owner, lease.Seconds()).Scan(&req.NamespaceCode, &req.AppCode, &req.RequestedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim recompute: %w", err)
+	}
+	return &req, nil
+}
+
+// FinishRecompute удаляет выполненный запрос пересчёта; если формулы за это время изменились
+// снова, запрос остаётся в очереди без аренды и выполняется ещё раз
+func (r *jobRepo) FinishRecompute(ctx context.Context, req *domain.RecomputeRequest, owner string) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		WITH done AS (
+			DELETE FROM formula_recomputes
+			WHERE namespace_code = $1 AND app_code = $2 AND lease_owner = $3 AND requested_at = $4
+		)
+		UPDATE formula_recomputes SET lease_owner = NULL, lease_until = NULL
+		WHERE namespace_code = $1 AND app_code = $2 AND lease_owner = $3 AND requested_at <> $4
+	`, req.NamespaceCode, req.AppCode, owner, req.RequestedAt)
+	if err != nil {
+		return fmt.Errorf("failed to finish recompute: %w", err)
+	}
+	return nil
+}
+
+func (r *jobRepo) CreateRun(ctx context.Context, run *domain.JobRun) error {
+	err := conn(ctx, r.db).QueryRowContext(ctx, `
+		INSERT INTO scheduled_job_runs (job_id, trigger, actor, status, started_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id
+	`, run.JobID, run.Trigger, run.Actor, run.Status, run.StartedAt).Scan(&run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to insert job run: %w", err)
+	}
+	return nil
+}
+
+func (r *jobRepo) FinishRun(ctx context.Context, run *domain.JobRun) error {
+	_, err := conn(ctx, r.db).ExecContext(ctx, `
+		UPDATE scheduled_job_runs SET status = $1, processed = $2, error = $3, finished_at = $4 WHERE id = $5
+	`, run.Status, run.Processed, run.Error, run.FinishedAt, run.ID)
+	if err != nil {
+		return fmt.Errorf("failed to update job run: %w", err)
+	}
+	return nil
+}
+
+// ListRuns возвращает последние выполнения задания, новые первыми
+func (r *jobRepo) ListRuns(ctx context.Context, jobID string, limit int) ([]*domain.JobRun, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, job_id, trigger, actor, status, processed, error, started_at, finished_at
+		FROM scheduled_job_runs
+		WHERE job_id = $1
+		ORDER BY id DESC
+		LIMIT $2
+	`, jobID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query job runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*domain.JobRun
+	for rows.Next() {
+		var run domain.JobRun
+		if err := rows.Scan(&run.ID, &run.JobID, &run.Trigger, &run.Actor, &run.Status, &run.Processed,
+			&run.Error, &run.StartedAt, &run.FinishedAt); err != nil {
+			return nil, err
+		}
+		runs = append(runs, &run)
+	}
+	return runs, rows.Err()
+}
+
+func (r *jobRepo) list(ctx context.Context, query string, args ...interface{}) ([]*domain.ScheduledJob, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*domain.ScheduledJob
+	for rows.Next() {
+		var (
+			job        domain.ScheduledJob
+			actionJSON []byte
+		)
+		if err := rows.Scan(&job.ID, &job.NamespaceCode, &job.Name, &job.Schedule, &job.Timezone, &actionJSON,
+			&job.Paused, &job.NextRunAt, &job.LastRunAt, &job.CreatedAt, &job.UpdatedAt); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(actionJSON, &job.Action); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal action of job %s: %w", job.ID, err)
+		}
+		jobs = append(jobs, &job)
+	}
+	return jobs, rows.Err()
+}
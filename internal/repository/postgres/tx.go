@@ -0,0 +1,98 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+)
+
+// querier — общее у *sql.DB и *sql.Tx, через него репозитории выполняют запросы
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txKey struct{}
+
+// txState — транзакция из контекста. После её завершения контекст мог уйти дальше,
+// например в отложенную после фиксации работу, и запросы снова идут через пул
+type txState struct {
+	tx   *sql.Tx
+	done bool
+}
+
+// conn возвращает транзакцию, если операция выполняется внутри Transactor.InTx, иначе db
+func conn(ctx context.Context, db *sql.DB) querier {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok && !state.done {
+		return state.tx
+	}
+	return db
+}
+
+// txHandle — транзакция репозитория. Вложенная в транзакцию из контекста не фиксируется
+// и не откатывается сама: это делает владелец, а ошибка откатывает всё целиком
+type txHandle struct {
+	querier
+	tx     *sql.Tx
+	nested bool
+}
+
+// begin начинает транзакцию репозитория или продолжает транзакцию из контекста
+func begin(ctx context.Context, db *sql.DB) (*txHandle, error) {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok && !state.done {
+		return &txHandle{querier: state.tx, tx: state.tx, nested: true}, nil
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &txHandle{querier: tx, tx: tx}, nil
+}
+
+func (t *txHandle) Commit() error {
+	if t.nested {
+		return nil
+	}
+	return t.tx.Commit()
+}
+
+func (t *txHandle) Rollback() error {
+	if t.nested {
+		return nil
+	}
+	return t.tx.Rollback()
+}
+
+type transactor struct {
+	db *sql.DB
+}
+
+// NewTransactor создаёт Transactor, выполняющий операции репозиториев в одной транзакции
+func NewTransactor(db *sql.DB) *transactor {
+	return &transactor{db: db}
+}
+
+// InTx выполняет fn в транзакции: репозитории, получившие контекст fn, выполняют запросы
+// в ней. Ошибка fn откатывает транзакцию, иначе она фиксируется. Внутри другой InTx
+// fn выполняется во внешней транзакции
+func (t *transactor) InTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if state, ok := ctx.Value(txKey{}).(*txState); ok && !state.done {
+		return fn(ctx)
+	}
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	state := &txState{tx: tx}
+	defer func() {
+		if !state.done {
+			state.done = true
+			tx.Rollback()
+		}
+	}()
+	if err := fn(context.WithValue(ctx, txKey{}, state)); err != nil {
+		return err
+	}
+	state.done = true
+	return tx.Commit()
+}
@@ -0,0 +1,128 @@
+package postgres
+
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+type workflowRepo struct {
+	db *sql.DB
+}
+
+func NewWorkflowRepo(db *sql.DB) *workflowRepo {
+	return &workflowRepo{db: db}
+}
+
+// Transition меняет состояние записи и сохраняет переход в одной транзакции.
+// Если поле состояния уже не равно from, запись изменена параллельным запросом
+func (r *workflowRepo) Transition(ctx context.Context, namespace, table, uid, field, from string, partial map[string]interface{}, record *domain.TransitionRecord) error {
+	jsonData, err := json.Marshal(partial)
+	if err != nil {
+		return fmt.Errorf("failed to marshal data: %w", err)
+	}
+
+	tx, err := begin(ctx, r.db)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := fmt.Sprintf(`
+		UPDATE %s.%s
+		SET data = data || $1::jsonb, updated_at = now(), updated_by = $5
+		WHERE uid = $2 AND deleted_at IS NULL AND COALESCE(data->>($3::text), '') = $4
+	`, namespace, table)
+
+	result, err := tx.ExecContext(ctx, query, jsonData, uid, field, from, actor(ctx))
+	if err != nil {
+		if index, ok := uniqueViolation(err); ok {
+			return &domain.DuplicateError{Index: index}
+		}
+		return fmt.Errorf("failed to update data: %w", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return r.stateChanged(ctx, namespace, table, uid)
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO workflow_transitions (namespace_code, app_code, record_uid, from_state, to_state, actor, comment, request_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`, record.NamespaceCode, record.AppCode, record.RecordUID, record.From, record.To, record.Actor, record.Comment, record.RequestID).Scan(&record.ID, &record.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to insert transition: %w", err)
+	}
+	return tx.Commit()
+}
+
+// ReplaceInState полностью обновляет запись, если её состояние не изменилось
+func (r *workflowRepo) ReplaceInState(ctx context.Context, namespace, table string, data *domain.AppData, field, state string) error {
+	jsonData, err := json.Marshal(data.Data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal data: %w", err)
+	}
+
+	query := fmt.Sprintf(`
+		UPDATE %s.%s
+		SET data = $1, updated_at = now(), updated_by = $5
+		WHERE uid = $2 AND deleted_at IS NULL AND COALESCE(data->>($3::text), '') = $4
+		RETURNING COALESCE(number, ''), created_at, updated_at, created_by, updated_by
+	`, namespace, table)
+
+	err = conn(ctx, r.db).QueryRowContext(ctx, query, jsonData, data.UID, field, state, actor(ctx)).Scan(&data.Number, &data.CreatedAt, &data.UpdatedAt, &data.CreatedBy, &data.UpdatedBy)
+	if err == sql.ErrNoRows {
+		return r.stateChanged(ctx, namespace, table, data.UID)
+	}
+	if err != nil {
+		if index, ok := uniqueViolation(err); ok {
+			return &domain.DuplicateError{Index: index}
+		}
+		return fmt.Errorf("failed to update data: %w", err)
+	}
+	return nil
+}
+
+// History возвращает переходы записи в порядке выполнения
+func (r *workflowRepo) History(ctx context.Context, namespace, table, uid string) ([]*domain.TransitionRecord, error) {
+	rows, err := conn(ctx, r.db).QueryContext(ctx, `
+		SELECT id, namespace_code, app_code, record_uid, from_state, to_state, actor, comment, request_id, created_at
+		FROM workflow_transitions
+		WHERE namespace_code = $1 AND app_code = $2 AND record_uid = $3
+		ORDER BY id
+	`, namespace, table, uid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query transitions: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*domain.TransitionRecord
+	for rows.Next() {
+		var t domain.TransitionRecord
+		if err := rows.Scan(&t.ID, &t.NamespaceCode, &t.AppCode, &t.RecordUID, &t.From, &t.To,
+			&t.Actor, &t.Comment, &t.RequestID, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		records = append(records, &t)
+	}
+	return records, rows.Err()
+}
+
+// stateChanged объясняет, почему условное обновление не затронуло запись
+func (r *workflowRepo) stateChanged(ctx context.Context, namespace, table, uid string) error {
+	var exists bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM %s.%s WHERE uid = $1 AND deleted_at IS NULL)", namespace, table)
+	if err := conn(ctx, r.db).QueryRowContext(ctx, query, uid).Scan(&exists); err != nil {
+		return fmt.Errorf("failed to check record: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("record with uid %s: %w", uid, domain.ErrNotFound)
+	}
+	return fmt.Errorf("record %s: state was changed concurrently: %w", uid, domain.ErrConflict)
+}
@@ -0,0 +1,55 @@
+// Package reqctx хранит в context.Context сведения о текущем запросе:
+// кто его выполняет, идентификатор запроса и адрес клиента
+package reqctx
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// SystemActor — субъект для операций, выполняемых фоновыми процессами сервиса
+const SystemActor = "system"
+
+// Meta — сведения о запросе, доступные во всех слоях
+type Meta struct {
+	RequestID string
+	Actor     string
+	Roles     []string // Роли субъекта, используются при согласовании изменений
+	SourceIP  string
+	APIKey    bool // Запрос аутентифицирован ключом API, а не пользователем
+	// Locales — локали из Accept-Language по убыванию предпочтения; пусто, если заголовка нет
+	Locales []string
+	// ContentLocale — локаль значений локализуемых полей в теле запроса из Content-Language
+	ContentLocale string
+}
+
+type metaKey struct{}
+
+func WithMeta(ctx context.Context, meta Meta) context.Context {
+	return context.WithValue(ctx, metaKey{}, meta)
+}
+
+// FromContext возвращает сведения о запросе; вне HTTP-запроса субъектом считается SystemActor
+func FromContext(ctx context.Context) Meta {
+	meta, ok := ctx.Value(metaKey{}).(Meta)
+	if !ok {
+		return Meta{Actor: SystemActor}
+	}
+	return meta
+}
+
+type writesKey struct{}
+
+// TrackWrites возвращает контекст, в котором MarkWritten отмечает зафиксированное
+// изменение данных, и функцию, сообщающую, было ли оно
+func TrackWrites(ctx context.Context) (context.Context, func() bool) {
+	written := new(atomic.Bool)
+	return context.WithValue(ctx, writesKey{}, written), written.Load
+}
+
+// MarkWritten отмечает, что запрос зафиксировал изменение данных; вне TrackWrites ничего не делает
+func MarkWritten(ctx context.Context) {
+	if written, ok := ctx.Value(writesKey{}).(*atomic.Bool); ok {
+		written.Store(true)
+	}
+}
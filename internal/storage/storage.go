@@ -0,0 +1,19 @@
+// Package storage содержит хранилища бинарных объектов (вложений записей)
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotExist — объекта с таким ключом нет в хранилище
+var ErrNotExist = errors.New("blob does not exist")
+
+// BlobStorage — хранилище объектов по ключу. Ключи имеют вид "a/b/c",
+// реализация сама решает, как отображать их на своё пространство имён
+type BlobStorage interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,32 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Query — обработчик запросов для postgres.NewConnector: каждый SQL-запрос репозиториев
+// становится спаном с текстом запроса. Параметры запроса в спан не попадают
+func Query(ctx context.Context, query string) (context.Context, func(err error) error) {
+	ctx, span := startQuery(ctx, query)
+	return ctx, func(err error) error {
+		End(span, err)
+		return err
+	}
+}
+
+// startQuery открывает спан запроса с именем по его первому слову: SELECT, UPDATE и т.д.
+func startQuery(ctx context.Context, query string) (context.Context, trace.Span) {
+	operation := "SQL"
+	if fields := strings.Fields(query); len(fields) > 0 {
+		operation = strings.ToUpper(fields[0])
+	}
+	return StartClient(ctx, "db "+operation,
+		attribute.String("db.system.name", "postgresql"),
+		attribute.String("db.operation.name", operation),
+		attribute.String("db.query.text", query),
+	)
+}
@@ -1,44 +1,467 @@
 package usecase
 
-import "app/backendv1/internal/domain"
+import (
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/tracing"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
 
 type AppDataUsecase interface {
-	Create(namespace, appName string, data *domain.AppData) error
-	GetDataByUID(namespace, appName, uid string) (*domain.AppData, error)
-	GetAll(namespace, appName string) ([]*domain.AppData, error)
-	Update(namespace, appName string, data *domain.AppData) error
-	UpdateDataPartial(namespace, appName, uid string, partialData map[string]interface{}) error
-	Delete(namespace, appName, uid string) error
+	Create(ctx context.Context, namespace, appName string, data *domain.AppData) error
+	GetDataByUID(ctx context.Context, namespace, appName, uid string) (*domain.AppData, error)
+	GetByNumber(ctx context.Context, namespace, appName, number string) (*domain.AppData, error)
+	GetAll(ctx context.Context, namespace, appName string, query domain.DataQuery) ([]*domain.AppData, error)
+	Update(ctx context.Context, namespace, appName string, data *domain.AppData) error
+	UpdateDataPartial(ctx context.Context, namespace, appName, uid string, partialData map[string]interface{}) error
+	Delete(ctx context.Context, namespace, appName, uid string) error
+	GetTrash(ctx context.Context, namespace, appName string) ([]*domain.AppData, error)
+	Restore(ctx context.Context, namespace, appName, uid string) error
+	PurgeDeleted(ctx context.Context, namespace, appName string, before time.Time) (int64, error)
 }
 
 type appDataUsecase struct {
-	repo AppDataUsecase
+	repo     AppDataUsecase
+	numbers  NumberingRepo
+	apps     AppUsecase
+	workflow WorkflowRepo
+	changes  ChangeRequestRepo
+	events   EventPublisher
+	quotas   QuotaChecker
+	tx       Transactor
+	audit    AuditUsecase
+	locales  localizer
 }
 
-func NewAppDataUsecase(repo AppDataUsecase) AppDataUsecase {
-	return &appDataUsecase{repo: repo}
+func NewAppDataUsecase(repo AppDataUsecase, numbers NumberingRepo, apps AppUsecase, namespaces NamespaceUsecase, workflow WorkflowRepo, changes ChangeRequestRepo, events EventPublisher, quotas QuotaChecker, tx Transactor, audit AuditUsecase) AppDataUsecase {
+	return &appDataUsecase{repo: repo, numbers: numbers, apps: apps, workflow: workflow, changes: changes, events: events, quotas: quotas, tx: tx, audit: audit, locales: localizer{namespaces: namespaces}}
 }
 
-func (u *appDataUsecase) Create(namespace, appName string, data *domain.AppData) error {
-	return u.repo.Create(namespace, appName, data)
+func (u *appDataUsecase) Create(ctx context.Context, namespace, appName string, data *domain.AppData) error {
+	ctx, span := startSpan(ctx, "appData.Create", namespace, appName)
+	defer span.End()
+
+	app, err := u.app(ctx, namespace, appName)
+	if err != nil {
+		return err
+	}
+	// номер выдаётся только по нумерации приложения
+	data.Number = ""
+	// вложения прикрепляются к уже созданной записи отдельным запросом
+	for _, field := range fileFields(app) {
+		delete(data.Data, field)
+	}
+	if data.Data == nil {
+		data.Data = make(map[string]interface{})
+	}
+	if err := u.locales.input(ctx, app, data.Data, nil); err != nil {
+		return err
+	}
+	// новая запись всегда начинает с начального состояния
+	if wf := app.Workflow; wf != nil {
+		if value, ok := data.Data[wf.Field]; ok && value != wf.Initial {
+			return fmt.Errorf("%w: new records start in state %s", domain.ErrValidation, wf.Initial)
+		}
+		data.Data[wf.Field] = wf.Initial
+	}
+	if err := applyFormulas(app, data.Data); err != nil {
+		return err
+	}
+	if wf := app.Workflow; wf != nil {
+		if err := checkRequired(wf, wf.Initial, data.Data); err != nil {
+			return err
+		}
+	}
+	err = inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		if err := u.quotas.CheckRecordQuota(ctx, namespace, appName); err != nil {
+			return err
+		}
+		if app.Numbering != nil {
+			number, err := allocateNumber(ctx, u.numbers, namespace, appName, app.Numbering, time.Now())
+			if err != nil {
+				return err
+			}
+			data.Number = number
+		}
+		if err := u.repo.Create(ctx, namespace, appName, data); err != nil {
+			return duplicateFields(app, err)
+		}
+		return recordAudit(ctx, u.audit, "app_data.create", namespace, appName, data.UID, diffFields(nil, data.Data))
+	})
+	if err != nil {
+		return err
+	}
+	publishEvent(ctx, u.events, domain.EventCreated, namespace, appName, data.UID, data.Data)
+	return nil
 }
 
-func (u *appDataUsecase) GetDataByUID(namespace, appName, uid string) (*domain.AppData, error) {
-	return u.repo.GetDataByUID(namespace, appName, uid)
+func (u *appDataUsecase) GetDataByUID(ctx context.Context, namespace, appName, uid string) (*domain.AppData, error) {
+	ctx, span := startSpan(ctx, "appData.GetDataByUID", namespace, appName)
+	defer span.End()
+
+	return u.localized(ctx, namespace, appName)(u.repo.GetDataByUID(ctx, namespace, appName, uid))
 }
 
-func (u *appDataUsecase) GetAll(namespace, appName string) ([]*domain.AppData, error) {
-	return u.repo.GetAll(namespace, appName)
+func (u *appDataUsecase) GetByNumber(ctx context.Context, namespace, appName, number string) (*domain.AppData, error) {
+	ctx, span := startSpan(ctx, "appData.GetByNumber", namespace, appName)
+	defer span.End()
+
+	return u.localized(ctx, namespace, appName)(u.repo.GetByNumber(ctx, namespace, appName, number))
+}
+
+// localized возвращает обёртку результата чтения, выбирающую значения локализуемых полей для клиента
+func (u *appDataUsecase) localized(ctx context.Context, namespace, appName string) func(*domain.AppData, error) (*domain.AppData, error) {
+	return func(record *domain.AppData, err error) (*domain.AppData, error) {
+		if err != nil || record == nil {
+			return record, err
+		}
+		app, err := u.app(ctx, namespace, appName)
+		if err != nil {
+			return nil, err
+		}
+		if err := u.locales.resolve(ctx, app, record); err != nil {
+			return nil, err
+		}
+		return record, nil
+	}
+}
+
+func (u *appDataUsecase) GetAll(ctx context.Context, namespace, appName string, query domain.DataQuery) ([]*domain.AppData, error) {
+	ctx, span := startSpan(ctx, "appData.GetAll", namespace, appName)
+	defer span.End()
+
+	app, err := u.app(ctx, namespace, appName)
+	if err != nil {
+		return nil, err
+	}
+	if query.Limit < 0 || query.Offset < 0 {
+		return nil, fmt.Errorf("%w: limit and offset must not be negative", domain.ErrValidation)
+	}
+	for i, filter := range query.Filters {
+		value, err := filterValue(app, filter)
+		if err != nil {
+			return nil, err
+		}
+		query.Filters[i].Value = value
+	}
+	if !app.Drafts {
+		// без черновиков данные записи и есть опубликованная версия
+		query.Stage = domain.StageDraft
+	}
+	if err := u.locales.query(ctx, app, &query); err != nil {
+		return nil, err
+	}
+	records, err := u.repo.GetAll(ctx, namespace, appName, query)
+	if err != nil {
+		return nil, err
+	}
+	if err := u.locales.resolve(ctx, app, records...); err != nil {
+		return nil, err
+	}
+	return records, nil
 }
 
-func (u *appDataUsecase) Update(namespace, appName string, data *domain.AppData) error {
-	return u.repo.Update(namespace, appName, data)
+func (u *appDataUsecase) Update(ctx context.Context, namespace, appName string, data *domain.AppData) error {
+	ctx, span := startSpan(ctx, "appData.Update", namespace, appName)
+	defer span.End()
+
+	app, err := u.app(ctx, namespace, appName)
+	if err != nil {
+		return err
+	}
+	before, err := u.repo.GetDataByUID(ctx, namespace, appName, data.UID)
+	if err != nil {
+		return err
+	}
+	if data.Data == nil {
+		data.Data = make(map[string]interface{})
+	}
+	if err := u.locales.input(ctx, app, data.Data, before.Data); err != nil {
+		return err
+	}
+	// значения полей типа file меняются только через загрузку и удаление вложений
+	for _, field := range fileFields(app) {
+		if value, ok := before.Data[field]; ok {
+			data.Data[field] = value
+		} else {
+			delete(data.Data, field)
+		}
+	}
+	if err := applyFormulas(app, data.Data); err != nil {
+		return err
+	}
+	if wf := app.Workflow; wf != nil {
+		// состояние меняется только переходом; запись заменяется, только если
+		// параллельный переход не успел его изменить
+		stored, state := currentState(wf, before.Data)
+		if value, ok := data.Data[wf.Field]; ok && value != state {
+			return fmt.Errorf("%w: field %s can only be changed by a transition", domain.ErrValidation, wf.Field)
+		}
+		if stored != "" {
+			data.Data[wf.Field] = stored
+		} else {
+			delete(data.Data, wf.Field)
+		}
+		if err := checkRequired(wf, state, data.Data); err != nil {
+			return err
+		}
+	}
+	// правка приложения с согласованием сохраняется как запрос и применяется после одобрения
+	if needsApproval(ctx, app) {
+		return requestChange(ctx, u.changes, u.tx, u.audit, app, data.UID, domain.ChangeUpdate, data.Data, &before.UpdatedAt)
+	}
+	err = inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		var err error
+		if wf := app.Workflow; wf != nil {
+			stored, _ := currentState(wf, before.Data)
+			err = u.workflow.ReplaceInState(ctx, namespace, appName, data, wf.Field, stored)
+		} else {
+			err = u.repo.Update(ctx, namespace, appName, data)
+		}
+		if err != nil {
+			return duplicateFields(app, err)
+		}
+		return recordAudit(ctx, u.audit, "app_data.update", namespace, appName, data.UID, diffFields(before.Data, data.Data))
+	})
+	if err != nil {
+		return err
+	}
+	publishEvent(ctx, u.events, domain.EventUpdated, namespace, appName, data.UID, data.Data)
+	return nil
+}
+
+func (u *appDataUsecase) UpdateDataPartial(ctx context.Context, namespace, appName, uid string, partialData map[string]interface{}) error {
+	ctx, span := startSpan(ctx, "appData.UpdateDataPartial", namespace, appName)
+	defer span.End()
+
+	app, err := u.app(ctx, namespace, appName)
+	if err != nil {
+		return err
+	}
+	before, err := u.repo.GetDataByUID(ctx, namespace, appName, uid)
+	if err != nil {
+		return err
+	}
+	if wf := app.Workflow; wf != nil {
+		// текущее состояние можно передать без изменений, сменить его можно только переходом
+		if _, state := currentState(wf, before.Data); partialData[wf.Field] == state {
+			delete(partialData, wf.Field)
+		}
+	}
+	for field := range partialData {
+		if err := checkWritable(app, field); err != nil {
+			return err
+		}
+	}
+	if len(partialData) == 0 {
+		return nil
+	}
+	if err := u.locales.input(ctx, app, partialData, before.Data); err != nil {
+		return err
+	}
+	requested := make(map[string]interface{}, len(partialData))
+	for field, value := range partialData {
+		requested[field] = value
+	}
+	// формулы и обязательные поля проверяются по записи целиком, в запрос попадают только изменения
+	computed := computedFields(app)
+	if len(computed) > 0 || app.Workflow != nil {
+		merged := make(map[string]interface{}, len(before.Data)+len(partialData))
+		for field, value := range before.Data {
+			merged[field] = value
+		}
+		for field, value := range partialData {
+			merged[field] = value
+		}
+		if err := applyFormulas(app, merged); err != nil {
+			return err
+		}
+		for _, field := range computed {
+			partialData[field] = merged[field]
+		}
+		if wf := app.Workflow; wf != nil {
+			_, state := currentState(wf, before.Data)
+			if err := checkRequired(wf, state, merged); err != nil {
+				return err
+			}
+		}
+	}
+	// в запросе сохраняются только поля клиента, формулы пересчитываются при применении
+	if needsApproval(ctx, app) {
+		return requestChange(ctx, u.changes, u.tx, u.audit, app, uid, domain.ChangePatch, requested, nil)
+	}
+	touched := make(map[string]interface{}, len(partialData))
+	for field := range partialData {
+		if value, ok := before.Data[field]; ok {
+			touched[field] = value
+		}
+	}
+	err = inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		if err := u.repo.UpdateDataPartial(ctx, namespace, appName, uid, partialData); err != nil {
+			return duplicateFields(app, err)
+		}
+		return recordAudit(ctx, u.audit, "app_data.patch", namespace, appName, uid, diffFields(touched, partialData))
+	})
+	if err != nil {
+		return err
+	}
+
+	after := make(map[string]interface{}, len(before.Data)+len(partialData))
+	for field, value := range before.Data {
+		after[field] = value
+	}
+	for field, value := range partialData {
+		after[field] = value
+	}
+	publishEvent(ctx, u.events, domain.EventUpdated, namespace, appName, uid, after)
+	return nil
+}
+
+func (u *appDataUsecase) Delete(ctx context.Context, namespace, appName, uid string) error {
+	ctx, span := startSpan(ctx, "appData.Delete", namespace, appName)
+	defer span.End()
+
+	err := inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		if err := u.repo.Delete(ctx, namespace, appName, uid); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.audit, "app_data.delete", namespace, appName, uid, nil)
+	})
+	if err != nil {
+		return err
+	}
+	publishEvent(ctx, u.events, domain.EventDeleted, namespace, appName, uid, nil)
+	return nil
 }
 
-func (u *appDataUsecase) UpdateDataPartial(namespace, appName, uid string, partialData map[string]interface{}) error {
-	return u.repo.UpdateDataPartial(namespace, appName, uid, partialData)
+func (u *appDataUsecase) GetTrash(ctx context.Context, namespace, appName string) ([]*domain.AppData, error) {
+	ctx, span := startSpan(ctx, "appData.GetTrash", namespace, appName)
+	defer span.End()
+
+	return u.repo.GetTrash(ctx, namespace, appName)
+}
+
+func (u *appDataUsecase) Restore(ctx context.Context, namespace, appName, uid string) error {
+	ctx, span := startSpan(ctx, "appData.Restore", namespace, appName)
+	defer span.End()
+
+	app, err := u.app(ctx, namespace, appName)
+	if err != nil {
+		return err
+	}
+	return inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		// пока запись была в корзине, её уникальные значения могла занять другая запись
+		if err := u.repo.Restore(ctx, namespace, appName, uid); err != nil {
+			return duplicateFields(app, err)
+		}
+		return recordAudit(ctx, u.audit, "app_data.restore", namespace, appName, uid, nil)
+	})
+}
+
+func (u *appDataUsecase) PurgeDeleted(ctx context.Context, namespace, appName string, before time.Time) (int64, error) {
+	ctx, span := startSpan(ctx, "appData.PurgeDeleted", namespace, appName)
+	defer span.End()
+
+	var purged int64
+	err := inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		var err error
+		purged, err = u.repo.PurgeDeleted(ctx, namespace, appName, before)
+		if err != nil || purged == 0 {
+			return err
+		}
+		return recordAudit(ctx, u.audit, "app_data.purge", namespace, appName, "", map[string]interface{}{"purged": purged, "before": before})
+	})
+	if err != nil {
+		return 0, err
+	}
+	return purged, nil
+}
+
+// app возвращает приложение, с данными которого идёт работа
+func (u *appDataUsecase) app(ctx context.Context, namespace, appName string) (*domain.App, error) {
+	app, err := u.apps.GetByCode(ctx, appName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		return nil, fmt.Errorf("app %s: %w", appName, domain.ErrNotFound)
+	}
+	return app, nil
+}
+
+// startSpan открывает спан операции с записями приложения
+func startSpan(ctx context.Context, name, namespace, appName string) (context.Context, trace.Span) {
+	return tracing.Start(ctx, name, attribute.String("app.namespace", namespace), attribute.String("app.code", appName))
+}
+
+// applyFormulas вычисляет значения вычисляемых полей записи
+func applyFormulas(app *domain.App, values map[string]interface{}) error {
+	program, err := compileFormulas(app)
+	if err != nil || program == nil {
+		return err
+	}
+	program.Apply(values, time.Now())
+	return nil
+}
+
+// filterValue приводит значение фильтра из запроса к типу поля. Для полей
+// вне схемы число и логическое значение распознаются по записи значения
+func filterValue(app *domain.App, filter domain.DataFilter) (interface{}, error) {
+	raw := fmt.Sprint(filter.Value)
+	if filter.Op == domain.FilterContains {
+		return raw, nil
+	}
+	switch filter.Field {
+	case domain.SystemCreatedAt, domain.SystemUpdatedAt:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02"} {
+			if t, err := time.Parse(layout, raw); err == nil {
+				return t, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: field %s expects an RFC 3339 time or a date", domain.ErrValidation, filter.Field)
+	case domain.SystemCreatedBy, domain.SystemUpdatedBy, domain.SystemNumber:
+		return raw, nil
+	}
+
+	fieldType := ""
+	if field := app.FieldByCode(filter.Field); field != nil {
+		fieldType = field.Type
+	}
+	switch fieldType {
+	case domain.FieldTypeNumber:
+		n, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: field %s expects a number", domain.ErrValidation, filter.Field)
+		}
+		return n, nil
+	case domain.FieldTypeBoolean:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: field %s expects a boolean", domain.ErrValidation, filter.Field)
+		}
+		return b, nil
+	case "":
+		if n, err := strconv.ParseFloat(raw, 64); err == nil {
+			return n, nil
+		}
+		if b, err := strconv.ParseBool(raw); err == nil {
+			return b, nil
+		}
+	}
+	return raw, nil
 }
 
-func (u *appDataUsecase) Delete(namespace, appName, uid string) error {
-	return u.repo.Delete(namespace, appName, uid)
+func fileFields(app *domain.App) []string {
+	var fields []string
+	for _, field := range app.Fields {
+		if field.Type == domain.FieldTypeFile {
+			fields = append(fields, field.Code)
+		}
+	}
+	return fields
 }
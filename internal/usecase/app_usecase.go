@@ -1,39 +1,288 @@
 package usecase
 
-import "app/backendv1/internal/domain"
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// fieldCodePattern — коды полей подставляются в пути jsonb, поэтому допускаются только идентификаторы
+var fieldCodePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+var fieldTypes = map[string]bool{
+	domain.FieldTypeString:   true,
+	domain.FieldTypeNumber:   true,
+	domain.FieldTypeBoolean:  true,
+	domain.FieldTypeDatetime: true,
+	domain.FieldTypeFile:     true,
+}
 
 type AppUsecase interface {
-	Create(app *domain.App) error
-	GetAll() ([]*domain.App, error)
-	GetAllByCodeNamespace(code string) ([]*domain.App, error)
-	Update(app *domain.App) error
-	Delete(code, namespaceCode string) error
+	Create(ctx context.Context, app *domain.App) error
+	GetAll(ctx context.Context) ([]*domain.App, error)
+	GetAllByCodeNamespace(ctx context.Context, code string) ([]*domain.App, error)
+	GetByCode(ctx context.Context, code, namespaceCode string) (*domain.App, error)
+	Update(ctx context.Context, app *domain.App) error
+	SetIcon(ctx context.Context, code, namespaceCode, iconID string) error
+	Delete(ctx context.Context, code, namespaceCode string) error
+	GetTrashByCodeNamespace(ctx context.Context, code string) ([]*domain.App, error)
+	Restore(ctx context.Context, code, namespaceCode string) error
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
 }
 
 type appUsecase struct {
-	repo AppUsecase
+	repo       AppUsecase
+	indexes    IndexRepo
+	recomputes RecomputeQueue
+	numbers    NumberingRepo
+	quotas     QuotaChecker
+	tx         Transactor
+	audit      AuditUsecase
+}
+
+func NewAppUsecase(repo AppUsecase, indexes IndexRepo, recomputes RecomputeQueue, numbers NumberingRepo, quotas QuotaChecker, tx Transactor, audit AuditUsecase) AppUsecase {
+	return &appUsecase{repo: repo, indexes: indexes, recomputes: recomputes, numbers: numbers, quotas: quotas, tx: tx, audit: audit}
+}
+
+func (u *appUsecase) Create(ctx context.Context, app *domain.App) error {
+	if err := validateApp(app); err != nil {
+		return err
+	}
+	// иконка назначается только загрузкой
+	app.Icon = ""
+	app.IndexError = ""
+	err := inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		if err := u.quotas.CheckAppQuota(ctx, app.NamespaceCode); err != nil {
+			return err
+		}
+		if err := u.repo.Create(ctx, app); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.audit, "app.create", app.NamespaceCode, app.Code, "", diffFields(nil, appFields(app)))
+	})
+	if err != nil {
+		return err
+	}
+	// индексы строятся после фиксации: CONCURRENTLY не работает в транзакции. Приложение
+	// уже создано, поэтому ошибка построения только отмечается у него и видна в ответе
+	u.syncIndexes(ctx, app)
+	return nil
+}
+
+func (u *appUsecase) GetAll(ctx context.Context) ([]*domain.App, error) {
+	return u.repo.GetAll(ctx)
+}
+
+func (u *appUsecase) GetAllByCodeNamespace(ctx context.Context, code string) ([]*domain.App, error) {
+	return u.repo.GetAllByCodeNamespace(ctx, code)
+}
+
+func (u *appUsecase) GetByCode(ctx context.Context, code, namespaceCode string) (*domain.App, error) {
+	return u.repo.GetByCode(ctx, code, namespaceCode)
+}
+
+func (u *appUsecase) Update(ctx context.Context, app *domain.App) error {
+	if err := validateApp(app); err != nil {
+		return err
+	}
+	before, err := u.repo.GetByCode(ctx, app.Code, app.NamespaceCode)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return fmt.Errorf("app %s: %w", app.Code, domain.ErrNotFound)
+	}
+	app.Icon = before.Icon
+	// если в записях уже есть повторы, новая уникальность не включится и схема останется прежней
+	if err := checkDuplicates(ctx, u.indexes, before, app); err != nil {
+		return err
+	}
+	err = inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		if err := u.repo.Update(ctx, app); err != nil {
+			return err
+		}
+		// записи, созданные до включения нумерации, получают номера в той же транзакции
+		if app.Numbering != nil {
+			if _, err := backfillNumbers(ctx, u.numbers, app); err != nil {
+				return fmt.Errorf("failed to number existing records: %w", err)
+			}
+		}
+		// сохранённые записи пересчитывает планировщик после фиксации: запрос ставится
+		// в очередь вместе со схемой и не теряется, если экземпляр упадёт
+		if formulasChanged(before, app) {
+			if err := u.recomputes.RequestRecompute(ctx, app.NamespaceCode, app.Code); err != nil {
+				return err
+			}
+		}
+		return recordAudit(ctx, u.audit, "app.update", app.NamespaceCode, app.Code, "", diffFields(appFields(before), appFields(app)))
+	})
+	if err != nil {
+		return err
+	}
+	// индексы меняются только после сохранения схемы: неудачное сохранение не оставит
+	// приложение без индексов, которые были у прежней схемы
+	u.syncIndexes(ctx, app)
+	return nil
+}
+
+// syncIndexes строит индексы сохранённой схемы; повтор, появившийся после проверки,
+// или сбой построения отмечается у приложения, и индексы достраиваются в фоне
+func (u *appUsecase) syncIndexes(ctx context.Context, app *domain.App) {
+	// схема уже сохранена, отключение клиента не должно оставить индексы недостроенными
+	if err := syncIndexes(context.WithoutCancel(ctx), u.indexes, app); err != nil {
+		slog.WarnContext(ctx, "indexes: failed to build indexes after schema change", "namespace", app.NamespaceCode, "app", app.Code, "error", err)
+	}
+}
+
+func (u *appUsecase) SetIcon(ctx context.Context, code, namespaceCode, iconID string) error {
+	before, err := u.repo.GetByCode(ctx, code, namespaceCode)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return fmt.Errorf("app %s: %w", code, domain.ErrNotFound)
+	}
+	return inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		if err := u.repo.SetIcon(ctx, code, namespaceCode, iconID); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.audit, "app.set_icon", namespaceCode, code, "", map[string]auditChange{"icon": {Old: before.Icon, New: iconID}})
+	})
+}
+
+func (u *appUsecase) Delete(ctx context.Context, code, namespaceCode string) error {
+	return inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		if err := u.repo.Delete(ctx, code, namespaceCode); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.audit, "app.delete", namespaceCode, code, "", nil)
+	})
 }
 
-func NewAppUsecase(repo AppUsecase) AppUsecase {
-	return &appUsecase{repo: repo}
+func (u *appUsecase) GetTrashByCodeNamespace(ctx context.Context, code string) ([]*domain.App, error) {
+	return u.repo.GetTrashByCodeNamespace(ctx, code)
 }
 
-func (u *appUsecase) Create(app *domain.App) error {
-	return u.repo.Create(app)
+func (u *appUsecase) Restore(ctx context.Context, code, namespaceCode string) error {
+	return inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		if err := u.repo.Restore(ctx, code, namespaceCode); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.audit, "app.restore", namespaceCode, code, "", nil)
+	})
 }
 
-func (u *appUsecase) GetAll() ([]*domain.App, error) {
-	return u.repo.GetAll()
+// PurgeDeleted удаляет приложения по одному, каждое в своей транзакции, поэтому
+// журнал пишется после удаления
+func (u *appUsecase) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	purged, err := u.repo.PurgeDeleted(ctx, before)
+	if purged > 0 {
+		if auditErr := recordAudit(ctx, u.audit, "app.purge", "", "", "", map[string]interface{}{"purged": purged, "before": before}); err == nil {
+			err = auditErr
+		}
+	}
+	return purged, err
 }
 
-func (u *appUsecase) GetAllByCodeNamespace(code string) ([]*domain.App, error) {
-	return u.repo.GetAllByCodeNamespace(code)
+func appFields(app *domain.App) map[string]interface{} {
+	if app == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"code":        app.Code,
+		"name":        app.Name,
+		"icon":        app.Icon,
+		"fields":      app.Fields,
+		"maxFileSize": app.MaxFileSize,
+		"workflow":    app.Workflow,
+		"approval":    app.Approval,
+		"retention":   app.Retention,
+		"unique":      app.Unique,
+		"numbering":   app.Numbering,
+	}
 }
 
-func (u *appUsecase) Update(app *domain.App) error {
-	return u.repo.Update(app)
+// validateApp проверяет схему полей приложения перед сохранением
+func validateApp(app *domain.App) error {
+	if app.MaxFileSize < 0 {
+		return fmt.Errorf("%w: maxFileSize must not be negative", domain.ErrValidation)
+	}
+	seen := make(map[string]bool, len(app.Fields))
+	for _, field := range app.Fields {
+		if !fieldCodePattern.MatchString(field.Code) {
+			return fmt.Errorf("%w: invalid field code %q", domain.ErrValidation, field.Code)
+		}
+		if seen[field.Code] {
+			return fmt.Errorf("%w: duplicate field %s", domain.ErrValidation, field.Code)
+		}
+		seen[field.Code] = true
+		if !fieldTypes[field.Type] {
+			return fmt.Errorf("%w: field %s has unknown type %q", domain.ErrValidation, field.Code, field.Type)
+		}
+		if field.Localized {
+			// значения локализуемого поля — словарь локалей, уникальность по нему не имеет смысла
+			if field.Type != domain.FieldTypeString || field.Formula != "" {
+				return fmt.Errorf("%w: only plain string fields can be localized, field %s", domain.ErrValidation, field.Code)
+			}
+			if field.Unique {
+				return fmt.Errorf("%w: localized field %s cannot be unique", domain.ErrValidation, field.Code)
+			}
+		}
+	}
+	// формулы разбираются и проверяются при сохранении схемы, чтобы ошибки не всплывали при записи данных
+	if _, err := compileFormulas(app); err != nil {
+		return err
+	}
+	if err := validateWorkflow(app); err != nil {
+		return err
+	}
+	if err := validateApproval(app); err != nil {
+		return err
+	}
+	if err := validateRetention(app); err != nil {
+		return err
+	}
+	if err := validateNumbering(app); err != nil {
+		return err
+	}
+	return validateIndexes(app)
 }
 
-func (u *appUsecase) Delete(code, namespaceCode string) error {
-	return u.repo.Delete(code, namespaceCode)
+// validateNumbering проверяет шаблон номеров: счётчик в нём ровно один, а при сбросе
+// по периодам шаблон содержит период, иначе номера разных периодов совпадут.
+// Номер передаётся в пути запроса, поэтому / в шаблоне недопустим
+func validateNumbering(app *domain.App) error {
+	policy := app.Numbering
+	if policy == nil {
+		return nil
+	}
+	if strings.Contains(policy.Format, "/") {
+		return fmt.Errorf("%w: numbering format must not contain /", domain.ErrValidation)
+	}
+	tokens := make(map[string]int)
+	for _, token := range policy.Tokens() {
+		tokens[token]++
+	}
+	if tokens["0"] != 1 {
+		return fmt.Errorf("%w: numbering format must contain exactly one counter like {00000}", domain.ErrValidation)
+	}
+	hasYear := tokens["YYYY"] > 0 || tokens["YY"] > 0
+	switch policy.Reset {
+	case domain.NumberResetNever:
+	case domain.NumberResetYearly:
+		if !hasYear {
+			return fmt.Errorf("%w: yearly numbering format must contain {YYYY} or {YY}", domain.ErrValidation)
+		}
+	case domain.NumberResetMonthly:
+		if !hasYear || tokens["MM"] == 0 {
+			return fmt.Errorf("%w: monthly numbering format must contain a year and {MM}", domain.ErrValidation)
+		}
+	default:
+		return fmt.Errorf("%w: unknown numbering reset %q", domain.ErrValidation, policy.Reset)
+	}
+	return nil
 }
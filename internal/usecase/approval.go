@@ -0,0 +1,311 @@
+package usecase
+
+import (
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/reqctx"
+	"context"
+	"fmt"
+	"time"
+)
+
+// defaultApprovalTTL — срок жизни запроса на изменение, если в политике он не задан
+const defaultApprovalTTL = 7 * 24 * time.Hour
+
+// ChangeRequestRepo — хранилище запросов на изменение и голосов проверяющих
+type ChangeRequestRepo interface {
+	Create(ctx context.Context, request *domain.ChangeRequest) error
+	// GetByID возвращает запрос вместе с голосами, nil если его нет
+	GetByID(ctx context.Context, id string) (*domain.ChangeRequest, error)
+	List(ctx context.Context, filter domain.ChangeRequestFilter) ([]*domain.ChangeRequest, error)
+	// Inbox возвращает до limit ожидающих запросов, по которым actor может проголосовать
+	Inbox(ctx context.Context, actor string, roles []string, limit int) ([]*domain.ChangeRequest, error)
+	// LockRecord блокирует запись до конца транзакции и возвращает время её изменения
+	LockRecord(ctx context.Context, namespace, appName, uid string) (time.Time, error)
+	// AddVote сохраняет голос; повторный голос на том же шаге — ErrConflict
+	AddVote(ctx context.Context, id string, vote *domain.ApprovalVote) error
+	// Advance переводит ожидающий запрос с шага step на следующий, false — если его уже перевели
+	Advance(ctx context.Context, id string, step int) (bool, error)
+	// Decide завершает ожидающий запрос на шаге step, false — если его уже завершили
+	Decide(ctx context.Context, id string, step int, status string) (bool, error)
+	// MarkFailed отмечает ожидающий запрос неудавшимся
+	MarkFailed(ctx context.Context, id, message string) error
+	// Expire переводит просроченные запросы в статус expired и возвращает их
+	Expire(ctx context.Context, now time.Time) ([]*domain.ChangeRequest, error)
+}
+
+type ApprovalUsecase interface {
+	Get(ctx context.Context, id string) (*domain.ChangeRequest, error)
+	List(ctx context.Context, filter domain.ChangeRequestFilter) ([]*domain.ChangeRequest, error)
+	// Inbox возвращает до limit запросов, ожидающих голоса текущего пользователя
+	Inbox(ctx context.Context, limit int) ([]*domain.ChangeRequest, error)
+	Approve(ctx context.Context, id string, decision domain.ApprovalDecision) (*domain.ChangeRequest, error)
+	Reject(ctx context.Context, id string, decision domain.ApprovalDecision) (*domain.ChangeRequest, error)
+	ExpireStale(ctx context.Context) (int64, error)
+}
+
+type approvalUsecase struct {
+	repo  ChangeRequestRepo
+	data  AppDataUsecase
+	tx    Transactor
+	audit AuditUsecase
+}
+
+// NewApprovalUsecase принимает usecase данных: одобренное изменение применяется
+// с теми же проверками, что и обычная правка записи
+func NewApprovalUsecase(repo ChangeRequestRepo, data AppDataUsecase, tx Transactor, audit AuditUsecase) ApprovalUsecase {
+	return &approvalUsecase{repo: repo, data: data, tx: tx, audit: audit}
+}
+
+func (u *approvalUsecase) Get(ctx context.Context, id string) (*domain.ChangeRequest, error) {
+	request, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if request == nil {
+		return nil, fmt.Errorf("change request %s: %w", id, domain.ErrNotFound)
+	}
+	return request, nil
+}
+
+func (u *approvalUsecase) List(ctx context.Context, filter domain.ChangeRequestFilter) ([]*domain.ChangeRequest, error) {
+	return u.repo.List(ctx, filter)
+}
+
+func (u *approvalUsecase) Inbox(ctx context.Context, limit int) ([]*domain.ChangeRequest, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	meta := reqctx.FromContext(ctx)
+	return u.repo.Inbox(ctx, meta.Actor, meta.Roles, limit)
+}
+
+// Approve учитывает голос «за». Набрав нужное число одобрений, запрос переходит
+// к следующему шагу, а после последнего шага изменение применяется к записи
+func (u *approvalUsecase) Approve(ctx context.Context, id string, decision domain.ApprovalDecision) (*domain.ChangeRequest, error) {
+	request, err := u.vote(ctx, id, domain.DecisionApprove, decision.Comment)
+	if err != nil {
+		return nil, err
+	}
+
+	step := request.Step
+	approvals := 0
+	for _, vote := range request.Votes {
+		if vote.Step == step && vote.Decision == domain.DecisionApprove {
+			approvals++
+		}
+	}
+	if approvals < request.Policy.Steps[step].Required {
+		return request, nil
+	}
+
+	if step+1 < len(request.Policy.Steps) {
+		if _, err := u.repo.Advance(ctx, id, step); err != nil {
+			return nil, err
+		}
+		return u.Get(ctx, id)
+	}
+
+	// решение и изменение записи фиксируются вместе; применяет изменение только тот,
+	// кто первым завершил запрос
+	var applyErr error
+	err = inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		decided, err := u.repo.Decide(ctx, id, step, domain.ChangeApproved)
+		if err != nil || !decided {
+			return err
+		}
+		if err := recordAudit(ctx, u.audit, "approval.approve", request.NamespaceCode, request.AppCode, request.RecordUID, map[string]interface{}{"request": id}); err != nil {
+			return err
+		}
+		applyErr = u.apply(withApproval(ctx), request)
+		return applyErr
+	})
+	if applyErr != nil {
+		// решение откатилось вместе с изменением, запрос отмечается неудавшимся отдельно
+		err := inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+			if err := u.repo.MarkFailed(ctx, id, applyErr.Error()); err != nil {
+				return err
+			}
+			return recordAudit(ctx, u.audit, "approval.fail", request.NamespaceCode, request.AppCode, request.RecordUID, map[string]interface{}{"request": id, "error": applyErr.Error()})
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+	return u.Get(ctx, id)
+}
+
+// Reject отклоняет запрос голосом любого проверяющего текущего шага
+func (u *approvalUsecase) Reject(ctx context.Context, id string, decision domain.ApprovalDecision) (*domain.ChangeRequest, error) {
+	request, err := u.vote(ctx, id, domain.DecisionReject, decision.Comment)
+	if err != nil {
+		return nil, err
+	}
+	err = inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		decided, err := u.repo.Decide(ctx, id, request.Step, domain.ChangeRejected)
+		if err != nil || !decided {
+			return err
+		}
+		return recordAudit(ctx, u.audit, "approval.reject", request.NamespaceCode, request.AppCode, request.RecordUID, map[string]interface{}{"request": id, "comment": decision.Comment})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u.Get(ctx, id)
+}
+
+func (u *approvalUsecase) ExpireStale(ctx context.Context) (int64, error) {
+	var expired []*domain.ChangeRequest
+	err := inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		var err error
+		expired, err = u.repo.Expire(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+		for _, request := range expired {
+			if err := recordAudit(ctx, u.audit, "approval.expire", request.NamespaceCode, request.AppCode, request.RecordUID, map[string]interface{}{"request": request.ID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(expired)), nil
+}
+
+// vote проверяет право текущего пользователя голосовать, сохраняет голос
+// и возвращает запрос с учётом голосов, поданных параллельно
+func (u *approvalUsecase) vote(ctx context.Context, id, decision, comment string) (*domain.ChangeRequest, error) {
+	request, err := u.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if request.Status != domain.ChangePending {
+		return nil, fmt.Errorf("%w: change request is %s", domain.ErrConflict, request.Status)
+	}
+	if time.Now().After(request.ExpiresAt) {
+		return nil, fmt.Errorf("%w: change request has expired", domain.ErrConflict)
+	}
+	meta := reqctx.FromContext(ctx)
+	if err := checkReviewer(request, meta); err != nil {
+		return nil, err
+	}
+
+	vote := &domain.ApprovalVote{Step: request.Step, Reviewer: meta.Actor, Decision: decision, Comment: comment}
+	if err := u.repo.AddVote(ctx, id, vote); err != nil {
+		return nil, err
+	}
+	return u.Get(ctx, id)
+}
+
+// apply применяет одобренное изменение в транзакции решения. Частичное обновление
+// ложится на текущую версию записи, а полная замена — только на ту, для которой запрошена
+func (u *approvalUsecase) apply(ctx context.Context, request *domain.ChangeRequest) error {
+	if request.Kind == domain.ChangePatch {
+		return u.data.UpdateDataPartial(ctx, request.NamespaceCode, request.AppCode, request.RecordUID, request.Data)
+	}
+	updatedAt, err := u.repo.LockRecord(ctx, request.NamespaceCode, request.AppCode, request.RecordUID)
+	if err != nil {
+		return err
+	}
+	if request.BaseUpdatedAt != nil && !updatedAt.Equal(*request.BaseUpdatedAt) {
+		return fmt.Errorf("%w: record was changed while the request was pending", domain.ErrConflict)
+	}
+	return u.data.Update(ctx, request.NamespaceCode, request.AppCode, &domain.AppData{UID: request.RecordUID, Data: request.Data})
+}
+
+// checkReviewer проверяет, что пользователь может голосовать на текущем шаге запроса:
+// автор изменения не согласует его сам, и голосовать на шаге можно один раз
+func checkReviewer(request *domain.ChangeRequest, meta reqctx.Meta) error {
+	if meta.Actor == request.RequestedBy {
+		return fmt.Errorf("%w: the author cannot review own change", domain.ErrForbidden)
+	}
+	step := request.Policy.Steps[request.Step]
+	if !step.Eligible(meta.Actor, meta.Roles) {
+		return fmt.Errorf("%w: %s is not a reviewer of this step", domain.ErrForbidden, meta.Actor)
+	}
+	for _, vote := range request.Votes {
+		if vote.Step == request.Step && vote.Reviewer == meta.Actor {
+			return fmt.Errorf("%w: %s has already voted", domain.ErrConflict, meta.Actor)
+		}
+	}
+	return nil
+}
+
+// requestChange сохраняет правку записи как запрос на согласование; base — время
+// изменения записи, которую заменяет полная замена
+func requestChange(ctx context.Context, repo ChangeRequestRepo, tx Transactor, audit AuditUsecase, app *domain.App, uid, kind string, data map[string]interface{}, base *time.Time) error {
+	ttl := defaultApprovalTTL
+	if app.Approval.TTL != "" {
+		ttl, _ = time.ParseDuration(app.Approval.TTL)
+	}
+	now := time.Now()
+	request := &domain.ChangeRequest{
+		NamespaceCode: app.NamespaceCode,
+		AppCode:       app.Code,
+		RecordUID:     uid,
+		Kind:          kind,
+		Data:          data,
+		Status:        domain.ChangePending,
+		Policy:        *app.Approval,
+		RequestedBy:   reqctx.FromContext(ctx).Actor,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(ttl),
+		BaseUpdatedAt: base,
+	}
+	err := inTx(ctx, tx, audit, func(ctx context.Context) error {
+		if err := repo.Create(ctx, request); err != nil {
+			return err
+		}
+		return recordAudit(ctx, audit, "approval.request", app.NamespaceCode, app.Code, uid, map[string]interface{}{"request": request.ID, "kind": kind})
+	})
+	if err != nil {
+		return err
+	}
+	return &domain.PendingApprovalError{Request: request}
+}
+
+type approvedKey struct{}
+
+// withApproval помечает контекст применения одобренного изменения:
+// такая правка записывается сразу, без нового запроса на согласование
+func withApproval(ctx context.Context) context.Context {
+	return context.WithValue(ctx, approvedKey{}, true)
+}
+
+func needsApproval(ctx context.Context, app *domain.App) bool {
+	approved, _ := ctx.Value(approvedKey{}).(bool)
+	return app.Approval != nil && !approved
+}
+
+// validateApproval проверяет правила согласования приложения
+func validateApproval(app *domain.App) error {
+	policy := app.Approval
+	if policy == nil {
+		return nil
+	}
+	if len(policy.Steps) == 0 {
+		return fmt.Errorf("%w: approval policy has no steps", domain.ErrValidation)
+	}
+	for i, step := range policy.Steps {
+		if len(step.Users) == 0 && len(step.Roles) == 0 {
+			return fmt.Errorf("%w: approval step %d has no reviewers", domain.ErrValidation, i+1)
+		}
+		if step.Required < 1 {
+			return fmt.Errorf("%w: approval step %d must require at least one approval", domain.ErrValidation, i+1)
+		}
+		if len(step.Roles) == 0 && step.Required > len(step.Users) {
+			return fmt.Errorf("%w: approval step %d requires %d of %d reviewers", domain.ErrValidation, i+1, step.Required, len(step.Users))
+		}
+	}
+	if policy.TTL != "" {
+		ttl, err := time.ParseDuration(policy.TTL)
+		if err != nil || ttl <= 0 {
+			return fmt.Errorf("%w: invalid approval ttl %q", domain.ErrValidation, policy.TTL)
+		}
+	}
+	return nil
+}
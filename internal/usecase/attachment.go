@@ -0,0 +1,287 @@
+package usecase
+
+import (
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/storage"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"path/filepath"
+)
+
+// gcBatchSize — сколько вложений удаляется сборщиком мусора за один запрос
+const gcBatchSize = 500
+
+// AttachmentRepo — хранилище метаданных вложений
+type AttachmentRepo interface {
+	Create(ctx context.Context, attachment *domain.Attachment) error
+	GetByField(ctx context.Context, namespace, appName, uid, field string) (*domain.Attachment, error)
+	Delete(ctx context.Context, id string) error
+	ListByRecords(ctx context.Context, namespace, appName string, uids []string) ([]*domain.Attachment, error)
+	FindOrphans(ctx context.Context, namespace, appName string, limit int) ([]*domain.Attachment, error)
+	FindDetached(ctx context.Context, limit int) ([]*domain.Attachment, error)
+}
+
+type AttachmentUsecase interface {
+	Upload(ctx context.Context, namespace, appName, uid, field, fileName string, body io.Reader) (*domain.Attachment, error)
+	Open(ctx context.Context, namespace, appName, uid, field string) (*domain.Attachment, io.ReadCloser, error)
+	Delete(ctx context.Context, namespace, appName, uid, field string) error
+	// RemoveRecords удаляет вложения окончательно удалённых записей
+	RemoveRecords(ctx context.Context, namespace, appName string, uids []string) (int64, error)
+	CollectGarbage(ctx context.Context) (int64, error)
+}
+
+type attachmentUsecase struct {
+	repo           AttachmentRepo
+	storage        storage.BlobStorage
+	apps           AppUsecase
+	data           AppDataUsecase
+	audit          AuditUsecase
+	defaultMaxSize int64
+}
+
+// NewAttachmentUsecase принимает репозиторий данных напрямую: поля типа file
+// нельзя менять через AppDataUsecase, это делает только загрузка вложений
+func NewAttachmentUsecase(repo AttachmentRepo, storage storage.BlobStorage, apps AppUsecase, data AppDataUsecase, audit AuditUsecase, defaultMaxSize int64) AttachmentUsecase {
+	return &attachmentUsecase{
+		repo:           repo,
+		storage:        storage,
+		apps:           apps,
+		data:           data,
+		audit:          audit,
+		defaultMaxSize: defaultMaxSize,
+	}
+}
+
+// Upload сохраняет файл в хранилище и записывает ссылку на него в поле записи.
+// Тип содержимого определяется по самим данным, заголовок клиента не учитывается
+func (u *attachmentUsecase) Upload(ctx context.Context, namespace, appName, uid, field, fileName string, body io.Reader) (*domain.Attachment, error) {
+	app, err := u.fileField(ctx, namespace, appName, field)
+	if err != nil {
+		return nil, err
+	}
+	// запись читается из репозитория в хранимом виде только для проверки, что она есть
+	if _, err := u.data.GetDataByUID(ctx, namespace, appName, uid); err != nil {
+		return nil, err
+	}
+	previous, err := u.repo.GetByField(ctx, namespace, appName, uid, field)
+	if err != nil {
+		return nil, err
+	}
+
+	limit := app.MaxFileSize
+	if limit <= 0 {
+		limit = u.defaultMaxSize
+	}
+
+	head := make([]byte, 512)
+	n, err := io.ReadFull(body, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	head = head[:n]
+
+	hash := sha256.New()
+	counter := &countingReader{r: io.LimitReader(io.MultiReader(bytes.NewReader(head), body), limit+1)}
+	attachment := &domain.Attachment{
+		ID:            newUUID(),
+		NamespaceCode: namespace,
+		AppCode:       appName,
+		RecordUID:     uid,
+		Field:         field,
+		FileName:      cleanFileName(fileName),
+		ContentType:   http.DetectContentType(head),
+	}
+	attachment.StorageKey = path.Join("attachments", namespace, appName, uid, attachment.ID)
+
+	if err := u.storage.Put(ctx, attachment.StorageKey, io.TeeReader(counter, hash)); err != nil {
+		return nil, fmt.Errorf("failed to store file: %w", err)
+	}
+	if counter.n > limit {
+		u.storage.Delete(ctx, attachment.StorageKey)
+		return nil, fmt.Errorf("%w: limit is %d bytes", domain.ErrTooLarge, limit)
+	}
+	attachment.Size = counter.n
+	attachment.SHA256 = hex.EncodeToString(hash.Sum(nil))
+
+	if err := u.repo.Create(ctx, attachment); err != nil {
+		u.storage.Delete(ctx, attachment.StorageKey)
+		return nil, err
+	}
+	if err := u.data.UpdateDataPartial(ctx, namespace, appName, uid, map[string]interface{}{field: attachment.Ref()}); err != nil {
+		u.remove(ctx, attachment)
+		return nil, err
+	}
+
+	change := auditChange{New: attachment.Ref()}
+	if previous != nil {
+		change.Old = previous.Ref()
+		u.remove(ctx, previous)
+	}
+	if err := recordAudit(ctx, u.audit, "attachment.upload", namespace, appName, uid, map[string]auditChange{field: change}); err != nil {
+		return nil, err
+	}
+	return attachment, nil
+}
+
+// Open возвращает метаданные вложения и поток с его содержимым, поток закрывает вызывающий
+func (u *attachmentUsecase) Open(ctx context.Context, namespace, appName, uid, field string) (*domain.Attachment, io.ReadCloser, error) {
+	attachment, err := u.current(ctx, namespace, appName, uid, field)
+	if err != nil {
+		return nil, nil, err
+	}
+	body, err := u.storage.Get(ctx, attachment.StorageKey)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open file %s: %w", attachment.ID, err)
+	}
+	return attachment, body, nil
+}
+
+func (u *attachmentUsecase) Delete(ctx context.Context, namespace, appName, uid, field string) error {
+	attachment, err := u.current(ctx, namespace, appName, uid, field)
+	if err != nil {
+		return err
+	}
+	if err := u.data.UpdateDataPartial(ctx, namespace, appName, uid, map[string]interface{}{field: nil}); err != nil {
+		return err
+	}
+	u.remove(ctx, attachment)
+	return recordAudit(ctx, u.audit, "attachment.delete", namespace, appName, uid, map[string]auditChange{field: {Old: attachment.Ref()}})
+}
+
+func (u *attachmentUsecase) RemoveRecords(ctx context.Context, namespace, appName string, uids []string) (int64, error) {
+	if len(uids) == 0 {
+		return 0, nil
+	}
+	attachments, err := u.repo.ListByRecords(ctx, namespace, appName, uids)
+	if err != nil {
+		return 0, err
+	}
+	var removed int64
+	for _, attachment := range attachments {
+		if err := u.remove(ctx, attachment); err != nil {
+			return removed, err
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// CollectGarbage удаляет вложения записей и приложений, которые окончательно удалены из корзины
+func (u *attachmentUsecase) CollectGarbage(ctx context.Context) (int64, error) {
+	var removed int64
+	apps, err := u.apps.GetAll(ctx)
+	if err != nil {
+		return removed, err
+	}
+	for _, app := range apps {
+		n, err := u.collect(ctx, func() ([]*domain.Attachment, error) {
+			return u.repo.FindOrphans(ctx, app.NamespaceCode, app.Code, gcBatchSize)
+		})
+		removed += n
+		if err != nil {
+			return removed, err
+		}
+	}
+	n, err := u.collect(ctx, func() ([]*domain.Attachment, error) {
+		return u.repo.FindDetached(ctx, gcBatchSize)
+	})
+	return removed + n, err
+}
+
+func (u *attachmentUsecase) collect(ctx context.Context, find func() ([]*domain.Attachment, error)) (int64, error) {
+	var removed int64
+	for {
+		batch, err := find()
+		if err != nil {
+			return removed, err
+		}
+		for _, attachment := range batch {
+			if err := u.remove(ctx, attachment); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+		if len(batch) < gcBatchSize {
+			return removed, nil
+		}
+	}
+}
+
+// remove удаляет файл и его метаданные. Сначала удаляется файл: если удаление
+// метаданных не пройдёт, сборщик мусора повторит попытку
+func (u *attachmentUsecase) remove(ctx context.Context, attachment *domain.Attachment) error {
+	if err := u.storage.Delete(ctx, attachment.StorageKey); err != nil {
+		slog.ErrorContext(ctx, "attachments: failed to delete blob", "key", attachment.StorageKey, "error", err)
+		return err
+	}
+	return u.repo.Delete(ctx, attachment.ID)
+}
+
+func (u *attachmentUsecase) current(ctx context.Context, namespace, appName, uid, field string) (*domain.Attachment, error) {
+	if _, err := u.fileField(ctx, namespace, appName, field); err != nil {
+		return nil, err
+	}
+	if _, err := u.data.GetDataByUID(ctx, namespace, appName, uid); err != nil {
+		return nil, err
+	}
+	attachment, err := u.repo.GetByField(ctx, namespace, appName, uid, field)
+	if err != nil {
+		return nil, err
+	}
+	if attachment == nil {
+		return nil, fmt.Errorf("file in field %s: %w", field, domain.ErrNotFound)
+	}
+	return attachment, nil
+}
+
+// fileField проверяет, что у приложения есть поле field типа file
+func (u *attachmentUsecase) fileField(ctx context.Context, namespace, appName, field string) (*domain.App, error) {
+	app, err := u.apps.GetByCode(ctx, appName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		return nil, fmt.Errorf("app %s: %w", appName, domain.ErrNotFound)
+	}
+	f := app.FieldByCode(field)
+	if f == nil || f.Type != domain.FieldTypeFile {
+		return nil, fmt.Errorf("%w: field %s is not a file field", domain.ErrValidation, field)
+	}
+	return app, nil
+}
+
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func cleanFileName(name string) string {
+	name = filepath.Base(filepath.Clean("/" + name))
+	if name == "/" || name == "." {
+		return "file"
+	}
+	return name
+}
+
+// newUUID возвращает случайный UUID версии 4
+func newUUID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,148 @@
+package usecase
+
+import (
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/metrics"
+	"app/backendv1/internal/reqctx"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"strings"
+)
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+type AuditUsecase interface {
+	Append(ctx context.Context, entry *domain.AuditEntry) error
+	// AppendAll добавляет записи в журнал по порядку одним шагом
+	AppendAll(ctx context.Context, entries []*domain.AuditEntry) error
+	Find(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error)
+	Verify(ctx context.Context) (*domain.AuditVerification, error)
+}
+
+type auditUsecase struct {
+	repo AuditUsecase
+}
+
+func NewAuditUsecase(repo AuditUsecase) AuditUsecase {
+	return &auditUsecase{repo: repo}
+}
+
+// Append дополняет запись сведениями о запросе из контекста и добавляет её в журнал
+func (u *auditUsecase) Append(ctx context.Context, entry *domain.AuditEntry) error {
+	withMeta(ctx, entry)
+	return u.repo.Append(ctx, entry)
+}
+
+func (u *auditUsecase) AppendAll(ctx context.Context, entries []*domain.AuditEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	for _, entry := range entries {
+		withMeta(ctx, entry)
+	}
+	return u.repo.AppendAll(ctx, entries)
+}
+
+// withMeta дополняет запись сведениями о запросе из контекста
+func withMeta(ctx context.Context, entry *domain.AuditEntry) {
+	meta := reqctx.FromContext(ctx)
+	if entry.Actor == "" {
+		entry.Actor = meta.Actor
+	}
+	if entry.RequestID == "" {
+		entry.RequestID = meta.RequestID
+	}
+	if entry.SourceIP == "" {
+		entry.SourceIP = meta.SourceIP
+	}
+}
+
+func (u *auditUsecase) Find(ctx context.Context, filter domain.AuditFilter) ([]*domain.AuditEntry, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuditLimit
+	}
+	if filter.Limit > maxAuditLimit {
+		filter.Limit = maxAuditLimit
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	return u.repo.Find(ctx, filter)
+}
+
+func (u *auditUsecase) Verify(ctx context.Context) (*domain.AuditVerification, error) {
+	return u.repo.Verify(ctx)
+}
+
+// recordAudit пишет операцию в журнал. В транзакции inTx запись откладывается до её
+// конца и добавляется вместе с изменением, а ошибка журнала откатывает изменение.
+// Вне транзакции операция к этому моменту уже выполнена, и ошибка журнала логируется
+// и возвращается: клиент должен узнать, что изменения нет в журнале
+func recordAudit(ctx context.Context, audit AuditUsecase, action, namespaceCode, appCode, uid string, diff interface{}) error {
+	// журнал пишется после каждого изменения: запрос с ключом идемпотентности, упавший
+	// после него, сохраняет ответ, а не выполняется при повторе заново
+	afterCommit(ctx, func() { reqctx.MarkWritten(ctx) })
+	// каждое изменение записей попадает в журнал, поэтому здесь же оно учитывается в метриках
+	if write, ok := strings.CutPrefix(action, "app_data."); ok {
+		afterCommit(ctx, func() { metrics.RecordWrite(namespaceCode, appCode, write) })
+	}
+	if audit == nil {
+		return nil
+	}
+	entry := &domain.AuditEntry{
+		Action:        action,
+		NamespaceCode: namespaceCode,
+		AppCode:       appCode,
+		UID:           uid,
+	}
+	if diff != nil {
+		raw, err := json.Marshal(diff)
+		if err != nil {
+			slog.ErrorContext(ctx, "audit: failed to marshal diff", "action", action, "error", err)
+		} else {
+			entry.Diff = raw
+		}
+	}
+	if deferAudit(ctx, entry) {
+		return nil
+	}
+	// изменение уже зафиксировано: отключение клиента не должно оставить его без записи в журнале
+	if err := audit.Append(context.WithoutCancel(ctx), entry); err != nil {
+		slog.ErrorContext(ctx, "audit: failed to record entry", "action", action, "error", err)
+		return fmt.Errorf("failed to record audit: %w", err)
+	}
+	return nil
+}
+
+// auditChange — изменение одного поля
+type auditChange struct {
+	Old interface{} `json:"old,omitempty"`
+	New interface{} `json:"new,omitempty"`
+}
+
+// diffFields возвращает изменившиеся поля между двумя состояниями объекта
+func diffFields(before, after map[string]interface{}) map[string]auditChange {
+	diff := make(map[string]auditChange)
+	for field, oldValue := range before {
+		newValue, ok := after[field]
+		if !ok {
+			diff[field] = auditChange{Old: oldValue}
+			continue
+		}
+		if !reflect.DeepEqual(oldValue, newValue) {
+			diff[field] = auditChange{Old: oldValue, New: newValue}
+		}
+	}
+	for field, newValue := range after {
+		if _, ok := before[field]; !ok {
+			diff[field] = auditChange{New: newValue}
+		}
+	}
+	return diff
+}
@@ -0,0 +1,638 @@
+package usecase
+
+import (
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/formula"
+	"app/backendv1/internal/reqctx"
+	"app/backendv1/internal/tracing"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxAutomationDepth — сколько правил подряд может вызвать друг друга цепочкой изменений
+const maxAutomationDepth = 5
+
+// ruleBatchSize — сколько записей читается за раз при запуске правила по всем записям
+const ruleBatchSize = 500
+
+// webhookTimeout ограничивает время ответа адресата вебхука
+const webhookTimeout = 10 * time.Second
+
+// messagePlaceholder — подстановка значения поля записи в текст уведомления
+var messagePlaceholder = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+var automationEvents = map[string]bool{
+	domain.EventCreated: true,
+	domain.EventUpdated: true,
+	domain.EventDeleted: true,
+}
+
+// EventPublisher принимает события изменения записей для асинхронной обработки
+type EventPublisher interface {
+	Publish(ctx context.Context, event domain.DataEvent)
+}
+
+// AutomationRepo — хранилище правил автоматизации и журнала их выполнения
+type AutomationRepo interface {
+	CreateRule(ctx context.Context, rule *domain.AutomationRule) error
+	// GetRule возвращает правило по ID, nil если его нет
+	GetRule(ctx context.Context, id string) (*domain.AutomationRule, error)
+	ListRules(ctx context.Context, namespace, appName string) ([]*domain.AutomationRule, error)
+	UpdateRule(ctx context.Context, rule *domain.AutomationRule) error
+	DeleteRule(ctx context.Context, id string) error
+	SaveRun(ctx context.Context, run *domain.AutomationRun) error
+	ListRuns(ctx context.Context, ruleID string, limit int) ([]*domain.AutomationRun, error)
+}
+
+type AutomationUsecase interface {
+	CreateRule(ctx context.Context, rule *domain.AutomationRule) error
+	GetRules(ctx context.Context, namespace, appName string) ([]*domain.AutomationRule, error)
+	GetRule(ctx context.Context, namespace, appName, id string) (*domain.AutomationRule, error)
+	UpdateRule(ctx context.Context, rule *domain.AutomationRule) error
+	DeleteRule(ctx context.Context, namespace, appName, id string) error
+	Runs(ctx context.Context, namespace, appName, id string, limit int) ([]*domain.AutomationRun, error)
+	// TestRun проверяет условие правила на примере записи и возвращает план действий, ничего не выполняя
+	TestRun(ctx context.Context, namespace, appName, id string, req domain.RuleTestRequest) (*domain.RuleTestResult, error)
+	// HandleEvent выполняет правила приложения, подходящие под событие
+	HandleEvent(ctx context.Context, event domain.DataEvent)
+	RunRule(ctx context.Context, namespace, appName, id string) (int64, error)
+}
+
+type automationUsecase struct {
+	repo          AutomationRepo
+	apps          AppUsecase
+	data          AppDataUsecase
+	notifications NotificationRepo
+	tx            Transactor
+	audit         AuditUsecase
+	client        *http.Client
+}
+
+func NewAutomationUsecase(repo AutomationRepo, apps AppUsecase, data AppDataUsecase, notifications NotificationRepo, tx Transactor, audit AuditUsecase) AutomationUsecase {
+	return &automationUsecase{
+		repo:          repo,
+		apps:          apps,
+		data:          data,
+		notifications: notifications,
+		tx:            tx,
+		audit:         audit,
+		client:        newWebhookClient(),
+	}
+}
+
+func (u *automationUsecase) CreateRule(ctx context.Context, rule *domain.AutomationRule) error {
+	if err := u.validateRule(ctx, rule); err != nil {
+		return err
+	}
+	return inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		if err := u.repo.CreateRule(ctx, rule); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.audit, "automation.create", rule.NamespaceCode, rule.AppCode, "", diffFields(nil, ruleFields(rule)))
+	})
+}
+
+func (u *automationUsecase) GetRules(ctx context.Context, namespace, appName string) ([]*domain.AutomationRule, error) {
+	return u.repo.ListRules(ctx, namespace, appName)
+}
+
+func (u *automationUsecase) GetRule(ctx context.Context, namespace, appName, id string) (*domain.AutomationRule, error) {
+	rule, err := u.repo.GetRule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil || rule.NamespaceCode != namespace || rule.AppCode != appName {
+		return nil, fmt.Errorf("rule %s: %w", id, domain.ErrNotFound)
+	}
+	return rule, nil
+}
+
+func (u *automationUsecase) UpdateRule(ctx context.Context, rule *domain.AutomationRule) error {
+	before, err := u.GetRule(ctx, rule.NamespaceCode, rule.AppCode, rule.ID)
+	if err != nil {
+		return err
+	}
+	if err := u.validateRule(ctx, rule); err != nil {
+		return err
+	}
+	return inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		if err := u.repo.UpdateRule(ctx, rule); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.audit, "automation.update", rule.NamespaceCode, rule.AppCode, "", diffFields(ruleFields(before), ruleFields(rule)))
+	})
+}
+
+func (u *automationUsecase) DeleteRule(ctx context.Context, namespace, appName, id string) error {
+	if _, err := u.GetRule(ctx, namespace, appName, id); err != nil {
+		return err
+	}
+	return inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		if err := u.repo.DeleteRule(ctx, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.audit, "automation.delete", namespace, appName, "", map[string]interface{}{"rule": id})
+	})
+}
+
+func (u *automationUsecase) Runs(ctx context.Context, namespace, appName, id string, limit int) ([]*domain.AutomationRun, error) {
+	if _, err := u.GetRule(ctx, namespace, appName, id); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	return u.repo.ListRuns(ctx, id, limit)
+}
+
+func (u *automationUsecase) TestRun(ctx context.Context, namespace, appName, id string, req domain.RuleTestRequest) (*domain.RuleTestResult, error) {
+	rule, err := u.GetRule(ctx, namespace, appName, id)
+	if err != nil {
+		return nil, err
+	}
+	app, err := u.app(ctx, namespace, appName)
+	if err != nil {
+		return nil, err
+	}
+	if req.Event == "" {
+		req.Event = domain.EventCreated
+	}
+	if !automationEvents[req.Event] {
+		return nil, fmt.Errorf("%w: unknown event %q", domain.ErrValidation, req.Event)
+	}
+
+	record := make(map[string]interface{}, len(req.Record))
+	for field, value := range req.Record {
+		record[field] = value
+	}
+	if err := applyFormulas(app, record); err != nil {
+		return nil, err
+	}
+
+	result := &domain.RuleTestResult{}
+	if !hasEvent(rule, req.Event) {
+		result.Error = fmt.Sprintf("rule does not handle %s events", req.Event)
+		return result, nil
+	}
+	matched, err := matchRule(app, rule, record)
+	if err != nil {
+		result.Error = err.Error()
+		return result, nil
+	}
+	result.Matched = matched
+	if matched {
+		result.Actions, err = planActions(app, rule, record)
+		if err != nil {
+			result.Error = err.Error()
+		}
+	}
+	return result, nil
+}
+
+// HandleEvent выполняет подходящие правила и записывает результат в журнал.
+// Действия выполняются от имени правила, их изменения порождают новые события
+// с цепочкой правил: правило не срабатывает повторно в своей же цепочке
+func (u *automationUsecase) HandleEvent(ctx context.Context, event domain.DataEvent) {
+	// правила выполняются в трассе запроса, изменившего запись
+	ctx, span := startSpan(tracing.FromCarrier(ctx, event.Trace), "automation.HandleEvent", event.NamespaceCode, event.AppCode)
+	defer span.End()
+
+	rules, err := u.repo.ListRules(ctx, event.NamespaceCode, event.AppCode)
+	if err != nil {
+		slog.ErrorContext(ctx, "automation: failed to load rules", "namespace", event.NamespaceCode, "app", event.AppCode, "error", err)
+		return
+	}
+	if len(rules) == 0 {
+		return
+	}
+	app, err := u.app(ctx, event.NamespaceCode, event.AppCode)
+	if err != nil {
+		slog.ErrorContext(ctx, "automation failed", "error", err)
+		return
+	}
+
+	for _, rule := range rules {
+		if rule.Enabled && hasEvent(rule, event.Type) {
+			u.runRule(ctx, app, rule, event)
+		}
+	}
+}
+
+// RunRule выполняет правило для всех записей приложения, подходящих под его условие,
+// независимо от событий, на которые правило подписано. Возвращает число выполнений
+func (u *automationUsecase) RunRule(ctx context.Context, namespace, appName, id string) (int64, error) {
+	rule, err := u.GetRule(ctx, namespace, appName, id)
+	if err != nil {
+		return 0, err
+	}
+	app, err := u.app(ctx, namespace, appName)
+	if err != nil {
+		return 0, err
+	}
+
+	meta := reqctx.FromContext(ctx)
+	var processed int64
+	for offset := 0; ; offset += ruleBatchSize {
+		records, err := u.data.GetAll(ctx, namespace, appName, domain.DataQuery{Limit: ruleBatchSize, Offset: offset})
+		if err != nil {
+			return processed, err
+		}
+		for _, record := range records {
+			if err := ctx.Err(); err != nil {
+				return processed, err
+			}
+			event := domain.DataEvent{
+				Type:          domain.EventScheduled,
+				NamespaceCode: namespace,
+				AppCode:       appName,
+				RecordUID:     record.UID,
+				Data:          record.Data,
+				Actor:         meta.Actor,
+				RequestID:     meta.RequestID,
+				OccurredAt:    time.Now(),
+			}
+			if u.runRule(ctx, app, rule, event) {
+				processed++
+			}
+		}
+		if len(records) < ruleBatchSize {
+			return processed, nil
+		}
+	}
+}
+
+// runRule проверяет условие правила и выполняет его действия с записью в журнал.
+// Возвращает false, если условие не выполнено
+func (u *automationUsecase) runRule(ctx context.Context, app *domain.App, rule *domain.AutomationRule, event domain.DataEvent) bool {
+	run := &domain.AutomationRun{
+		RuleID:        rule.ID,
+		NamespaceCode: event.NamespaceCode,
+		AppCode:       event.AppCode,
+		RecordUID:     event.RecordUID,
+		Event:         event.Type,
+		Depth:         len(event.Chain),
+		StartedAt:     time.Now(),
+	}
+
+	values := event.Data
+	if values == nil {
+		values = map[string]interface{}{}
+	}
+	matched, err := matchRule(app, rule, values)
+	if err == nil && !matched {
+		return false
+	}
+	if inChain(event.Chain, rule.ID) || len(event.Chain) >= maxAutomationDepth {
+		run.Status = domain.RunSkipped
+		run.Error = "loop protection: " + strings.Join(append(append([]string(nil), event.Chain...), rule.ID), " -> ")
+		u.saveRun(ctx, run)
+		return true
+	}
+	if err == nil {
+		actionCtx := reqctx.WithMeta(ctx, reqctx.Meta{
+			RequestID: event.RequestID,
+			Actor:     "automation:" + rule.ID,
+		})
+		actionCtx = withAutomationChain(actionCtx, append(append([]string(nil), event.Chain...), rule.ID))
+		run.Actions, err = u.execute(actionCtx, app, rule, event, values)
+	}
+	run.Status = domain.RunSuccess
+	if err != nil {
+		run.Status = domain.RunFailed
+		run.Error = err.Error()
+	}
+	u.saveRun(ctx, run)
+	return true
+}
+
+func (u *automationUsecase) saveRun(ctx context.Context, run *domain.AutomationRun) {
+	run.FinishedAt = time.Now()
+	if err := u.repo.SaveRun(ctx, run); err != nil {
+		slog.ErrorContext(ctx, "automation: failed to save rule run", "rule", run.RuleID, "error", err)
+	}
+}
+
+// execute выполняет действия правила. Изменения полей записи собираются
+// в одно частичное обновление и применяются первыми
+func (u *automationUsecase) execute(ctx context.Context, app *domain.App, rule *domain.AutomationRule, event domain.DataEvent, values map[string]interface{}) ([]domain.ActionResult, error) {
+	planned, err := planActions(app, rule, values)
+	if err != nil {
+		return nil, err
+	}
+
+	patch := make(map[string]interface{})
+	for _, action := range planned {
+		if action.Type == domain.ActionSetField {
+			patch[action.Target] = action.Data
+		}
+	}
+	if len(patch) > 0 {
+		// у приложения с согласованием правка уходит на согласование, это не ошибка правила
+		var pending *domain.PendingApprovalError
+		if err := u.data.UpdateDataPartial(ctx, event.NamespaceCode, event.AppCode, event.RecordUID, patch); err != nil && !errors.As(err, &pending) {
+			return planned, fmt.Errorf("set fields: %w", err)
+		}
+	}
+
+	for i, action := range rule.Actions {
+		var err error
+		switch action.Type {
+		case domain.ActionCreateRecord:
+			err = u.createRecord(ctx, app, action, planned[i])
+		case domain.ActionWebhook:
+			err = u.callWebhook(ctx, rule, action, event, values)
+		case domain.ActionNotify:
+			err = u.notify(ctx, rule, event, planned[i])
+		}
+		if err != nil {
+			planned[i].Error = err.Error()
+			return planned, fmt.Errorf("action %d (%s): %w", i+1, action.Type, err)
+		}
+	}
+	return planned, nil
+}
+
+func (u *automationUsecase) createRecord(ctx context.Context, app *domain.App, action domain.AutomationAction, planned domain.ActionResult) error {
+	namespace := action.Namespace
+	if namespace == "" {
+		namespace = app.NamespaceCode
+	}
+	values, _ := planned.Data.(map[string]interface{})
+	return u.data.Create(ctx, namespace, action.App, &domain.AppData{Data: values})
+}
+
+func (u *automationUsecase) callWebhook(ctx context.Context, rule *domain.AutomationRule, action domain.AutomationAction, event domain.DataEvent, values map[string]interface{}) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"rule":      rule.ID,
+		"event":     event.Type,
+		"namespace": event.NamespaceCode,
+		"app":       event.AppCode,
+		"uid":       event.RecordUID,
+		"data":      values,
+	})
+	if err != nil {
+		return err
+	}
+	return postWebhook(ctx, u.client, action.URL, action.Headers, body)
+}
+
+func (u *automationUsecase) notify(ctx context.Context, rule *domain.AutomationRule, event domain.DataEvent, planned domain.ActionResult) error {
+	message, _ := planned.Data.(string)
+	for _, recipient := range strings.Split(planned.Target, ",") {
+		n := &domain.Notification{
+			Recipient:     recipient,
+			Message:       message,
+			NamespaceCode: event.NamespaceCode,
+			AppCode:       event.AppCode,
+			RecordUID:     event.RecordUID,
+			RuleID:        rule.ID,
+		}
+		if err := u.notifications.Create(ctx, n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (u *automationUsecase) app(ctx context.Context, namespace, appName string) (*domain.App, error) {
+	app, err := u.apps.GetByCode(ctx, appName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		return nil, fmt.Errorf("app %s: %w", appName, domain.ErrNotFound)
+	}
+	return app, nil
+}
+
+// validateRule проверяет правило по схеме приложения: формулы компилируются,
+// поля и приложения из действий существуют
+func (u *automationUsecase) validateRule(ctx context.Context, rule *domain.AutomationRule) error {
+	app, err := u.app(ctx, rule.NamespaceCode, rule.AppCode)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(rule.Name) == "" {
+		return fmt.Errorf("%w: rule name is required", domain.ErrValidation)
+	}
+	if len(rule.Events) == 0 {
+		return fmt.Errorf("%w: rule must handle at least one event", domain.ErrValidation)
+	}
+	for _, event := range rule.Events {
+		if !automationEvents[event] {
+			return fmt.Errorf("%w: unknown event %q", domain.ErrValidation, event)
+		}
+	}
+	types := fieldFormulaTypes(app)
+	if rule.Condition != "" {
+		if _, err := formula.CompileCondition(rule.Condition, types); err != nil {
+			return fmt.Errorf("%w: condition: %v", domain.ErrValidation, err)
+		}
+	}
+	if len(rule.Actions) == 0 {
+		return fmt.Errorf("%w: rule has no actions", domain.ErrValidation)
+	}
+
+	for i, action := range rule.Actions {
+		if err := u.validateAction(ctx, app, action, types); err != nil {
+			return fmt.Errorf("%w: action %d (%s): %v", domain.ErrValidation, i+1, action.Type, err)
+		}
+	}
+	return nil
+}
+
+func (u *automationUsecase) validateAction(ctx context.Context, app *domain.App, action domain.AutomationAction, types map[string]formula.Type) error {
+	switch action.Type {
+	case domain.ActionSetField:
+		field := app.FieldByCode(action.Field)
+		if field == nil {
+			return fmt.Errorf("unknown field %q", action.Field)
+		}
+		if err := checkWritable(app, action.Field); err != nil {
+			return err
+		}
+		if (action.Value == nil) == (action.Formula == "") {
+			return fmt.Errorf("exactly one of value and formula is required")
+		}
+		if action.Formula != "" {
+			x, err := formula.CompileExpression(action.Formula, types)
+			if err != nil {
+				return err
+			}
+			if want := formulaTypes[field.Type]; x.Type() != want && x.Type() != formula.Null {
+				return fmt.Errorf("formula returns %s, field %s is %s", x.Type(), field.Code, want)
+			}
+		}
+	case domain.ActionCreateRecord:
+		namespace := action.Namespace
+		if namespace == "" {
+			namespace = app.NamespaceCode
+		}
+		if _, err := u.app(ctx, namespace, action.App); err != nil {
+			return fmt.Errorf("target app %s.%s not found", namespace, action.App)
+		}
+		for target, source := range action.Formulas {
+			if _, err := formula.CompileExpression(source, types); err != nil {
+				return fmt.Errorf("formula for %s: %v", target, err)
+			}
+		}
+	case domain.ActionWebhook:
+		if err := checkWebhookURL(action.URL); err != nil {
+			return err
+		}
+	case domain.ActionNotify:
+		if len(action.To) == 0 || action.Message == "" {
+			return fmt.Errorf("recipients and message are required")
+		}
+	default:
+		return fmt.Errorf("unknown action type")
+	}
+	return nil
+}
+
+// matchRule вычисляет условие правила по значениям записи
+func matchRule(app *domain.App, rule *domain.AutomationRule, values map[string]interface{}) (bool, error) {
+	if rule.Condition == "" {
+		return true, nil
+	}
+	condition, err := formula.CompileCondition(rule.Condition, fieldFormulaTypes(app))
+	if err != nil {
+		return false, fmt.Errorf("condition: %w", err)
+	}
+	return condition.Eval(values, time.Now()), nil
+}
+
+// planActions вычисляет значения действий по записи: что будет записано, создано или отправлено
+func planActions(app *domain.App, rule *domain.AutomationRule, values map[string]interface{}) ([]domain.ActionResult, error) {
+	types := fieldFormulaTypes(app)
+	now := time.Now()
+	eval := func(source string) (interface{}, error) {
+		x, err := formula.CompileExpression(source, types)
+		if err != nil {
+			return nil, err
+		}
+		return x.Eval(values, now), nil
+	}
+
+	planned := make([]domain.ActionResult, len(rule.Actions))
+	for i, action := range rule.Actions {
+		result := domain.ActionResult{Type: action.Type}
+		switch action.Type {
+		case domain.ActionSetField:
+			result.Target = action.Field
+			result.Data = action.Value
+			if action.Formula != "" {
+				value, err := eval(action.Formula)
+				if err != nil {
+					return planned, fmt.Errorf("action %d: %w", i+1, err)
+				}
+				result.Data = value
+			}
+		case domain.ActionCreateRecord:
+			result.Target = action.App
+			if action.Namespace != "" {
+				result.Target = action.Namespace + "." + action.App
+			}
+			data := make(map[string]interface{}, len(action.Values)+len(action.Formulas))
+			for field, value := range action.Values {
+				data[field] = value
+			}
+			for field, source := range action.Formulas {
+				value, err := eval(source)
+				if err != nil {
+					return planned, fmt.Errorf("action %d: %w", i+1, err)
+				}
+				data[field] = value
+			}
+			result.Data = data
+		case domain.ActionWebhook:
+			result.Target = action.URL
+		case domain.ActionNotify:
+			result.Target = strings.Join(action.To, ",")
+			result.Data = messagePlaceholder.ReplaceAllStringFunc(action.Message, func(placeholder string) string {
+				value := values[placeholder[1:len(placeholder)-1]]
+				if value == nil {
+					return ""
+				}
+				return fmt.Sprint(value)
+			})
+		}
+		planned[i] = result
+	}
+	return planned, nil
+}
+
+func hasEvent(rule *domain.AutomationRule, event string) bool {
+	for _, e := range rule.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+func inChain(chain []string, ruleID string) bool {
+	for _, id := range chain {
+		if id == ruleID {
+			return true
+		}
+	}
+	return false
+}
+
+func ruleFields(rule *domain.AutomationRule) map[string]interface{} {
+	if rule == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"name":      rule.Name,
+		"events":    rule.Events,
+		"condition": rule.Condition,
+		"actions":   rule.Actions,
+		"enabled":   rule.Enabled,
+	}
+}
+
+type automationChainKey struct{}
+
+// withAutomationChain помечает контекст изменений, выполняемых правилами автоматизации
+func withAutomationChain(ctx context.Context, chain []string) context.Context {
+	return context.WithValue(ctx, automationChainKey{}, chain)
+}
+
+// publishEvent отправляет событие изменения записи на обработку правилами
+func publishEvent(ctx context.Context, events EventPublisher, eventType, namespace, appName, uid string, data map[string]interface{}) {
+	if events == nil {
+		return
+	}
+	meta := reqctx.FromContext(ctx)
+	chain, _ := ctx.Value(automationChainKey{}).([]string)
+	// событие обрабатывается асинхронно, поэтому данные копируются
+	snapshot := make(map[string]interface{}, len(data))
+	for field, value := range data {
+		snapshot[field] = value
+	}
+	event := domain.DataEvent{
+		Type:          eventType,
+		NamespaceCode: namespace,
+		AppCode:       appName,
+		RecordUID:     uid,
+		Data:          snapshot,
+		Actor:         meta.Actor,
+		RequestID:     meta.RequestID,
+		Chain:         chain,
+		Trace:         tracing.Carrier(ctx),
+		OccurredAt:    time.Now(),
+	}
+	// правила не должны срабатывать на изменения пакета, который откатится, а событие
+	// зафиксированного изменения публикуется, даже если клиент уже отключился
+	ctx = context.WithoutCancel(ctx)
+	afterCommit(ctx, func() { events.Publish(ctx, event) })
+}
@@ -0,0 +1,281 @@
+package usecase
+
+import (
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/metrics"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Transactor выполняет операции репозиториев в одной транзакции базы: репозитории,
+// получившие контекст fn, работают в ней. Ошибка fn откатывает все изменения
+type Transactor interface {
+	InTx(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type txScopeKey struct{}
+
+// txScope — то, что операции откладывают до конца транзакции inTx
+type txScope struct {
+	// audit — записи журнала, которые добавляются одним шагом перед фиксацией: блокировка
+	// цепочки журнала берётся в самом конце, а не на всё время выполнения транзакции
+	audit []*domain.AuditEntry
+	// hooks — действия после фиксации
+	hooks []func()
+}
+
+// inTx выполняет fn в транзакции tx. Записи журнала, сделанные в fn, добавляются перед
+// фиксацией, и ошибка журнала откатывает транзакцию; события и метрики выполняются после
+// фиксации. Внутри другой inTx, например в пакете, fn выполняется в её транзакции
+func inTx(ctx context.Context, tx Transactor, audit AuditUsecase, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(txScopeKey{}).(*txScope); ok {
+		return fn(ctx)
+	}
+	scope := &txScope{}
+	ctx = context.WithValue(ctx, txScopeKey{}, scope)
+	err := tx.InTx(ctx, func(ctx context.Context) error {
+		if err := fn(ctx); err != nil {
+			return err
+		}
+		if audit == nil {
+			return nil
+		}
+		// без записи в журнале изменения не фиксируются
+		if err := audit.AppendAll(ctx, scope.audit); err != nil {
+			return fmt.Errorf("failed to record audit: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, hook := range scope.hooks {
+		hook()
+	}
+	return nil
+}
+
+// afterCommit выполняет fn после фиксации транзакции inTx, а вне её — сразу.
+// Так события автоматизации и метрики не появляются для изменений, которые откатятся
+func afterCommit(ctx context.Context, fn func()) {
+	if scope, ok := ctx.Value(txScopeKey{}).(*txScope); ok {
+		scope.hooks = append(scope.hooks, fn)
+		return
+	}
+	fn()
+}
+
+// deferAudit откладывает запись журнала до конца транзакции inTx; false — вне её
+func deferAudit(ctx context.Context, entry *domain.AuditEntry) bool {
+	scope, ok := ctx.Value(txScopeKey{}).(*txScope)
+	if ok {
+		scope.audit = append(scope.audit, entry)
+	}
+	return ok
+}
+
+type BatchUsecase interface {
+	// Execute выполняет операции по порядку в одной транзакции: либо все, либо ни одной.
+	// Каждая операция списывается из ограничения частоты изменений субъекта principal в
+	// своём namespace. Ошибка операции возвращается как *domain.BatchError с её номером
+	Execute(ctx context.Context, principal string, operations []domain.BatchOperation) ([]domain.BatchResult, error)
+}
+
+type batchUsecase struct {
+	tx            Transactor
+	data          AppDataUsecase
+	audit         AuditUsecase
+	limits        LimitsUsecase
+	maxOperations int
+}
+
+// NewBatchUsecase создаёт usecase пакетов; maxOperations ограничивает число операций в пакете
+func NewBatchUsecase(tx Transactor, data AppDataUsecase, audit AuditUsecase, limits LimitsUsecase, maxOperations int) BatchUsecase {
+	return &batchUsecase{tx: tx, data: data, audit: audit, limits: limits, maxOperations: maxOperations}
+}
+
+func (u *batchUsecase) Execute(ctx context.Context, principal string, operations []domain.BatchOperation) ([]domain.BatchResult, error) {
+	if len(operations) == 0 {
+		return nil, fmt.Errorf("%w: batch has no operations", domain.ErrValidation)
+	}
+	if len(operations) > u.maxOperations {
+		return nil, fmt.Errorf("%w: batch has %d operations, the limit is %d", domain.ErrValidation, len(operations), u.maxOperations)
+	}
+	refs := make(map[string]bool)
+	for i, op := range operations {
+		if err := validateOperation(op, refs); err != nil {
+			return nil, &domain.BatchError{Index: i, Op: op.Op, Err: err}
+		}
+	}
+	for i, op := range operations {
+		if err := u.allow(ctx, principal, op.Namespace); err != nil {
+			return nil, &domain.BatchError{Index: i, Op: op.Op, Err: err}
+		}
+	}
+
+	var results []domain.BatchResult
+	err := inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		results = make([]domain.BatchResult, 0, len(operations))
+		created := make(map[string]string)
+		for i, op := range operations {
+			result, err := u.execute(ctx, op, created)
+			if err != nil {
+				return &domain.BatchError{Index: i, Op: op.Op, Err: err}
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// allow списывает операцию из корзины изменений namespace так же, как отдельный запрос.
+// Если проверить ограничение не удалось, операция пропускается
+func (u *batchUsecase) allow(ctx context.Context, principal, namespace string) error {
+	decision, err := u.limits.Allow(ctx, principal, namespace, domain.RouteWrite)
+	label := namespace
+	if errors.Is(err, domain.ErrNotFound) {
+		// несуществующий namespace ограничивается значениями по умолчанию, 404 даст сама операция;
+		// в метрику он не попадает
+		label = ""
+		decision, err = u.limits.Allow(ctx, principal, "", domain.RouteWrite)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "rate limit check failed", "class", domain.RouteWrite, "error", err)
+		return nil
+	}
+	if !decision.Allowed {
+		metrics.RateLimited(label, domain.RouteWrite)
+		return &domain.RateLimitError{Namespace: namespace, Decision: decision}
+	}
+	return nil
+}
+
+// validateOperation проверяет операцию до начала транзакции; refs — имена, объявленные
+// предыдущими операциями: ссылаться можно только на запись, созданную раньше
+func validateOperation(op domain.BatchOperation, refs map[string]bool) error {
+	if op.Namespace == "" || op.App == "" {
+		return fmt.Errorf("%w: namespace and app are required", domain.ErrValidation)
+	}
+	switch op.Op {
+	case domain.BatchCreate:
+		if op.UID != "" {
+			return fmt.Errorf("%w: create does not take a uid", domain.ErrValidation)
+		}
+	case domain.BatchUpdate, domain.BatchPatch, domain.BatchDelete:
+		if op.UID == "" {
+			return fmt.Errorf("%w: %s requires a uid", domain.ErrValidation, op.Op)
+		}
+		if op.Ref != "" {
+			return fmt.Errorf("%w: only create can declare a ref", domain.ErrValidation)
+		}
+	default:
+		return fmt.Errorf("%w: unknown operation %q, expected create, update, patch or delete", domain.ErrValidation, op.Op)
+	}
+	values := []interface{}{op.UID}
+	for _, value := range op.Data {
+		values = append(values, value)
+	}
+	for _, value := range values {
+		for _, name := range refNames(value) {
+			if !refs[name] {
+				return fmt.Errorf("%w: %s%s does not refer to a record created earlier in the batch", domain.ErrValidation, domain.BatchRefPrefix, name)
+			}
+		}
+	}
+	if op.Ref != "" {
+		if refs[op.Ref] {
+			return fmt.Errorf("%w: ref %s is declared twice", domain.ErrValidation, op.Ref)
+		}
+		refs[op.Ref] = true
+	}
+	return nil
+}
+
+func (u *batchUsecase) execute(ctx context.Context, op domain.BatchOperation, created map[string]string) (domain.BatchResult, error) {
+	result := domain.BatchResult{Op: op.Op, Namespace: op.Namespace, App: op.App, UID: resolveRef(op.UID, created).(string), Ref: op.Ref}
+	data := make(map[string]interface{}, len(op.Data))
+	for field, value := range op.Data {
+		data[field] = resolveRef(value, created)
+	}
+
+	var err error
+	switch op.Op {
+	case domain.BatchCreate:
+		record := &domain.AppData{Data: data}
+		if err = u.data.Create(ctx, op.Namespace, op.App, record); err == nil {
+			result.UID, result.Record = record.UID, record
+			if op.Ref != "" {
+				created[op.Ref] = record.UID
+			}
+		}
+	case domain.BatchUpdate:
+		record := &domain.AppData{UID: result.UID, Data: data}
+		if err = u.data.Update(ctx, op.Namespace, op.App, record); err == nil {
+			result.Record = record
+		}
+	case domain.BatchPatch:
+		err = u.data.UpdateDataPartial(ctx, op.Namespace, op.App, result.UID, data)
+	case domain.BatchDelete:
+		err = u.data.Delete(ctx, op.Namespace, op.App, result.UID)
+	}
+	// запрос на согласование нельзя отменить вместе с пакетом, а пакет — применить частично
+	var pending *domain.PendingApprovalError
+	if errors.As(err, &pending) {
+		return result, fmt.Errorf("%w: changes to app %s require approval and cannot be part of a batch", domain.ErrValidation, op.App)
+	}
+	return result, err
+}
+
+// refNames возвращает имена записей, на которые ссылается значение, включая вложенные списки и объекты
+func refNames(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		if name, ok := strings.CutPrefix(v, domain.BatchRefPrefix); ok {
+			return []string{name}
+		}
+	case []interface{}:
+		var names []string
+		for _, item := range v {
+			names = append(names, refNames(item)...)
+		}
+		return names
+	case map[string]interface{}:
+		var names []string
+		for _, item := range v {
+			names = append(names, refNames(item)...)
+		}
+		return names
+	}
+	return nil
+}
+
+// resolveRef заменяет ссылки "$ref:имя" в значении, включая вложенные списки и объекты,
+// на uid созданных записей
+func resolveRef(value interface{}, created map[string]string) interface{} {
+	switch v := value.(type) {
+	case string:
+		if name, ok := strings.CutPrefix(v, domain.BatchRefPrefix); ok {
+			return created[name]
+		}
+	case []interface{}:
+		resolved := make([]interface{}, len(v))
+		for i, item := range v {
+			resolved[i] = resolveRef(item, created)
+		}
+		return resolved
+	case map[string]interface{}:
+		resolved := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			resolved[key] = resolveRef(item, created)
+		}
+		return resolved
+	}
+	return value
+}
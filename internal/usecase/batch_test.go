@@ -0,0 +1,44 @@
+package usecase
+
+import (
+	"app/backendv1/internal/domain"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestResolveNestedRef(t *testing.T) {
+	refs := map[string]bool{"order": true}
+	op := domain.BatchOperation{
+		Op:        domain.BatchCreate,
+		Namespace: "shop",
+		App:       "lines",
+		Data: map[string]interface{}{
+			"source": map[string]interface{}{
+				"order": "$ref:order",
+				"links": []interface{}{map[string]interface{}{"uid": "$ref:order"}, "plain"},
+			},
+		},
+	}
+	if err := validateOperation(op, refs); err != nil {
+		t.Fatalf("validateOperation: %v", err)
+	}
+
+	got := resolveRef(op.Data["source"], map[string]string{"order": "uid-1"})
+	want := map[string]interface{}{
+		"order": "uid-1",
+		"links": []interface{}{map[string]interface{}{"uid": "uid-1"}, "plain"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("resolveRef = %#v, want %#v", got, want)
+	}
+	// исходное значение операции не меняется
+	if op.Data["source"].(map[string]interface{})["order"] != "$ref:order" {
+		t.Error("resolveRef modified the operation data")
+	}
+
+	op.Data = map[string]interface{}{"source": map[string]interface{}{"order": "$ref:missing"}}
+	if err := validateOperation(op, refs); !errors.Is(err, domain.ErrValidation) {
+		t.Errorf("validateOperation with unknown nested ref = %v, want validation error", err)
+	}
+}
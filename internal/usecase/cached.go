@@ -0,0 +1,215 @@
+package usecase
+
+import (
+	"app/backendv1/internal/cache"
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/metrics"
+	"app/backendv1/internal/reqctx"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"time"
+)
+
+// Декораторы ниже кэшируют частые чтения. Изменение через декоратор сразу сбрасывает
+// затронутые ключи на этом экземпляре; изменения в обход него и на других экземплярах
+// приходят уведомлениями триггеров базы (см. worker.CacheInvalidator). Чтение, начатое
+// до изменения, может вернуть в кэш старое значение — его срок ограничен ttl
+
+// cacheGet читает значение key в v; ошибки кэша не мешают чтению из базы
+func cacheGet(ctx context.Context, c cache.Cache, name, key string, v interface{}) bool {
+	data, ok, err := c.Get(ctx, key)
+	if err != nil {
+		slog.WarnContext(ctx, "cache read failed", "key", key, "error", err)
+	}
+	hit := ok && err == nil && json.Unmarshal(data, v) == nil
+	metrics.CacheLookup(name, hit)
+	return hit
+}
+
+func cacheSet(ctx context.Context, c cache.Cache, key string, v interface{}, ttl time.Duration) {
+	data, err := json.Marshal(v)
+	if err == nil {
+		err = c.Set(ctx, key, data, ttl)
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "cache write failed", "key", key, "error", err)
+	}
+}
+
+func cacheInvalidate(ctx context.Context, c cache.Cache, keys ...string) {
+	for _, key := range keys {
+		if err := cache.Invalidate(ctx, c, key); err != nil {
+			slog.WarnContext(ctx, "cache invalidation failed", "key", key, "error", err)
+		}
+	}
+}
+
+type cachedNamespaces struct {
+	NamespaceUsecase
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// NewCachedNamespaceUsecase кэширует список namespace
+func NewCachedNamespaceUsecase(uc NamespaceUsecase, c cache.Cache, ttl time.Duration) NamespaceUsecase {
+	return &cachedNamespaces{NamespaceUsecase: uc, cache: c, ttl: ttl}
+}
+
+func (u *cachedNamespaces) GetAll(ctx context.Context) ([]domain.Namespace, error) {
+	var namespaces []domain.Namespace
+	if cacheGet(ctx, u.cache, "namespaces", cache.NamespacesKey, &namespaces) {
+		return namespaces, nil
+	}
+	namespaces, err := u.NamespaceUsecase.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cacheSet(ctx, u.cache, cache.NamespacesKey, namespaces, u.ttl)
+	return namespaces, nil
+}
+
+func (u *cachedNamespaces) Create(ctx context.Context, record *domain.Namespace) error {
+	defer cacheInvalidate(ctx, u.cache, cache.NamespacesKey)
+	return u.NamespaceUsecase.Create(ctx, record)
+}
+
+// Update сбрасывает и записи namespace: от его цепочки локалей зависят значения локализуемых полей
+func (u *cachedNamespaces) Update(ctx context.Context, code string, record *domain.Namespace) error {
+	defer cacheInvalidate(ctx, u.cache, cache.NamespacesKey, cache.NamespaceRecordsPattern(code))
+	return u.NamespaceUsecase.Update(ctx, code, record)
+}
+
+func (u *cachedNamespaces) SetIcon(ctx context.Context, code, iconID string) error {
+	defer cacheInvalidate(ctx, u.cache, cache.NamespacesKey)
+	return u.NamespaceUsecase.SetIcon(ctx, code, iconID)
+}
+
+func (u *cachedNamespaces) Delete(ctx context.Context, code string) error {
+	defer cacheInvalidate(ctx, u.cache, cache.NamespacesKey, cache.NamespaceRecordsPattern(code))
+	return u.NamespaceUsecase.Delete(ctx, code)
+}
+
+func (u *cachedNamespaces) Restore(ctx context.Context, code string) error {
+	defer cacheInvalidate(ctx, u.cache, cache.NamespacesKey)
+	return u.NamespaceUsecase.Restore(ctx, code)
+}
+
+func (u *cachedNamespaces) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	defer cacheInvalidate(ctx, u.cache, cache.NamespacesKey)
+	return u.NamespaceUsecase.PurgeDeleted(ctx, before)
+}
+
+type cachedApps struct {
+	AppUsecase
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// NewCachedAppUsecase кэширует список всех приложений
+func NewCachedAppUsecase(uc AppUsecase, c cache.Cache, ttl time.Duration) AppUsecase {
+	return &cachedApps{AppUsecase: uc, cache: c, ttl: ttl}
+}
+
+func (u *cachedApps) GetAll(ctx context.Context) ([]*domain.App, error) {
+	var apps []*domain.App
+	if cacheGet(ctx, u.cache, "apps", cache.AppsKey, &apps) {
+		return apps, nil
+	}
+	apps, err := u.AppUsecase.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cacheSet(ctx, u.cache, cache.AppsKey, apps, u.ttl)
+	return apps, nil
+}
+
+func (u *cachedApps) Create(ctx context.Context, app *domain.App) error {
+	defer cacheInvalidate(ctx, u.cache, cache.AppsKey)
+	return u.AppUsecase.Create(ctx, app)
+}
+
+// Update сбрасывает и записи приложения: их чтение зависит от описания полей
+func (u *cachedApps) Update(ctx context.Context, app *domain.App) error {
+	defer cacheInvalidate(ctx, u.cache, cache.AppsKey, cache.AppRecordsPattern(app.NamespaceCode, app.Code))
+	return u.AppUsecase.Update(ctx, app)
+}
+
+func (u *cachedApps) SetIcon(ctx context.Context, code, namespaceCode, iconID string) error {
+	defer cacheInvalidate(ctx, u.cache, cache.AppsKey)
+	return u.AppUsecase.SetIcon(ctx, code, namespaceCode, iconID)
+}
+
+func (u *cachedApps) Delete(ctx context.Context, code, namespaceCode string) error {
+	defer cacheInvalidate(ctx, u.cache, cache.AppsKey, cache.AppRecordsPattern(namespaceCode, code))
+	return u.AppUsecase.Delete(ctx, code, namespaceCode)
+}
+
+func (u *cachedApps) Restore(ctx context.Context, code, namespaceCode string) error {
+	defer cacheInvalidate(ctx, u.cache, cache.AppsKey)
+	return u.AppUsecase.Restore(ctx, code, namespaceCode)
+}
+
+func (u *cachedApps) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	defer cacheInvalidate(ctx, u.cache, cache.AppsKey)
+	return u.AppUsecase.PurgeDeleted(ctx, before)
+}
+
+type cachedAppData struct {
+	AppDataUsecase
+	cache cache.Cache
+	ttl   time.Duration
+}
+
+// NewCachedAppDataUsecase кэширует чтение записи по uid. Значения локализуемых полей
+// зависят от Accept-Language, поэтому под ключом записи хранятся её варианты по цепочкам локалей
+func NewCachedAppDataUsecase(uc AppDataUsecase, c cache.Cache, ttl time.Duration) AppDataUsecase {
+	return &cachedAppData{AppDataUsecase: uc, cache: c, ttl: ttl}
+}
+
+func (u *cachedAppData) GetDataByUID(ctx context.Context, namespace, appName, uid string) (*domain.AppData, error) {
+	key := cache.RecordKey(namespace, appName, uid)
+	variant := strings.Join(reqctx.FromContext(ctx).Locales, ",")
+	var variants map[string]*domain.AppData
+	if cacheGet(ctx, u.cache, "records", key, &variants) && variants[variant] != nil {
+		return variants[variant], nil
+	}
+	record, err := u.AppDataUsecase.GetDataByUID(ctx, namespace, appName, uid)
+	// uid в другой записи (без дефисов, в верхнем регистре) не совпал бы с ключом,
+	// который сбрасывает триггер, поэтому кэшируется только канонический вид
+	if err != nil || record == nil || record.UID != strings.ToLower(uid) {
+		return record, err
+	}
+	if variants == nil {
+		variants = make(map[string]*domain.AppData)
+	}
+	variants[variant] = record
+	cacheSet(ctx, u.cache, key, variants, u.ttl)
+	return record, nil
+}
+
+func (u *cachedAppData) Update(ctx context.Context, namespace, appName string, data *domain.AppData) error {
+	defer cacheInvalidate(ctx, u.cache, cache.RecordKey(namespace, appName, data.UID))
+	return u.AppDataUsecase.Update(ctx, namespace, appName, data)
+}
+
+func (u *cachedAppData) UpdateDataPartial(ctx context.Context, namespace, appName, uid string, partialData map[string]interface{}) error {
+	defer cacheInvalidate(ctx, u.cache, cache.RecordKey(namespace, appName, uid))
+	return u.AppDataUsecase.UpdateDataPartial(ctx, namespace, appName, uid, partialData)
+}
+
+func (u *cachedAppData) Delete(ctx context.Context, namespace, appName, uid string) error {
+	defer cacheInvalidate(ctx, u.cache, cache.RecordKey(namespace, appName, uid))
+	return u.AppDataUsecase.Delete(ctx, namespace, appName, uid)
+}
+
+func (u *cachedAppData) Restore(ctx context.Context, namespace, appName, uid string) error {
+	defer cacheInvalidate(ctx, u.cache, cache.RecordKey(namespace, appName, uid))
+	return u.AppDataUsecase.Restore(ctx, namespace, appName, uid)
+}
+
+func (u *cachedAppData) PurgeDeleted(ctx context.Context, namespace, appName string, before time.Time) (int64, error) {
+	defer cacheInvalidate(ctx, u.cache, cache.AppRecordsPattern(namespace, appName))
+	return u.AppDataUsecase.PurgeDeleted(ctx, namespace, appName, before)
+}
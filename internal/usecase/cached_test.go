@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"app/backendv1/internal/cache"
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/reqctx"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+// localizedData возвращает запись, значение которой зависит от локалей запроса, и считает чтения
+type localizedData struct {
+	AppDataUsecase
+	// canonical — uid записи в базе, если он отличается от запрошенного
+	canonical string
+	reads     int
+}
+
+func (u *localizedData) GetDataByUID(ctx context.Context, namespace, appName, uid string) (*domain.AppData, error) {
+	u.reads++
+	title := "default"
+	if locales := reqctx.FromContext(ctx).Locales; len(locales) > 0 {
+		title = locales[0]
+	}
+	if u.canonical != "" {
+		uid = u.canonical
+	}
+	return &domain.AppData{UID: strings.ToLower(uid), Data: map[string]interface{}{"title": title}}, nil
+}
+
+func (u *localizedData) Update(ctx context.Context, namespace, appName string, data *domain.AppData) error {
+	return nil
+}
+
+func withLocales(locales ...string) context.Context {
+	return reqctx.WithMeta(context.Background(), reqctx.Meta{Locales: locales})
+}
+
+func TestCachedAppDataLocaleVariants(t *testing.T) {
+	data := &localizedData{}
+	uc := NewCachedAppDataUsecase(data, cache.NewLRU(100), time.Minute)
+
+	read := func(ctx context.Context, uid string) string {
+		t.Helper()
+		record, err := uc.GetDataByUID(ctx, "ns", "app", uid)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return record.Data["title"].(string)
+	}
+
+	steps := []struct {
+		name  string
+		ctx   context.Context
+		want  string
+		reads int
+	}{
+		{"first read goes to the usecase", withLocales("de", "en"), "de", 1},
+		{"same chain is served from cache", withLocales("de", "en"), "de", 1},
+		{"other chain is a separate variant", withLocales("fr"), "fr", 2},
+		{"no Accept-Language is a separate variant", context.Background(), "default", 3},
+		{"variants are kept together", withLocales("de", "en"), "de", 3},
+		{"chain order matters", withLocales("en", "de"), "en", 4},
+		{"previous variant survives adding one", withLocales("fr"), "fr", 4},
+	}
+	for _, step := range steps {
+		if got := read(step.ctx, "abc"); got != step.want {
+			t.Errorf("%s: title = %q, want %q", step.name, got, step.want)
+		}
+		if data.reads != step.reads {
+			t.Errorf("%s: %d reads, want %d", step.name, data.reads, step.reads)
+		}
+	}
+
+	// изменение сбрасывает все варианты записи
+	if err := uc.Update(context.Background(), "ns", "app", &domain.AppData{UID: "abc"}); err != nil {
+		t.Fatal(err)
+	}
+	read(withLocales("de", "en"), "abc")
+	read(withLocales("fr"), "abc")
+	if data.reads != 6 {
+		t.Errorf("after update: %d reads, want 6", data.reads)
+	}
+}
+
+func TestCachedAppDataNonCanonicalUID(t *testing.T) {
+	data := &localizedData{canonical: "0b7c6f1e-2d3a-4c5b-8e9f-a1b2c3d4e5f6"}
+	c := cache.NewLRU(100)
+	uc := NewCachedAppDataUsecase(data, c, time.Minute)
+
+	// uid не в каноническом виде не кэшируется: триггер сбросил бы другой ключ
+	uid := "0B7C6F1E2D3A4C5B8E9FA1B2C3D4E5F6"
+	for i := 0; i < 2; i++ {
+		if _, err := uc.GetDataByUID(withLocales("de"), "ns", "app", uid); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if data.reads != 2 {
+		t.Errorf("%d reads, want 2", data.reads)
+	}
+	if _, ok, _ := c.Get(context.Background(), cache.RecordKey("ns", "app", uid)); ok {
+		t.Error("record read by non-canonical uid was cached")
+	}
+}
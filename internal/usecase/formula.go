@@ -0,0 +1,161 @@
+package usecase
+
+import (
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/formula"
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+var formulaTypes = map[string]formula.Type{
+	domain.FieldTypeString:   formula.String,
+	domain.FieldTypeNumber:   formula.Number,
+	domain.FieldTypeBoolean:  formula.Boolean,
+	domain.FieldTypeDatetime: formula.Datetime,
+}
+
+// compileFormulas собирает программу вычисляемых полей приложения, nil если их нет
+func compileFormulas(app *domain.App) (*formula.Program, error) {
+	fields := make(map[string]formula.Type)
+	var defs []formula.Definition
+	for _, field := range app.Fields {
+		t, ok := formulaTypes[field.Type]
+		if field.Formula == "" {
+			if ok {
+				fields[field.Code] = t
+			}
+			continue
+		}
+		if !ok {
+			return nil, fmt.Errorf("%w: field %s of type %s cannot be computed", domain.ErrValidation, field.Code, field.Type)
+		}
+		defs = append(defs, formula.Definition{Field: field.Code, Type: t, Source: field.Formula})
+	}
+	if len(defs) == 0 {
+		return nil, nil
+	}
+
+	program, err := formula.Compile(defs, fields)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", domain.ErrValidation, err)
+	}
+	return program, nil
+}
+
+// ComputedRepo — служебная запись вычисляемых полей в обеих версиях записи, включая корзину
+type ComputedRepo interface {
+	// LockComputed блокирует до конца транзакции и возвращает до limit записей версии stage
+	// с uid больше after в порядке uid; after == "" — с начала
+	LockComputed(ctx context.Context, namespace, appName, stage, after string, limit int) ([]*domain.AppData, error)
+	// SetComputed записывает значения полей в версию stage, не меняя время и автора изменения
+	SetComputed(ctx context.Context, namespace, appName, stage, uid string, values map[string]interface{}) error
+}
+
+// RecomputeQueue — очередь пересчёта записей после изменения формул; её разбирает планировщик
+type RecomputeQueue interface {
+	RequestRecompute(ctx context.Context, namespace, appName string) error
+}
+
+// recomputeRecords пересчитывает вычисляемые поля черновиков и опубликованных версий всех
+// записей приложения, в том числе в корзине, и сохраняет только изменившиеся значения.
+// Каждая пачка читается с блокировкой и пишется в своей транзакции: параллельное изменение
+// записи не перетрёт значение, а блокировки не держатся дольше одной пачки
+func recomputeRecords(ctx context.Context, tx Transactor, repo ComputedRepo, app *domain.App) (int64, error) {
+	program, err := compileFormulas(app)
+	if err != nil || program == nil {
+		return 0, err
+	}
+	computed := computedFields(app)
+
+	var updated int64
+	for _, stage := range []string{domain.StageDraft, domain.StagePublished} {
+		for after := ""; ; {
+			var (
+				records []*domain.AppData
+				changed int64
+			)
+			err := inTx(ctx, tx, nil, func(ctx context.Context) error {
+				var err error
+				records, err = repo.LockComputed(ctx, app.NamespaceCode, app.Code, stage, after, jobBatchSize)
+				if err != nil {
+					return err
+				}
+				now := time.Now()
+				for _, record := range records {
+					changes := computeChanges(program, computed, record.Data, now)
+					if len(changes) == 0 {
+						continue
+					}
+					if err := repo.SetComputed(ctx, app.NamespaceCode, app.Code, stage, record.UID, changes); err != nil {
+						return err
+					}
+					changed++
+				}
+				return nil
+			})
+			if err != nil {
+				return updated, err
+			}
+			updated += changed
+			if len(records) < jobBatchSize {
+				break
+			}
+			after = records[len(records)-1].UID
+		}
+	}
+	return updated, nil
+}
+
+// computeChanges вычисляет формулы по значениям записи и возвращает поля, значения которых изменились
+func computeChanges(program *formula.Program, computed []string, data map[string]interface{}, now time.Time) map[string]interface{} {
+	values := make(map[string]interface{}, len(data))
+	for field, value := range data {
+		values[field] = value
+	}
+	program.Apply(values, now)
+
+	changes := make(map[string]interface{})
+	for _, field := range computed {
+		if !reflect.DeepEqual(values[field], data[field]) {
+			changes[field] = values[field]
+		}
+	}
+	return changes
+}
+
+// formulasChanged сообщает, изменились ли вычисляемые поля приложения или их формулы
+func formulasChanged(before, after *domain.App) bool {
+	formulas := func(app *domain.App) map[string]string {
+		result := make(map[string]string)
+		for _, field := range app.Fields {
+			if field.Formula != "" {
+				result[field.Code] = field.Type + ":" + field.Formula
+			}
+		}
+		return result
+	}
+	return !reflect.DeepEqual(formulas(before), formulas(after))
+}
+
+func computedFields(app *domain.App) []string {
+	var fields []string
+	for _, field := range app.Fields {
+		if field.Formula != "" {
+			fields = append(fields, field.Code)
+		}
+	}
+	return fields
+}
+
+// fieldFormulaTypes возвращает типы всех полей, на которые может ссылаться условие
+func fieldFormulaTypes(app *domain.App) map[string]formula.Type {
+	types := make(map[string]formula.Type, len(app.Fields))
+	for _, field := range app.Fields {
+		if t, ok := formulaTypes[field.Type]; ok {
+			types[field.Code] = t
+		}
+	}
+	return types
+}
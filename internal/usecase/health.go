@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// readyTimeout ограничивает проверку готовности, чтобы зависшая база не держала пробу дольше её таймаута
+const readyTimeout = 2 * time.Second
+
+type HealthRepo interface {
+	Ping(ctx context.Context) error
+	SchemaVersion(ctx context.Context) (int, error)
+}
+
+type HealthUsecase interface {
+	// Ready проверяет, что база доступна и миграции применены, а экземпляр не останавливается
+	Ready(ctx context.Context) domain.Readiness
+	// Drain помечает экземпляр останавливающимся: после этого он не готов принимать запросы
+	Drain()
+}
+
+type healthUsecase struct {
+	repo          HealthRepo
+	schemaVersion int
+	draining      atomic.Bool
+}
+
+// NewHealthUsecase создаёт проверку готовности; schemaVersion — версия схемы, которую ожидает эта сборка
+func NewHealthUsecase(repo HealthRepo, schemaVersion int) HealthUsecase {
+	return &healthUsecase{repo: repo, schemaVersion: schemaVersion}
+}
+
+func (u *healthUsecase) Ready(ctx context.Context) domain.Readiness {
+	ctx, cancel := context.WithTimeout(ctx, readyTimeout)
+	defer cancel()
+
+	checks := map[string]string{"database": "ok", "migrations": "ok"}
+	if u.draining.Load() {
+		checks["shutdown"] = "shutting down"
+	}
+	if err := u.repo.Ping(ctx); err != nil {
+		checks["database"] = err.Error()
+		checks["migrations"] = "unknown"
+	} else if version, err := u.repo.SchemaVersion(ctx); err != nil {
+		checks["migrations"] = err.Error()
+	} else if version < u.schemaVersion {
+		checks["migrations"] = fmt.Sprintf("schema version %d, expected %d", version, u.schemaVersion)
+	}
+
+	ready := true
+	for _, result := range checks {
+		if result != "ok" {
+			ready = false
+		}
+	}
+	return domain.Readiness{Ready: ready, Checks: checks}
+}
+
+func (u *healthUsecase) Drain() {
+	u.draining.Store(true)
+}
@@ -0,0 +1,385 @@
+package usecase
+
+import (
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/storage"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/image/draw"
+)
+
+const (
+	// maxIconSize — максимальный размер загружаемой иконки в байтах
+	maxIconSize = 2 << 20
+	// maxIconDimension — максимальная ширина и высота растровой иконки, защищает от распаковки огромных изображений
+	maxIconDimension = 4096
+	// iconGCGrace — не удалять иконки моложе этого срока: ссылка на них может ещё записываться
+	iconGCGrace = time.Hour
+
+	contentTypePNG  = "image/png"
+	contentTypeJPEG = "image/jpeg"
+	contentTypeSVG  = "image/svg+xml"
+)
+
+// IconRepo — хранилище метаданных иконок
+type IconRepo interface {
+	Create(ctx context.Context, icon *domain.Icon) error
+	GetByID(ctx context.Context, id string) (*domain.Icon, error)
+	Delete(ctx context.Context, id string) error
+	FindUnreferenced(ctx context.Context, before time.Time, limit int) ([]*domain.Icon, error)
+}
+
+type IconUsecase interface {
+	UploadAppIcon(ctx context.Context, namespaceCode, appCode string, body io.Reader) (*domain.Icon, error)
+	DeleteAppIcon(ctx context.Context, namespaceCode, appCode string) error
+	UploadNamespaceIcon(ctx context.Context, code string, body io.Reader) (*domain.Icon, error)
+	DeleteNamespaceIcon(ctx context.Context, code string) error
+	// Open возвращает иконку и поток с исходником (size = 0) или уменьшенной копией
+	Open(ctx context.Context, id string, size int) (*domain.Icon, string, io.ReadCloser, error)
+	CollectGarbage(ctx context.Context) (int64, error)
+}
+
+type iconUsecase struct {
+	repo       IconRepo
+	storage    storage.BlobStorage
+	apps       AppUsecase
+	namespaces NamespaceUsecase
+}
+
+func NewIconUsecase(repo IconRepo, storage storage.BlobStorage, apps AppUsecase, namespaces NamespaceUsecase) IconUsecase {
+	return &iconUsecase{repo: repo, storage: storage, apps: apps, namespaces: namespaces}
+}
+
+func (u *iconUsecase) UploadAppIcon(ctx context.Context, namespaceCode, appCode string, body io.Reader) (*domain.Icon, error) {
+	app, err := u.apps.GetByCode(ctx, appCode, namespaceCode)
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		return nil, fmt.Errorf("app %s: %w", appCode, domain.ErrNotFound)
+	}
+	return u.replace(ctx, app.Icon, body, func(iconID string) error {
+		return u.apps.SetIcon(ctx, appCode, namespaceCode, iconID)
+	})
+}
+
+func (u *iconUsecase) DeleteAppIcon(ctx context.Context, namespaceCode, appCode string) error {
+	app, err := u.apps.GetByCode(ctx, appCode, namespaceCode)
+	if err != nil {
+		return err
+	}
+	if app == nil || app.Icon == "" {
+		return fmt.Errorf("icon of app %s: %w", appCode, domain.ErrNotFound)
+	}
+	if err := u.apps.SetIcon(ctx, appCode, namespaceCode, ""); err != nil {
+		return err
+	}
+	u.remove(ctx, app.Icon)
+	return nil
+}
+
+func (u *iconUsecase) UploadNamespaceIcon(ctx context.Context, code string, body io.Reader) (*domain.Icon, error) {
+	namespace, err := u.namespaces.GetByCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if namespace == nil {
+		return nil, fmt.Errorf("namespace %s: %w", code, domain.ErrNotFound)
+	}
+	return u.replace(ctx, namespace.Icon, body, func(iconID string) error {
+		return u.namespaces.SetIcon(ctx, code, iconID)
+	})
+}
+
+func (u *iconUsecase) DeleteNamespaceIcon(ctx context.Context, code string) error {
+	namespace, err := u.namespaces.GetByCode(ctx, code)
+	if err != nil {
+		return err
+	}
+	if namespace == nil || namespace.Icon == "" {
+		return fmt.Errorf("icon of namespace %s: %w", code, domain.ErrNotFound)
+	}
+	if err := u.namespaces.SetIcon(ctx, code, ""); err != nil {
+		return err
+	}
+	u.remove(ctx, namespace.Icon)
+	return nil
+}
+
+func (u *iconUsecase) Open(ctx context.Context, id string, size int) (*domain.Icon, string, io.ReadCloser, error) {
+	icon, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if icon == nil {
+		return nil, "", nil, fmt.Errorf("icon %s: %w", id, domain.ErrNotFound)
+	}
+
+	key, contentType := iconKey(icon.ID, "original"), icon.ContentType
+	// SVG масштабируется клиентом, уменьшенные копии для него не создаются
+	if size != 0 && icon.ContentType != contentTypeSVG {
+		if !isIconVariant(size) {
+			return nil, "", nil, fmt.Errorf("%w: unsupported icon size %d", domain.ErrValidation, size)
+		}
+		key, contentType = iconKey(icon.ID, strconv.Itoa(size)), contentTypePNG
+	}
+
+	body, err := u.storage.Get(ctx, key)
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("failed to open icon %s: %w", id, err)
+	}
+	return icon, contentType, body, nil
+}
+
+// CollectGarbage удаляет иконки, на которые больше никто не ссылается,
+// например иконки окончательно удалённых приложений
+func (u *iconUsecase) CollectGarbage(ctx context.Context) (int64, error) {
+	var removed int64
+	for {
+		icons, err := u.repo.FindUnreferenced(ctx, time.Now().Add(-iconGCGrace), gcBatchSize)
+		if err != nil {
+			return removed, err
+		}
+		for _, icon := range icons {
+			if err := u.remove(ctx, icon.ID); err != nil {
+				return removed, err
+			}
+			removed++
+		}
+		if len(icons) < gcBatchSize {
+			return removed, nil
+		}
+	}
+}
+
+// replace сохраняет новую иконку, переключает на неё ссылку через setRef и удаляет прежнюю
+func (u *iconUsecase) replace(ctx context.Context, previousID string, body io.Reader, setRef func(iconID string) error) (*domain.Icon, error) {
+	raw, err := io.ReadAll(io.LimitReader(body, maxIconSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	if len(raw) > maxIconSize {
+		return nil, fmt.Errorf("%w: icon limit is %d bytes", domain.ErrTooLarge, maxIconSize)
+	}
+
+	sum := sha256.Sum256(raw)
+	icon := &domain.Icon{
+		ID:     newUUID(),
+		Size:   int64(len(raw)),
+		SHA256: hex.EncodeToString(sum[:]),
+	}
+
+	variants, err := prepareIcon(icon, raw)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := u.storage.Put(ctx, iconKey(icon.ID, "original"), bytes.NewReader(raw)); err != nil {
+		return nil, fmt.Errorf("failed to store icon: %w", err)
+	}
+	for size, data := range variants {
+		if err := u.storage.Put(ctx, iconKey(icon.ID, strconv.Itoa(size)), bytes.NewReader(data)); err != nil {
+			u.removeBlobs(ctx, icon.ID)
+			return nil, fmt.Errorf("failed to store icon variant: %w", err)
+		}
+	}
+	if err := u.repo.Create(ctx, icon); err != nil {
+		u.removeBlobs(ctx, icon.ID)
+		return nil, err
+	}
+	if err := setRef(icon.ID); err != nil {
+		u.remove(ctx, icon.ID)
+		return nil, err
+	}
+
+	if previousID != "" {
+		u.remove(ctx, previousID)
+	}
+	return icon, nil
+}
+
+// remove удаляет файлы иконки и её метаданные. Ссылки на иконки, загруженные
+// до появления управляемых иконок, не имеют метаданных и просто пропускаются
+func (u *iconUsecase) remove(ctx context.Context, id string) error {
+	icon, err := u.repo.GetByID(ctx, id)
+	if err != nil || icon == nil {
+		return err
+	}
+	if err := u.removeBlobs(ctx, id); err != nil {
+		slog.ErrorContext(ctx, "icons: failed to delete blobs", "icon", id, "error", err)
+		return err
+	}
+	return u.repo.Delete(ctx, id)
+}
+
+func (u *iconUsecase) removeBlobs(ctx context.Context, id string) error {
+	keys := []string{iconKey(id, "original")}
+	for _, size := range domain.IconVariantSizes {
+		keys = append(keys, iconKey(id, strconv.Itoa(size)))
+	}
+	for _, key := range keys {
+		if err := u.storage.Delete(ctx, key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func iconKey(id, variant string) string {
+	return path.Join("icons", id, variant)
+}
+
+func isIconVariant(size int) bool {
+	for _, s := range domain.IconVariantSizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
+
+// prepareIcon определяет формат иконки, проверяет её и для растровых
+// изображений возвращает уменьшенные копии в PNG по размерам IconVariantSizes
+func prepareIcon(icon *domain.Icon, raw []byte) (map[int][]byte, error) {
+	switch http.DetectContentType(raw) {
+	case contentTypePNG:
+		icon.ContentType = contentTypePNG
+	case contentTypeJPEG:
+		icon.ContentType = contentTypeJPEG
+	default:
+		if err := validateSVG(raw); err != nil {
+			return nil, err
+		}
+		icon.ContentType = contentTypeSVG
+		return nil, nil
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(raw))
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid image: %v", domain.ErrValidation, err)
+	}
+	if config.Width > maxIconDimension || config.Height > maxIconDimension {
+		return nil, fmt.Errorf("%w: icon must not exceed %dx%d pixels", domain.ErrValidation, maxIconDimension, maxIconDimension)
+	}
+	icon.Width, icon.Height = config.Width, config.Height
+
+	var src image.Image
+	if icon.ContentType == contentTypePNG {
+		src, err = png.Decode(bytes.NewReader(raw))
+	} else {
+		src, err = jpeg.Decode(bytes.NewReader(raw))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid image: %v", domain.ErrValidation, err)
+	}
+
+	variants := make(map[int][]byte, len(domain.IconVariantSizes))
+	for _, size := range domain.IconVariantSizes {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, resizeToFit(src, size)); err != nil {
+			return nil, fmt.Errorf("failed to encode icon variant: %w", err)
+		}
+		variants[size] = buf.Bytes()
+	}
+	return variants, nil
+}
+
+// resizeToFit вписывает изображение в квадрат size×size с сохранением пропорций.
+// Изображения меньше квадрата не увеличиваются
+func resizeToFit(src image.Image, size int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+		draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+		return dst
+	}
+	if w >= h {
+		w, h = size, max(1, h*size/w)
+	} else {
+		w, h = max(1, w*size/h), size
+	}
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	return dst
+}
+
+// validateSVG пропускает только SVG без скриптов, обработчиков событий и внешних ссылок:
+// иконки отдаются браузеру с того же origin, что и API
+func validateSVG(raw []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(raw))
+	root := true
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("%w: icon must be PNG, JPEG or SVG", domain.ErrValidation)
+		}
+		switch t := token.(type) {
+		case xml.Directive:
+			return fmt.Errorf("%w: SVG must not contain DTD declarations", domain.ErrValidation)
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			if root && name != "svg" {
+				return fmt.Errorf("%w: icon must be PNG, JPEG or SVG", domain.ErrValidation)
+			}
+			root = false
+			if name == "script" || name == "foreignobject" {
+				return fmt.Errorf("%w: SVG must not contain <%s>", domain.ErrValidation, t.Name.Local)
+			}
+			for _, attr := range t.Attr {
+				attrName := strings.ToLower(attr.Name.Local)
+				if strings.HasPrefix(attrName, "on") {
+					return fmt.Errorf("%w: SVG must not contain event handlers", domain.ErrValidation)
+				}
+				if attrName == "href" && !strings.HasPrefix(strings.TrimSpace(attr.Value), "#") || hasExternalURL(attr.Value) {
+					return fmt.Errorf("%w: SVG must not reference external resources", domain.ErrValidation)
+				}
+			}
+		case xml.CharData:
+			if hasExternalURL(string(t)) {
+				return fmt.Errorf("%w: SVG must not reference external resources", domain.ErrValidation)
+			}
+		}
+	}
+	if root {
+		return fmt.Errorf("%w: icon must be PNG, JPEG or SVG", domain.ErrValidation)
+	}
+	return nil
+}
+
+// hasExternalURL ищет в CSS ссылки url(...) и @import, указывающие не на элементы самого документа
+func hasExternalURL(value string) bool {
+	lower := strings.ToLower(value)
+	if strings.Contains(lower, "@import") {
+		return true
+	}
+	for {
+		i := strings.Index(lower, "url(")
+		if i < 0 {
+			return false
+		}
+		lower = lower[i+len("url("):]
+		target := strings.TrimLeft(lower, " \t\n'\"")
+		if !strings.HasPrefix(target, "#") {
+			return true
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package usecase
+
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"fmt"
+	"time"
+)
+
+type IdempotencyRepo interface {
+	Reserve(ctx context.Context, key, hash string, ttl, lock time.Duration) (*domain.IdempotencyRecord, error)
+	Complete(ctx context.Context, key string, response domain.IdempotentResponse) error
+	Release(ctx context.Context, key string) error
+	Purge(ctx context.Context, ttl time.Duration) (int64, error)
+}
+
+type IdempotencyUsecase interface {
+	// Begin закрепляет ключ за запросом с хешем hash. Возвращает сохранённый ответ, если
+	// запрос с этим ключом уже выполнен, и nil, если запрос нужно выполнить. Тот же ключ
+	// с другим запросом — ErrIdempotencyMismatch, ещё выполняющийся запрос — ErrConflict
+	Begin(ctx context.Context, key, hash string) (*domain.IdempotentResponse, error)
+	// Complete сохраняет ответ выполненного запроса для повторов
+	Complete(ctx context.Context, key string, response domain.IdempotentResponse) error
+	// Release освобождает ключ запроса, ответ на который сохранять нельзя
+	Release(ctx context.Context, key string) error
+	// Purge удаляет ключи, срок хранения которых истёк
+	Purge(ctx context.Context) error
+}
+
+type idempotencyUsecase struct {
+	repo IdempotencyRepo
+	ttl  time.Duration
+	lock time.Duration
+}
+
+// NewIdempotencyUsecase создаёт usecase ключей идемпотентности: ответы хранятся ttl,
+// ключ запроса, не завершившегося за lock, считается брошенным и закрепляется заново
+func NewIdempotencyUsecase(repo IdempotencyRepo, ttl, lock time.Duration) IdempotencyUsecase {
+	return &idempotencyUsecase{repo: repo, ttl: ttl, lock: lock}
+}
+
+func (u *idempotencyUsecase) Begin(ctx context.Context, key, hash string) (*domain.IdempotentResponse, error) {
+	record, err := u.repo.Reserve(ctx, key, hash, u.ttl, u.lock)
+	if err != nil || record == nil {
+		return nil, err
+	}
+	if record.RequestHash != hash {
+		return nil, domain.ErrIdempotencyMismatch
+	}
+	if record.Response == nil {
+		return nil, fmt.Errorf("%w: a request with this idempotency key is still in progress", domain.ErrConflict)
+	}
+	return record.Response, nil
+}
+
+func (u *idempotencyUsecase) Complete(ctx context.Context, key string, response domain.IdempotentResponse) error {
+	return u.repo.Complete(ctx, key, response)
+}
+
+func (u *idempotencyUsecase) Release(ctx context.Context, key string) error {
+	return u.repo.Release(ctx, key)
+}
+
+func (u *idempotencyUsecase) Purge(ctx context.Context) error {
+	_, err := u.repo.Purge(ctx, u.ttl)
+	return err
+}
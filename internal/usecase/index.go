@@ -0,0 +1,216 @@
+package usecase
+
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"strings"
+)
+
+// maxIndexName — ограничение PostgreSQL на длину имени
+const maxIndexName = 63
+
+// IndexRepo управляет индексами таблиц записей
+type IndexRepo interface {
+	SyncIndexes(ctx context.Context, namespace, table string, specs []domain.IndexSpec) error
+	ListIndexes(ctx context.Context, namespace, table string) ([]*domain.IndexInfo, error)
+	// HasDuplicates сообщает, есть ли записи, которые нарушили бы уникальный индекс spec
+	HasDuplicates(ctx context.Context, namespace, table string, spec domain.IndexSpec) (bool, error)
+	// SetIndexError отмечает приложение ошибкой построения индексов; пустая строка снимает отметку
+	SetIndexError(ctx context.Context, namespace, table, message string) error
+}
+
+type IndexUsecase interface {
+	List(ctx context.Context, namespace, appName string) ([]*domain.IndexInfo, error)
+	// Sync повторно строит индексы по схеме приложения, например после прерванного построения
+	Sync(ctx context.Context, namespace, appName string) ([]*domain.IndexInfo, error)
+	// Reconcile достраивает индексы приложений, отмеченных ошибкой построения
+	Reconcile(ctx context.Context) error
+}
+
+type indexUsecase struct {
+	repo IndexRepo
+	apps AppUsecase
+}
+
+func NewIndexUsecase(repo IndexRepo, apps AppUsecase) IndexUsecase {
+	return &indexUsecase{repo: repo, apps: apps}
+}
+
+func (u *indexUsecase) List(ctx context.Context, namespace, appName string) ([]*domain.IndexInfo, error) {
+	if _, err := u.app(ctx, namespace, appName); err != nil {
+		return nil, err
+	}
+	return u.repo.ListIndexes(ctx, namespace, appName)
+}
+
+func (u *indexUsecase) Sync(ctx context.Context, namespace, appName string) ([]*domain.IndexInfo, error) {
+	app, err := u.app(ctx, namespace, appName)
+	if err != nil {
+		return nil, err
+	}
+	if err := syncIndexes(ctx, u.repo, app); err != nil {
+		return nil, err
+	}
+	return u.repo.ListIndexes(ctx, namespace, appName)
+}
+
+func (u *indexUsecase) Reconcile(ctx context.Context) error {
+	apps, err := u.apps.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+	for _, app := range apps {
+		if app.IndexError == "" {
+			continue
+		}
+		if err := syncIndexes(ctx, u.repo, app); err != nil {
+			slog.WarnContext(ctx, "indexes: reconciliation failed", "namespace", app.NamespaceCode, "app", app.Code, "error", err)
+			continue
+		}
+		slog.InfoContext(ctx, "indexes: reconciled", "namespace", app.NamespaceCode, "app", app.Code)
+	}
+	return nil
+}
+
+// syncIndexes приводит индексы к сохранённой схеме приложения. Схема при неудаче не
+// откатывается: ошибка сохраняется у приложения, и индексы достраивает Reconcile или
+// повторный Sync. Удачное построение снимает отметку
+func syncIndexes(ctx context.Context, repo IndexRepo, app *domain.App) error {
+	err := duplicateFields(app, repo.SyncIndexes(ctx, app.NamespaceCode, app.Code, appIndexes(app)))
+	message := ""
+	if err != nil {
+		message = err.Error()
+	}
+	// отметка сохраняется, даже если построение прервала отмена запроса
+	if markErr := repo.SetIndexError(context.WithoutCancel(ctx), app.NamespaceCode, app.Code, message); markErr != nil {
+		slog.ErrorContext(ctx, "indexes: failed to save index state", "namespace", app.NamespaceCode, "app", app.Code, "error", markErr)
+	}
+	app.IndexError = message
+	return err
+}
+
+// checkDuplicates проверяет до сохранения схемы, что уже записанные данные не нарушают
+// уникальность, которой не было в схеме before
+func checkDuplicates(ctx context.Context, repo IndexRepo, before, app *domain.App) error {
+	existing := make(map[string]bool)
+	for _, spec := range appIndexes(before) {
+		existing[spec.Name] = true
+	}
+	for _, spec := range appIndexes(app) {
+		if !spec.Unique || existing[spec.Name] {
+			continue
+		}
+		duplicates, err := repo.HasDuplicates(ctx, app.NamespaceCode, app.Code, spec)
+		if err != nil {
+			return err
+		}
+		if duplicates {
+			return &domain.DuplicateError{Index: spec.Name, Fields: spec.Fields}
+		}
+	}
+	return nil
+}
+
+func (u *indexUsecase) app(ctx context.Context, namespace, appName string) (*domain.App, error) {
+	app, err := u.apps.GetByCode(ctx, appName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		return nil, fmt.Errorf("app %s: %w", appName, domain.ErrNotFound)
+	}
+	return app, nil
+}
+
+// appIndexes возвращает индексы, которые должны быть у таблицы записей приложения.
+// Имя зависит от полей и вида индекса, поэтому изменённое описание создаёт новый индекс
+func appIndexes(app *domain.App) []domain.IndexSpec {
+	var specs []domain.IndexSpec
+	add := func(prefix string, spec domain.IndexSpec) {
+		spec.Name = indexName(prefix, app.Code, spec.Fields)
+		specs = append(specs, spec)
+	}
+	for _, field := range app.Fields {
+		if field.Unique {
+			add("ux", domain.IndexSpec{Fields: []string{field.Code}, Unique: true})
+		}
+		if field.Indexed && !field.Unique {
+			if field.Type == domain.FieldTypeFile {
+				add("gx", domain.IndexSpec{Fields: []string{field.Code}, Gin: true})
+			} else {
+				add("ix", domain.IndexSpec{Fields: []string{field.Code}})
+			}
+		}
+	}
+	for _, fields := range app.Unique {
+		add("ux", domain.IndexSpec{Fields: fields, Unique: true})
+	}
+	return specs
+}
+
+// indexName строит имя индекса. Двойное подчёркивание отделяет приложение и поля,
+// чтобы имена индексов разных приложений namespace не совпадали; слишком длинное
+// имя сокращается с хешем полного
+func indexName(prefix, table string, fields []string) string {
+	name := strings.ToLower(prefix + "_" + table + "__" + strings.Join(fields, "__"))
+	if len(name) <= maxIndexName {
+		return name
+	}
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	return fmt.Sprintf("%s_%08x", name[:maxIndexName-9], h.Sum32())
+}
+
+// duplicateFields дополняет ошибку уникальности полями, которым принадлежит индекс
+func duplicateFields(app *domain.App, err error) error {
+	var dup *domain.DuplicateError
+	if !errors.As(err, &dup) || dup.Fields != nil {
+		return err
+	}
+	for _, spec := range appIndexes(app) {
+		if spec.Name == dup.Index {
+			dup.Fields = spec.Fields
+		}
+	}
+	return err
+}
+
+// validateIndexes проверяет уникальные и индексируемые поля приложения
+func validateIndexes(app *domain.App) error {
+	for _, field := range app.Fields {
+		if field.Unique && field.Type == domain.FieldTypeFile {
+			return fmt.Errorf("%w: file field %s cannot be unique", domain.ErrValidation, field.Code)
+		}
+	}
+	for _, fields := range app.Unique {
+		if len(fields) == 0 {
+			return fmt.Errorf("%w: unique constraint has no fields", domain.ErrValidation)
+		}
+		seen := make(map[string]bool, len(fields))
+		for _, code := range fields {
+			field := app.FieldByCode(code)
+			if field == nil {
+				return fmt.Errorf("%w: unique constraint refers to unknown field %s", domain.ErrValidation, code)
+			}
+			if field.Type == domain.FieldTypeFile {
+				return fmt.Errorf("%w: file field %s cannot be unique", domain.ErrValidation, code)
+			}
+			if seen[code] {
+				return fmt.Errorf("%w: field %s repeats in unique constraint", domain.ErrValidation, code)
+			}
+			seen[code] = true
+		}
+	}
+	names := make(map[string]bool)
+	for _, spec := range appIndexes(app) {
+		if names[spec.Name] {
+			return fmt.Errorf("%w: duplicate index on %s", domain.ErrValidation, strings.Join(spec.Fields, ", "))
+		}
+		names[spec.Name] = true
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package usecase
+
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// ratePeriod — период, за который корзина токенов наполняется целиком
+	ratePeriod = time.Minute
+	// limitsTTL — сколько экземпляр помнит ограничения namespace; изменения, сделанные
+	// через другой экземпляр, вступают в силу не позже этого срока
+	limitsTTL = 30 * time.Second
+)
+
+type LimitsRepo interface {
+	SetLimits(ctx context.Context, namespace string, limits *domain.Limits) error
+	CountApps(ctx context.Context, namespace string) (int64, error)
+	CountRecords(ctx context.Context, namespace, table string, limit int64) (int64, error)
+}
+
+// RateLimitRepo хранит корзины токенов ограничения частоты запросов
+type RateLimitRepo interface {
+	Take(ctx context.Context, key string, limit int, period time.Duration) (domain.RateDecision, error)
+	Purge(ctx context.Context, idle time.Duration) (int64, error)
+}
+
+// QuotaChecker проверяет квоты namespace перед созданием приложений и записей. Проверка
+// выполняется в транзакции создания: до её конца параллельные создания ждут своей очереди
+type QuotaChecker interface {
+	CheckAppQuota(ctx context.Context, namespace string) error
+	CheckRecordQuota(ctx context.Context, namespace, appName string) error
+}
+
+type LimitsUsecase interface {
+	QuotaChecker
+	// Get возвращает действующие ограничения namespace: свои значения поверх значений по умолчанию
+	Get(ctx context.Context, namespace string) (domain.Limits, error)
+	// Set задаёт собственные ограничения namespace; nil возвращает значения по умолчанию
+	Set(ctx context.Context, namespace string, limits *domain.Limits) error
+	// Allow списывает запрос субъекта principal из корзины класса маршрутов class в namespace.
+	// Запросы вне namespace (namespace пустой) ограничиваются значениями по умолчанию
+	Allow(ctx context.Context, principal, namespace, class string) (domain.RateDecision, error)
+	// PurgeBuckets удаляет корзины, которые уже наполнились
+	PurgeBuckets(ctx context.Context) error
+}
+
+type cachedLimits struct {
+	limits  domain.Limits
+	expires time.Time
+}
+
+type limitsUsecase struct {
+	repo       LimitsRepo
+	buckets    RateLimitRepo
+	namespaces NamespaceUsecase
+	defaults   domain.Limits
+	tx         Transactor
+	audit      AuditUsecase
+
+	mu    sync.Mutex
+	cache map[string]cachedLimits
+}
+
+// NewLimitsUsecase создаёт usecase ограничений; defaults — ограничения из настроек сервиса
+// для namespace, у которых нет своих
+func NewLimitsUsecase(repo LimitsRepo, buckets RateLimitRepo, namespaces NamespaceUsecase, defaults domain.Limits, tx Transactor, audit AuditUsecase) LimitsUsecase {
+	return &limitsUsecase{
+		repo:       repo,
+		buckets:    buckets,
+		namespaces: namespaces,
+		defaults:   defaults,
+		tx:         tx,
+		audit:      audit,
+		cache:      make(map[string]cachedLimits),
+	}
+}
+
+func (u *limitsUsecase) Get(ctx context.Context, namespace string) (domain.Limits, error) {
+	if namespace == "" {
+		return u.defaults, nil
+	}
+	u.mu.Lock()
+	cached, ok := u.cache[namespace]
+	u.mu.Unlock()
+	if ok && time.Now().Before(cached.expires) {
+		return cached.limits, nil
+	}
+
+	ns, err := u.namespaces.GetByCode(ctx, namespace)
+	if err != nil {
+		return domain.Limits{}, err
+	}
+	if ns == nil {
+		return domain.Limits{}, fmt.Errorf("namespace %s: %w", namespace, domain.ErrNotFound)
+	}
+	limits := ns.Limits.Over(u.defaults)
+
+	u.mu.Lock()
+	u.cache[namespace] = cachedLimits{limits: limits, expires: time.Now().Add(limitsTTL)}
+	u.mu.Unlock()
+	return limits, nil
+}
+
+func (u *limitsUsecase) Set(ctx context.Context, namespace string, limits *domain.Limits) error {
+	if limits != nil {
+		for name, value := range map[string]int64{
+			"readsPerMinute":         int64(limits.ReadsPerMinute),
+			"writesPerMinute":        int64(limits.WritesPerMinute),
+			"schemaChangesPerMinute": int64(limits.SchemaPerMinute),
+			"maxApps":                limits.MaxApps,
+			"maxRecordsPerApp":       limits.MaxRecordsPerApp,
+		} {
+			if value < -1 {
+				return fmt.Errorf("%w: %s must be -1 (no limit), 0 (default) or positive", domain.ErrValidation, name)
+			}
+		}
+	}
+	before, err := u.namespaces.GetByCode(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	if before == nil {
+		return fmt.Errorf("namespace %s: %w", namespace, domain.ErrNotFound)
+	}
+	err = inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		if err := u.repo.SetLimits(ctx, namespace, limits); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.audit, "namespace.set_limits", namespace, "", "", map[string]auditChange{"limits": {Old: before.Limits, New: limits}})
+	})
+	if err != nil {
+		return err
+	}
+	u.mu.Lock()
+	delete(u.cache, namespace)
+	u.mu.Unlock()
+	return nil
+}
+
+func (u *limitsUsecase) Allow(ctx context.Context, principal, namespace, class string) (domain.RateDecision, error) {
+	limits, err := u.Get(ctx, namespace)
+	if err != nil {
+		return domain.RateDecision{}, err
+	}
+	limit := limits.PerMinute(class)
+	if limit <= 0 {
+		return domain.RateDecision{Allowed: true}, nil
+	}
+	return u.buckets.Take(ctx, principal+"|"+namespace+"|"+class, limit, ratePeriod)
+}
+
+func (u *limitsUsecase) PurgeBuckets(ctx context.Context) error {
+	_, err := u.buckets.Purge(ctx, ratePeriod)
+	return err
+}
+
+func (u *limitsUsecase) CheckAppQuota(ctx context.Context, namespace string) error {
+	limits, err := u.Get(ctx, namespace)
+	if err != nil || limits.MaxApps <= 0 {
+		return err
+	}
+	count, err := u.repo.CountApps(ctx, namespace)
+	if err != nil {
+		return err
+	}
+	if count >= limits.MaxApps {
+		return fmt.Errorf("%w: namespace %s already has %d of %d apps", domain.ErrQuotaExceeded, namespace, count, limits.MaxApps)
+	}
+	return nil
+}
+
+// CheckRecordQuota проверяет квоту записей приложения
+func (u *limitsUsecase) CheckRecordQuota(ctx context.Context, namespace, appName string) error {
+	limits, err := u.Get(ctx, namespace)
+	if err != nil || limits.MaxRecordsPerApp <= 0 {
+		return err
+	}
+	count, err := u.repo.CountRecords(ctx, namespace, appName, limits.MaxRecordsPerApp)
+	if err != nil {
+		return err
+	}
+	if count >= limits.MaxRecordsPerApp {
+		return fmt.Errorf("%w: app %s already has %d records, the limit is %d", domain.ErrQuotaExceeded, appName, count, limits.MaxRecordsPerApp)
+	}
+	return nil
+}
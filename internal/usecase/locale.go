@@ -0,0 +1,159 @@
+package usecase
+
+import (
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/reqctx"
+	"context"
+	"fmt"
+)
+
+// localizer приводит значения локализуемых полей: при записи собирает словарь локалей,
+// при чтении выбирает значение по Accept-Language и цепочке локалей namespace
+type localizer struct {
+	namespaces NamespaceUsecase
+}
+
+// fallback возвращает цепочку локалей namespace
+func (l localizer) fallback(ctx context.Context, namespace string) ([]string, error) {
+	ns, err := l.namespaces.GetByCode(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if ns == nil {
+		return nil, fmt.Errorf("namespace %s: %w", namespace, domain.ErrNotFound)
+	}
+	return ns.Locales, nil
+}
+
+// resolve заменяет словари локалей в записях значением для клиента. Без Accept-Language
+// записи отдаются как есть, со всеми локалями, — так их видят редакторы
+func (l localizer) resolve(ctx context.Context, app *domain.App, records ...*domain.AppData) error {
+	fields := localizedFields(app)
+	preferred := reqctx.FromContext(ctx).Locales
+	if len(fields) == 0 || len(preferred) == 0 {
+		return nil
+	}
+	fallback, err := l.fallback(ctx, app.NamespaceCode)
+	if err != nil {
+		return err
+	}
+	chain := domain.LocaleChain(preferred, fallback)
+	for _, record := range records {
+		if record == nil {
+			continue
+		}
+		for _, field := range fields {
+			if value, ok := record.Data[field]; ok {
+				record.Data[field] = domain.ResolveLocalized(value, chain)
+			}
+		}
+	}
+	return nil
+}
+
+// query задаёт выборке порядок локалей, по которому фильтруются и сортируются локализуемые поля
+func (l localizer) query(ctx context.Context, app *domain.App, query *domain.DataQuery) error {
+	query.Localized = localizedFields(app)
+	if len(query.Localized) == 0 {
+		return nil
+	}
+	fallback, err := l.fallback(ctx, app.NamespaceCode)
+	if err != nil {
+		return err
+	}
+	query.Locales = domain.LocaleChain(reqctx.FromContext(ctx).Locales, fallback)
+	return nil
+}
+
+// input приводит значения локализуемых полей из запроса к словарю локалей. Строка
+// записывается в локаль из Content-Language, а без него — в основную локаль namespace,
+// остальные локали берутся из before. Словарь заменяет значение поля целиком
+func (l localizer) input(ctx context.Context, app *domain.App, values, before map[string]interface{}) error {
+	fields := localizedFields(app)
+	if len(fields) == 0 {
+		return nil
+	}
+	fallback, err := l.fallback(ctx, app.NamespaceCode)
+	if err != nil {
+		return err
+	}
+	allowed := func(tag string) bool {
+		if !domain.IsLocale(tag) {
+			return false
+		}
+		if len(fallback) == 0 {
+			return true
+		}
+		for _, locale := range fallback {
+			if locale == tag {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, field := range fields {
+		value, ok := values[field]
+		if !ok {
+			continue
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			localized := make(map[string]interface{}, len(v))
+			for tag, text := range v {
+				if !allowed(tag) {
+					return fmt.Errorf("%w: field %s: unsupported locale %q", domain.ErrValidation, field, tag)
+				}
+				if text == nil {
+					continue
+				}
+				if _, ok := text.(string); !ok {
+					return fmt.Errorf("%w: field %s: value for locale %s must be a string", domain.ErrValidation, field, tag)
+				}
+				localized[tag] = text
+			}
+			values[field] = localized
+		case string, nil:
+			locale := reqctx.FromContext(ctx).ContentLocale
+			if v == nil && locale == "" {
+				// null без локали очищает поле целиком
+				continue
+			}
+			if locale == "" && len(fallback) > 0 {
+				locale = fallback[0]
+			}
+			if locale == "" {
+				return fmt.Errorf("%w: field %s is localized, set Content-Language or pass values by locale", domain.ErrValidation, field)
+			}
+			if !allowed(locale) {
+				return fmt.Errorf("%w: unsupported locale %q", domain.ErrValidation, locale)
+			}
+			localized := make(map[string]interface{})
+			if previous, ok := before[field].(map[string]interface{}); ok {
+				for tag, text := range previous {
+					localized[tag] = text
+				}
+			}
+			if v == nil {
+				delete(localized, locale)
+			} else {
+				localized[locale] = v
+			}
+			values[field] = localized
+		default:
+			return fmt.Errorf("%w: field %s is localized, expected a string or values by locale", domain.ErrValidation, field)
+		}
+	}
+	return nil
+}
+
+// localizedFields возвращает коды локализуемых полей приложения
+func localizedFields(app *domain.App) []string {
+	var fields []string
+	for _, field := range app.Fields {
+		if field.Localized {
+			fields = append(fields, field.Code)
+		}
+	}
+	return fields
+}
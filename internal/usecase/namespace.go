@@ -1,43 +1,146 @@
 package usecase
 
-import "app/backendv1/internal/domain"
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"fmt"
+	"time"
+)
 
 type NamespaceUsecase interface {
-	Create(*domain.Namespace) error
-	GetAll() ([]domain.Namespace, error)
-	GetByCode(code string) (*domain.Namespace, error)
-	Update(code string, record *domain.Namespace) error
-	Delete(code string) error
+	Create(ctx context.Context, record *domain.Namespace) error
+	GetAll(ctx context.Context) ([]domain.Namespace, error)
+	GetByCode(ctx context.Context, code string) (*domain.Namespace, error)
+	Update(ctx context.Context, code string, record *domain.Namespace) error
+	SetIcon(ctx context.Context, code, iconID string) error
+	Delete(ctx context.Context, code string) error
+	GetTrash(ctx context.Context) ([]domain.Namespace, error)
+	Restore(ctx context.Context, code string) error
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
 }
 
 // RecordService — конкретная реализация бизнес-логики
 type namespaceService struct {
-	repo NamespaceUsecase
+	repo  NamespaceUsecase
+	tx    Transactor
+	audit AuditUsecase
 }
 
-func NewNamespaceService(repo NamespaceUsecase) *namespaceService {
-	return &namespaceService{repo: repo}
+func NewNamespaceService(repo NamespaceUsecase, tx Transactor, audit AuditUsecase) *namespaceService {
+	return &namespaceService{repo: repo, tx: tx, audit: audit}
 }
 
 // Реализация интерфейса RecordUsecase
 
-func (s *namespaceService) Create(record *domain.Namespace) error {
+func (s *namespaceService) Create(ctx context.Context, record *domain.Namespace) error {
 	// можно добавить валидацию или другую бизнес-логику
-	return s.repo.Create(record)
+	// иконка назначается только загрузкой, ограничения — через LimitsUsecase
+	record.Icon = ""
+	record.Limits = nil
+	if err := validateLocales(record.Locales); err != nil {
+		return err
+	}
+	return inTx(ctx, s.tx, s.audit, func(ctx context.Context) error {
+		if err := s.repo.Create(ctx, record); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, "namespace.create", record.Code, "", "", diffFields(nil, namespaceFields(record)))
+	})
 }
 
-func (s *namespaceService) GetAll() ([]domain.Namespace, error) {
-	return s.repo.GetAll()
+func (s *namespaceService) GetAll(ctx context.Context) ([]domain.Namespace, error) {
+	return s.repo.GetAll(ctx)
 }
 
-func (s *namespaceService) GetByCode(code string) (*domain.Namespace, error) {
-	return s.repo.GetByCode(code)
+func (s *namespaceService) GetByCode(ctx context.Context, code string) (*domain.Namespace, error) {
+	return s.repo.GetByCode(ctx, code)
 }
 
-func (s *namespaceService) Update(code string, record *domain.Namespace) error {
-	return s.repo.Update(code, record)
+func (s *namespaceService) Update(ctx context.Context, code string, record *domain.Namespace) error {
+	if err := validateLocales(record.Locales); err != nil {
+		return err
+	}
+	before, err := s.repo.GetByCode(ctx, code)
+	if err != nil {
+		return err
+	}
+	return inTx(ctx, s.tx, s.audit, func(ctx context.Context) error {
+		if err := s.repo.Update(ctx, code, record); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, "namespace.update", code, "", "", diffFields(namespaceFields(before), map[string]interface{}{"name": record.Name, "locales": record.Locales}))
+	})
 }
 
-func (s *namespaceService) Delete(code string) error {
-	return s.repo.Delete(code)
+func (s *namespaceService) SetIcon(ctx context.Context, code, iconID string) error {
+	before, err := s.repo.GetByCode(ctx, code)
+	if err != nil {
+		return err
+	}
+	var oldIcon string
+	if before != nil {
+		oldIcon = before.Icon
+	}
+	return inTx(ctx, s.tx, s.audit, func(ctx context.Context) error {
+		if err := s.repo.SetIcon(ctx, code, iconID); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, "namespace.set_icon", code, "", "", map[string]auditChange{"icon": {Old: oldIcon, New: iconID}})
+	})
+}
+
+func (s *namespaceService) Delete(ctx context.Context, code string) error {
+	return inTx(ctx, s.tx, s.audit, func(ctx context.Context) error {
+		if err := s.repo.Delete(ctx, code); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, "namespace.delete", code, "", "", nil)
+	})
+}
+
+func (s *namespaceService) GetTrash(ctx context.Context) ([]domain.Namespace, error) {
+	return s.repo.GetTrash(ctx)
+}
+
+func (s *namespaceService) Restore(ctx context.Context, code string) error {
+	return inTx(ctx, s.tx, s.audit, func(ctx context.Context) error {
+		if err := s.repo.Restore(ctx, code); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.audit, "namespace.restore", code, "", "", nil)
+	})
+}
+
+// PurgeDeleted удаляет namespace по одному, каждый в своей транзакции, поэтому
+// журнал пишется после удаления
+func (s *namespaceService) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	purged, err := s.repo.PurgeDeleted(ctx, before)
+	if purged > 0 {
+		if auditErr := recordAudit(ctx, s.audit, "namespace.purge", "", "", "", map[string]interface{}{"purged": purged, "before": before}); err == nil {
+			err = auditErr
+		}
+	}
+	return purged, err
+}
+
+func namespaceFields(namespace *domain.Namespace) map[string]interface{} {
+	if namespace == nil {
+		return nil
+	}
+	return map[string]interface{}{"code": namespace.Code, "name": namespace.Name, "locales": namespace.Locales}
+}
+
+// validateLocales проверяет цепочку локалей namespace
+func validateLocales(locales []string) error {
+	seen := make(map[string]bool, len(locales))
+	for _, tag := range locales {
+		if !domain.IsLocale(tag) {
+			return fmt.Errorf("%w: invalid locale %q", domain.ErrValidation, tag)
+		}
+		if seen[tag] {
+			return fmt.Errorf("%w: duplicate locale %s", domain.ErrValidation, tag)
+		}
+		seen[tag] = true
+	}
+	return nil
 }
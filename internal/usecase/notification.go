@@ -0,0 +1,45 @@
+package usecase
+
+import (
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/reqctx"
+	"context"
+	"strconv"
+)
+
+// NotificationRepo — хранилище уведомлений пользователей
+type NotificationRepo interface {
+	Create(ctx context.Context, notification *domain.Notification) error
+	ListByRecipient(ctx context.Context, recipient string, unreadOnly bool, limit int) ([]*domain.Notification, error)
+	// MarkRead отмечает уведомление получателя прочитанным, ErrNotFound если его нет
+	MarkRead(ctx context.Context, id int64, recipient string) error
+}
+
+// NotificationUsecase — уведомления текущего пользователя
+type NotificationUsecase interface {
+	List(ctx context.Context, unreadOnly bool, limit int) ([]*domain.Notification, error)
+	MarkRead(ctx context.Context, id string) error
+}
+
+type notificationUsecase struct {
+	repo NotificationRepo
+}
+
+func NewNotificationUsecase(repo NotificationRepo) NotificationUsecase {
+	return &notificationUsecase{repo: repo}
+}
+
+func (u *notificationUsecase) List(ctx context.Context, unreadOnly bool, limit int) ([]*domain.Notification, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	return u.repo.ListByRecipient(ctx, reqctx.FromContext(ctx).Actor, unreadOnly, limit)
+}
+
+func (u *notificationUsecase) MarkRead(ctx context.Context, id string) error {
+	n, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return domain.ErrNotFound
+	}
+	return u.repo.MarkRead(ctx, n, reqctx.FromContext(ctx).Actor)
+}
@@ -0,0 +1,64 @@
+package usecase
+
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"time"
+)
+
+// numberBackfillBatch — сколько записей без номера читается за один запрос при нумерации
+const numberBackfillBatch = 500
+
+// NumberingRepo — счётчики номеров записей и сами номера
+type NumberingRepo interface {
+	// NextValue увеличивает счётчик периода и возвращает новое значение. Строка счётчика
+	// заблокирована до конца транзакции, поэтому при откате номер не теряется
+	NextValue(ctx context.Context, namespace, appName, period string) (int64, error)
+	// NumberTaken сообщает, занят ли номер записью приложения, в том числе в корзине
+	NumberTaken(ctx context.Context, namespace, appName, number string) (bool, error)
+	// Unnumbered возвращает до limit записей без номера в порядке создания, включая корзину
+	Unnumbered(ctx context.Context, namespace, appName string, limit int) ([]*domain.AppData, error)
+	SetNumber(ctx context.Context, namespace, appName, uid, number string) error
+}
+
+// allocateNumber выделяет номер записи, созданной в момент at. Номера, уже занятые
+// записями по прежнему шаблону нумерации, пропускаются
+func allocateNumber(ctx context.Context, repo NumberingRepo, namespace, appName string, policy *domain.NumberingPolicy, at time.Time) (string, error) {
+	at = at.UTC()
+	for {
+		value, err := repo.NextValue(ctx, namespace, appName, policy.Period(at))
+		if err != nil {
+			return "", err
+		}
+		number := policy.Number(at, value)
+		taken, err := repo.NumberTaken(ctx, namespace, appName, number)
+		if err != nil || !taken {
+			return number, err
+		}
+	}
+}
+
+// backfillNumbers нумерует записи, созданные до включения нумерации, в порядке создания;
+// период счётчика берётся по времени создания записи
+func backfillNumbers(ctx context.Context, repo NumberingRepo, app *domain.App) (int64, error) {
+	var numbered int64
+	for {
+		batch, err := repo.Unnumbered(ctx, app.NamespaceCode, app.Code, numberBackfillBatch)
+		if err != nil {
+			return numbered, err
+		}
+		for _, record := range batch {
+			number, err := allocateNumber(ctx, repo, app.NamespaceCode, app.Code, app.Numbering, record.CreatedAt)
+			if err != nil {
+				return numbered, err
+			}
+			if err := repo.SetNumber(ctx, app.NamespaceCode, app.Code, record.UID, number); err != nil {
+				return numbered, err
+			}
+			numbered++
+		}
+		if len(batch) < numberBackfillBatch {
+			return numbered, nil
+		}
+	}
+}
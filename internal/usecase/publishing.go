@@ -0,0 +1,192 @@
+package usecase
+
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// PublishingRepo хранит опубликованные версии записей приложений с черновиками
+type PublishingRepo interface {
+	Publish(ctx context.Context, namespace, table, uid string) error
+	Unpublish(ctx context.Context, namespace, table, uid string) error
+	SchedulePublish(ctx context.Context, namespace, table, uid string, at time.Time) error
+	ScheduleUnpublish(ctx context.Context, namespace, table, uid string, at time.Time) error
+	CancelSchedule(ctx context.Context, namespace, table, uid string) error
+	GetPublished(ctx context.Context, namespace, table, uid string) (*domain.AppData, error)
+	GetPublishedByNumber(ctx context.Context, namespace, table, number string) (*domain.AppData, error)
+	// PublishDue и UnpublishDue выполняют запланированные действия, срок которых наступил, и возвращают uid записей
+	PublishDue(ctx context.Context, namespace, table string, now time.Time) ([]string, error)
+	UnpublishDue(ctx context.Context, namespace, table string, now time.Time) ([]string, error)
+}
+
+type PublishingUsecase interface {
+	// Publish публикует черновик записи; если at в будущем, публикация откладывается до этого времени
+	Publish(ctx context.Context, namespace, appName, uid string, at *time.Time) error
+	// Unpublish снимает запись с публикации сразу или в момент at
+	Unpublish(ctx context.Context, namespace, appName, uid string, at *time.Time) error
+	CancelSchedule(ctx context.Context, namespace, appName, uid string) error
+	// Get и GetByNumber возвращают опубликованную версию записи
+	Get(ctx context.Context, namespace, appName, uid string) (*domain.AppData, error)
+	GetByNumber(ctx context.Context, namespace, appName, number string) (*domain.AppData, error)
+	// RunScheduled выполняет наступившие публикации и снятия с публикации во всех приложениях с черновиками
+	RunScheduled(ctx context.Context) error
+}
+
+type publishingUsecase struct {
+	repo    PublishingRepo
+	apps    AppUsecase
+	data    AppDataUsecase
+	tx      Transactor
+	audit   AuditUsecase
+	locales localizer
+}
+
+// NewPublishingUsecase принимает репозиторий данных напрямую: обе версии записи
+// читаются в хранимом виде и локализуются здесь одинаково
+func NewPublishingUsecase(repo PublishingRepo, apps AppUsecase, namespaces NamespaceUsecase, data AppDataUsecase, tx Transactor, audit AuditUsecase) PublishingUsecase {
+	return &publishingUsecase{repo: repo, apps: apps, data: data, tx: tx, audit: audit, locales: localizer{namespaces: namespaces}}
+}
+
+func (u *publishingUsecase) Publish(ctx context.Context, namespace, appName, uid string, at *time.Time) error {
+	if _, err := u.draftsApp(ctx, namespace, appName); err != nil {
+		return err
+	}
+	return inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		if at != nil && at.After(time.Now()) {
+			if err := u.repo.SchedulePublish(ctx, namespace, appName, uid, *at); err != nil {
+				return err
+			}
+			return recordAudit(ctx, u.audit, "app_data.publish_scheduled", namespace, appName, uid, map[string]interface{}{"publishAt": *at})
+		}
+		if err := u.repo.Publish(ctx, namespace, appName, uid); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.audit, "app_data.publish", namespace, appName, uid, nil)
+	})
+}
+
+func (u *publishingUsecase) Unpublish(ctx context.Context, namespace, appName, uid string, at *time.Time) error {
+	if _, err := u.draftsApp(ctx, namespace, appName); err != nil {
+		return err
+	}
+	return inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		if at != nil && at.After(time.Now()) {
+			if err := u.repo.ScheduleUnpublish(ctx, namespace, appName, uid, *at); err != nil {
+				return err
+			}
+			return recordAudit(ctx, u.audit, "app_data.unpublish_scheduled", namespace, appName, uid, map[string]interface{}{"unpublishAt": *at})
+		}
+		if err := u.repo.Unpublish(ctx, namespace, appName, uid); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.audit, "app_data.unpublish", namespace, appName, uid, nil)
+	})
+}
+
+func (u *publishingUsecase) CancelSchedule(ctx context.Context, namespace, appName, uid string) error {
+	if _, err := u.draftsApp(ctx, namespace, appName); err != nil {
+		return err
+	}
+	return inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		if err := u.repo.CancelSchedule(ctx, namespace, appName, uid); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.audit, "app_data.schedule_cancel", namespace, appName, uid, nil)
+	})
+}
+
+// Get возвращает опубликованную версию; у приложения без черновиков это сама запись
+func (u *publishingUsecase) Get(ctx context.Context, namespace, appName, uid string) (*domain.AppData, error) {
+	app, err := u.app(ctx, namespace, appName)
+	if err != nil {
+		return nil, err
+	}
+	if !app.Drafts {
+		return u.resolve(ctx, app)(u.data.GetDataByUID(ctx, namespace, appName, uid))
+	}
+	return u.resolve(ctx, app)(u.repo.GetPublished(ctx, namespace, appName, uid))
+}
+
+func (u *publishingUsecase) GetByNumber(ctx context.Context, namespace, appName, number string) (*domain.AppData, error) {
+	app, err := u.app(ctx, namespace, appName)
+	if err != nil {
+		return nil, err
+	}
+	if !app.Drafts {
+		return u.resolve(ctx, app)(u.data.GetByNumber(ctx, namespace, appName, number))
+	}
+	return u.resolve(ctx, app)(u.repo.GetPublishedByNumber(ctx, namespace, appName, number))
+}
+
+// resolve выбирает значения локализуемых полей прочитанной версии записи для клиента
+func (u *publishingUsecase) resolve(ctx context.Context, app *domain.App) func(*domain.AppData, error) (*domain.AppData, error) {
+	return func(record *domain.AppData, err error) (*domain.AppData, error) {
+		if err != nil {
+			return nil, err
+		}
+		if err := u.locales.resolve(ctx, app, record); err != nil {
+			return nil, err
+		}
+		return record, nil
+	}
+}
+
+func (u *publishingUsecase) RunScheduled(ctx context.Context) error {
+	apps, err := u.apps.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list apps: %w", err)
+	}
+	now := time.Now()
+	for _, app := range apps {
+		if !app.Drafts {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		// публикация раньше снятия: запись с обоими наступившими сроками окажется снятой
+		published, err := u.repo.PublishDue(ctx, app.NamespaceCode, app.Code, now)
+		if err != nil {
+			slog.ErrorContext(ctx, "publisher: scheduled publication failed", "namespace", app.NamespaceCode, "app", app.Code, "error", err)
+			continue
+		}
+		for _, uid := range published {
+			recordAudit(ctx, u.audit, "app_data.publish", app.NamespaceCode, app.Code, uid, map[string]interface{}{"scheduled": true})
+		}
+		unpublished, err := u.repo.UnpublishDue(ctx, app.NamespaceCode, app.Code, now)
+		if err != nil {
+			slog.ErrorContext(ctx, "publisher: scheduled unpublication failed", "namespace", app.NamespaceCode, "app", app.Code, "error", err)
+			continue
+		}
+		for _, uid := range unpublished {
+			recordAudit(ctx, u.audit, "app_data.unpublish", app.NamespaceCode, app.Code, uid, map[string]interface{}{"scheduled": true})
+		}
+	}
+	return nil
+}
+
+func (u *publishingUsecase) app(ctx context.Context, namespace, appName string) (*domain.App, error) {
+	app, err := u.apps.GetByCode(ctx, appName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		return nil, fmt.Errorf("app %s: %w", appName, domain.ErrNotFound)
+	}
+	return app, nil
+}
+
+// draftsApp возвращает приложение, если в нём включены черновики; иначе публиковать нечего
+func (u *publishingUsecase) draftsApp(ctx context.Context, namespace, appName string) (*domain.App, error) {
+	app, err := u.app(ctx, namespace, appName)
+	if err != nil {
+		return nil, err
+	}
+	if !app.Drafts {
+		return nil, fmt.Errorf("%w: app %s has no drafts", domain.ErrValidation, appName)
+	}
+	return app, nil
+}
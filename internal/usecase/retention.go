@@ -0,0 +1,230 @@
+package usecase
+
+import (
+	"app/backendv1/internal/domain"
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+)
+
+// RetentionRepo применяет сроки хранения к таблицам записей
+type RetentionRepo interface {
+	// ApplyRetention удаляет или архивирует до limit записей старше before, кроме записей
+	// под удержанием, и возвращает их uid
+	ApplyRetention(ctx context.Context, namespace, table string, policy domain.RetentionPolicy, before time.Time, limit int) ([]string, error)
+	// CountHeld возвращает число записей под удержанием, срок хранения которых истёк
+	CountHeld(ctx context.Context, namespace, table string, policy domain.RetentionPolicy, before time.Time) (int64, error)
+	SetLegalHold(ctx context.Context, namespace, table, uid string, hold bool) error
+}
+
+type RetentionUsecase interface {
+	// SetLegalHold ставит запись под удержание или снимает его
+	SetLegalHold(ctx context.Context, namespace, appName, uid string, hold bool) error
+	// Enforce применяет сроки хранения ко всем приложениям, где они заданы
+	Enforce(ctx context.Context) error
+	Metrics() []domain.RetentionMetrics
+}
+
+type retentionUsecase struct {
+	repo        RetentionRepo
+	apps        AppUsecase
+	attachments AttachmentUsecase
+	events      EventPublisher
+	tx          Transactor
+	audit       AuditUsecase
+	batchSize   int
+
+	mu      sync.Mutex
+	metrics map[string]*domain.RetentionMetrics
+}
+
+// NewRetentionUsecase создаёт usecase сроков хранения; batchSize — сколько записей
+// удаляется одним запросом, чтобы не держать долгих блокировок на больших таблицах
+func NewRetentionUsecase(repo RetentionRepo, apps AppUsecase, attachments AttachmentUsecase, events EventPublisher, tx Transactor, audit AuditUsecase, batchSize int) RetentionUsecase {
+	return &retentionUsecase{
+		repo:        repo,
+		apps:        apps,
+		attachments: attachments,
+		events:      events,
+		tx:          tx,
+		audit:       audit,
+		batchSize:   batchSize,
+		metrics:     make(map[string]*domain.RetentionMetrics),
+	}
+}
+
+func (u *retentionUsecase) SetLegalHold(ctx context.Context, namespace, appName, uid string, hold bool) error {
+	app, err := u.apps.GetByCode(ctx, appName, namespace)
+	if err != nil {
+		return err
+	}
+	if app == nil {
+		return fmt.Errorf("app %s: %w", appName, domain.ErrNotFound)
+	}
+	return inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		if err := u.repo.SetLegalHold(ctx, namespace, appName, uid, hold); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.audit, "app_data.legal_hold", namespace, appName, uid, map[string]interface{}{"legalHold": hold})
+	})
+}
+
+func (u *retentionUsecase) Enforce(ctx context.Context) error {
+	apps, err := u.apps.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list apps: %w", err)
+	}
+	for _, app := range apps {
+		if app.Retention == nil {
+			continue
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := u.enforceApp(ctx, app); err != nil {
+			slog.ErrorContext(ctx, "retention failed", "namespace", app.NamespaceCode, "app", app.Code, "error", err)
+		}
+	}
+	return nil
+}
+
+// enforceApp удаляет записи приложения с истёкшим сроком пачками по batchSize. Каждая
+// пачка удаляется в одной транзакции с записями журнала об удалённых записях
+func (u *retentionUsecase) enforceApp(ctx context.Context, app *domain.App) error {
+	policy := *app.Retention
+	mode := retentionMode(policy)
+	started := time.Now()
+	before := started.AddDate(0, 0, -policy.Days)
+
+	var (
+		removed, batches int64
+		err              error
+	)
+	for ctx.Err() == nil {
+		var uids []string
+		err = inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+			var err error
+			uids, err = u.repo.ApplyRetention(ctx, app.NamespaceCode, app.Code, policy, before, u.batchSize)
+			if err != nil {
+				return err
+			}
+			for _, uid := range uids {
+				if err := recordAudit(ctx, u.audit, "app_data.retention", app.NamespaceCode, app.Code, uid, map[string]interface{}{
+					"mode": mode, "before": before,
+				}); err != nil {
+					return err
+				}
+				publishEvent(ctx, u.events, domain.EventDeleted, app.NamespaceCode, app.Code, uid, nil)
+			}
+			return nil
+		})
+		if err != nil {
+			break
+		}
+		removed += int64(len(uids))
+		batches++
+		if mode == domain.RetentionDelete {
+			u.removeAttachments(ctx, app, uids)
+		}
+		if len(uids) < u.batchSize {
+			break
+		}
+	}
+
+	held, heldErr := u.repo.CountHeld(ctx, app.NamespaceCode, app.Code, policy, before)
+	if err == nil {
+		err = heldErr
+	}
+
+	u.record(app, policy, removed, batches, held, started, err)
+	if removed > 0 {
+		slog.InfoContext(ctx, "retention: records removed", "mode", mode, "namespace", app.NamespaceCode, "app", app.Code, "count", removed)
+	}
+	return err
+}
+
+// removeAttachments удаляет вложения окончательно удалённых записей. Вложения архивных
+// записей остаются; если удалить не вышло, их удалит сборщик мусора
+func (u *retentionUsecase) removeAttachments(ctx context.Context, app *domain.App, uids []string) {
+	if u.attachments == nil {
+		return
+	}
+	if _, err := u.attachments.RemoveRecords(ctx, app.NamespaceCode, app.Code, uids); err != nil {
+		slog.ErrorContext(ctx, "retention: failed to remove attachments", "namespace", app.NamespaceCode, "app", app.Code, "error", err)
+	}
+}
+
+func (u *retentionUsecase) record(app *domain.App, policy domain.RetentionPolicy, removed, batches, held int64, started time.Time, err error) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	key := app.NamespaceCode + "." + app.Code
+	m, ok := u.metrics[key]
+	if !ok {
+		m = &domain.RetentionMetrics{NamespaceCode: app.NamespaceCode, AppCode: app.Code}
+		u.metrics[key] = m
+	}
+	m.Runs++
+	m.Batches += batches
+	if retentionMode(policy) == domain.RetentionArchive {
+		m.Archived += removed
+	} else {
+		m.Deleted += removed
+	}
+	m.Held = held
+	m.LastRunAt = &started
+	m.LastDuration = time.Since(started).String()
+	m.LastError = ""
+	if err != nil {
+		m.Errors++
+		m.LastError = err.Error()
+	}
+}
+
+func (u *retentionUsecase) Metrics() []domain.RetentionMetrics {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	result := make([]domain.RetentionMetrics, 0, len(u.metrics))
+	for _, m := range u.metrics {
+		result = append(result, *m)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].NamespaceCode != result[j].NamespaceCode {
+			return result[i].NamespaceCode < result[j].NamespaceCode
+		}
+		return result[i].AppCode < result[j].AppCode
+	})
+	return result
+}
+
+func retentionMode(policy domain.RetentionPolicy) string {
+	if policy.Mode == "" {
+		return domain.RetentionDelete
+	}
+	return policy.Mode
+}
+
+// validateRetention проверяет срок хранения записей приложения
+func validateRetention(app *domain.App) error {
+	policy := app.Retention
+	if policy == nil {
+		return nil
+	}
+	if policy.Days < 1 {
+		return fmt.Errorf("%w: retention days must be at least 1", domain.ErrValidation)
+	}
+	if mode := retentionMode(*policy); mode != domain.RetentionDelete && mode != domain.RetentionArchive {
+		return fmt.Errorf("%w: unknown retention mode %q", domain.ErrValidation, policy.Mode)
+	}
+	if policy.Field != "" {
+		field := app.FieldByCode(policy.Field)
+		if field == nil || field.Type != domain.FieldTypeDatetime {
+			return fmt.Errorf("%w: retention field %s must be a datetime field of the app", domain.ErrValidation, policy.Field)
+		}
+	}
+	return nil
+}
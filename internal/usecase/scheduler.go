@@ -0,0 +1,547 @@
+package usecase
+
+import (
+	"app/backendv1/internal/cron"
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/reqctx"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// jobBatchSize — сколько записей обрабатывает задание за один запрос к базе
+	jobBatchSize = 500
+	// maxExportRecords ограничивает выгрузку, отправляемую одним вебхуком
+	maxExportRecords = 10000
+	// jobClaimLimit — сколько наступивших заданий экземпляр выполняет за один проход;
+	// задания берутся в аренду по одному, непосредственно перед выполнением
+	jobClaimLimit = 10
+)
+
+// JobRunning — задание выполняется; итоговый статус — domain.RunSuccess или domain.RunFailed
+const JobRunning = "running"
+
+// JobRepo — хранилище заданий и истории их выполнения. Задание выполняется
+// тем экземпляром сервиса, который взял его в аренду (lease) до её истечения
+type JobRepo interface {
+	Create(ctx context.Context, job *domain.ScheduledJob) error
+	// GetByID возвращает задание, nil если его нет
+	GetByID(ctx context.Context, id string) (*domain.ScheduledJob, error)
+	ListByNamespace(ctx context.Context, namespace string) ([]*domain.ScheduledJob, error)
+	Update(ctx context.Context, job *domain.ScheduledJob) error
+	Delete(ctx context.Context, id string) error
+	SetPaused(ctx context.Context, id string, paused bool, nextRunAt *time.Time) error
+	// ClaimDue берёт в аренду до limit заданий, время запуска которых наступило
+	ClaimDue(ctx context.Context, owner string, lease time.Duration, limit int) ([]*domain.ScheduledJob, error)
+	// Claim берёт в аренду задание для ручного запуска, false — если оно уже выполняется
+	Claim(ctx context.Context, id, owner string, lease time.Duration) (bool, error)
+	// Extend продлевает аренду owner на lease от текущего времени, false — если аренда уже не его
+	Extend(ctx context.Context, id, owner string, lease time.Duration) (bool, error)
+	// Release снимает аренду; nextRunAt == nil оставляет расписание без изменений
+	Release(ctx context.Context, id, owner string, lastRunAt time.Time, nextRunAt *time.Time) error
+	CreateRun(ctx context.Context, run *domain.JobRun) error
+	FinishRun(ctx context.Context, run *domain.JobRun) error
+	ListRuns(ctx context.Context, jobID string, limit int) ([]*domain.JobRun, error)
+
+	RecomputeQueue
+	// ClaimRecompute берёт в аренду самый ранний запрос пересчёта, nil если очередь пуста
+	ClaimRecompute(ctx context.Context, owner string, lease time.Duration) (*domain.RecomputeRequest, error)
+	// FinishRecompute удаляет выполненный запрос, если его не повторили за время пересчёта
+	FinishRecompute(ctx context.Context, req *domain.RecomputeRequest, owner string) error
+}
+
+type SchedulerUsecase interface {
+	Create(ctx context.Context, job *domain.ScheduledJob) error
+	GetAll(ctx context.Context, namespace string) ([]*domain.ScheduledJob, error)
+	Get(ctx context.Context, namespace, id string) (*domain.ScheduledJob, error)
+	Update(ctx context.Context, job *domain.ScheduledJob) error
+	Delete(ctx context.Context, namespace, id string) error
+	Pause(ctx context.Context, namespace, id string) (*domain.ScheduledJob, error)
+	Resume(ctx context.Context, namespace, id string) (*domain.ScheduledJob, error)
+	// Trigger запускает задание в фоне, не меняя его расписания, и возвращает начатый запуск
+	Trigger(ctx context.Context, namespace, id string) (*domain.JobRun, error)
+	Runs(ctx context.Context, namespace, id string, limit int) ([]*domain.JobRun, error)
+	// RunDue выполняет задания, время запуска которых наступило, и пересчёты после изменения формул
+	RunDue(ctx context.Context) (int, error)
+}
+
+type schedulerUsecase struct {
+	repo       JobRepo
+	apps       AppUsecase
+	records    ComputedRepo
+	data       AppDataUsecase
+	automation AutomationUsecase
+	tx         Transactor
+	audit      AuditUsecase
+	owner      string
+	lease      time.Duration
+	client     *http.Client
+}
+
+// NewSchedulerUsecase принимает и репозиторий записей, и usecase: пересчёт формул
+// пишет в записи напрямую, а удаление и выгрузка проходят обычные проверки.
+// lease — на сколько задание закрепляется за экземпляром, должна превышать время его выполнения
+func NewSchedulerUsecase(repo JobRepo, apps AppUsecase, records ComputedRepo, data AppDataUsecase, automation AutomationUsecase, tx Transactor, audit AuditUsecase, lease time.Duration) SchedulerUsecase {
+	return &schedulerUsecase{
+		repo:       repo,
+		apps:       apps,
+		records:    records,
+		data:       data,
+		automation: automation,
+		tx:         tx,
+		audit:      audit,
+		owner:      newUUID(),
+		lease:      lease,
+		client:     newWebhookClient(),
+	}
+}
+
+func (u *schedulerUsecase) Create(ctx context.Context, job *domain.ScheduledJob) error {
+	next, err := u.validate(ctx, job)
+	if err != nil {
+		return err
+	}
+	job.NextRunAt = next
+	if job.Paused {
+		job.NextRunAt = nil
+	}
+	return inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		if err := u.repo.Create(ctx, job); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.audit, "job.create", job.NamespaceCode, job.Action.App, "", diffFields(nil, jobFields(job)))
+	})
+}
+
+func (u *schedulerUsecase) GetAll(ctx context.Context, namespace string) ([]*domain.ScheduledJob, error) {
+	return u.repo.ListByNamespace(ctx, namespace)
+}
+
+func (u *schedulerUsecase) Get(ctx context.Context, namespace, id string) (*domain.ScheduledJob, error) {
+	job, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if job == nil || job.NamespaceCode != namespace {
+		return nil, fmt.Errorf("job %s: %w", id, domain.ErrNotFound)
+	}
+	return job, nil
+}
+
+func (u *schedulerUsecase) Update(ctx context.Context, job *domain.ScheduledJob) error {
+	before, err := u.Get(ctx, job.NamespaceCode, job.ID)
+	if err != nil {
+		return err
+	}
+	next, err := u.validate(ctx, job)
+	if err != nil {
+		return err
+	}
+	// пауза меняется отдельными запросами
+	job.Paused = before.Paused
+	job.NextRunAt = next
+	if job.Paused {
+		job.NextRunAt = nil
+	}
+	return inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		if err := u.repo.Update(ctx, job); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.audit, "job.update", job.NamespaceCode, job.Action.App, "", diffFields(jobFields(before), jobFields(job)))
+	})
+}
+
+func (u *schedulerUsecase) Delete(ctx context.Context, namespace, id string) error {
+	job, err := u.Get(ctx, namespace, id)
+	if err != nil {
+		return err
+	}
+	return inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		if err := u.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.audit, "job.delete", namespace, job.Action.App, "", map[string]interface{}{"job": id})
+	})
+}
+
+func (u *schedulerUsecase) Pause(ctx context.Context, namespace, id string) (*domain.ScheduledJob, error) {
+	return u.setPaused(ctx, namespace, id, true)
+}
+
+func (u *schedulerUsecase) Resume(ctx context.Context, namespace, id string) (*domain.ScheduledJob, error) {
+	return u.setPaused(ctx, namespace, id, false)
+}
+
+// setPaused приостанавливает задание или возобновляет его со следующего по расписанию времени
+func (u *schedulerUsecase) setPaused(ctx context.Context, namespace, id string, paused bool) (*domain.ScheduledJob, error) {
+	job, err := u.Get(ctx, namespace, id)
+	if err != nil {
+		return nil, err
+	}
+	var next *time.Time
+	if !paused {
+		if next, err = nextRun(job, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+	action := "job.resume"
+	if paused {
+		action = "job.pause"
+	}
+	err = inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		if err := u.repo.SetPaused(ctx, id, paused, next); err != nil {
+			return err
+		}
+		return recordAudit(ctx, u.audit, action, namespace, job.Action.App, "", map[string]interface{}{"job": id})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u.Get(ctx, namespace, id)
+}
+
+func (u *schedulerUsecase) Trigger(ctx context.Context, namespace, id string) (*domain.JobRun, error) {
+	job, err := u.Get(ctx, namespace, id)
+	if err != nil {
+		return nil, err
+	}
+	claimed, err := u.repo.Claim(ctx, id, u.owner, u.lease)
+	if err != nil {
+		return nil, err
+	}
+	if !claimed {
+		return nil, fmt.Errorf("%w: job %s is already running", domain.ErrConflict, id)
+	}
+	run, err := u.startRun(ctx, job, domain.TriggerManual)
+	if err != nil {
+		if err := u.repo.Release(ctx, id, u.owner, time.Now(), nil); err != nil {
+			slog.ErrorContext(ctx, "scheduler: failed to release job", "job", id, "error", err)
+		}
+		return nil, err
+	}
+	started := *run
+
+	// запрос не ждёт выполнения: результат появится в истории запусков. Контекст
+	// сохраняет субъекта и идентификатор запроса, но не отменяется вместе с ним
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		u.finishRun(ctx, job, run)
+		if err := u.repo.Release(ctx, id, u.owner, run.StartedAt, nil); err != nil {
+			slog.ErrorContext(ctx, "scheduler: failed to release job", "job", id, "error", err)
+		}
+	}()
+	return &started, nil
+}
+
+func (u *schedulerUsecase) Runs(ctx context.Context, namespace, id string, limit int) ([]*domain.JobRun, error) {
+	if _, err := u.Get(ctx, namespace, id); err != nil {
+		return nil, err
+	}
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+	return u.repo.ListRuns(ctx, id, limit)
+}
+
+// RunDue выполняет наступившие задания по одному: каждое берётся в аренду прямо
+// перед выполнением, чтобы аренда не истекала, пока задание ждёт очереди. Пропущенные
+// за время простоя запуски не догоняются: следующий считается от текущего времени
+func (u *schedulerUsecase) RunDue(ctx context.Context) (int, error) {
+	var count int
+	for ; count < jobClaimLimit; count++ {
+		jobs, err := u.repo.ClaimDue(ctx, u.owner, u.lease, 1)
+		if err != nil {
+			return count, err
+		}
+		if len(jobs) == 0 {
+			break
+		}
+		job := jobs[0]
+		jobCtx := reqctx.WithMeta(ctx, reqctx.Meta{RequestID: newUUID(), Actor: "scheduler:" + job.ID})
+		run, err := u.startRun(jobCtx, job, domain.TriggerSchedule)
+		if err != nil {
+			slog.ErrorContext(ctx, "scheduler: failed to save job run", "job", job.ID, "error", err)
+		}
+		u.finishRun(jobCtx, job, run)
+
+		next, err := nextRun(job, time.Now())
+		if err != nil {
+			// расписание больше не даёт запусков — задание ставится на паузу
+			slog.ErrorContext(ctx, "scheduler: job failed", "job", job.ID, "error", err)
+			if err := u.repo.SetPaused(ctx, job.ID, true, nil); err != nil {
+				slog.ErrorContext(ctx, "scheduler: failed to pause job", "job", job.ID, "error", err)
+			}
+		}
+		if err := u.repo.Release(ctx, job.ID, u.owner, run.StartedAt, next); err != nil {
+			slog.ErrorContext(ctx, "scheduler: failed to release job", "job", job.ID, "error", err)
+		}
+	}
+	for ; count < jobClaimLimit; count++ {
+		req, err := u.repo.ClaimRecompute(ctx, u.owner, u.lease)
+		if err != nil {
+			return count, err
+		}
+		if req == nil {
+			break
+		}
+		u.runRecompute(ctx, req)
+	}
+	return count, nil
+}
+
+// runRecompute пересчитывает записи приложения, формулы которого изменились. Неудачный
+// пересчёт остаётся в аренде и повторяется, когда она истечёт; запрос удалённого
+// приложения снимается с очереди
+func (u *schedulerUsecase) runRecompute(ctx context.Context, req *domain.RecomputeRequest) {
+	ctx = reqctx.WithMeta(ctx, reqctx.Meta{RequestID: newUUID(), Actor: "scheduler:recompute"})
+	updated, err := u.recompute(ctx, req.NamespaceCode, req.AppCode)
+	if err != nil && !errors.Is(err, domain.ErrNotFound) {
+		slog.ErrorContext(ctx, "scheduler: failed to recompute records", "namespace", req.NamespaceCode, "app", req.AppCode, "error", err)
+		return
+	}
+	slog.InfoContext(ctx, "scheduler: records recomputed", "namespace", req.NamespaceCode, "app", req.AppCode, "updated", updated)
+	if err := u.repo.FinishRecompute(ctx, req, u.owner); err != nil {
+		slog.ErrorContext(ctx, "scheduler: failed to finish recompute", "namespace", req.NamespaceCode, "app", req.AppCode, "error", err)
+	}
+}
+
+// startRun сохраняет в истории начатый запуск задания; при ошибке запуск
+// всё равно возвращается, чтобы задание могло выполниться
+func (u *schedulerUsecase) startRun(ctx context.Context, job *domain.ScheduledJob, trigger string) (*domain.JobRun, error) {
+	run := &domain.JobRun{
+		JobID:     job.ID,
+		Trigger:   trigger,
+		Actor:     reqctx.FromContext(ctx).Actor,
+		Status:    JobRunning,
+		StartedAt: time.Now(),
+	}
+	return run, u.repo.CreateRun(ctx, run)
+}
+
+// finishRun выполняет действие задания, продлевая его аренду, и сохраняет результат в истории
+func (u *schedulerUsecase) finishRun(ctx context.Context, job *domain.ScheduledJob, run *domain.JobRun) {
+	stop := u.keepLease(ctx, job.ID)
+	processed, err := u.execute(ctx, job)
+	stop()
+	finished := time.Now()
+	run.Processed = processed
+	run.FinishedAt = &finished
+	run.Status = domain.RunSuccess
+	if err != nil {
+		run.Status = domain.RunFailed
+		run.Error = err.Error()
+	}
+	if err := u.repo.FinishRun(ctx, run); err != nil {
+		slog.ErrorContext(ctx, "scheduler: failed to save job run", "job", job.ID, "error", err)
+	}
+	recordAudit(ctx, u.audit, "job.run", job.NamespaceCode, job.Action.App, "", map[string]interface{}{
+		"job": job.ID, "trigger": run.Trigger, "status": run.Status, "processed": processed,
+	})
+}
+
+// keepLease продлевает аренду задания каждую треть её срока, пока не вызвана
+// возвращённая функция: долгое задание не должен взять и запустить второй экземпляр
+func (u *schedulerUsecase) keepLease(ctx context.Context, id string) (stop func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(u.lease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				extended, err := u.repo.Extend(ctx, id, u.owner, u.lease)
+				if err != nil {
+					slog.ErrorContext(ctx, "scheduler: failed to extend job lease", "job", id, "error", err)
+				} else if !extended {
+					slog.WarnContext(ctx, "scheduler: job lease was lost", "job", id)
+				}
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+func (u *schedulerUsecase) execute(ctx context.Context, job *domain.ScheduledJob) (int64, error) {
+	action := job.Action
+	switch action.Type {
+	case domain.JobRecompute:
+		return u.recompute(ctx, job.NamespaceCode, action.App)
+	case domain.JobPurge:
+		return u.purge(ctx, job.NamespaceCode, action)
+	case domain.JobExport:
+		return u.export(ctx, job, action)
+	case domain.JobRunRule:
+		return u.automation.RunRule(ctx, job.NamespaceCode, action.App, action.RuleID)
+	}
+	return 0, fmt.Errorf("unknown job action %q", action.Type)
+}
+
+// recompute пересчитывает вычисляемые поля записей, например формулы с now().
+// Это служебная операция, она не требует согласования
+func (u *schedulerUsecase) recompute(ctx context.Context, namespace, appName string) (int64, error) {
+	app, err := u.app(ctx, namespace, appName)
+	if err != nil {
+		return 0, err
+	}
+	return recomputeRecords(ctx, u.tx, u.records, app)
+}
+
+// purge перемещает в корзину записи, у которых дата в поле старше срока
+func (u *schedulerUsecase) purge(ctx context.Context, namespace string, action domain.JobAction) (int64, error) {
+	age, err := time.ParseDuration(action.OlderThan)
+	if err != nil {
+		return 0, err
+	}
+	cutoff := time.Now().Add(-age).UTC().Format(time.RFC3339)
+	query := domain.DataQuery{
+		Filters: []domain.DataFilter{{Field: action.Field, Op: domain.FilterLt, Value: cutoff}},
+		Limit:   jobBatchSize,
+	}
+
+	var deleted int64
+	for {
+		// удалённые записи пропадают из выборки, поэтому всегда читается первая страница
+		records, err := u.data.GetAll(ctx, namespace, action.App, query)
+		if err != nil {
+			return deleted, err
+		}
+		for _, record := range records {
+			if err := u.data.Delete(ctx, namespace, action.App, record.UID); err != nil {
+				return deleted, err
+			}
+			deleted++
+		}
+		if len(records) < jobBatchSize {
+			return deleted, nil
+		}
+	}
+}
+
+// export отправляет записи, подходящие под фильтры, одним POST-запросом
+func (u *schedulerUsecase) export(ctx context.Context, job *domain.ScheduledJob, action domain.JobAction) (int64, error) {
+	var records []*domain.AppData
+	truncated := false
+	for offset := 0; ; offset += jobBatchSize {
+		filters := append([]domain.DataFilter(nil), action.Filters...)
+		page, err := u.data.GetAll(ctx, job.NamespaceCode, action.App, domain.DataQuery{Filters: filters, Limit: jobBatchSize, Offset: offset})
+		if err != nil {
+			return 0, err
+		}
+		records = append(records, page...)
+		if len(records) >= maxExportRecords {
+			records, truncated = records[:maxExportRecords], true
+			break
+		}
+		if len(page) < jobBatchSize {
+			break
+		}
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"job":       job.ID,
+		"namespace": job.NamespaceCode,
+		"app":       action.App,
+		"records":   records,
+		"truncated": truncated,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if err := postWebhook(ctx, u.client, action.URL, action.Headers, body); err != nil {
+		return 0, err
+	}
+	return int64(len(records)), nil
+}
+
+func (u *schedulerUsecase) app(ctx context.Context, namespace, appName string) (*domain.App, error) {
+	app, err := u.apps.GetByCode(ctx, appName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		return nil, fmt.Errorf("app %s: %w", appName, domain.ErrNotFound)
+	}
+	return app, nil
+}
+
+// validate проверяет задание и возвращает время его первого запуска
+func (u *schedulerUsecase) validate(ctx context.Context, job *domain.ScheduledJob) (*time.Time, error) {
+	if strings.TrimSpace(job.Name) == "" {
+		return nil, fmt.Errorf("%w: job name is required", domain.ErrValidation)
+	}
+	next, err := nextRun(job, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	action := job.Action
+	app, err := u.apps.GetByCode(ctx, action.App, job.NamespaceCode)
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		return nil, fmt.Errorf("%w: app %s not found in namespace %s", domain.ErrValidation, action.App, job.NamespaceCode)
+	}
+
+	switch action.Type {
+	case domain.JobRecompute:
+	case domain.JobPurge:
+		field := app.FieldByCode(action.Field)
+		if field == nil || field.Type != domain.FieldTypeDatetime {
+			return nil, fmt.Errorf("%w: purge needs a datetime field of the app", domain.ErrValidation)
+		}
+		if age, err := time.ParseDuration(action.OlderThan); err != nil || age <= 0 {
+			return nil, fmt.Errorf("%w: invalid olderThan %q", domain.ErrValidation, action.OlderThan)
+		}
+	case domain.JobExport:
+		if err := checkWebhookURL(action.URL); err != nil {
+			return nil, fmt.Errorf("%w: %v", domain.ErrValidation, err)
+		}
+	case domain.JobRunRule:
+		if _, err := u.automation.GetRule(ctx, job.NamespaceCode, action.App, action.RuleID); err != nil {
+			return nil, fmt.Errorf("%w: rule %s not found in app %s", domain.ErrValidation, action.RuleID, action.App)
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown job action %q", domain.ErrValidation, action.Type)
+	}
+	return next, nil
+}
+
+// nextRun вычисляет следующий запуск задания после after в его часовом поясе
+func nextRun(job *domain.ScheduledJob, after time.Time) (*time.Time, error) {
+	schedule, err := cron.Parse(job.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("%w: schedule: %v", domain.ErrValidation, err)
+	}
+	loc := time.UTC
+	if job.Timezone != "" {
+		if loc, err = time.LoadLocation(job.Timezone); err != nil {
+			return nil, fmt.Errorf("%w: unknown timezone %q", domain.ErrValidation, job.Timezone)
+		}
+	}
+	next := schedule.Next(after.In(loc))
+	if next.IsZero() {
+		return nil, fmt.Errorf("%w: schedule %q never fires", domain.ErrValidation, job.Schedule)
+	}
+	return &next, nil
+}
+
+func jobFields(job *domain.ScheduledJob) map[string]interface{} {
+	if job == nil {
+		return nil
+	}
+	return map[string]interface{}{
+		"name":     job.Name,
+		"schedule": job.Schedule,
+		"timezone": job.Timezone,
+		"action":   job.Action,
+	}
+}
@@ -0,0 +1,119 @@
+package usecase
+
+import (
+	"app/backendv1/internal/tracing"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// errBlockedAddress — адрес вебхука ведёт во внутреннюю сеть сервиса
+var errBlockedAddress = errors.New("webhook address is not allowed")
+
+// blockedPrefixes — сети, не покрытые проверками netip.Addr: 0.0.0.0/8 и общий адрес
+// провайдера 100.64.0.0/10, через который бывают доступны внутренние сервисы облака
+var blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+}
+
+// blockedAddr сообщает, что адрес внутренний: loopback, частные сети, link-local
+// (в том числе метаданные облака 169.254.169.254), multicast и неуказанный адрес
+func blockedAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if addr.IsLoopback() || addr.IsPrivate() || addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() || addr.IsUnspecified() {
+		return true
+	}
+	for _, prefix := range blockedPrefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// newWebhookClient создаёт клиент вебхуков, который не подключается к внутренним адресам.
+// Адрес проверяется при подключении, уже после разрешения имени, поэтому имя, указывающее
+// во внутреннюю сеть, тоже не пройдёт. Перенаправления не выполняются: ответ 3xx — ошибка
+func newWebhookClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: webhookTimeout,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			addrPort, err := netip.ParseAddrPort(address)
+			if err != nil {
+				return fmt.Errorf("%w: %s", errBlockedAddress, address)
+			}
+			if blockedAddr(addrPort.Addr()) {
+				return fmt.Errorf("%w: %s", errBlockedAddress, addrPort.Addr())
+			}
+			return nil
+		},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = dialer.DialContext
+	// прокси из окружения подключался бы к адресату сам, в обход проверки
+	transport.Proxy = nil
+	return &http.Client{
+		Timeout:   webhookTimeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// checkWebhookURL проверяет адрес вебхука при сохранении: схему, хост и то, что адрес,
+// заданный IP, не внутренний. Имена проверяет клиент при каждом подключении
+func checkWebhookURL(raw string) error {
+	target, err := url.Parse(raw)
+	if err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" {
+		return fmt.Errorf("invalid url %q", raw)
+	}
+	host := target.Hostname()
+	if addr, err := netip.ParseAddr(host); err == nil && blockedAddr(addr) {
+		return fmt.Errorf("url %q points to an internal address", raw)
+	}
+	if strings.EqualFold(host, "localhost") || strings.HasSuffix(strings.ToLower(host), ".localhost") {
+		return fmt.Errorf("url %q points to an internal address", raw)
+	}
+	return nil
+}
+
+// postWebhook отправляет JSON на адрес вебхука. Заголовок traceparent позволяет
+// получателю продолжить трассу запроса, вызвавшего вебхук
+func postWebhook(ctx context.Context, client *http.Client, url string, headers map[string]string, body []byte) (err error) {
+	// строка запроса может содержать токены, в спан она не попадает
+	ctx, span := tracing.StartClient(ctx, "webhook POST", attribute.String("url.full", strings.SplitN(url, "?", 2)[0]))
+	defer func() { tracing.End(span, err) }()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	tracing.InjectHTTP(ctx, req.Header)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package usecase
+
+import (
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/formula"
+	"app/backendv1/internal/reqctx"
+	"context"
+	"fmt"
+	"time"
+)
+
+// WorkflowRepo — смена состояния записей и история переходов
+type WorkflowRepo interface {
+	// Transition объединяет данные записи с partial, если поле состояния всё ещё равно from,
+	// и сохраняет переход в историю в той же транзакции
+	Transition(ctx context.Context, namespace, appName, uid, field, from string, partial map[string]interface{}, record *domain.TransitionRecord) error
+	// ReplaceInState полностью заменяет данные записи, если поле состояния всё ещё равно state
+	ReplaceInState(ctx context.Context, namespace, appName string, data *domain.AppData, field, state string) error
+	History(ctx context.Context, namespace, appName, uid string) ([]*domain.TransitionRecord, error)
+}
+
+type WorkflowUsecase interface {
+	Transition(ctx context.Context, namespace, appName, uid string, req domain.TransitionRequest) (*domain.AppData, error)
+	History(ctx context.Context, namespace, appName, uid string) ([]*domain.TransitionRecord, error)
+}
+
+type workflowUsecase struct {
+	repo    WorkflowRepo
+	data    AppDataUsecase
+	apps    AppUsecase
+	events  EventPublisher
+	tx      Transactor
+	audit   AuditUsecase
+	locales localizer
+}
+
+// NewWorkflowUsecase принимает репозиторий данных напрямую: переход объединяет правки
+// с хранимой записью, а не с версией, локализованной для клиента
+func NewWorkflowUsecase(repo WorkflowRepo, data AppDataUsecase, apps AppUsecase, namespaces NamespaceUsecase, events EventPublisher, tx Transactor, audit AuditUsecase) WorkflowUsecase {
+	return &workflowUsecase{repo: repo, data: data, apps: apps, events: events, tx: tx, audit: audit, locales: localizer{namespaces: namespaces}}
+}
+
+// Transition переводит запись в состояние req.To, если переход объявлен в схеме,
+// его условие выполняется и в новом состоянии заполнены обязательные поля
+func (u *workflowUsecase) Transition(ctx context.Context, namespace, appName, uid string, req domain.TransitionRequest) (*domain.AppData, error) {
+	app, err := u.apps.GetByCode(ctx, appName, namespace)
+	if err != nil {
+		return nil, err
+	}
+	if app == nil {
+		return nil, fmt.Errorf("app %s: %w", appName, domain.ErrNotFound)
+	}
+	wf := app.Workflow
+	if wf == nil {
+		return nil, fmt.Errorf("%w: app %s has no workflow", domain.ErrValidation, appName)
+	}
+	if wf.State(req.To) == nil {
+		return nil, fmt.Errorf("%w: unknown state %q", domain.ErrValidation, req.To)
+	}
+
+	record, err := u.data.GetDataByUID(ctx, namespace, appName, uid)
+	if err != nil {
+		return nil, err
+	}
+	stored, from := currentState(wf, record.Data)
+	allowed := wf.Allowed(from, req.To)
+	if len(allowed) == 0 {
+		return nil, fmt.Errorf("%w: transition from %s to %s is not allowed", domain.ErrValidation, from, req.To)
+	}
+
+	// правки приложений с согласованием проходят через запросы на изменение
+	if needsApproval(ctx, app) && len(req.Data) > 0 {
+		return nil, fmt.Errorf("%w: app %s requires approval of changes, transition cannot change fields", domain.ErrValidation, appName)
+	}
+	for field := range req.Data {
+		if err := checkWritable(app, field); err != nil {
+			return nil, err
+		}
+	}
+	if err := u.locales.input(ctx, app, req.Data, record.Data); err != nil {
+		return nil, err
+	}
+	merged := make(map[string]interface{}, len(record.Data)+len(req.Data)+1)
+	for field, value := range record.Data {
+		merged[field] = value
+	}
+	for field, value := range req.Data {
+		merged[field] = value
+	}
+	merged[wf.Field] = req.To
+	if err := applyFormulas(app, merged); err != nil {
+		return nil, err
+	}
+
+	if err := checkGuards(app, allowed, merged); err != nil {
+		return nil, err
+	}
+	if err := checkRequired(wf, req.To, merged); err != nil {
+		return nil, err
+	}
+
+	partial := make(map[string]interface{}, len(req.Data)+1)
+	for field, value := range req.Data {
+		partial[field] = value
+	}
+	for _, field := range computedFields(app) {
+		partial[field] = merged[field]
+	}
+	partial[wf.Field] = req.To
+
+	meta := reqctx.FromContext(ctx)
+	transition := &domain.TransitionRecord{
+		NamespaceCode: namespace,
+		AppCode:       appName,
+		RecordUID:     uid,
+		From:          from,
+		To:            req.To,
+		Actor:         meta.Actor,
+		Comment:       req.Comment,
+		RequestID:     meta.RequestID,
+	}
+	touched := make(map[string]interface{}, len(partial))
+	for field := range partial {
+		if value, ok := record.Data[field]; ok {
+			touched[field] = value
+		}
+	}
+	err = inTx(ctx, u.tx, u.audit, func(ctx context.Context) error {
+		if err := u.repo.Transition(ctx, namespace, appName, uid, wf.Field, stored, partial, transition); err != nil {
+			return duplicateFields(app, err)
+		}
+		return recordAudit(ctx, u.audit, "app_data.transition", namespace, appName, uid, diffFields(touched, partial))
+	})
+	if err != nil {
+		return nil, err
+	}
+	publishEvent(ctx, u.events, domain.EventUpdated, namespace, appName, uid, merged)
+
+	record.Data = merged
+	if err := u.locales.resolve(ctx, app, record); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+func (u *workflowUsecase) History(ctx context.Context, namespace, appName, uid string) ([]*domain.TransitionRecord, error) {
+	if _, err := u.data.GetDataByUID(ctx, namespace, appName, uid); err != nil {
+		return nil, err
+	}
+	return u.repo.History(ctx, namespace, appName, uid)
+}
+
+// currentState возвращает значение поля состояния как оно хранится в записи и
+// действующее состояние: записи без значения считаются находящимися в начальном
+func currentState(wf *domain.Workflow, values map[string]interface{}) (stored, state string) {
+	stored, _ = values[wf.Field].(string)
+	if stored == "" {
+		return stored, wf.Initial
+	}
+	return stored, stored
+}
+
+// checkWritable запрещает менять поля, значения которых задаются не клиентом
+func checkWritable(app *domain.App, code string) error {
+	field := app.FieldByCode(code)
+	switch {
+	case app.Workflow != nil && code == app.Workflow.Field:
+		return fmt.Errorf("%w: field %s can only be changed by a transition", domain.ErrValidation, code)
+	case field == nil:
+		return nil
+	case field.Type == domain.FieldTypeFile:
+		return fmt.Errorf("%w: field %s can only be changed by uploading a file", domain.ErrValidation, code)
+	case field.Formula != "":
+		return fmt.Errorf("%w: field %s is computed", domain.ErrValidation, code)
+	}
+	return nil
+}
+
+// checkGuards проверяет, что хотя бы один из подходящих переходов разрешён своим условием
+func checkGuards(app *domain.App, transitions []domain.WorkflowTransition, values map[string]interface{}) error {
+	types := fieldFormulaTypes(app)
+	now := time.Now()
+	for _, t := range transitions {
+		if t.Guard == "" {
+			return nil
+		}
+		guard, err := formula.CompileCondition(t.Guard, types)
+		if err != nil {
+			return fmt.Errorf("%w: guard of transition to %s: %v", domain.ErrValidation, t.To, err)
+		}
+		if guard.Eval(values, now) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: conditions of transition to %s are not met", domain.ErrValidation, transitions[0].To)
+}
+
+// checkRequired проверяет заполненность полей, обязательных в состоянии state
+func checkRequired(wf *domain.Workflow, state string, values map[string]interface{}) error {
+	s := wf.State(state)
+	if s == nil {
+		return nil
+	}
+	for _, field := range s.RequiredFields {
+		if value, ok := values[field]; !ok || value == nil || value == "" {
+			return fmt.Errorf("%w: field %s is required in state %s", domain.ErrValidation, field, state)
+		}
+	}
+	return nil
+}
+
+// validateWorkflow проверяет описание машины состояний приложения
+func validateWorkflow(app *domain.App) error {
+	wf := app.Workflow
+	if wf == nil {
+		return nil
+	}
+	field := app.FieldByCode(wf.Field)
+	if field == nil || field.Type != domain.FieldTypeString || field.Formula != "" {
+		return fmt.Errorf("%w: workflow field must be a plain string field of the app", domain.ErrValidation)
+	}
+	if len(wf.States) == 0 {
+		return fmt.Errorf("%w: workflow has no states", domain.ErrValidation)
+	}
+
+	seen := make(map[string]bool, len(wf.States))
+	for _, state := range wf.States {
+		if state.Code == "" {
+			return fmt.Errorf("%w: workflow state code is empty", domain.ErrValidation)
+		}
+		if seen[state.Code] {
+			return fmt.Errorf("%w: duplicate workflow state %s", domain.ErrValidation, state.Code)
+		}
+		seen[state.Code] = true
+		for _, required := range state.RequiredFields {
+			if app.FieldByCode(required) == nil {
+				return fmt.Errorf("%w: state %s requires unknown field %s", domain.ErrValidation, state.Code, required)
+			}
+		}
+	}
+	if !seen[wf.Initial] {
+		return fmt.Errorf("%w: initial state %q is not declared", domain.ErrValidation, wf.Initial)
+	}
+
+	types := fieldFormulaTypes(app)
+	for _, t := range wf.Transitions {
+		if !seen[t.To] {
+			return fmt.Errorf("%w: transition to unknown state %q", domain.ErrValidation, t.To)
+		}
+		for _, from := range t.From {
+			if !seen[from] {
+				return fmt.Errorf("%w: transition from unknown state %q", domain.ErrValidation, from)
+			}
+		}
+		if t.Guard != "" {
+			if _, err := formula.CompileCondition(t.Guard, types); err != nil {
+				return fmt.Errorf("%w: guard of transition to %s: %v", domain.ErrValidation, t.To, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package worker
+
+import (
+	"app/backendv1/internal/usecase"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// ApprovalExpirer периодически закрывает запросы на изменение, не согласованные в срок
+type ApprovalExpirer struct {
+	approvals usecase.ApprovalUsecase
+	interval  time.Duration
+}
+
+func NewApprovalExpirer(approvals usecase.ApprovalUsecase, interval time.Duration) *ApprovalExpirer {
+	return &ApprovalExpirer{approvals: approvals, interval: interval}
+}
+
+// Run закрывает просроченные запросы сразу и затем каждые interval, пока не отменён ctx
+func (e *ApprovalExpirer) Run(ctx context.Context) {
+	runEvery(ctx, e.interval, e.ExpireOnce)
+}
+
+func (e *ApprovalExpirer) ExpireOnce(ctx context.Context) {
+	n, err := e.approvals.ExpireStale(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "approvals: expiry failed", "error", err)
+	}
+	if n > 0 {
+		slog.InfoContext(ctx, "approvals: change requests expired", "count", n)
+	}
+}
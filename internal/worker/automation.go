@@ -0,0 +1,75 @@
+package worker
+
+import (
+	"app/backendv1/internal/domain"
+	"app/backendv1/internal/metrics"
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// EventHandler обрабатывает события изменения записей
+type EventHandler interface {
+	HandleEvent(ctx context.Context, event domain.DataEvent)
+}
+
+// AutomationQueue — очередь событий изменения записей в памяти процесса.
+// Запрос, изменивший запись, не ждёт выполнения правил автоматизации
+type AutomationQueue struct {
+	events  chan domain.DataEvent
+	workers int
+}
+
+func NewAutomationQueue(size, workers int) *AutomationQueue {
+	if workers < 1 {
+		workers = 1
+	}
+	return &AutomationQueue{events: make(chan domain.DataEvent, size), workers: workers}
+}
+
+// Publish ставит событие в очередь; при переполнении событие отбрасывается,
+// чтобы запись данных не блокировалась медленными правилами. Каждое отброшенное
+// событие попадает в журнал и метрику automation_events_dropped_total
+func (q *AutomationQueue) Publish(ctx context.Context, event domain.DataEvent) {
+	select {
+	case q.events <- event:
+	default:
+		metrics.AutomationEventDropped(event.NamespaceCode, event.Type)
+		slog.WarnContext(ctx, "automation: queue is full, event dropped", "event", event.Type, "namespace", event.NamespaceCode, "app", event.AppCode, "uid", event.RecordUID)
+	}
+}
+
+// Run обрабатывает события в нескольких горутинах, пока не отменён ctx. При остановке
+// группы события, уже стоящие в очереди, обрабатываются до конца
+func (q *AutomationQueue) Run(ctx context.Context, handler EventHandler) {
+	var wg sync.WaitGroup
+	for i := 0; i < q.workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-stopping(ctx):
+					q.drain(ctx, handler)
+					return
+				case event := <-q.events:
+					handler.HandleEvent(ctx, event)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func (q *AutomationQueue) drain(ctx context.Context, handler EventHandler) {
+	for ctx.Err() == nil {
+		select {
+		case event := <-q.events:
+			handler.HandleEvent(ctx, event)
+		default:
+			return
+		}
+	}
+}
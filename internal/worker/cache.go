@@ -0,0 +1,98 @@
+package worker
+
+import (
+	"app/backendv1/internal/cache"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// CacheInvalidator слушает уведомления триггеров базы об изменениях и сбрасывает
+// устаревшие ключи кэша. Так кэш узнаёт об изменениях, сделанных другими экземплярами
+// и запросами в обход кэширующих usecase
+type CacheInvalidator struct {
+	dsn   string
+	cache cache.Cache
+}
+
+func NewCacheInvalidator(dsn string, c cache.Cache) *CacheInvalidator {
+	return &CacheInvalidator{dsn: dsn, cache: c}
+}
+
+// Run слушает канал cache.Channel, пока не отменён ctx или группа не остановлена
+func (i *CacheInvalidator) Run(ctx context.Context) {
+	listener := pq.NewListener(i.dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			slog.WarnContext(ctx, "cache invalidator: connection problem", "error", err)
+		}
+	})
+	defer listener.Close()
+	// Listen ждёт соединения с базой; остановка закрывает listener и прерывает ожидание
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-stopping(ctx):
+		}
+		listener.Close()
+	}()
+	if !i.listen(ctx, listener) {
+		return
+	}
+
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-stopping(ctx):
+			return
+		case notification := <-listener.Notify:
+			if notification == nil {
+				// соединение восстановлено, уведомления за время разрыва потеряны
+				slog.InfoContext(ctx, "cache invalidator: reconnected, flushing cache")
+				if err := i.cache.DeletePrefix(ctx, ""); err != nil {
+					slog.ErrorContext(ctx, "cache invalidator: flush failed", "error", err)
+				}
+				continue
+			}
+			if err := cache.Invalidate(ctx, i.cache, notification.Extra); err != nil {
+				slog.ErrorContext(ctx, "cache invalidator: invalidation failed", "key", notification.Extra, "error", err)
+			}
+		case <-ping.C:
+			// проверка соединения: разрыв без пинга обнаружился бы только при следующем уведомлении
+			go listener.Ping()
+		}
+	}
+}
+
+// listen подписывается на канал cache.Channel, повторяя неудачные попытки с паузой до
+// минуты. Без подписки изменения других экземпляров не доходят до кэша, поэтому после
+// неудачных попыток кэш сбрасывается. false — если группа остановлена раньше
+func (i *CacheInvalidator) listen(ctx context.Context, listener *pq.Listener) bool {
+	delay := time.Second
+	for attempt := 0; ; attempt++ {
+		err := listener.Listen(cache.Channel)
+		if err == nil || errors.Is(err, pq.ErrChannelAlreadyOpen) {
+			if attempt > 0 {
+				slog.InfoContext(ctx, "cache invalidator: listening, flushing cache")
+				if err := i.cache.DeletePrefix(ctx, ""); err != nil {
+					slog.ErrorContext(ctx, "cache invalidator: flush failed", "error", err)
+				}
+			}
+			return true
+		}
+		slog.ErrorContext(ctx, "cache invalidator: failed to listen", "error", err, "retry_in", delay)
+		select {
+		case <-ctx.Done():
+			return false
+		case <-stopping(ctx):
+			return false
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, time.Minute)
+	}
+}
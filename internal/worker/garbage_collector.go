@@ -0,0 +1,40 @@
+package worker
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Collector удаляет файлы, на которые больше не ссылаются данные сервиса
+type Collector interface {
+	CollectGarbage(ctx context.Context) (int64, error)
+}
+
+// GarbageCollector периодически запускает сборщики мусора: вложения
+// окончательно удалённых записей, иконки удалённых приложений и т.п.
+type GarbageCollector struct {
+	collectors map[string]Collector
+	interval   time.Duration
+}
+
+func NewGarbageCollector(interval time.Duration, collectors map[string]Collector) *GarbageCollector {
+	return &GarbageCollector{collectors: collectors, interval: interval}
+}
+
+// Run запускает сборку мусора сразу и затем каждые interval, пока не отменён ctx
+func (g *GarbageCollector) Run(ctx context.Context) {
+	runEvery(ctx, g.interval, g.CollectOnce)
+}
+
+func (g *GarbageCollector) CollectOnce(ctx context.Context) {
+	for name, collector := range g.collectors {
+		n, err := collector.CollectGarbage(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "gc failed", "collector", name, "error", err)
+		}
+		if n > 0 {
+			slog.InfoContext(ctx, "gc: files removed", "collector", name, "count", n)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package worker
+
+import (
+	"app/backendv1/internal/usecase"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// IdempotencyPurger удаляет ключи идемпотентности, срок хранения которых истёк
+type IdempotencyPurger struct {
+	idempotency usecase.IdempotencyUsecase
+	interval    time.Duration
+}
+
+func NewIdempotencyPurger(idempotency usecase.IdempotencyUsecase, interval time.Duration) *IdempotencyPurger {
+	return &IdempotencyPurger{idempotency: idempotency, interval: interval}
+}
+
+// Run удаляет ключи сразу и затем каждые interval, пока не отменён ctx
+func (p *IdempotencyPurger) Run(ctx context.Context) {
+	runEvery(ctx, p.interval, p.RunOnce)
+}
+
+func (p *IdempotencyPurger) RunOnce(ctx context.Context) {
+	if err := p.idempotency.Purge(ctx); err != nil {
+		slog.ErrorContext(ctx, "idempotency keys purge failed", "error", err)
+	}
+}
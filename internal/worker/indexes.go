@@ -0,0 +1,30 @@
+package worker
+
+import (
+	"app/backendv1/internal/usecase"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// IndexReconciler достраивает индексы приложений, построение которых после изменения
+// схемы не удалось, например из-за повтора, записанного во время построения
+type IndexReconciler struct {
+	indexes  usecase.IndexUsecase
+	interval time.Duration
+}
+
+func NewIndexReconciler(indexes usecase.IndexUsecase, interval time.Duration) *IndexReconciler {
+	return &IndexReconciler{indexes: indexes, interval: interval}
+}
+
+// Run проверяет приложения сразу и затем каждые interval, пока не отменён ctx
+func (r *IndexReconciler) Run(ctx context.Context) {
+	runEvery(ctx, r.interval, r.RunOnce)
+}
+
+func (r *IndexReconciler) RunOnce(ctx context.Context) {
+	if err := r.indexes.Reconcile(ctx); err != nil {
+		slog.ErrorContext(ctx, "index reconciliation failed", "error", err)
+	}
+}
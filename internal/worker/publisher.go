@@ -0,0 +1,29 @@
+package worker
+
+import (
+	"app/backendv1/internal/usecase"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Publisher выполняет запланированные публикации и снятия с публикации записей
+type Publisher struct {
+	publishing usecase.PublishingUsecase
+	interval   time.Duration
+}
+
+func NewPublisher(publishing usecase.PublishingUsecase, interval time.Duration) *Publisher {
+	return &Publisher{publishing: publishing, interval: interval}
+}
+
+// Run проверяет расписание сразу и затем каждые interval, пока не отменён ctx
+func (p *Publisher) Run(ctx context.Context) {
+	runEvery(ctx, p.interval, p.RunOnce)
+}
+
+func (p *Publisher) RunOnce(ctx context.Context) {
+	if err := p.publishing.RunScheduled(ctx); err != nil {
+		slog.ErrorContext(ctx, "publisher failed", "error", err)
+	}
+}
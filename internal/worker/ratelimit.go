@@ -0,0 +1,30 @@
+package worker
+
+import (
+	"app/backendv1/internal/usecase"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// RateLimitPurger удаляет наполнившиеся корзины ограничения частоты запросов,
+// чтобы их число не росло с каждым новым субъектом
+type RateLimitPurger struct {
+	limits   usecase.LimitsUsecase
+	interval time.Duration
+}
+
+func NewRateLimitPurger(limits usecase.LimitsUsecase, interval time.Duration) *RateLimitPurger {
+	return &RateLimitPurger{limits: limits, interval: interval}
+}
+
+// Run удаляет корзины сразу и затем каждые interval, пока не отменён ctx
+func (p *RateLimitPurger) Run(ctx context.Context) {
+	runEvery(ctx, p.interval, p.RunOnce)
+}
+
+func (p *RateLimitPurger) RunOnce(ctx context.Context) {
+	if err := p.limits.PurgeBuckets(ctx); err != nil {
+		slog.ErrorContext(ctx, "rate limit purge failed", "error", err)
+	}
+}
@@ -0,0 +1,29 @@
+package worker
+
+import (
+	"app/backendv1/internal/usecase"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// RetentionEnforcer периодически удаляет или архивирует записи с истёкшим сроком хранения
+type RetentionEnforcer struct {
+	retention usecase.RetentionUsecase
+	interval  time.Duration
+}
+
+func NewRetentionEnforcer(retention usecase.RetentionUsecase, interval time.Duration) *RetentionEnforcer {
+	return &RetentionEnforcer{retention: retention, interval: interval}
+}
+
+// Run применяет сроки хранения сразу и затем каждые interval, пока не отменён ctx
+func (e *RetentionEnforcer) Run(ctx context.Context) {
+	runEvery(ctx, e.interval, e.EnforceOnce)
+}
+
+func (e *RetentionEnforcer) EnforceOnce(ctx context.Context) {
+	if err := e.retention.Enforce(ctx); err != nil {
+		slog.ErrorContext(ctx, "retention failed", "error", err)
+	}
+}
@@ -0,0 +1,39 @@
+package worker
+
+import (
+	"app/backendv1/internal/usecase"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Scheduler периодически выполняет наступившие запланированные задания.
+// Несколько экземпляров сервиса делят задания через аренду в базе
+type Scheduler struct {
+	jobs     usecase.SchedulerUsecase
+	interval time.Duration
+}
+
+func NewScheduler(jobs usecase.SchedulerUsecase, interval time.Duration) *Scheduler {
+	return &Scheduler{jobs: jobs, interval: interval}
+}
+
+// Run проверяет задания сразу и затем каждые interval, пока не отменён ctx
+func (s *Scheduler) Run(ctx context.Context) {
+	runEvery(ctx, s.interval, s.RunOnce)
+}
+
+// RunOnce выполняет наступившие задания, пока они не закончатся
+func (s *Scheduler) RunOnce(ctx context.Context) {
+	for ctx.Err() == nil {
+		n, err := s.jobs.RunDue(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "scheduler failed", "error", err)
+			return
+		}
+		if n == 0 {
+			return
+		}
+		slog.InfoContext(ctx, "scheduler: jobs ran", "count", n)
+	}
+}
@@ -0,0 +1,66 @@
+package worker
+
+import (
+	"app/backendv1/internal/usecase"
+	"context"
+	"log/slog"
+	"time"
+)
+
+// TrashPurger периодически окончательно удаляет объекты,
+// пролежавшие в корзине дольше срока хранения
+type TrashPurger struct {
+	namespaces usecase.NamespaceUsecase
+	apps       usecase.AppUsecase
+	appData    usecase.AppDataUsecase
+	retention  time.Duration
+	interval   time.Duration
+}
+
+func NewTrashPurger(namespaces usecase.NamespaceUsecase, apps usecase.AppUsecase, appData usecase.AppDataUsecase, retention, interval time.Duration) *TrashPurger {
+	return &TrashPurger{
+		namespaces: namespaces,
+		apps:       apps,
+		appData:    appData,
+		retention:  retention,
+		interval:   interval,
+	}
+}
+
+// Run запускает очистку сразу и затем каждые interval, пока не отменён ctx
+func (p *TrashPurger) Run(ctx context.Context) {
+	runEvery(ctx, p.interval, p.PurgeOnce)
+}
+
+// PurgeOnce выполняет один проход очистки: записи, затем приложения, затем namespace
+func (p *TrashPurger) PurgeOnce(ctx context.Context) {
+	before := time.Now().Add(-p.retention)
+
+	apps, err := p.apps.GetAll(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "trash purge: failed to list apps", "error", err)
+		return
+	}
+	for _, app := range apps {
+		n, err := p.appData.PurgeDeleted(ctx, app.NamespaceCode, app.Code, before)
+		if err != nil {
+			slog.ErrorContext(ctx, "trash purge failed", "namespace", app.NamespaceCode, "app", app.Code, "error", err)
+			continue
+		}
+		if n > 0 {
+			slog.InfoContext(ctx, "trash purge: records removed", "namespace", app.NamespaceCode, "app", app.Code, "count", n)
+		}
+	}
+
+	if n, err := p.apps.PurgeDeleted(ctx, before); err != nil {
+		slog.ErrorContext(ctx, "trash purge: failed to purge apps", "error", err)
+	} else if n > 0 {
+		slog.InfoContext(ctx, "trash purge: apps removed", "count", n)
+	}
+
+	if n, err := p.namespaces.PurgeDeleted(ctx, before); err != nil {
+		slog.ErrorContext(ctx, "trash purge: failed to purge namespaces", "error", err)
+	} else if n > 0 {
+		slog.InfoContext(ctx, "trash purge: namespaces removed", "count", n)
+	}
+}
@@ -0,0 +1,79 @@
+// Package worker содержит фоновые процессы сервиса
+package worker
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Group запускает фоновые процессы и останавливает их при завершении сервиса
+type Group struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	stop   chan struct{}
+	wg     sync.WaitGroup
+}
+
+type stopKey struct{}
+
+func NewGroup(ctx context.Context) *Group {
+	stop := make(chan struct{})
+	ctx, cancel := context.WithCancel(context.WithValue(ctx, stopKey{}, stop))
+	return &Group{ctx: ctx, cancel: cancel, stop: stop}
+}
+
+// Go запускает процесс run в отдельной горутине
+func (g *Group) Go(run func(ctx context.Context)) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		run(g.ctx)
+	}()
+}
+
+// Shutdown просит процессы остановиться и ждёт их. Начатая работа доделывается,
+// новая не начинается; если ctx истёк раньше, контекст процессов отменяется
+// и их запросы к базе прерываются
+func (g *Group) Shutdown(ctx context.Context) error {
+	close(g.stop)
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		g.cancel()
+		return nil
+	case <-ctx.Done():
+		g.cancel()
+		<-done
+		return ctx.Err()
+	}
+}
+
+// stopping возвращает канал, который закрывается при остановке группы.
+// Вне группы канал nil и никогда не срабатывает
+func stopping(ctx context.Context) <-chan struct{} {
+	stop, _ := ctx.Value(stopKey{}).(chan struct{})
+	return stop
+}
+
+// runEvery вызывает fn сразу и затем каждые interval, пока не отменён ctx или группа не остановлена
+func runEvery(ctx context.Context, interval time.Duration, fn func(ctx context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		fn(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-stopping(ctx):
+			return
+		case <-ticker.C:
+		}
+	}
+}